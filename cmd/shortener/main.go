@@ -17,9 +17,8 @@ import (
 	"github.com/blokhinnv/shorty/internal/app/log"
 	"github.com/joho/godotenv"
 
+	"github.com/blokhinnv/shorty/internal/app/server"
 	"github.com/blokhinnv/shorty/internal/app/server/config"
-	grpc "github.com/blokhinnv/shorty/internal/app/server/grpc"
-	"github.com/blokhinnv/shorty/internal/app/server/http"
 )
 
 // Глобальные переменные сборки
@@ -41,12 +40,17 @@ func parseFlags(cfg *config.FlagConfig) {
 	)
 	flag.StringVar(&cfg.SecretKey, "k", "", "secret key to sign uid cookies")
 	flag.StringVar(&cfg.DatabaseDSN, "d", "", "postgres connect string")
+	flag.StringVar(&cfg.RedisURL, "r", "", "redis URL")
+	flag.StringVar(&cfg.MetricsAddress, "m", "", "address to serve metrics at")
 	flag.BoolVar(&cfg.EnableHTTPS, "s", false, "whether to enable HTTPS or not")
 
 	flag.StringVar(&cfg.JSONConfigPath, "c", "", "path to json config (shorthand)")
 	flag.StringVar(&cfg.JSONConfigPath, "config", "", "path to json config")
 	flag.StringVar(&cfg.TrustedSubnet, "t", "", "CIDR")
 	flag.BoolVar(&cfg.StartGRPC, "g", false, "Wheither to start GRPC or HTTP server")
+	flag.StringVar(&cfg.Protocols, "p", "", "protocols to serve: http, grpc or all")
+	flag.StringVar(&cfg.GRPCAddress, "grpc-address", "", "gRPC server address")
+	flag.BoolVar(&cfg.GRPCMultiplex, "grpc-multiplex", false, "serve gRPC on the HTTP port")
 	flag.Parse()
 }
 
@@ -87,10 +91,21 @@ func main() {
 
 	flagCfg := config.FlagConfig{}
 	parseFlags(&flagCfg)
+	// -g оставлен для совместимости: запускает только gRPC
+	if flagCfg.StartGRPC && flagCfg.Protocols == "" {
+		flagCfg.Protocols = server.GRPC
+	}
 	serverCfg, err := config.NewServerConfig(&flagCfg)
 	if err != nil {
 		log.Fatal(err.Error())
 	}
+	// подкоманда migrate работает со схемой БД и не запускает сервер
+	if flag.Arg(0) == "migrate" {
+		if err := runMigrate(context.Background(), serverCfg, flag.Args()[1:]); err != nil {
+			log.Fatal(err.Error())
+		}
+		return
+	}
 	shutdownCtx, stop := signal.NotifyContext(
 		context.Background(),
 		syscall.SIGTERM,
@@ -98,9 +113,7 @@ func main() {
 		syscall.SIGQUIT,
 	)
 	defer stop()
-	if flagCfg.StartGRPC {
-		grpc.RunGRPCServer(shutdownCtx, serverCfg)
-	} else {
-		http.RunHTTPServer(shutdownCtx, serverCfg)
+	if err := server.Run(shutdownCtx, serverCfg); err != nil {
+		log.Fatal(err.Error())
 	}
 }
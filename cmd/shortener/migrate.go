@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/blokhinnv/shorty/internal/app/database"
+	"github.com/blokhinnv/shorty/internal/app/server/config"
+)
+
+// migrateUsage - подсказка по подкоманде migrate.
+const migrateUsage = "usage: shortener [flags] migrate up|down|status"
+
+// runMigrate - выполняет подкоманду migrate: применяет все новые миграции (up),
+// откатывает последнюю (down) или печатает состояние схемы (status).
+func runMigrate(ctx context.Context, cfg *config.ServerConfig, args []string) error {
+	if len(args) != 1 {
+		return errors.New(migrateUsage)
+	}
+	m, closeDB, err := database.NewMigrator(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+	switch args[0] {
+	case "up":
+		n, err := m.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Applied %d migrations\n", n)
+	case "down":
+		rolledBack, err := m.Down(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Rolled back %04d_%s\n", rolledBack.Version, rolledBack.Name)
+	case "status":
+		status, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, st := range status {
+			appliedAt := "pending"
+			if st.Applied {
+				appliedAt = st.AppliedAt.Local().Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", st.Version, st.Name, appliedAt)
+		}
+		return w.Flush()
+	default:
+		return errors.New(migrateUsage)
+	}
+	return nil
+}
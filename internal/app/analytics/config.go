@@ -0,0 +1,23 @@
+package analytics
+
+import (
+	"time"
+
+	"github.com/blokhinnv/shorty/internal/app/server/config"
+)
+
+// RecorderConfig - click recorder config.
+type RecorderConfig struct {
+	BufferSize    int
+	FlushInterval time.Duration
+	GeoIPPath     string
+}
+
+// GetRecorderConfig - click recorder config constructor based on server config.
+func GetRecorderConfig(cfg *config.ServerConfig) *RecorderConfig {
+	return &RecorderConfig{
+		BufferSize:    cfg.ClicksBufferSize,
+		FlushInterval: cfg.ClicksFlushInterval,
+		GeoIPPath:     cfg.GeoIPPath,
+	}
+}
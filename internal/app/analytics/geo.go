@@ -0,0 +1,90 @@
+package analytics
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strings"
+)
+
+// CountryResolver determines the country of the client by its IP.
+type CountryResolver interface {
+	// Country returns the country code or an empty string if it's unknown.
+	Country(ip net.IP) string
+}
+
+// noopResolver is used if there is no GeoIP database.
+type noopResolver struct{}
+
+// Country always returns an empty string.
+func (noopResolver) Country(ip net.IP) string {
+	return ""
+}
+
+// networkCountry - a network and the country it belongs to.
+type networkCountry struct {
+	network *net.IPNet
+	country string
+}
+
+// NetworkResolver resolves countries by a list of networks.
+// It's a coarse lookup which is good enough for analytics.
+type NetworkResolver struct {
+	networks []networkCountry
+}
+
+// NewNetworkResolver reads networks from CSV lines like "5.255.255.0/24,RU".
+func NewNetworkResolver(r io.Reader) (*NetworkResolver, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 2
+	reader.Comment = '#'
+	lines, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	networks := make([]networkCountry, 0, len(lines))
+	for _, line := range lines {
+		_, network, err := net.ParseCIDR(strings.TrimSpace(line[0]))
+		if err != nil {
+			return nil, fmt.Errorf("can't parse GeoIP network: %w", err)
+		}
+		country := strings.ToUpper(strings.TrimSpace(line[1]))
+		networks = append(networks, networkCountry{network: network, country: country})
+	}
+	// the most specific network should win
+	sort.SliceStable(networks, func(i, j int) bool {
+		onesI, _ := networks[i].network.Mask.Size()
+		onesJ, _ := networks[j].network.Mask.Size()
+		return onesI > onesJ
+	})
+	return &NetworkResolver{networks: networks}, nil
+}
+
+// Country returns the country code of the network which contains ip.
+func (r *NetworkResolver) Country(ip net.IP) string {
+	if ip == nil {
+		return ""
+	}
+	for _, n := range r.networks {
+		if n.network.Contains(ip) {
+			return n.country
+		}
+	}
+	return ""
+}
+
+// newCountryResolver loads the GeoIP database if the path is set.
+func newCountryResolver(path string) (CountryResolver, error) {
+	if path == "" {
+		return noopResolver{}, nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return NewNetworkResolver(file)
+}
@@ -0,0 +1,133 @@
+// Package analytics collects and aggregates clicks on short URLs.
+package analytics
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/blokhinnv/shorty/internal/app/log"
+	"github.com/blokhinnv/shorty/internal/app/storage"
+)
+
+// clickEvent - a click which is waiting for processing.
+type clickEvent struct {
+	click storage.Click
+	ip    net.IP
+}
+
+// Recorder saves clicks to the storage in the background,
+// so that redirects are not slowed down.
+type Recorder struct {
+	s             storage.Storage
+	geo           CountryResolver
+	clicksCh      chan clickEvent
+	bufferSize    int
+	flushInterval time.Duration
+	closed        bool
+	m             sync.RWMutex
+	done          chan struct{}
+}
+
+// NewRecorder - Recorder constructor.
+func NewRecorder(s storage.Storage, conf *RecorderConfig) (*Recorder, error) {
+	geo, err := newCountryResolver(conf.GeoIPPath)
+	if err != nil {
+		return nil, err
+	}
+	r := &Recorder{
+		s:             s,
+		geo:           geo,
+		clicksCh:      make(chan clickEvent, conf.BufferSize),
+		bufferSize:    conf.BufferSize,
+		flushInterval: conf.FlushInterval,
+		done:          make(chan struct{}),
+	}
+	go r.loop()
+	return r, nil
+}
+
+// Record queues a click on the short URL. It never blocks:
+// if the queue is full, the click is dropped.
+// A nil recorder drops all clicks.
+func (r *Recorder) Record(urlID, referrer, userAgent string, ip net.IP) {
+	if r == nil {
+		return
+	}
+	r.m.RLock()
+	defer r.m.RUnlock()
+	if r.closed {
+		return
+	}
+	event := clickEvent{
+		click: storage.Click{
+			URLID:     urlID,
+			ClickedAt: time.Now(),
+			Referrer:  referrer,
+			UserAgent: userAgent,
+		},
+		ip: ip,
+	}
+	select {
+	case r.clicksCh <- event:
+	default:
+		log.Warnf("Clicks queue is full, dropping click on %v", urlID)
+	}
+}
+
+// saveClicks resolves countries and passes the batch to the storage.
+func (r *Recorder) saveClicks(events []clickEvent) {
+	if len(events) == 0 {
+		return
+	}
+	clicks := make([]storage.Click, 0, len(events))
+	for _, e := range events {
+		e.click.Country = r.geo.Country(e.ip)
+		clicks = append(clicks, e.click)
+	}
+	if err := r.s.AddClicks(context.Background(), clicks); err != nil {
+		log.Printf("Error while saving clicks: %v\n", err)
+	}
+}
+
+// loop - the main goroutine loop for saving clicks.
+func (r *Recorder) loop() {
+	events := make([]clickEvent, 0)
+	ticker := time.NewTicker(r.flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case event, ok := <-r.clicksCh:
+			if !ok {
+				log.Info("Finishing saving clicks...")
+				r.saveClicks(events)
+				close(r.done)
+				return
+			}
+			events = append(events, event)
+			// don't let the batch grow unbounded between ticks
+			if len(events) >= r.bufferSize {
+				r.saveClicks(events)
+				events = make([]clickEvent, 0)
+			}
+		case <-ticker.C:
+			r.saveClicks(events)
+			events = make([]clickEvent, 0)
+		}
+	}
+}
+
+// Close stops accepting clicks and waits until queued clicks are saved.
+func (r *Recorder) Close() {
+	if r == nil {
+		return
+	}
+	r.m.Lock()
+	if !r.closed {
+		r.closed = true
+		close(r.clicksCh)
+	}
+	r.m.Unlock()
+	<-r.done
+}
@@ -0,0 +1,67 @@
+package analytics
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blokhinnv/shorty/internal/app/storage"
+)
+
+func TestNetworkResolver(t *testing.T) {
+	geo, err := NewNetworkResolver(strings.NewReader(
+		"# network,country\n5.255.0.0/16,ru\n5.255.255.0/24,KZ\n2a02:6b8::/32,RU\n",
+	))
+	require.NoError(t, err)
+	assert.Equal(t, "RU", geo.Country(net.ParseIP("5.255.1.1")))
+	assert.Equal(t, "KZ", geo.Country(net.ParseIP("5.255.255.5")))
+	assert.Equal(t, "RU", geo.Country(net.ParseIP("2a02:6b8::1")))
+	assert.Equal(t, "", geo.Country(net.ParseIP("8.8.8.8")))
+	assert.Equal(t, "", geo.Country(nil))
+
+	_, err = NewNetworkResolver(strings.NewReader("5.255.0.0,RU\n"))
+	assert.Error(t, err)
+}
+
+func TestRecorder(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s := storage.NewMockStorage(ctrl)
+	var saved []storage.Click
+	s.EXPECT().
+		AddClicks(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, clicks []storage.Click) error {
+			saved = append(saved, clicks...)
+			return nil
+		}).
+		AnyTimes()
+
+	recorder, err := NewRecorder(s, &RecorderConfig{BufferSize: 10, FlushInterval: time.Hour})
+	require.NoError(t, err)
+	recorder.geo, _ = NewNetworkResolver(strings.NewReader("5.255.0.0/16,RU\n"))
+	recorder.Record("qwerty", "http://ya.ru", "curl/7.81.0", net.ParseIP("5.255.1.1"))
+	recorder.Record("qwerty", "", "", nil)
+	// the queued clicks must be saved on close
+	recorder.Close()
+	// clicks after closing are dropped
+	recorder.Record("qwerty", "", "", nil)
+
+	require.Len(t, saved, 2)
+	assert.Equal(t, "qwerty", saved[0].URLID)
+	assert.Equal(t, "http://ya.ru", saved[0].Referrer)
+	assert.Equal(t, "curl/7.81.0", saved[0].UserAgent)
+	assert.Equal(t, "RU", saved[0].Country)
+	assert.Equal(t, "", saved[1].Country)
+}
+
+func TestNilRecorder(t *testing.T) {
+	var recorder *Recorder
+	recorder.Record("qwerty", "", "", nil)
+	recorder.Close()
+}
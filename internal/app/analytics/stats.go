@@ -0,0 +1,104 @@
+package analytics
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/blokhinnv/shorty/internal/app/storage"
+)
+
+// ErrUnknownGranularity - the requested bucket size is not supported.
+var ErrUnknownGranularity = errors.New("unknown granularity")
+
+// Granularity - the size of a time series bucket.
+type Granularity string
+
+// Supported granularities.
+const (
+	Hourly Granularity = "hour"
+	Daily  Granularity = "day"
+)
+
+// ParseGranularity converts a string to Granularity. Daily buckets are used by default.
+func ParseGranularity(s string) (Granularity, error) {
+	switch Granularity(s) {
+	case "", Daily:
+		return Daily, nil
+	case Hourly:
+		return Hourly, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownGranularity, s)
+}
+
+// truncate returns the start of the bucket which contains t.
+func (g Granularity) truncate(t time.Time) time.Time {
+	t = t.UTC()
+	if g == Hourly {
+		return t.Truncate(time.Hour)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Bucket - number of clicks in a time interval.
+type Bucket struct {
+	Start  time.Time `json:"start"`
+	Clicks int       `json:"clicks"`
+}
+
+// Stats - aggregated clicks on a short URL.
+type Stats struct {
+	URLID       string         `json:"url_id"`
+	Clicks      int            `json:"clicks"`
+	FirstClick  *time.Time     `json:"first_click,omitempty"`
+	LastClick   *time.Time     `json:"last_click,omitempty"`
+	Granularity Granularity    `json:"granularity"`
+	Buckets     []Bucket       `json:"buckets"`
+	Referrers   map[string]int `json:"referrers"`
+	UserAgents  map[string]int `json:"user_agents"`
+	Countries   map[string]int `json:"countries"`
+}
+
+// Aggregate builds stats from the clicks on a short URL.
+// Only non-empty buckets are returned, oldest first.
+func Aggregate(urlID string, clicks []storage.Click, g Granularity) Stats {
+	stats := Stats{
+		URLID:       urlID,
+		Clicks:      len(clicks),
+		Granularity: g,
+		Buckets:     make([]Bucket, 0),
+		Referrers:   make(map[string]int),
+		UserAgents:  make(map[string]int),
+		Countries:   make(map[string]int),
+	}
+	buckets := make(map[time.Time]int)
+	for _, c := range clicks {
+		clickedAt := c.ClickedAt
+		if stats.FirstClick == nil || clickedAt.Before(*stats.FirstClick) {
+			stats.FirstClick = &clickedAt
+		}
+		if stats.LastClick == nil || clickedAt.After(*stats.LastClick) {
+			stats.LastClick = &clickedAt
+		}
+		buckets[g.truncate(clickedAt)]++
+		stats.Referrers[valueOr(c.Referrer, "direct")]++
+		stats.UserAgents[valueOr(c.UserAgent, "unknown")]++
+		stats.Countries[valueOr(c.Country, "unknown")]++
+	}
+	for start, n := range buckets {
+		stats.Buckets = append(stats.Buckets, Bucket{Start: start, Clicks: n})
+	}
+	sort.Slice(stats.Buckets, func(i, j int) bool {
+		return stats.Buckets[i].Start.Before(stats.Buckets[j].Start)
+	})
+	return stats
+}
+
+// valueOr returns def if s is empty.
+func valueOr(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
@@ -0,0 +1,71 @@
+package analytics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/blokhinnv/shorty/internal/app/storage"
+)
+
+func TestParseGranularity(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Granularity
+		wantErr error
+	}{
+		{in: "", want: Daily},
+		{in: "day", want: Daily},
+		{in: "hour", want: Hourly},
+		{in: "week", wantErr: ErrUnknownGranularity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseGranularity(tt.in)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ParseGranularity() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	day := time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)
+	clicks := []storage.Click{
+		{URLID: "qwerty", ClickedAt: day.Add(10*time.Hour + 5*time.Minute), Country: "RU"},
+		{URLID: "qwerty", ClickedAt: day.Add(10*time.Hour + 55*time.Minute), Referrer: "http://ya.ru"},
+		{URLID: "qwerty", ClickedAt: day.Add(26 * time.Hour), UserAgent: "curl/7.81.0"},
+	}
+
+	t.Run("hourly", func(t *testing.T) {
+		stats := Aggregate("qwerty", clicks, Hourly)
+		assert.Equal(t, 3, stats.Clicks)
+		assert.Equal(t, clicks[0].ClickedAt, *stats.FirstClick)
+		assert.Equal(t, clicks[2].ClickedAt, *stats.LastClick)
+		assert.Equal(t, []Bucket{
+			{Start: day.Add(10 * time.Hour), Clicks: 2},
+			{Start: day.Add(26 * time.Hour), Clicks: 1},
+		}, stats.Buckets)
+		assert.Equal(t, map[string]int{"direct": 2, "http://ya.ru": 1}, stats.Referrers)
+		assert.Equal(t, map[string]int{"unknown": 2, "curl/7.81.0": 1}, stats.UserAgents)
+		assert.Equal(t, map[string]int{"unknown": 2, "RU": 1}, stats.Countries)
+	})
+
+	t.Run("daily", func(t *testing.T) {
+		stats := Aggregate("qwerty", clicks, Daily)
+		assert.Equal(t, []Bucket{
+			{Start: day, Clicks: 2},
+			{Start: day.Add(24 * time.Hour), Clicks: 1},
+		}, stats.Buckets)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		stats := Aggregate("qwerty", nil, Daily)
+		assert.Equal(t, 0, stats.Clicks)
+		assert.Nil(t, stats.FirstClick)
+		assert.Empty(t, stats.Buckets)
+	})
+}
@@ -5,12 +5,17 @@ import (
 	"fmt"
 
 	"github.com/blokhinnv/shorty/internal/app/log"
+	"github.com/blokhinnv/shorty/internal/app/metrics"
 
+	"github.com/blokhinnv/shorty/internal/app/database/kv"
+	"github.com/blokhinnv/shorty/internal/app/database/migrate"
 	"github.com/blokhinnv/shorty/internal/app/database/postgres"
+	"github.com/blokhinnv/shorty/internal/app/database/redis"
 	"github.com/blokhinnv/shorty/internal/app/database/sqlite"
 	"github.com/blokhinnv/shorty/internal/app/database/text"
 	"github.com/blokhinnv/shorty/internal/app/server/config"
 	"github.com/blokhinnv/shorty/internal/app/storage"
+	"github.com/blokhinnv/shorty/internal/app/tracing"
 )
 
 // Storage types.
@@ -18,14 +23,29 @@ const (
 	Postgres = iota
 	Text
 	SQLite
+	Redis
+	KV
 )
 
+// storageTypeNames - names of the storage types used in metrics.
+var storageTypeNames = map[int]string{
+	Postgres: "postgres",
+	Text:     "text",
+	SQLite:   "sqlite",
+	Redis:    "redis",
+	KV:       "kv",
+}
+
 // inferStorageType determines which storage type to use
 // based on config.
 func inferStorageType(cfg *config.ServerConfig) int {
 	switch {
 	case cfg.PostgresDatabaseDSN != "":
 		return Postgres
+	case cfg.RedisURL != "":
+		return Redis
+	case cfg.FileStoragePath != "" && cfg.FileStorageEngine == "kv":
+		return KV
 	case cfg.FileStoragePath != "":
 		return Text
 	default:
@@ -34,7 +54,28 @@ func inferStorageType(cfg *config.ServerConfig) int {
 }
 
 // NewDBStorage - database-based storage constructor.
+// Operations of the storage are traced and measured, it's wrapped with a cache if its size is set.
 func NewDBStorage(cfg *config.ServerConfig) (storage.Storage, error) {
+	backend, err := newBackend(cfg)
+	if err != nil {
+		return nil, err
+	}
+	name := storageTypeNames[inferStorageType(cfg)]
+	var s storage.Storage = metrics.NewInstrumentedStorage(tracing.NewTracedStorage(backend, name), name)
+	if cfg.CacheSize <= 0 {
+		return s, nil
+	}
+	cacheConfig := storage.CacheConfig{
+		Size:        cfg.CacheSize,
+		TTL:         cfg.CacheTTL,
+		NegativeTTL: cfg.CacheNegativeTTL,
+	}
+	log.Printf("Starting cache with config %+v\n", cacheConfig)
+	return storage.NewCachedStorage(s, cacheConfig), nil
+}
+
+// newBackend creates the storage of the configured type.
+func newBackend(cfg *config.ServerConfig) (storage.Storage, error) {
 	storageType := inferStorageType(cfg)
 	switch storageType {
 	case SQLite:
@@ -49,6 +90,33 @@ func NewDBStorage(cfg *config.ServerConfig) (storage.Storage, error) {
 		textStorageConfig := text.GetTextStorageConfig(cfg)
 		log.Printf("Starting TextStorage with config %+v\n", textStorageConfig)
 		return text.NewTextStorage(textStorageConfig)
+	case Redis:
+		redisConfig := redis.GetRedisConfig(cfg)
+		log.Printf("Starting RedisStorage with config %+v\n", redisConfig)
+		return redis.NewRedisStorage(redisConfig)
+	case KV:
+		kvConfig := kv.GetKVStorageConfig(cfg)
+		log.Printf("Starting KVStorage with config %+v\n", kvConfig)
+		return kv.NewKVStorage(kvConfig)
 	}
 	return nil, fmt.Errorf("unknown storage type %v", storageType)
 }
+
+// NewMigrator - schema migrator constructor for the configured storage.
+// The returned function closes the connection.
+func NewMigrator(cfg *config.ServerConfig) (*migrate.Migrator, func(), error) {
+	storageType := inferStorageType(cfg)
+	switch storageType {
+	case SQLite:
+		return sqlite.NewMigrator(sqlite.GetSQLiteConfig(cfg))
+	case Postgres:
+		return postgres.NewMigrator(postgres.GetPostgresConfig(cfg))
+	case Text:
+		return nil, nil, fmt.Errorf("text storage has no schema to migrate")
+	case KV:
+		return nil, nil, fmt.Errorf("kv storage has no schema to migrate")
+	case Redis:
+		return nil, nil, fmt.Errorf("redis storage has no schema to migrate")
+	}
+	return nil, nil, fmt.Errorf("unknown storage type %v", storageType)
+}
@@ -0,0 +1,27 @@
+// Package kv implements storage based on an embedded key-value database.
+package kv
+
+import (
+	"time"
+
+	"github.com/blokhinnv/shorty/internal/app/server/config"
+)
+
+// KVStorageConfig - embedded key-value storage config.
+type KVStorageConfig struct {
+	DBPath       string
+	ClearOnStart bool
+	ReapInterval time.Duration
+	// users who shorten the same URL share one link
+	ShareURLs bool
+}
+
+// GetKVStorageConfig - key-value storage config constructor based on server config.
+func GetKVStorageConfig(cfg *config.ServerConfig) *KVStorageConfig {
+	return &KVStorageConfig{
+		DBPath:       cfg.FileStoragePath,
+		ClearOnStart: cfg.FileStorageClearOnStart,
+		ReapInterval: cfg.ExpiredReapInterval,
+		ShareURLs:    cfg.ShareDuplicateURLs,
+	}
+}
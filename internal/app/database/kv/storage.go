@@ -0,0 +1,945 @@
+package kv
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/blokhinnv/shorty/internal/app/log"
+	"github.com/blokhinnv/shorty/internal/app/storage"
+)
+
+// Buckets of the database.
+var (
+	// url id => JSON record
+	urlsBucket = []byte("urls")
+	// url \x00 url id => nothing
+	byURLBucket = []byte("by_url")
+	// user id | url id => time the user got the URL
+	userURLsBucket = []byte("user_urls")
+	// user id => number of URLs the user owns
+	usersBucket = []byte("users")
+	// expiration time | url id => nothing
+	expiresBucket = []byte("expires")
+	// url id \x00 click time | sequence number => JSON click
+	clicksBucket = []byte("clicks")
+	// counter name => value
+	statsBucket = []byte("stats")
+	// username => JSON account
+	accountsBucket = []byte("accounts")
+	// hash of the key => JSON API key
+	apiKeysBucket = []byte("api_keys")
+	// url id \x00 change time | sequence number => JSON revision
+	historyBucket = []byte("history")
+
+	allBuckets = [][]byte{
+		urlsBucket,
+		byURLBucket,
+		userURLsBucket,
+		usersBucket,
+		expiresBucket,
+		clicksBucket,
+		statsBucket,
+		accountsBucket,
+		apiKeysBucket,
+		historyBucket,
+	}
+)
+
+// Counters of the stats bucket.
+var (
+	urlsCounter  = []byte("urls")
+	usersCounter = []byte("users")
+	idsCounter   = []byte("ids")
+)
+
+// how long to wait for the database file lock
+const openTimeout = time.Second
+
+// uint64Key encodes the number so that keys are sorted in numerical order.
+func uint64Key(n uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, n)
+	return key
+}
+
+// userKey - key prefix of the user's URLs.
+func userKey(userID uint32) []byte {
+	key := make([]byte, 4)
+	binary.BigEndian.PutUint32(key, userID)
+	return key
+}
+
+// byURLKey - key of the URL stored under the ID.
+func byURLKey(url, urlID string) []byte {
+	return append(byURLPrefix(url), urlID...)
+}
+
+// byURLPrefix - key prefix of the IDs the URL is stored under.
+func byURLPrefix(url string) []byte {
+	return append([]byte(url), 0)
+}
+
+// userURLKey - key of the URL owned by the user.
+func userURLKey(userID uint32, urlID string) []byte {
+	return append(userKey(userID), urlID...)
+}
+
+// expiresKey - key of the expiration index.
+func expiresKey(expiresAt time.Time, urlID string) []byte {
+	return append(uint64Key(uint64(expiresAt.UnixNano())), urlID...)
+}
+
+// clicksPrefix - key prefix of the URL visits.
+func clicksPrefix(urlID string) []byte {
+	return append([]byte(urlID), 0)
+}
+
+// historyPrefix - key prefix of the previous destinations of the URL.
+func historyPrefix(urlID string) []byte {
+	return append([]byte(urlID), 0)
+}
+
+// keysWithPrefix returns the keys which start with the prefix.
+// The keys are copied, so they can be used to modify the bucket.
+func keysWithPrefix(b *bolt.Bucket, prefix []byte) [][]byte {
+	var keys [][]byte
+	c := b.Cursor()
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+		keys = append(keys, append([]byte(nil), k...))
+	}
+	return keys
+}
+
+// deleteWithPrefix deletes the keys which start with the prefix.
+func deleteWithPrefix(b *bolt.Bucket, prefix []byte) error {
+	for _, k := range keysWithPrefix(b, prefix) {
+		if err := b.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// incr adds delta to the counter and returns the new value.
+func incr(b *bolt.Bucket, key []byte, delta int64) (int64, error) {
+	var n int64
+	if v := b.Get(key); v != nil {
+		n = int64(binary.BigEndian.Uint64(v))
+	}
+	n += delta
+	return n, b.Put(key, uint64Key(uint64(n)))
+}
+
+// counter returns the value of the counter.
+func counter(b *bolt.Bucket, key []byte) int {
+	v := b.Get(key)
+	if v == nil {
+		return 0
+	}
+	return int(binary.BigEndian.Uint64(v))
+}
+
+// KVStorage implements the Storage interface based on bbolt.
+type KVStorage struct {
+	db        *bolt.DB
+	shareURLs bool
+	quit      chan struct{}
+}
+
+// NewKVStorage - A constructor for a new URL storage.
+func NewKVStorage(conf *KVStorageConfig) (*KVStorage, error) {
+	db, err := bolt.Open(conf.DBPath, 0o600, &bolt.Options{Timeout: openTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("can't access to DB %s: %v", conf.DBPath, err)
+	}
+	s := &KVStorage{db: db, shareURLs: conf.ShareURLs, quit: make(chan struct{})}
+	if conf.ClearOnStart {
+		err = s.Clear(context.Background())
+	} else {
+		err = db.Update(createBuckets)
+	}
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	s.registerPurgeExpired(conf.ReapInterval)
+	return s, nil
+}
+
+// createBuckets creates the buckets which don't exist.
+func createBuckets(tx *bolt.Tx) error {
+	for _, name := range allBuckets {
+		if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// registerPurgeExpired starts purging expired URLs on a timer.
+func (s *KVStorage) registerPurgeExpired(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := s.PurgeExpired(context.Background()); err != nil {
+					log.Infof("Error while purging expired URLs: %v", err)
+				}
+			case <-s.quit:
+				return
+			}
+		}
+	}()
+}
+
+// getRecord reads the record by its ID.
+func getRecord(tx *bolt.Tx, urlID string) (storage.Record, error) {
+	v := tx.Bucket(urlsBucket).Get([]byte(urlID))
+	if v == nil {
+		return storage.Record{}, storage.ErrURLWasNotFound
+	}
+	var rec storage.Record
+	if err := json.Unmarshal(v, &rec); err != nil {
+		return storage.Record{}, fmt.Errorf("record %v is corrupted: %w", urlID, err)
+	}
+	return rec, nil
+}
+
+// putRecord writes the record.
+func putRecord(tx *bolt.Tx, rec storage.Record) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(urlsBucket).Put([]byte(rec.URLID), data)
+}
+
+// addOwned adds the URL to the user's list.
+func addOwned(tx *bolt.Tx, userID uint32, urlID string) error {
+	key := userURLKey(userID, urlID)
+	b := tx.Bucket(userURLsBucket)
+	if b.Get(key) != nil {
+		return nil
+	}
+	if err := b.Put(key, uint64Key(uint64(time.Now().UnixNano()))); err != nil {
+		return err
+	}
+	return changeOwned(tx, userID, 1)
+}
+
+// removeOwned removes the URL from the user's list.
+func removeOwned(tx *bolt.Tx, userID uint32, urlID string) error {
+	key := userURLKey(userID, urlID)
+	b := tx.Bucket(userURLsBucket)
+	if b.Get(key) == nil {
+		return nil
+	}
+	if err := b.Delete(key); err != nil {
+		return err
+	}
+	return changeOwned(tx, userID, -1)
+}
+
+// changeOwned changes the number of URLs the user owns.
+// Only the users who own URLs are counted in the stats.
+func changeOwned(tx *bolt.Tx, userID uint32, delta int64) error {
+	n, err := incr(tx.Bucket(usersBucket), userKey(userID), delta)
+	if err != nil {
+		return err
+	}
+	switch {
+	case n == 0:
+		if err := tx.Bucket(usersBucket).Delete(userKey(userID)); err != nil {
+			return err
+		}
+		_, err = incr(tx.Bucket(statsBucket), usersCounter, -1)
+	case n == delta:
+		_, err = incr(tx.Bucket(statsBucket), usersCounter, 1)
+	}
+	return err
+}
+
+// addURL adds the URL for the user. If shareExisting is set and the links are shared,
+// the user becomes one more owner of the URL stored for other users,
+// otherwise such a URL is reported as a duplicate.
+func (s *KVStorage) addURL(
+	tx *bolt.Tx,
+	url, urlID string,
+	userID uint32,
+	opts storage.LinkOptions,
+	shareExisting bool,
+) error {
+	expiresAt := opts.ExpiresAt
+	var shared *storage.Record
+	restore := false
+	prefix := byURLPrefix(url)
+	for _, k := range keysWithPrefix(tx.Bucket(byURLBucket), prefix) {
+		id := string(k[len(prefix):])
+		rec, err := getRecord(tx, id)
+		if errors.Is(err, storage.ErrURLWasNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		// a deleted (or expired) URL gets back under the same ID
+		if rec.IsDeleted || rec.IsExpired() {
+			restore = restore || id == urlID
+			continue
+		}
+		// the user has already shortened the URL
+		if rec.HasOwner(userID) {
+			return &storage.DuplicateURLError{URL: url, URLID: id}
+		}
+		if s.shareURLs {
+			shared = &rec
+		}
+	}
+	if shared != nil {
+		dupErr := &storage.DuplicateURLError{URL: url, URLID: shared.URLID}
+		if !shareExisting {
+			return dupErr
+		}
+		shared.Owners = append(shared.OwnerIDs(), userID)
+		if err := putRecord(tx, *shared); err != nil {
+			return err
+		}
+		if err := addOwned(tx, userID, shared.URLID); err != nil {
+			return err
+		}
+		log.Infof("Added user %v to owners of %v\n", userID, shared.URLID)
+		dupErr.NewOwner = true
+		return dupErr
+	}
+	if restore {
+		prev, err := getRecord(tx, urlID)
+		if err != nil {
+			return err
+		}
+		// the owners of an expired URL don't get it back
+		for _, owner := range prev.OwnerIDs() {
+			if err := removeOwned(tx, owner, urlID); err != nil {
+				return err
+			}
+		}
+		if !prev.ExpiresAt.IsZero() {
+			if err := tx.Bucket(expiresBucket).Delete(expiresKey(prev.ExpiresAt, urlID)); err != nil {
+				return err
+			}
+		}
+		// the visits and the history belong to the previous owners
+		if err := deleteWithPrefix(tx.Bucket(clicksBucket), clicksPrefix(urlID)); err != nil {
+			return err
+		}
+		if err := deleteWithPrefix(tx.Bucket(historyBucket), historyPrefix(urlID)); err != nil {
+			return err
+		}
+	} else {
+		if tx.Bucket(urlsBucket).Get([]byte(urlID)) != nil {
+			return fmt.Errorf(
+				"%w: url=%v, urlID=%v, userID=%v",
+				storage.ErrURLIDTaken,
+				url,
+				urlID,
+				userID,
+			)
+		}
+		if _, err := incr(tx.Bucket(statsBucket), urlsCounter, 1); err != nil {
+			return err
+		}
+	}
+	rec := storage.Record{
+		URL:                url,
+		OriginalURL:        opts.OriginalURL,
+		URLID:              urlID,
+		UserID:             userID,
+		Owners:             []uint32{userID},
+		Added:              time.Now(),
+		ExpiresAt:          expiresAt,
+		WarnBeforeRedirect: opts.WarnBeforeRedirect,
+		RedirectCode:       opts.RedirectCode,
+	}
+	if err := putRecord(tx, rec); err != nil {
+		return err
+	}
+	if err := tx.Bucket(byURLBucket).Put(byURLKey(url, urlID), nil); err != nil {
+		return err
+	}
+	if !expiresAt.IsZero() {
+		if err := tx.Bucket(expiresBucket).Put(expiresKey(expiresAt, urlID), nil); err != nil {
+			return err
+		}
+	}
+	return addOwned(tx, userID, urlID)
+}
+
+// AddURL - method for adding a new URL to the database.
+func (s *KVStorage) AddURL(
+	ctx context.Context,
+	url, urlID string,
+	userID uint32,
+	opts storage.LinkOptions,
+) error {
+	var addErr error
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		addErr = s.addURL(tx, url, urlID, userID, opts, true)
+		// the user has been added to the owners, so the changes are committed
+		var dupErr *storage.DuplicateURLError
+		if errors.As(addErr, &dupErr) && dupErr.NewOwner {
+			return nil
+		}
+		return addErr
+	})
+	if err == nil {
+		err = addErr
+	}
+	if err != nil {
+		log.Infof("Error while adding URL: %v", err)
+		return err
+	}
+	log.Infof("Added %v=>%v to storage\n", url, urlID)
+	return nil
+}
+
+// AddURLBatch adds a batch of URLs to the store.
+func (s *KVStorage) AddURLBatch(
+	ctx context.Context,
+	urlIDs map[string]string,
+	userID uint32,
+) error {
+	var violationErr error
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		for url, urlID := range urlIDs {
+			err := s.addURL(tx, url, urlID, userID, storage.LinkOptions{}, false)
+			if err != nil {
+				// the URL (or its ID) is already stored
+				if errors.Is(err, storage.ErrUniqueViolation) {
+					violationErr = err
+					continue
+				}
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return violationErr
+}
+
+// GetURLByID returns a URL by its ID.
+func (s *KVStorage) GetURLByID(ctx context.Context, urlID string) (storage.Record, error) {
+	var rec storage.Record
+	err := s.db.View(func(tx *bolt.Tx) (err error) {
+		rec, err = getRecord(tx, urlID)
+		return err
+	})
+	if err != nil {
+		return storage.Record{}, err
+	}
+	if rec.IsDeleted {
+		return storage.Record{}, storage.ErrURLWasDeleted
+	}
+	if rec.IsExpired() {
+		return storage.Record{}, storage.ErrURLExpired
+	}
+	return rec, nil
+}
+
+// GetURLsByUser gets URLs owned by the user in the order they were added.
+func (s *KVStorage) GetURLsByUser(
+	ctx context.Context,
+	userID uint32,
+) ([]storage.Record, error) {
+	results := make([]storage.Record, 0)
+	added := make(map[string]uint64)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		prefix := userKey(userID)
+		c := tx.Bucket(userURLsBucket).Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			rec, err := getRecord(tx, string(k[len(prefix):]))
+			if err != nil {
+				return err
+			}
+			if rec.IsDeleted {
+				continue
+			}
+			rec.UserID = userID
+			added[rec.URLID] = binary.BigEndian.Uint64(v)
+			results = append(results, rec)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, storage.ErrURLWasNotFound
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return added[results[i].URLID] < added[results[j].URLID]
+	})
+	return results, nil
+}
+
+// DeleteMany removes the user from the owners of the URLs
+// and flags the URLs without owners as deleted.
+func (s *KVStorage) DeleteMany(ctx context.Context, userID uint32, urlIDs []string) error {
+	updated := make([]string, 0)
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		for _, urlID := range urlIDs {
+			rec, err := getRecord(tx, urlID)
+			if errors.Is(err, storage.ErrURLWasNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if !rec.HasOwner(userID) {
+				continue
+			}
+			owners := make([]uint32, 0, len(rec.OwnerIDs()))
+			for _, owner := range rec.OwnerIDs() {
+				if owner != userID {
+					owners = append(owners, owner)
+				}
+			}
+			rec.Owners = owners
+			if len(owners) == 0 {
+				rec.IsDeleted = true
+				updated = append(updated, urlID)
+			}
+			if err := putRecord(tx, rec); err != nil {
+				return err
+			}
+			if err := removeOwned(tx, userID, urlID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Infof("Error while deleting URL: %v", err)
+		return err
+	}
+	log.Infof("Set %v as deleted\n", updated)
+	return nil
+}
+
+// PurgeExpired removes expired URLs from the database.
+func (s *KVStorage) PurgeExpired(ctx context.Context) (int64, error) {
+	var n int64
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		var keys [][]byte
+		now := uint64Key(uint64(time.Now().UnixNano()))
+		c := tx.Bucket(expiresBucket).Cursor()
+		for k, _ := c.First(); k != nil && bytes.Compare(k[:8], now) < 0; k, _ = c.Next() {
+			keys = append(keys, append([]byte(nil), k...))
+		}
+		for _, k := range keys {
+			if err := tx.Bucket(expiresBucket).Delete(k); err != nil {
+				return err
+			}
+			urlID := string(k[8:])
+			rec, err := getRecord(tx, urlID)
+			if errors.Is(err, storage.ErrURLWasNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			for _, owner := range rec.OwnerIDs() {
+				if err := removeOwned(tx, owner, urlID); err != nil {
+					return err
+				}
+			}
+			if err := tx.Bucket(byURLBucket).Delete(byURLKey(rec.URL, urlID)); err != nil {
+				return err
+			}
+			if err := tx.Bucket(urlsBucket).Delete([]byte(urlID)); err != nil {
+				return err
+			}
+			// the IDs may be reused, so the visits and the history go along with the URLs
+			if err := deleteWithPrefix(tx.Bucket(clicksBucket), clicksPrefix(urlID)); err != nil {
+				return err
+			}
+			if err := deleteWithPrefix(tx.Bucket(historyBucket), historyPrefix(urlID)); err != nil {
+				return err
+			}
+			if _, err := incr(tx.Bucket(statsBucket), urlsCounter, -1); err != nil {
+				return err
+			}
+			n++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if n > 0 {
+		log.Infof("Purged %v expired URLs\n", n)
+	}
+	return n, nil
+}
+
+// Close closes the database.
+func (s *KVStorage) Close(ctx context.Context) {
+	close(s.quit)
+	s.db.Close()
+}
+
+// Ping checks the connection to the repository.
+func (s *KVStorage) Ping(ctx context.Context) bool {
+	return s.db.View(func(tx *bolt.Tx) error { return nil }) == nil
+}
+
+// Clear clears the storage.
+func (s *KVStorage) Clear(ctx context.Context) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		for _, name := range allBuckets {
+			err := tx.DeleteBucket(name)
+			if err != nil && !errors.Is(err, bolt.ErrBucketNotFound) {
+				return err
+			}
+		}
+		return createBuckets(tx)
+	})
+}
+
+// Returns DB stats.
+func (s *KVStorage) GetStats(ctx context.Context) (int, int, error) {
+	var urls, users int
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(statsBucket)
+		urls, users = counter(b, urlsCounter), counter(b, usersCounter)
+		return nil
+	})
+	return urls, users, err
+}
+
+// AddClicks saves a batch of short URL visits.
+func (s *KVStorage) AddClicks(ctx context.Context, clicks []storage.Click) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(clicksBucket)
+		for _, c := range clicks {
+			data, err := json.Marshal(c)
+			if err != nil {
+				return err
+			}
+			seq, err := b.NextSequence()
+			if err != nil {
+				return err
+			}
+			// keys are ordered by the time of the visit
+			key := append(clicksPrefix(c.URLID), uint64Key(uint64(c.ClickedAt.UnixNano()))...)
+			if err := b.Put(append(key, uint64Key(seq)...), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetClicks gets all visits of a short URL ordered by time.
+func (s *KVStorage) GetClicks(ctx context.Context, urlID string) ([]storage.Click, error) {
+	results := make([]storage.Click, 0)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		prefix := clicksPrefix(urlID)
+		c := tx.Bucket(clicksBucket).Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			var click storage.Click
+			if err := json.Unmarshal(v, &click); err != nil {
+				return err
+			}
+			results = append(results, click)
+		}
+		return nil
+	})
+	return results, err
+}
+
+// AddUser registers the user.
+func (s *KVStorage) AddUser(ctx context.Context, user storage.User) error {
+	data, err := json.Marshal(user)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(accountsBucket)
+		if b.Get([]byte(user.Username)) != nil {
+			return fmt.Errorf("%w: username=%v", storage.ErrUsernameTaken, user.Username)
+		}
+		// the ID belongs to a user without an account or to another account
+		taken := tx.Bucket(usersBucket).Get(userKey(user.UserID)) != nil
+		err := b.ForEach(func(_, v []byte) error {
+			var other storage.User
+			if err := json.Unmarshal(v, &other); err != nil {
+				return err
+			}
+			taken = taken || other.UserID == user.UserID
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if taken {
+			return fmt.Errorf("%w: userID=%v", storage.ErrUserIDTaken, user.UserID)
+		}
+		return b.Put([]byte(user.Username), data)
+	})
+}
+
+// GetUser gets the registered user by name.
+func (s *KVStorage) GetUser(ctx context.Context, username string) (storage.User, error) {
+	var user storage.User
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(accountsBucket).Get([]byte(username))
+		if v == nil {
+			return storage.ErrUserNotFound
+		}
+		return json.Unmarshal(v, &user)
+	})
+	return user, err
+}
+
+// AddAPIKey saves the API key of the user.
+func (s *KVStorage) AddAPIKey(ctx context.Context, key storage.APIKey) error {
+	data, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(apiKeysBucket).Put([]byte(key.KeyHash), data)
+	})
+}
+
+// GetAPIKey gets the API key by its hash.
+func (s *KVStorage) GetAPIKey(ctx context.Context, keyHash string) (storage.APIKey, error) {
+	var key storage.APIKey
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(apiKeysBucket).Get([]byte(keyHash))
+		if v == nil {
+			return storage.ErrAPIKeyNotFound
+		}
+		return json.Unmarshal(v, &key)
+	})
+	return key, err
+}
+
+// MoveURLs passes the URLs owned by one user to another.
+func (s *KVStorage) MoveURLs(ctx context.Context, fromUserID, toUserID uint32) (int, error) {
+	moved := 0
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		prefix := userKey(fromUserID)
+		for _, k := range keysWithPrefix(tx.Bucket(userURLsBucket), prefix) {
+			urlID := string(k[len(prefix):])
+			rec, err := getRecord(tx, urlID)
+			if err != nil {
+				return err
+			}
+			owners := make([]uint32, 0, len(rec.OwnerIDs())+1)
+			for _, owner := range rec.OwnerIDs() {
+				if owner != fromUserID && owner != toUserID {
+					owners = append(owners, owner)
+				}
+			}
+			rec.Owners = append(owners, toUserID)
+			if err := putRecord(tx, rec); err != nil {
+				return err
+			}
+			if err := removeOwned(tx, fromUserID, urlID); err != nil {
+				return err
+			}
+			if err := addOwned(tx, toUserID, urlID); err != nil {
+				return err
+			}
+			moved++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	log.Infof("Moved %v URLs of user %v to user %v\n", moved, fromUserID, toUserID)
+	return moved, nil
+}
+
+// SetPreview saves the preview of the URL's destination.
+func (s *KVStorage) SetPreview(ctx context.Context, urlID string, preview storage.Preview) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		rec, err := getRecord(tx, urlID)
+		if err != nil {
+			return err
+		}
+		rec.Preview = &preview
+		return putRecord(tx, rec)
+	})
+}
+
+// GetURLsToCheck gets the active URLs which haven't been checked since the time.
+func (s *KVStorage) GetURLsToCheck(
+	ctx context.Context,
+	checkedBefore time.Time,
+	limit int,
+) ([]storage.Record, error) {
+	results := make([]storage.Record, 0)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(urlsBucket).ForEach(func(k, v []byte) error {
+			var rec storage.Record
+			if err := json.Unmarshal(v, &rec); err != nil {
+				return fmt.Errorf("record %s is corrupted: %w", k, err)
+			}
+			if rec.NeedsCheck(checkedBefore) {
+				results = append(results, rec)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return storage.OldestChecked(results, limit), nil
+}
+
+// SetHealth saves the result of the check of the URL's destination.
+func (s *KVStorage) SetHealth(ctx context.Context, urlID string, health storage.Health) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		rec, err := getRecord(tx, urlID)
+		if err != nil {
+			return err
+		}
+		rec.Health = &health
+		return putRecord(tx, rec)
+	})
+}
+
+// UpdateURL changes the destination of the URL owned by the user
+// and saves the previous one to the history.
+func (s *KVStorage) UpdateURL(
+	ctx context.Context,
+	urlID string,
+	userID uint32,
+	url, originalURL string,
+) error {
+	var prevURL string
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		rec, err := getRecord(tx, urlID)
+		if err != nil {
+			return err
+		}
+		if err := rec.CheckUpdate(userID); err != nil {
+			return err
+		}
+		// the destination is the same
+		if rec.URL == url {
+			return nil
+		}
+		// the new destination may be stored under another ID
+		prefix := byURLPrefix(url)
+		for _, k := range keysWithPrefix(tx.Bucket(byURLBucket), prefix) {
+			id := string(k[len(prefix):])
+			other, err := getRecord(tx, id)
+			if errors.Is(err, storage.ErrURLWasNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			active := !other.IsDeleted && !other.IsExpired()
+			if active && (s.shareURLs || other.HasOwner(userID)) {
+				return &storage.DuplicateURLError{URL: url, URLID: id}
+			}
+		}
+		data, err := json.Marshal(storage.Revision{
+			URLID:     urlID,
+			URL:       rec.URL,
+			ChangedAt: time.Now(),
+			ChangedBy: userID,
+		})
+		if err != nil {
+			return err
+		}
+		b := tx.Bucket(historyBucket)
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		// keys are ordered by the time of the change
+		key := append(historyPrefix(urlID), uint64Key(uint64(time.Now().UnixNano()))...)
+		if err := b.Put(append(key, uint64Key(seq)...), data); err != nil {
+			return err
+		}
+		if err := tx.Bucket(byURLBucket).Delete(byURLKey(rec.URL, urlID)); err != nil {
+			return err
+		}
+		if err := tx.Bucket(byURLBucket).Put(byURLKey(url, urlID), nil); err != nil {
+			return err
+		}
+		prevURL = rec.URL
+		rec.URL = url
+		rec.OriginalURL = originalURL
+		rec.Preview = nil
+		rec.Health = nil
+		return putRecord(tx, rec)
+	})
+	if err != nil {
+		return err
+	}
+	if prevURL != "" {
+		log.Infof("Changed destination of %v from %v to %v\n", urlID, prevURL, url)
+	}
+	return nil
+}
+
+// GetURLHistory gets the previous destinations of a short URL ordered by time.
+func (s *KVStorage) GetURLHistory(ctx context.Context, urlID string) ([]storage.Revision, error) {
+	results := make([]storage.Revision, 0)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		prefix := historyPrefix(urlID)
+		c := tx.Bucket(historyBucket).Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			var r storage.Revision
+			if err := json.Unmarshal(v, &r); err != nil {
+				return err
+			}
+			results = append(results, r)
+		}
+		return nil
+	})
+	return results, err
+}
+
+// NextSequence returns the next value of the counter used to generate IDs.
+func (s *KVStorage) NextSequence(ctx context.Context) (uint64, error) {
+	var n int64
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(statsBucket)
+		// the counter starts after the URLs shortened before it
+		if b.Get(idsCounter) == nil {
+			if err := b.Put(idsCounter, uint64Key(uint64(counter(b, urlsCounter)))); err != nil {
+				return err
+			}
+		}
+		var err error
+		n, err = incr(b, idsCounter, 1)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return uint64(n - 1), nil
+}
@@ -0,0 +1,217 @@
+// Package migrate applies versioned schema migrations to SQL-based storages.
+//
+// Migrations are SQL scripts named like 0001_create_url.up.sql and
+// 0001_create_url.down.sql. Applied versions are kept in the schema_version table.
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/blokhinnv/shorty/internal/app/log"
+)
+
+// Migration errors.
+var (
+	ErrBadMigration = errors.New("migration is not valid")
+	ErrNoVersion    = errors.New("no migrations have been applied")
+	ErrIrreversible = errors.New("migration can't be rolled back")
+)
+
+// migrationFileRe - pattern for the names of migration scripts.
+var migrationFileRe = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is a single schema change.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status describes whether the migration has been applied.
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Driver runs migrations on a particular database.
+type Driver interface {
+	// Init creates the schema_version table if it doesn't exist.
+	Init(ctx context.Context) error
+	// Applied returns the applied versions along with the time they were applied.
+	Applied(ctx context.Context) (map[int]time.Time, error)
+	// Apply runs the script and records (or forgets) the version in a single transaction.
+	// An empty script only changes the record.
+	Apply(ctx context.Context, m Migration, script string, up bool) error
+	// Baseline returns the version of a database created before
+	// the migrations were introduced (0 for a new database).
+	Baseline(ctx context.Context) (int, error)
+}
+
+// Migrator applies migrations using the driver.
+type Migrator struct {
+	driver     Driver
+	migrations []Migration
+}
+
+// Load reads migrations from the root of the file system.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int]*Migration)
+	for _, e := range entries {
+		match := migrationFileRe.FindStringSubmatch(e.Name())
+		if e.IsDir() || match == nil {
+			continue
+		}
+		version, _ := strconv.Atoi(match[1])
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf(
+				"%w: version %d is used by %v and %v",
+				ErrBadMigration,
+				version,
+				m.Name,
+				match[2],
+			)
+		}
+		script, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, err
+		}
+		if match[3] == "up" {
+			m.Up = string(script)
+		} else {
+			m.Down = string(script)
+		}
+	}
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("%w: version %d has no up script", ErrBadMigration, m.Version)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// New - Migrator constructor. Migrations are loaded from the file system.
+func New(driver Driver, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{driver: driver, migrations: migrations}, nil
+}
+
+// applied initializes the schema_version table and returns the applied versions.
+// A database created before the migrations gets its baseline versions recorded.
+func (m *Migrator) applied(ctx context.Context) (map[int]time.Time, error) {
+	if err := m.driver.Init(ctx); err != nil {
+		return nil, err
+	}
+	applied, err := m.driver.Applied(ctx)
+	if err != nil || len(applied) > 0 {
+		return applied, err
+	}
+	baseline, err := m.driver.Baseline(ctx)
+	if err != nil {
+		return nil, err
+	}
+	applied = make(map[int]time.Time)
+	for _, migration := range m.migrations {
+		if migration.Version > baseline {
+			break
+		}
+		if err := m.driver.Apply(ctx, migration, "", true); err != nil {
+			return nil, err
+		}
+		log.Infof("Marked migration %04d_%v as applied\n", migration.Version, migration.Name)
+		applied[migration.Version] = time.Now()
+	}
+	return applied, nil
+}
+
+// Up applies all pending migrations and returns how many have been applied.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		if err := m.driver.Apply(ctx, migration, migration.Up, true); err != nil {
+			return n, fmt.Errorf("can't apply %04d_%v: %w", migration.Version, migration.Name, err)
+		}
+		log.Infof("Applied migration %04d_%v\n", migration.Version, migration.Name)
+		n++
+	}
+	return n, nil
+}
+
+// Down rolls back the latest applied migration and returns it.
+func (m *Migrator) Down(ctx context.Context) (Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return Migration{}, err
+	}
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		if migration.Down == "" {
+			return Migration{}, fmt.Errorf(
+				"%w: %04d_%v has no down script",
+				ErrIrreversible,
+				migration.Version,
+				migration.Name,
+			)
+		}
+		if err := m.driver.Apply(ctx, migration, migration.Down, false); err != nil {
+			return Migration{}, fmt.Errorf(
+				"can't roll back %04d_%v: %w",
+				migration.Version,
+				migration.Name,
+				err,
+			)
+		}
+		log.Infof("Rolled back migration %04d_%v\n", migration.Version, migration.Name)
+		return migration, nil
+	}
+	return Migration{}, ErrNoVersion
+}
+
+// Status returns the state of every known migration.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		appliedAt, ok := applied[migration.Version]
+		result = append(result, Status{Migration: migration, Applied: ok, AppliedAt: appliedAt})
+	}
+	return result, nil
+}
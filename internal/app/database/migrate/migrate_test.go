@@ -0,0 +1,154 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// fakeDriver keeps the applied versions and scripts in memory.
+type fakeDriver struct {
+	applied  map[int]time.Time
+	scripts  []string
+	baseline int
+}
+
+func (d *fakeDriver) Init(ctx context.Context) error {
+	return nil
+}
+
+func (d *fakeDriver) Applied(ctx context.Context) (map[int]time.Time, error) {
+	applied := make(map[int]time.Time)
+	for v, t := range d.applied {
+		applied[v] = t
+	}
+	return applied, nil
+}
+
+func (d *fakeDriver) Apply(ctx context.Context, m Migration, script string, up bool) error {
+	if script == "fail" {
+		return errors.New("syntax error")
+	}
+	if script != "" {
+		d.scripts = append(d.scripts, script)
+	}
+	if up {
+		d.applied[m.Version] = time.Now()
+	} else {
+		delete(d.applied, m.Version)
+	}
+	return nil
+}
+
+func (d *fakeDriver) Baseline(ctx context.Context) (int, error) {
+	return d.baseline, nil
+}
+
+type MigrateSuite struct {
+	suite.Suite
+	fsys   fstest.MapFS
+	driver *fakeDriver
+}
+
+func (suite *MigrateSuite) SetupTest() {
+	suite.fsys = fstest.MapFS{
+		"0001_create_url.up.sql":   {Data: []byte("create url")},
+		"0001_create_url.down.sql": {Data: []byte("drop url")},
+		"0002_add_column.up.sql":   {Data: []byte("add column")},
+		"0010_clicks.up.sql":       {Data: []byte("create clicks")},
+		"0010_clicks.down.sql":     {Data: []byte("drop clicks")},
+		"README.md":                {Data: []byte("not a migration")},
+	}
+	suite.driver = &fakeDriver{applied: make(map[int]time.Time)}
+}
+
+func (suite *MigrateSuite) TestLoad() {
+	migrations, err := Load(suite.fsys)
+	suite.NoError(err)
+	suite.Len(migrations, 3)
+	suite.Equal(10, migrations[2].Version)
+	suite.Equal("clicks", migrations[2].Name)
+	suite.Equal("drop clicks", migrations[2].Down)
+	suite.Empty(migrations[1].Down)
+}
+
+func (suite *MigrateSuite) TestLoadNoUp() {
+	suite.fsys["0003_broken.down.sql"] = &fstest.MapFile{Data: []byte("drop")}
+	_, err := Load(suite.fsys)
+	suite.ErrorIs(err, ErrBadMigration)
+}
+
+func (suite *MigrateSuite) TestLoadSameVersion() {
+	suite.fsys["0002_another.up.sql"] = &fstest.MapFile{Data: []byte("drop")}
+	_, err := Load(suite.fsys)
+	suite.ErrorIs(err, ErrBadMigration)
+}
+
+func (suite *MigrateSuite) TestUp() {
+	ctx := context.Background()
+	m, err := New(suite.driver, suite.fsys)
+	suite.Require().NoError(err)
+	n, err := m.Up(ctx)
+	suite.NoError(err)
+	suite.Equal(3, n)
+	suite.Equal([]string{"create url", "add column", "create clicks"}, suite.driver.scripts)
+	// nothing left to apply
+	n, err = m.Up(ctx)
+	suite.NoError(err)
+	suite.Equal(0, n)
+}
+
+func (suite *MigrateSuite) TestUpFailed() {
+	ctx := context.Background()
+	suite.fsys["0002_add_column.up.sql"] = &fstest.MapFile{Data: []byte("fail")}
+	m, _ := New(suite.driver, suite.fsys)
+	n, err := m.Up(ctx)
+	suite.Error(err)
+	suite.Equal(1, n)
+	suite.NotContains(suite.driver.applied, 10)
+}
+
+func (suite *MigrateSuite) TestBaseline() {
+	ctx := context.Background()
+	suite.driver.baseline = 1
+	m, _ := New(suite.driver, suite.fsys)
+	n, err := m.Up(ctx)
+	suite.NoError(err)
+	suite.Equal(2, n)
+	suite.Equal([]string{"add column", "create clicks"}, suite.driver.scripts)
+}
+
+func (suite *MigrateSuite) TestDown() {
+	ctx := context.Background()
+	m, _ := New(suite.driver, suite.fsys)
+	_, err := m.Down(ctx)
+	suite.ErrorIs(err, ErrNoVersion)
+
+	m.Up(ctx)
+	rolledBack, err := m.Down(ctx)
+	suite.NoError(err)
+	suite.Equal(10, rolledBack.Version)
+	_, err = m.Down(ctx)
+	suite.ErrorIs(err, ErrIrreversible)
+}
+
+func (suite *MigrateSuite) TestStatus() {
+	ctx := context.Background()
+	suite.driver.applied[1] = time.Now()
+	m, _ := New(suite.driver, suite.fsys)
+	status, err := m.Status(ctx)
+	suite.NoError(err)
+	suite.Len(status, 3)
+	suite.True(status[0].Applied)
+	suite.False(status[0].AppliedAt.IsZero())
+	suite.False(status[1].Applied)
+	suite.False(status[2].Applied)
+}
+
+func TestMigrateSuite(t *testing.T) {
+	suite.Run(t, new(MigrateSuite))
+}
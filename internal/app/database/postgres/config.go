@@ -2,6 +2,8 @@
 package postgres
 
 import (
+	"time"
+
 	"github.com/blokhinnv/shorty/internal/app/server/config"
 )
 
@@ -11,6 +13,10 @@ type PostgresConfig struct {
 	// not to store login/password in code
 	DatabaseDSN  string
 	ClearOnStart bool
+	// how often to purge expired URLs (0 disables purging)
+	ReapInterval time.Duration
+	// users who shorten the same URL share one link
+	ShareURLs bool
 }
 
 // GetPostgresConfig - Postgres config constructor based on server config
@@ -18,5 +24,7 @@ func GetPostgresConfig(cfg *config.ServerConfig) *PostgresConfig {
 	return &PostgresConfig{
 		DatabaseDSN:  cfg.PostgresDatabaseDSN,
 		ClearOnStart: cfg.PostgresClearOnStart,
+		ReapInterval: cfg.ExpiredReapInterval,
+		ShareURLs:    cfg.ShareDuplicateURLs,
 	}
 }
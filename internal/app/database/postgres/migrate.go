@@ -0,0 +1,151 @@
+package postgres
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/blokhinnv/shorty/internal/app/database/migrate"
+)
+
+// migrationsFS - schema migrations of the Postgres storage.
+//
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// SQL queries to keep track of the schema version.
+const (
+	createSchemaVersionSQL = `
+CREATE TABLE IF NOT EXISTS schema_version(
+	version INTEGER PRIMARY KEY,
+	name VARCHAR NOT NULL,
+	applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);`
+	selectSchemaVersionsSQL = "SELECT version, applied_at FROM schema_version;"
+	insertSchemaVersionSQL  = "INSERT INTO schema_version(version, name) VALUES ($1, $2);"
+	deleteSchemaVersionSQL  = "DELETE FROM schema_version WHERE version = $1;"
+	hasSchemaVersionSQL     = "SELECT EXISTS(SELECT 1 FROM schema_version WHERE version = $1);"
+	// concurrent migrations of the same database are serialized
+	lockSchemaVersionSQL = "LOCK TABLE schema_version IN EXCLUSIVE MODE;"
+)
+
+// migrationDriver runs migrations on Postgres.
+type migrationDriver struct {
+	conn *pgxpool.Pool
+}
+
+// Init creates the schema_version table if it doesn't exist.
+func (d *migrationDriver) Init(ctx context.Context) error {
+	_, err := d.conn.Exec(ctx, createSchemaVersionSQL)
+	return err
+}
+
+// Applied returns the applied versions along with the time they were applied.
+func (d *migrationDriver) Applied(ctx context.Context) (map[int]time.Time, error) {
+	rows, err := d.conn.Query(ctx, selectSchemaVersionsSQL)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
+
+// Apply runs the script and records (or forgets) the version in a single transaction.
+// If another server has applied (or rolled back) the migration while
+// the lock was awaited, nothing is done.
+func (d *migrationDriver) Apply(
+	ctx context.Context,
+	m migrate.Migration,
+	script string,
+	up bool,
+) error {
+	return pgx.BeginFunc(ctx, d.conn, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, lockSchemaVersionSQL); err != nil {
+			return err
+		}
+		var applied bool
+		if err := tx.QueryRow(ctx, hasSchemaVersionSQL, m.Version).Scan(&applied); err != nil {
+			return err
+		}
+		if applied == up {
+			return nil
+		}
+		if script != "" {
+			if _, err := tx.Exec(ctx, script); err != nil {
+				return err
+			}
+		}
+		var err error
+		if up {
+			_, err = tx.Exec(ctx, insertSchemaVersionSQL, m.Version, m.Name)
+		} else {
+			_, err = tx.Exec(ctx, deleteSchemaVersionSQL, m.Version)
+		}
+		return err
+	})
+}
+
+// Baseline returns the version of a database created before the migrations.
+// Such a database gets every migration: the scripts which create the tables
+// and indexes it already has skip them with IF NOT EXISTS.
+func (d *migrationDriver) Baseline(ctx context.Context) (int, error) {
+	return 0, nil
+}
+
+// newMigrator - migrator constructor for the database.
+func newMigrator(conn *pgxpool.Pool) (*migrate.Migrator, error) {
+	migrations, err := fs.Sub(migrationsFS, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	return migrate.New(&migrationDriver{conn: conn}, migrations)
+}
+
+// NewMigrator - migrator constructor for the database from the config.
+// The returned function closes the connection.
+func NewMigrator(conf *PostgresConfig) (*migrate.Migrator, func(), error) {
+	conn, err := pgxpool.New(context.Background(), conf.DatabaseDSN)
+	if err != nil {
+		return nil, nil, err
+	}
+	m, err := newMigrator(conn)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	return m, conn.Close, nil
+}
+
+// initDB initializes the database structure for further work.
+func initDB(conn *pgxpool.Pool, clearOnStart bool) error {
+	m, err := newMigrator(conn)
+	if err != nil {
+		return err
+	}
+	if _, err := m.Up(context.Background()); err != nil {
+		return fmt.Errorf("can't migrate DB: %v", err)
+	}
+	if clearOnStart {
+		if _, err := conn.Exec(context.Background(), clearSQL); err != nil {
+			return fmt.Errorf("can't clear table: %v", err)
+		}
+	}
+	return nil
+}
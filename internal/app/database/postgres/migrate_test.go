@@ -0,0 +1,50 @@
+package postgres
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcurrentUp(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewPostgresStorage(pgCfg)
+	if err != nil {
+		t.Skip("Skipping test, reason: connection to postgres cannot be established")
+	}
+	s.Close(ctx)
+	m, closeDB, err := NewMigrator(pgCfg)
+	require.NoError(t, err)
+	defer closeDB()
+	_, err = m.Down(ctx)
+	require.NoError(t, err)
+
+	// the servers started together apply the migration once
+	var wg sync.WaitGroup
+	errs := make([]error, 5)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			m, closeDB, err := NewMigrator(pgCfg)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			defer closeDB()
+			_, errs[i] = m.Up(ctx)
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		assert.NoError(t, err)
+	}
+	status, err := m.Status(ctx)
+	require.NoError(t, err)
+	for _, st := range status {
+		assert.True(t, st.Applied, st.Name)
+	}
+}
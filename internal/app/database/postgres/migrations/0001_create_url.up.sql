@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS Url(
+	encoding_id SERIAL PRIMARY KEY,
+	url VARCHAR NOT NULL,
+	url_id VARCHAR NOT NULL,
+	user_id BIGINT NOT NULL,
+	added TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	requested_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	is_deleted BOOLEAN DEFAULT FALSE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_url ON Url(url);
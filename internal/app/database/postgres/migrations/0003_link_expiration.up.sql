@@ -0,0 +1 @@
+ALTER TABLE Url ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ DEFAULT NULL;
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS Click(
+	click_id BIGSERIAL PRIMARY KEY,
+	url_id VARCHAR NOT NULL,
+	clicked_at TIMESTAMPTZ NOT NULL,
+	referrer VARCHAR NOT NULL DEFAULT '',
+	user_agent VARCHAR NOT NULL DEFAULT '',
+	country VARCHAR NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS idx_click_url_id ON Click(url_id, clicked_at);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS UrlOwner;
+DROP INDEX IF EXISTS idx_url_url;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_url ON Url(url);
@@ -0,0 +1 @@
+ALTER TABLE Url ADD COLUMN IF NOT EXISTS original_url TEXT DEFAULT NULL;
@@ -0,0 +1 @@
+ALTER TABLE Url DROP COLUMN IF EXISTS preview;
@@ -0,0 +1 @@
+ALTER TABLE Url ADD COLUMN IF NOT EXISTS preview JSONB DEFAULT NULL;
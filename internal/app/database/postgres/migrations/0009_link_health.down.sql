@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_url_checked_at;
+ALTER TABLE Url DROP COLUMN IF EXISTS check_error;
+ALTER TABLE Url DROP COLUMN IF EXISTS check_failures;
+ALTER TABLE Url DROP COLUMN IF EXISTS check_status;
+ALTER TABLE Url DROP COLUMN IF EXISTS checked_at;
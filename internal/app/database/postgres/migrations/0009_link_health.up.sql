@@ -0,0 +1,5 @@
+ALTER TABLE Url ADD COLUMN IF NOT EXISTS checked_at TIMESTAMPTZ DEFAULT NULL;
+ALTER TABLE Url ADD COLUMN IF NOT EXISTS check_status INT DEFAULT NULL;
+ALTER TABLE Url ADD COLUMN IF NOT EXISTS check_failures INT NOT NULL DEFAULT 0;
+ALTER TABLE Url ADD COLUMN IF NOT EXISTS check_error VARCHAR DEFAULT NULL;
+CREATE INDEX IF NOT EXISTS idx_url_checked_at ON Url(checked_at);
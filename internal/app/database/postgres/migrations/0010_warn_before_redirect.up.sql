@@ -0,0 +1 @@
+ALTER TABLE Url ADD COLUMN IF NOT EXISTS warn_before_redirect BOOLEAN NOT NULL DEFAULT FALSE;
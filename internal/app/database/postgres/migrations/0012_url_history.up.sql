@@ -0,0 +1,9 @@
+-- the previous destinations of the short URLs
+CREATE TABLE IF NOT EXISTS UrlHistory(
+	revision_id BIGSERIAL PRIMARY KEY,
+	url_id VARCHAR NOT NULL,
+	url VARCHAR NOT NULL,
+	changed_at TIMESTAMPTZ NOT NULL,
+	changed_by BIGINT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_url_history_url_id ON UrlHistory(url_id, changed_at);
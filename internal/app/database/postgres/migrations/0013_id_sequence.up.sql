@@ -0,0 +1,3 @@
+-- the counter of the generated IDs, it starts after the URLs shortened before
+CREATE SEQUENCE IF NOT EXISTS url_id_seq MINVALUE 0 START 0;
+SELECT setval('url_id_seq', (SELECT COUNT(*) FROM Url), false);
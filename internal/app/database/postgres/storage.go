@@ -2,8 +2,11 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/blokhinnv/shorty/internal/app/log"
 
@@ -15,18 +18,59 @@ import (
 
 // SQL queries to implement the necessary logic.
 const (
-	selectByURLIDSQL      = "SELECT url, user_id, is_deleted FROM Url WHERE url_id = $1;"
-	selectByUserIDSQL     = "SELECT url, url_id, is_deleted FROM Url WHERE user_id = $1;"
-	insertSQL             = "INSERT INTO Url(url, url_id, user_id) VALUES ($1, $2, $3);"
-	restoreSQL            = "UPDATE Url SET is_deleted=FALSE, user_id=$2 WHERE url_id=$1 AND is_deleted=TRUE;"
-	deleteBatchByURLIDSQL = "UPDATE Url SET is_deleted=TRUE WHERE url_id=ANY($1) AND user_id=$2 RETURNING url;"
-	uniqueViolationCode   = "23505"
-	clearSQL              = "DELETE FROM Url;"
+	recordColumns         = "u.url, u.original_url, u.url_id, u.user_id, u.is_deleted, u.expires_at, u.preview, u.checked_at, u.check_status, u.check_failures, u.check_error, u.warn_before_redirect, u.redirect_code"
+	selectByURLIDSQL      = "SELECT " + recordColumns + " FROM Url u WHERE u.url_id = $1;"
+	selectForUpdateSQL    = "SELECT " + recordColumns + " FROM Url u WHERE u.url_id = $1 FOR UPDATE;"
+	selectByUserIDSQL     = "SELECT " + recordColumns + " FROM Url u JOIN UrlOwner o ON o.encoding_id = u.encoding_id WHERE o.user_id = $1;"
+	selectToCheckSQL      = "SELECT " + recordColumns + " FROM Url u WHERE u.is_deleted=FALSE AND (u.expires_at IS NULL OR u.expires_at >= NOW()) AND (u.checked_at IS NULL OR u.checked_at < $1) ORDER BY u.checked_at ASC NULLS FIRST LIMIT $2;"
+	selectOwnedByURLSQL   = "SELECT u.url_id FROM Url u JOIN UrlOwner o ON o.encoding_id = u.encoding_id WHERE u.url = $1 AND o.user_id = $2 AND u.is_deleted=FALSE AND (u.expires_at IS NULL OR u.expires_at >= NOW());"
+	selectActiveByURLSQL  = "SELECT encoding_id, url_id FROM Url WHERE url = $1 AND is_deleted=FALSE AND (expires_at IS NULL OR expires_at >= NOW()) LIMIT 1;"
+	insertSQL             = "INSERT INTO Url(url, url_id, user_id, expires_at, original_url, warn_before_redirect, redirect_code) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING encoding_id;"
+	insertOwnerSQL        = "INSERT INTO UrlOwner(encoding_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING;"
+	selectRestorableSQL   = "SELECT encoding_id, url_id FROM Url WHERE url = $1 AND (is_deleted=TRUE OR expires_at < NOW()) LIMIT 1 FOR UPDATE;"
+	restoreSQL            = "UPDATE Url SET is_deleted=FALSE, user_id=$2, expires_at=$4, url_id=$1, original_url=$5, warn_before_redirect=$6, redirect_code=$7, preview=NULL, checked_at=NULL, check_status=NULL, check_failures=0, check_error=NULL WHERE encoding_id = $3;"
+	deleteOwnersSQL       = "DELETE FROM UrlOwner WHERE encoding_id = $1;"
+	deleteBatchByURLIDSQL = `
+WITH removed AS (
+	DELETE FROM UrlOwner o USING Url u
+	WHERE o.encoding_id = u.encoding_id AND u.url_id = ANY($1) AND o.user_id = $2
+	RETURNING o.encoding_id
+)
+UPDATE Url SET is_deleted=TRUE
+WHERE encoding_id IN (SELECT encoding_id FROM removed) AND NOT EXISTS (
+	SELECT 1 FROM UrlOwner o WHERE o.encoding_id = Url.encoding_id AND o.user_id <> $2
+)
+RETURNING url;`
+	deleteExpiredSQL        = "DELETE FROM Url WHERE expires_at < NOW();"
+	deleteExpiredClicksSQL  = "DELETE FROM Click WHERE url_id IN (SELECT url_id FROM Url WHERE expires_at < NOW());"
+	deleteExpiredHistorySQL = "DELETE FROM UrlHistory WHERE url_id IN (SELECT url_id FROM Url WHERE expires_at < NOW());"
+	deleteOrphanOwnersSQL   = "DELETE FROM UrlOwner WHERE encoding_id NOT IN (SELECT encoding_id FROM Url);"
+	deleteClicksSQL         = "DELETE FROM Click WHERE url_id = $1;"
+	deleteHistorySQL        = "DELETE FROM UrlHistory WHERE url_id = $1;"
+	insertClickSQL          = "INSERT INTO Click(url_id, clicked_at, referrer, user_agent, country) VALUES ($1, $2, $3, $4, $5);"
+	selectClicksSQL         = "SELECT clicked_at, referrer, user_agent, country FROM Click WHERE url_id = $1 ORDER BY clicked_at;"
+	uniqueViolationCode     = "23505"
+	insertUserSQL           = "INSERT INTO Account(user_id, username, password_hash, created_at) SELECT $1::BIGINT, $2::VARCHAR, $3::VARCHAR, $4::TIMESTAMPTZ WHERE NOT EXISTS (SELECT 1 FROM UrlOwner WHERE user_id = $1);"
+	selectUserSQL           = "SELECT user_id, password_hash, created_at FROM Account WHERE username = $1;"
+	insertAPIKeySQL         = "INSERT INTO ApiKey(key_hash, user_id, created_at) VALUES ($1, $2, $3);"
+	selectAPIKeySQL         = "SELECT user_id, created_at FROM ApiKey WHERE key_hash = $1;"
+	copyOwnedSQL            = "INSERT INTO UrlOwner(encoding_id, user_id) SELECT encoding_id, $1 FROM UrlOwner WHERE user_id = $2 ON CONFLICT DO NOTHING;"
+	deleteOwnedSQL          = "DELETE FROM UrlOwner WHERE user_id = $1;"
+	updatePreviewSQL        = "UPDATE Url SET preview = $1 WHERE url_id = $2;"
+	updateHealthSQL         = "UPDATE Url SET checked_at = $1, check_status = $2, check_failures = $3, check_error = $4 WHERE url_id = $5;"
+	selectOwnersSQL         = "SELECT o.user_id FROM UrlOwner o JOIN Url u ON u.encoding_id = o.encoding_id WHERE u.url_id = $1;"
+	updateURLSQL            = "UPDATE Url SET url = $1, original_url = $2, preview=NULL, checked_at=NULL, check_status=NULL, check_failures=0, check_error=NULL WHERE url_id = $3;"
+	insertRevisionSQL       = "INSERT INTO UrlHistory(url_id, url, changed_at, changed_by) VALUES ($1, $2, $3, $4);"
+	selectRevisionsSQL      = "SELECT url, changed_at, changed_by FROM UrlHistory WHERE url_id = $1 ORDER BY changed_at, revision_id;"
+	nextSequenceSQL         = "SELECT nextval('url_id_seq');"
+	clearSQL                = "DELETE FROM Url; DELETE FROM UrlOwner; DELETE FROM Click; DELETE FROM Account; DELETE FROM ApiKey; DELETE FROM UrlHistory; ALTER SEQUENCE url_id_seq RESTART;"
 )
 
 // PostgresStorage implements the Storage interface based on Postgres.
 type PostgresStorage struct {
-	conn *pgxpool.Pool
+	conn      *pgxpool.Pool
+	shareURLs bool
+	quit      chan struct{}
 }
 
 // NewPostgresStorage - A constructor for a new URL storage.
@@ -50,58 +94,271 @@ func NewPostgresStorage(conf *PostgresConfig) (*PostgresStorage, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &PostgresStorage{conn}, nil
+	s := &PostgresStorage{conn: conn, shareURLs: conf.ShareURLs, quit: make(chan struct{})}
+	s.registerPurgeExpired(conf.ReapInterval)
+	return s, nil
 }
 
-// AddURL - Method for adding a new URL to the database.
-func (s *PostgresStorage) AddURL(ctx context.Context, url, urlID string, userID uint32) error {
-	res, err := s.conn.Exec(ctx, restoreSQL, urlID, userID)
+// registerPurgeExpired starts purging expired URLs on a timer.
+func (s *PostgresStorage) registerPurgeExpired(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := s.PurgeExpired(context.Background()); err != nil {
+					log.Infof("Error while purging expired URLs: %v", err)
+				}
+			case <-s.quit:
+				return
+			}
+		}
+	}()
+}
+
+// PurgeExpired removes expired URLs from the database.
+func (s *PostgresStorage) PurgeExpired(ctx context.Context) (int64, error) {
+	tx, err := s.conn.Begin(ctx)
 	if err != nil {
-		log.Infof("Error while updating URL: %v", err)
-		return err
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+	// the IDs may be reused, so the visits and the history go along with the URLs
+	for _, query := range []string{deleteExpiredClicksSQL, deleteExpiredHistorySQL} {
+		if _, err := tx.Exec(ctx, query); err != nil {
+			return 0, err
+		}
+	}
+	res, err := tx.Exec(ctx, deleteExpiredSQL)
+	if err != nil {
+		return 0, err
 	}
 	n := res.RowsAffected()
-	// found a line to restore
 	if n > 0 {
-		return nil
+		if _, err := tx.Exec(ctx, deleteOrphanOwnersSQL); err != nil {
+			return 0, err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
 	}
-	// found a line to restore => need to add
-	_, err = s.conn.Exec(ctx, insertSQL, url, urlID, userID)
+	if n > 0 {
+		log.Infof("Purged %v expired URLs\n", n)
+	}
+	return n, nil
+}
+
+// isUniqueViolation checks if the query has violated a unique index.
+func isUniqueViolation(err error) bool {
+	var pgerr *pgconn.PgError
+	return errors.As(err, &pgerr) && pgerr.Code == uniqueViolationCode
+}
+
+// addURL adds the URL for the user. If addOwner is set and the links are shared,
+// the user becomes one more owner of the URL stored for other users,
+// otherwise such a URL is reported as a duplicate.
+func (s *PostgresStorage) addURL(
+	ctx context.Context,
+	url, urlID string,
+	userID uint32,
+	opts storage.LinkOptions,
+	addOwner bool,
+) error {
+	expiresAt := sql.NullTime{Time: opts.ExpiresAt, Valid: !opts.ExpiresAt.IsZero()}
+	originalURL := sql.NullString{String: opts.OriginalURL, Valid: opts.OriginalURL != ""}
+	var existingID string
+	var encodingID int64
+	// the URL and its owners change together
+	tx, err := s.conn.BeginTx(ctx, pgx.TxOptions{})
 	if err != nil {
-		log.Infof("Error while adding URL: %v", err)
-		var pgerr *pgconn.PgError
-		if errors.As(err, &pgerr) {
-			if pgerr.Code == uniqueViolationCode {
-				return fmt.Errorf(
-					"%w: url=%v, urlID=%v, userID=%v",
-					storage.ErrUniqueViolation,
-					url,
-					urlID,
-					userID,
-				)
+		return err
+	}
+	defer tx.Rollback(ctx)
+	// the user has already shortened the URL
+	err = tx.QueryRow(ctx, selectOwnedByURLSQL, url, userID).Scan(&existingID)
+	if err == nil {
+		return &storage.DuplicateURLError{URL: url, URLID: existingID}
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	if s.shareURLs {
+		err := tx.QueryRow(ctx, selectActiveByURLSQL, url).Scan(&encodingID, &existingID)
+		switch {
+		case err == nil:
+			dupErr := &storage.DuplicateURLError{URL: url, URLID: existingID}
+			if !addOwner {
+				return dupErr
+			}
+			if _, err := tx.Exec(ctx, insertOwnerSQL, encodingID, userID); err != nil {
+				return err
+			}
+			if err := tx.Commit(ctx); err != nil {
+				return err
+			}
+			log.Infof("Added user %v to owners of %v\n", userID, existingID)
+			dupErr.NewOwner = true
+			return dupErr
+		case !errors.Is(err, pgx.ErrNoRows):
+			return err
+		}
+	}
+	// a deleted (or expired) URL gets back under the new ID;
+	// the row is locked, so a concurrent add can't restore it once more
+	var prevID string
+	err = tx.QueryRow(ctx, selectRestorableSQL, url).Scan(&encodingID, &prevID)
+	restored := err == nil
+	switch {
+	case restored:
+		// the visits and the history belong to the previous owners
+		for _, query := range []string{deleteClicksSQL, deleteHistorySQL} {
+			if _, err := tx.Exec(ctx, query, prevID); err != nil {
+				return err
 			}
 		}
+		_, err = tx.Exec(
+			ctx,
+			restoreSQL,
+			urlID,
+			userID,
+			encodingID,
+			expiresAt,
+			originalURL,
+			opts.WarnBeforeRedirect,
+			opts.RedirectCode,
+		)
+	case errors.Is(err, pgx.ErrNoRows):
+		// nothing to restore => must be added
+		err = tx.QueryRow(
+			ctx,
+			insertSQL,
+			url,
+			urlID,
+			userID,
+			expiresAt,
+			originalURL,
+			opts.WarnBeforeRedirect,
+			opts.RedirectCode,
+		).Scan(&encodingID)
+	}
+	if err != nil {
+		log.Infof("Error while adding URL: %v", err)
+		if isUniqueViolation(err) {
+			return fmt.Errorf(
+				"%w: url=%v, urlID=%v, userID=%v",
+				storage.ErrURLIDTaken,
+				url,
+				urlID,
+				userID,
+			)
+		}
+		return err
+	}
+	// the owners of an expired URL don't get it back
+	if restored {
+		if _, err := tx.Exec(ctx, deleteOwnersSQL, encodingID); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(ctx, insertOwnerSQL, encodingID, userID); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
 		return err
 	}
 	log.Infof("Added %v=>%v to storage\n", url, urlID)
 	return nil
 }
 
+// AddURL - Method for adding a new URL to the database.
+func (s *PostgresStorage) AddURL(
+	ctx context.Context,
+	url, urlID string,
+	userID uint32,
+	opts storage.LinkOptions,
+) error {
+	return s.addURL(ctx, url, urlID, userID, opts, true)
+}
+
+// scanRecord reads the record selected with recordColumns.
+func scanRecord(row pgx.Row) (storage.Record, error) {
+	var rec storage.Record
+	var expiresAt, checkedAt sql.NullTime
+	var originalURL, checkError sql.NullString
+	var checkStatus sql.NullInt64
+	var checkFailures int
+	var preview []byte
+	err := row.Scan(
+		&rec.URL,
+		&originalURL,
+		&rec.URLID,
+		&rec.UserID,
+		&rec.IsDeleted,
+		&expiresAt,
+		&preview,
+		&checkedAt,
+		&checkStatus,
+		&checkFailures,
+		&checkError,
+		&rec.WarnBeforeRedirect,
+		&rec.RedirectCode,
+	)
+	if err != nil {
+		return storage.Record{}, err
+	}
+	rec.ExpiresAt = expiresAt.Time
+	rec.OriginalURL = originalURL.String
+	rec.Preview = toPreview(preview)
+	if checkedAt.Valid {
+		rec.Health = &storage.Health{
+			CheckedAt:  checkedAt.Time,
+			StatusCode: int(checkStatus.Int64),
+			Error:      checkError.String,
+			Failures:   checkFailures,
+		}
+	}
+	return rec, nil
+}
+
+// scanRecords reads the records selected with recordColumns skipping the deleted ones.
+func scanRecords(rows pgx.Rows) ([]storage.Record, error) {
+	results := make([]storage.Record, 0)
+	// Iterate through all records with the rows.Next() method until
+	// until we go through all available results
+	for rows.Next() {
+		rec, err := scanRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		if !rec.IsDeleted {
+			results = append(results, rec)
+		}
+	}
+	// After the loop, check the records for potential errors (break
+	// network connection to the database server in the process of getting query results)
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
 // GetURLByID returns a URL by its ID in the database.
 func (s *PostgresStorage) GetURLByID(ctx context.Context, urlID string) (storage.Record, error) {
-	rec := storage.Record{URLID: urlID}
-	// Get rows
-	var isDeleted bool
-	err := s.conn.QueryRow(ctx, selectByURLIDSQL, urlID).
-		Scan(&rec.URL, &rec.UserID, &isDeleted)
-
+	rec, err := scanRecord(s.conn.QueryRow(ctx, selectByURLIDSQL, urlID))
 	// any error here (including ErrNoRows) means no result found
 	if err != nil {
 		return storage.Record{}, storage.ErrURLWasNotFound
 	}
-	if isDeleted {
+	if rec.IsDeleted {
 		return storage.Record{}, storage.ErrURLWasDeleted
 	}
+	if rec.IsExpired() {
+		return storage.Record{}, storage.ErrURLExpired
+	}
 	return rec, nil
 }
 
@@ -110,35 +367,23 @@ func (s *PostgresStorage) GetURLsByUser(
 	ctx context.Context,
 	userID uint32,
 ) ([]storage.Record, error) {
-	results := make([]storage.Record, 0)
-
 	rows, err := s.conn.Query(ctx, selectByUserIDSQL, userID)
 	if err != nil {
 		return nil, err
 	}
 	// don't forget to close the object!
 	defer rows.Close()
-
-	// Iterate through all records with the rows.Next() method until
-	// until we go through all available results
-	for rows.Next() {
-		var isDeleted bool
-		rec := storage.Record{UserID: userID}
-		if err := rows.Scan(&rec.URL, &rec.URLID, &isDeleted); err != nil {
-			return nil, err
-		}
-		if !isDeleted {
-			results = append(results, rec)
-		}
-	}
-	// After the loop, check the records for potential errors (break
-	// network connection to the database server in the process of getting query results)
-	if err := rows.Err(); err != nil {
+	results, err := scanRecords(rows)
+	if err != nil {
 		return nil, err
 	}
 	if len(results) == 0 {
 		return nil, storage.ErrURLWasNotFound
 	}
+	// the URLs are listed as the user's ones
+	for i := range results {
+		results[i].UserID = userID
+	}
 	return results, nil
 }
 
@@ -148,35 +393,25 @@ func (s *PostgresStorage) AddURLBatch(
 	urlIDs map[string]string,
 	userID uint32,
 ) error {
-	batch := &pgx.Batch{}
+	var violationErr error
 	for url, urlID := range urlIDs {
 		// pgx automatically prepares and caches statements by default
-		res, err := s.conn.Exec(ctx, restoreSQL, urlID, userID)
+		err := s.addURL(ctx, url, urlID, userID, storage.LinkOptions{}, false)
 		if err != nil {
+			// the URL (or its ID) is already stored
+			if errors.Is(err, storage.ErrUniqueViolation) {
+				violationErr = err
+				continue
+			}
 			return err
 		}
-		n := res.RowsAffected()
-		if n > 0 {
-			continue
-		}
-		batch.Queue(insertSQL, url, urlID, userID)
 		log.Println("added row: ", url, urlID, userID)
 	}
-	br := s.conn.SendBatch(ctx, batch)
-	defer br.Close()
-	_, err := br.Exec()
-	var pgerr *pgconn.PgError
-	if errors.As(err, &pgerr) {
-		if pgerr.Code == uniqueViolationCode {
-			return storage.ErrUniqueViolation
-		}
-	} else {
-		return err
-	}
-	return nil
+	return violationErr
 }
 
-// DeleteMany flags the URL to be deleted.
+// DeleteMany removes the user from the owners of the URLs
+// and flags the URLs without owners as deleted.
 func (s *PostgresStorage) DeleteMany(ctx context.Context, userID uint32, urlIDs []string) error {
 	rows, err := s.conn.Query(ctx, deleteBatchByURLIDSQL, urlIDs, userID)
 	if err != nil {
@@ -208,6 +443,7 @@ func (s *PostgresStorage) DeleteMany(ctx context.Context, userID uint32, urlIDs
 
 // Close closes the connection to Postgres.
 func (s *PostgresStorage) Close(ctx context.Context) {
+	close(s.quit)
 	s.conn.Close()
 }
 
@@ -232,10 +468,296 @@ func (s *PostgresStorage) GetStats(ctx context.Context) (int, int, error) {
 	}
 
 	var users int
-	err = s.conn.QueryRow(ctx, "SELECT COUNT(DISTINCT user_id) FROM Url").
+	err = s.conn.QueryRow(ctx, "SELECT COUNT(DISTINCT user_id) FROM UrlOwner").
 		Scan(&users)
 	if err != nil {
 		return 0, 0, err
 	}
 	return urls, users, nil
 }
+
+// AddClicks saves a batch of short URL visits.
+func (s *PostgresStorage) AddClicks(ctx context.Context, clicks []storage.Click) error {
+	batch := &pgx.Batch{}
+	for _, c := range clicks {
+		batch.Queue(insertClickSQL, c.URLID, c.ClickedAt, c.Referrer, c.UserAgent, c.Country)
+	}
+	return s.conn.SendBatch(ctx, batch).Close()
+}
+
+// GetClicks gets all visits of a short URL ordered by time.
+func (s *PostgresStorage) GetClicks(ctx context.Context, urlID string) ([]storage.Click, error) {
+	rows, err := s.conn.Query(ctx, selectClicksSQL, urlID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	results := make([]storage.Click, 0)
+	for rows.Next() {
+		c := storage.Click{URLID: urlID}
+		if err := rows.Scan(&c.ClickedAt, &c.Referrer, &c.UserAgent, &c.Country); err != nil {
+			return nil, err
+		}
+		results = append(results, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// AddUser registers the user.
+func (s *PostgresStorage) AddUser(ctx context.Context, user storage.User) error {
+	res, err := s.conn.Exec(
+		ctx,
+		insertUserSQL,
+		user.UserID,
+		user.Username,
+		user.PasswordHash,
+		user.CreatedAt,
+	)
+	switch {
+	case isUniqueViolation(err):
+		// either the name or the ID is used by another account
+		if _, err := s.GetUser(ctx, user.Username); err == nil {
+			return fmt.Errorf("%w: username=%v", storage.ErrUsernameTaken, user.Username)
+		}
+		return fmt.Errorf("%w: userID=%v", storage.ErrUserIDTaken, user.UserID)
+	case err != nil:
+		return err
+	}
+	// the ID belongs to a user without an account
+	if res.RowsAffected() == 0 {
+		return fmt.Errorf("%w: userID=%v", storage.ErrUserIDTaken, user.UserID)
+	}
+	return nil
+}
+
+// GetUser gets the registered user by name.
+func (s *PostgresStorage) GetUser(ctx context.Context, username string) (storage.User, error) {
+	user := storage.User{Username: username}
+	err := s.conn.QueryRow(ctx, selectUserSQL, username).
+		Scan(&user.UserID, &user.PasswordHash, &user.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return storage.User{}, storage.ErrUserNotFound
+	}
+	if err != nil {
+		return storage.User{}, err
+	}
+	return user, nil
+}
+
+// AddAPIKey saves the API key of the user.
+func (s *PostgresStorage) AddAPIKey(ctx context.Context, key storage.APIKey) error {
+	_, err := s.conn.Exec(ctx, insertAPIKeySQL, key.KeyHash, key.UserID, key.CreatedAt)
+	return err
+}
+
+// GetAPIKey gets the API key by its hash.
+func (s *PostgresStorage) GetAPIKey(ctx context.Context, keyHash string) (storage.APIKey, error) {
+	key := storage.APIKey{KeyHash: keyHash}
+	err := s.conn.QueryRow(ctx, selectAPIKeySQL, keyHash).Scan(&key.UserID, &key.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return storage.APIKey{}, storage.ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return storage.APIKey{}, err
+	}
+	return key, nil
+}
+
+// MoveURLs passes the URLs owned by one user to another.
+func (s *PostgresStorage) MoveURLs(ctx context.Context, fromUserID, toUserID uint32) (int, error) {
+	tx, err := s.conn.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+	if _, err := tx.Exec(ctx, copyOwnedSQL, toUserID, fromUserID); err != nil {
+		return 0, err
+	}
+	res, err := tx.Exec(ctx, deleteOwnedSQL, fromUserID)
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	n := res.RowsAffected()
+	log.Infof("Moved %v URLs of user %v to user %v\n", n, fromUserID, toUserID)
+	return int(n), nil
+}
+
+// toPreview decodes the stored preview, the preview which can't be decoded is missing.
+func toPreview(data []byte) *storage.Preview {
+	if len(data) == 0 {
+		return nil
+	}
+	var preview storage.Preview
+	if err := json.Unmarshal(data, &preview); err != nil {
+		log.Warnf("Can't decode preview: %v", err)
+		return nil
+	}
+	return &preview
+}
+
+// SetPreview saves the preview of the URL's destination.
+func (s *PostgresStorage) SetPreview(ctx context.Context, urlID string, preview storage.Preview) error {
+	data, err := json.Marshal(preview)
+	if err != nil {
+		return err
+	}
+	res, err := s.conn.Exec(ctx, updatePreviewSQL, string(data), urlID)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return storage.ErrURLWasNotFound
+	}
+	return nil
+}
+
+// GetURLsToCheck gets the active URLs which haven't been checked since the time.
+func (s *PostgresStorage) GetURLsToCheck(
+	ctx context.Context,
+	checkedBefore time.Time,
+	limit int,
+) ([]storage.Record, error) {
+	rows, err := s.conn.Query(ctx, selectToCheckSQL, checkedBefore, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanRecords(rows)
+}
+
+// SetHealth saves the result of the check of the URL's destination.
+func (s *PostgresStorage) SetHealth(ctx context.Context, urlID string, health storage.Health) error {
+	res, err := s.conn.Exec(
+		ctx,
+		updateHealthSQL,
+		health.CheckedAt,
+		sql.NullInt64{Int64: int64(health.StatusCode), Valid: health.StatusCode != 0},
+		health.Failures,
+		sql.NullString{String: health.Error, Valid: health.Error != ""},
+		urlID,
+	)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return storage.ErrURLWasNotFound
+	}
+	return nil
+}
+
+// selectOwners gets the users who own the URL.
+func selectOwners(ctx context.Context, tx pgx.Tx, urlID string) ([]uint32, error) {
+	rows, err := tx.Query(ctx, selectOwnersSQL, urlID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	owners := make([]uint32, 0)
+	for rows.Next() {
+		var owner uint32
+		if err := rows.Scan(&owner); err != nil {
+			return nil, err
+		}
+		owners = append(owners, owner)
+	}
+	return owners, rows.Err()
+}
+
+// UpdateURL changes the destination of the URL owned by the user
+// and saves the previous one to the history.
+func (s *PostgresStorage) UpdateURL(
+	ctx context.Context,
+	urlID string,
+	userID uint32,
+	url, originalURL string,
+) error {
+	tx, err := s.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	rec, err := scanRecord(tx.QueryRow(ctx, selectForUpdateSQL, urlID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return storage.ErrURLWasNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if rec.Owners, err = selectOwners(ctx, tx, urlID); err != nil {
+		return err
+	}
+	if err := rec.CheckUpdate(userID); err != nil {
+		return err
+	}
+	// the destination is the same
+	if rec.URL == url {
+		return nil
+	}
+	// the new destination may be stored under another ID
+	var existingID string
+	err = tx.QueryRow(ctx, selectOwnedByURLSQL, url, userID).Scan(&existingID)
+	if err == nil {
+		return &storage.DuplicateURLError{URL: url, URLID: existingID}
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	if s.shareURLs {
+		var encodingID int64
+		err := tx.QueryRow(ctx, selectActiveByURLSQL, url).Scan(&encodingID, &existingID)
+		if err == nil {
+			return &storage.DuplicateURLError{URL: url, URLID: existingID}
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+	}
+	if _, err := tx.Exec(ctx, insertRevisionSQL, urlID, rec.URL, time.Now(), userID); err != nil {
+		return err
+	}
+	nullOriginalURL := sql.NullString{String: originalURL, Valid: originalURL != ""}
+	if _, err := tx.Exec(ctx, updateURLSQL, url, nullOriginalURL, urlID); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	log.Infof("Changed destination of %v from %v to %v\n", urlID, rec.URL, url)
+	return nil
+}
+
+// GetURLHistory gets the previous destinations of a short URL ordered by time.
+func (s *PostgresStorage) GetURLHistory(ctx context.Context, urlID string) ([]storage.Revision, error) {
+	rows, err := s.conn.Query(ctx, selectRevisionsSQL, urlID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	results := make([]storage.Revision, 0)
+	for rows.Next() {
+		r := storage.Revision{URLID: urlID}
+		if err := rows.Scan(&r.URL, &r.ChangedAt, &r.ChangedBy); err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// NextSequence returns the next value of the counter used to generate IDs.
+func (s *PostgresStorage) NextSequence(ctx context.Context) (uint64, error) {
+	var n int64
+	if err := s.conn.QueryRow(ctx, nextSequenceSQL).Scan(&n); err != nil {
+		return 0, err
+	}
+	return uint64(n), nil
+}
@@ -0,0 +1,877 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/blokhinnv/shorty/internal/app/log"
+	"github.com/blokhinnv/shorty/internal/app/storage"
+)
+
+// Redis keys. Every record is a hash, its owners are a set and the URLs
+// of a user are a sorted set ordered by the time they were added.
+const (
+	keyPrefix   = "shorty:"
+	statsKey    = keyPrefix + "stats"
+	usersKey    = keyPrefix + "users"
+	urlsCounter = "urls"
+	idsCounter  = "ids"
+	// how many times a transaction is retried if the watched keys have changed
+	maxTxRetries = 10
+)
+
+// urlKey - key of the record hash.
+func urlKey(urlID string) string {
+	return keyPrefix + "url:" + urlID
+}
+
+// ownersKey - key of the set of users who own the record.
+func ownersKey(urlID string) string {
+	return keyPrefix + "owners:" + urlID
+}
+
+// userKey - key of the sorted set of the user's URL IDs.
+func userKey(userID uint32) string {
+	return keyPrefix + "user:" + strconv.FormatUint(uint64(userID), 10)
+}
+
+// byURLKey - key of the set of IDs the URL is stored under.
+func byURLKey(url string) string {
+	return keyPrefix + "by_url:" + url
+}
+
+// clicksKey - key of the list of the URL visits.
+func clicksKey(urlID string) string {
+	return keyPrefix + "clicks:" + urlID
+}
+
+// historyKey - key of the list of the previous destinations of the URL.
+func historyKey(urlID string) string {
+	return keyPrefix + "history:" + urlID
+}
+
+// accountIDsKey - key of the set of the IDs of the registered users.
+const accountIDsKey = keyPrefix + "account_ids"
+
+// accountKey - key of the JSON account of the user.
+func accountKey(username string) string {
+	return keyPrefix + "account:" + username
+}
+
+// apiKeyKey - key of the JSON API key.
+func apiKeyKey(keyHash string) string {
+	return keyPrefix + "api_key:" + keyHash
+}
+
+// hashRecord - the form a record is kept in a hash. Times are unix nanoseconds.
+type hashRecord struct {
+	URL         string `redis:"url"`
+	OriginalURL string `redis:"original_url"`
+	UserID      uint32 `redis:"user_id"`
+	Added       int64  `redis:"added"`
+	IsDeleted   bool   `redis:"is_deleted"`
+	ExpiresAt   int64  `redis:"expires_at"`
+	// the visitors see the destination before they are redirected
+	WarnBeforeRedirect bool `redis:"warn_before_redirect"`
+	// the status code of the redirect, zero means the default one
+	RedirectCode int `redis:"redirect_code"`
+	// JSON of the preview, empty until it's fetched
+	Preview string `redis:"preview"`
+	// JSON of the result of the last check, empty until it's checked
+	Health string `redis:"health"`
+}
+
+// fields returns the hash fields of the record.
+func (h hashRecord) fields() map[string]any {
+	return map[string]any{
+		"url":                  h.URL,
+		"original_url":         h.OriginalURL,
+		"user_id":              h.UserID,
+		"added":                h.Added,
+		"is_deleted":           h.IsDeleted,
+		"expires_at":           h.ExpiresAt,
+		"warn_before_redirect": h.WarnBeforeRedirect,
+		"redirect_code":        h.RedirectCode,
+		"preview":              h.Preview,
+		"health":               h.Health,
+	}
+}
+
+// toRecord converts the hash to a storage record.
+func (h hashRecord) toRecord(urlID string) storage.Record {
+	rec := storage.Record{
+		URL:                h.URL,
+		OriginalURL:        h.OriginalURL,
+		URLID:              urlID,
+		UserID:             h.UserID,
+		Added:              time.Unix(0, h.Added),
+		IsDeleted:          h.IsDeleted,
+		WarnBeforeRedirect: h.WarnBeforeRedirect,
+		RedirectCode:       h.RedirectCode,
+	}
+	if h.ExpiresAt != 0 {
+		rec.ExpiresAt = time.Unix(0, h.ExpiresAt)
+	}
+	if h.Preview != "" {
+		var preview storage.Preview
+		if err := json.Unmarshal([]byte(h.Preview), &preview); err == nil {
+			rec.Preview = &preview
+		}
+	}
+	if h.Health != "" {
+		var health storage.Health
+		if err := json.Unmarshal([]byte(h.Health), &health); err == nil {
+			rec.Health = &health
+		}
+	}
+	return rec
+}
+
+// toUnixNano converts time to the form which is stored in a hash.
+func toUnixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+// RedisStorage implements the Storage interface based on Redis.
+type RedisStorage struct {
+	client       *goredis.Client
+	reapInterval time.Duration
+	shareURLs    bool
+}
+
+// NewRedisStorage - A constructor for a new URL storage.
+func NewRedisStorage(conf *RedisConfig) (*RedisStorage, error) {
+	opts, err := goredis.ParseURL(conf.URL)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse Redis URL %s: %v", conf.URL, err)
+	}
+	client := goredis.NewClient(opts)
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("can't access Redis %s: %v", conf.URL, err)
+	}
+	s := &RedisStorage{
+		client:       client,
+		reapInterval: conf.ReapInterval,
+		shareURLs:    conf.ShareURLs,
+	}
+	if conf.ClearOnStart {
+		if err := s.Clear(ctx); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("can't clear Redis: %v", err)
+		}
+	}
+	// the counter of the generated IDs starts after the URLs shortened before it
+	urls, _, err := s.GetStats(ctx)
+	if err == nil {
+		err = client.HSetNX(ctx, statsKey, idsCounter, urls).Err()
+	}
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("can't init Redis: %v", err)
+	}
+	return s, nil
+}
+
+// getRecord reads the record hash.
+func getRecord(ctx context.Context, c goredis.Cmdable, urlID string) (storage.Record, error) {
+	cmd := c.HGetAll(ctx, urlKey(urlID))
+	fields, err := cmd.Result()
+	if err != nil {
+		return storage.Record{}, err
+	}
+	// expired records are removed by Redis
+	if len(fields) == 0 {
+		return storage.Record{}, storage.ErrURLWasNotFound
+	}
+	var h hashRecord
+	if err := cmd.Scan(&h); err != nil {
+		return storage.Record{}, err
+	}
+	return h.toRecord(urlID), nil
+}
+
+// watch runs the transaction and retries it if the watched keys have changed.
+func (s *RedisStorage) watch(ctx context.Context, fn func(*goredis.Tx) error, keys ...string) error {
+	for i := 0; i < maxTxRetries; i++ {
+		err := s.client.Watch(ctx, fn, keys...)
+		if !errors.Is(err, goredis.TxFailedErr) {
+			return err
+		}
+	}
+	return goredis.TxFailedErr
+}
+
+// addOwner queues adding the user to the owners of the URL.
+func addOwner(ctx context.Context, pipe goredis.Pipeliner, urlID string, userID uint32) {
+	pipe.SAdd(ctx, ownersKey(urlID), userID)
+	pipe.ZAdd(ctx, userKey(userID), goredis.Z{Score: float64(time.Now().UnixNano()), Member: urlID})
+	pipe.SAdd(ctx, usersKey, userID)
+}
+
+// expire queues setting the time Redis removes the URL at.
+func (s *RedisStorage) expire(
+	ctx context.Context,
+	pipe goredis.Pipeliner,
+	urlID string,
+	expiresAt time.Time,
+) {
+	// the IDs may be reused, so the visits and the history go along with the URLs
+	keys := []string{urlKey(urlID), ownersKey(urlID), clicksKey(urlID), historyKey(urlID)}
+	for _, key := range keys {
+		if expiresAt.IsZero() || s.reapInterval <= 0 {
+			pipe.Persist(ctx, key)
+		} else {
+			pipe.PExpireAt(ctx, key, expiresAt.Add(s.reapInterval))
+		}
+	}
+}
+
+// addURL adds the URL for the user. If shareExisting is set and the links are shared,
+// the user becomes one more owner of the URL stored for other users,
+// otherwise such a URL is reported as a duplicate.
+func (s *RedisStorage) addURL(
+	ctx context.Context,
+	url, urlID string,
+	userID uint32,
+	opts storage.LinkOptions,
+	shareExisting bool,
+) error {
+	expiresAt := opts.ExpiresAt
+	txf := func(tx *goredis.Tx) error {
+		ids, err := tx.SMembers(ctx, byURLKey(url)).Result()
+		if err != nil {
+			return err
+		}
+		var shared string
+		restore := false
+		for _, id := range ids {
+			rec, err := getRecord(ctx, tx, id)
+			if errors.Is(err, storage.ErrURLWasNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			// a deleted (or expired) URL gets back under the same ID
+			if rec.IsDeleted || rec.IsExpired() {
+				restore = restore || id == urlID
+				continue
+			}
+			owned, err := tx.SIsMember(ctx, ownersKey(id), userID).Result()
+			if err != nil {
+				return err
+			}
+			// the user has already shortened the URL
+			if owned {
+				return &storage.DuplicateURLError{URL: url, URLID: id}
+			}
+			if s.shareURLs {
+				shared = id
+			}
+		}
+		if shared != "" {
+			dupErr := &storage.DuplicateURLError{URL: url, URLID: shared}
+			if !shareExisting {
+				return dupErr
+			}
+			_, err := tx.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+				addOwner(ctx, pipe, shared, userID)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			log.Infof("Added user %v to owners of %v\n", userID, shared)
+			dupErr.NewOwner = true
+			return dupErr
+		}
+		var prevOwners []string
+		if restore {
+			// the owners of an expired URL don't get it back
+			if prevOwners, err = tx.SMembers(ctx, ownersKey(urlID)).Result(); err != nil {
+				return err
+			}
+		} else {
+			n, err := tx.Exists(ctx, urlKey(urlID)).Result()
+			if err != nil {
+				return err
+			}
+			if n > 0 {
+				return fmt.Errorf(
+					"%w: url=%v, urlID=%v, userID=%v",
+					storage.ErrURLIDTaken,
+					url,
+					urlID,
+					userID,
+				)
+			}
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+			for _, owner := range prevOwners {
+				pipe.ZRem(ctx, keyPrefix+"user:"+owner, urlID)
+			}
+			if restore {
+				pipe.Del(ctx, ownersKey(urlID))
+			} else {
+				pipe.HIncrBy(ctx, statsKey, urlsCounter, 1)
+			}
+			// the visits and the history of a removed URL may outlive it,
+			// the ones of a restored URL belong to the previous owners
+			pipe.Del(ctx, clicksKey(urlID), historyKey(urlID))
+			h := hashRecord{
+				URL:                url,
+				OriginalURL:        opts.OriginalURL,
+				UserID:             userID,
+				Added:              time.Now().UnixNano(),
+				ExpiresAt:          toUnixNano(expiresAt),
+				WarnBeforeRedirect: opts.WarnBeforeRedirect,
+				RedirectCode:       opts.RedirectCode,
+			}
+			pipe.HSet(ctx, urlKey(urlID), h.fields())
+			pipe.SAdd(ctx, byURLKey(url), urlID)
+			addOwner(ctx, pipe, urlID, userID)
+			s.expire(ctx, pipe, urlID, expiresAt)
+			return nil
+		})
+		return err
+	}
+	if err := s.watch(ctx, txf, byURLKey(url), urlKey(urlID)); err != nil {
+		log.Infof("Error while adding URL: %v", err)
+		return err
+	}
+	log.Infof("Added %v=>%v to storage\n", url, urlID)
+	return nil
+}
+
+// AddURL - method for adding a new URL to Redis.
+func (s *RedisStorage) AddURL(
+	ctx context.Context,
+	url, urlID string,
+	userID uint32,
+	opts storage.LinkOptions,
+) error {
+	return s.addURL(ctx, url, urlID, userID, opts, true)
+}
+
+// AddURLBatch adds a batch of URLs to the store.
+func (s *RedisStorage) AddURLBatch(
+	ctx context.Context,
+	urlIDs map[string]string,
+	userID uint32,
+) error {
+	var violationErr error
+	for url, urlID := range urlIDs {
+		err := s.addURL(ctx, url, urlID, userID, storage.LinkOptions{}, false)
+		if err != nil {
+			// the URL (or its ID) is already stored
+			if errors.Is(err, storage.ErrUniqueViolation) {
+				violationErr = err
+				continue
+			}
+			return err
+		}
+	}
+	return violationErr
+}
+
+// GetURLByID returns a URL by its ID.
+func (s *RedisStorage) GetURLByID(ctx context.Context, urlID string) (storage.Record, error) {
+	rec, err := getRecord(ctx, s.client, urlID)
+	if err != nil {
+		return storage.Record{}, storage.ErrURLWasNotFound
+	}
+	if rec.IsDeleted {
+		return storage.Record{}, storage.ErrURLWasDeleted
+	}
+	if rec.IsExpired() {
+		return storage.Record{}, storage.ErrURLExpired
+	}
+	return rec, nil
+}
+
+// GetURLsByUser gets URLs owned by the user.
+func (s *RedisStorage) GetURLsByUser(
+	ctx context.Context,
+	userID uint32,
+) ([]storage.Record, error) {
+	ids, err := s.client.ZRange(ctx, userKey(userID), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	// the sorted set keeps the IDs of the URLs removed by Redis,
+	// so the IDs may be taken by other users since then
+	hashCmds := make([]*goredis.MapStringStringCmd, len(ids))
+	ownedCmds := make([]*goredis.BoolCmd, len(ids))
+	_, err = s.client.Pipelined(ctx, func(pipe goredis.Pipeliner) error {
+		for i, id := range ids {
+			hashCmds[i] = pipe.HGetAll(ctx, urlKey(id))
+			ownedCmds[i] = pipe.SIsMember(ctx, ownersKey(id), userID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	results := make([]storage.Record, 0, len(ids))
+	for i, hashCmd := range hashCmds {
+		// the URL has been removed by Redis or belongs to other users now
+		if len(hashCmd.Val()) == 0 || !ownedCmds[i].Val() {
+			continue
+		}
+		var h hashRecord
+		if err := hashCmd.Scan(&h); err != nil {
+			return nil, err
+		}
+		rec := h.toRecord(ids[i])
+		rec.UserID = userID
+		if !rec.IsDeleted {
+			results = append(results, rec)
+		}
+	}
+	if len(results) == 0 {
+		return nil, storage.ErrURLWasNotFound
+	}
+	return results, nil
+}
+
+// DeleteMany removes the user from the owners of the URLs
+// and flags the URLs without owners as deleted.
+func (s *RedisStorage) DeleteMany(ctx context.Context, userID uint32, urlIDs []string) error {
+	updated := make([]string, 0)
+	for _, urlID := range urlIDs {
+		deleted := false
+		txf := func(tx *goredis.Tx) error {
+			owned, err := tx.SIsMember(ctx, ownersKey(urlID), userID).Result()
+			if err != nil || !owned {
+				return err
+			}
+			n, err := tx.SCard(ctx, ownersKey(urlID)).Result()
+			if err != nil {
+				return err
+			}
+			_, err = tx.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+				pipe.SRem(ctx, ownersKey(urlID), userID)
+				pipe.ZRem(ctx, userKey(userID), urlID)
+				// no owners remain
+				if n == 1 {
+					pipe.HSet(ctx, urlKey(urlID), "is_deleted", true)
+				}
+				return nil
+			})
+			deleted = err == nil && n == 1
+			return err
+		}
+		if err := s.watch(ctx, txf, ownersKey(urlID)); err != nil {
+			log.Infof("Error while deleting URL: %v", err)
+			return err
+		}
+		if deleted {
+			updated = append(updated, urlID)
+		}
+	}
+	log.Infof("Set %v as deleted\n", updated)
+	return nil
+}
+
+// Close closes the connection to Redis.
+func (s *RedisStorage) Close(ctx context.Context) {
+	s.client.Close()
+}
+
+// Ping checks the connection to the repository.
+func (s *RedisStorage) Ping(ctx context.Context) bool {
+	return s.client.Ping(ctx).Err() == nil
+}
+
+// Clear clears the storage.
+func (s *RedisStorage) Clear(ctx context.Context) error {
+	iter := s.client.Scan(ctx, 0, keyPrefix+"*", 0).Iterator()
+	for iter.Next(ctx) {
+		if err := s.client.Del(ctx, iter.Val()).Err(); err != nil {
+			return err
+		}
+	}
+	return iter.Err()
+}
+
+// Returns DB stats. URLs removed by Redis after expiration are still counted.
+func (s *RedisStorage) GetStats(ctx context.Context) (int, int, error) {
+	urls, err := s.client.HGet(ctx, statsKey, urlsCounter).Int()
+	if err != nil && !errors.Is(err, goredis.Nil) {
+		return 0, 0, err
+	}
+	users, err := s.client.SCard(ctx, usersKey).Result()
+	if err != nil {
+		return 0, 0, err
+	}
+	return urls, int(users), nil
+}
+
+// AddClicks saves a batch of short URL visits.
+func (s *RedisStorage) AddClicks(ctx context.Context, clicks []storage.Click) error {
+	// the visits expire along with the URL
+	ttls := make(map[string]*goredis.DurationCmd)
+	_, err := s.client.Pipelined(ctx, func(pipe goredis.Pipeliner) error {
+		for _, c := range clicks {
+			if _, ok := ttls[c.URLID]; !ok {
+				ttls[c.URLID] = pipe.PTTL(ctx, urlKey(c.URLID))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	_, err = s.client.Pipelined(ctx, func(pipe goredis.Pipeliner) error {
+		for _, c := range clicks {
+			data, err := json.Marshal(c)
+			if err != nil {
+				return err
+			}
+			pipe.RPush(ctx, clicksKey(c.URLID), data)
+		}
+		for urlID, ttl := range ttls {
+			if ttl.Val() > 0 {
+				pipe.PExpire(ctx, clicksKey(urlID), ttl.Val())
+			}
+		}
+		return nil
+	})
+	return err
+}
+
+// GetClicks gets all visits of a short URL ordered by time.
+func (s *RedisStorage) GetClicks(ctx context.Context, urlID string) ([]storage.Click, error) {
+	items, err := s.client.LRange(ctx, clicksKey(urlID), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	results := make([]storage.Click, 0, len(items))
+	for _, item := range items {
+		var c storage.Click
+		if err := json.Unmarshal([]byte(item), &c); err != nil {
+			return nil, err
+		}
+		results = append(results, c)
+	}
+	// batches may be written out of order
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].ClickedAt.Before(results[j].ClickedAt)
+	})
+	return results, nil
+}
+
+// AddUser registers the user.
+func (s *RedisStorage) AddUser(ctx context.Context, user storage.User) error {
+	data, err := json.Marshal(user)
+	if err != nil {
+		return err
+	}
+	n, err := s.client.Exists(ctx, accountKey(user.Username)).Result()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return fmt.Errorf("%w: username=%v", storage.ErrUsernameTaken, user.Username)
+	}
+	// the ID belongs to a user without an account or to another account
+	known, err := s.client.SIsMember(ctx, usersKey, user.UserID).Result()
+	if err != nil {
+		return err
+	}
+	if known {
+		return fmt.Errorf("%w: userID=%v", storage.ErrUserIDTaken, user.UserID)
+	}
+	added, err := s.client.SAdd(ctx, accountIDsKey, user.UserID).Result()
+	if err != nil {
+		return err
+	}
+	if added == 0 {
+		return fmt.Errorf("%w: userID=%v", storage.ErrUserIDTaken, user.UserID)
+	}
+	ok, err := s.client.SetNX(ctx, accountKey(user.Username), data, 0).Result()
+	if err == nil && !ok {
+		err = fmt.Errorf("%w: username=%v", storage.ErrUsernameTaken, user.Username)
+	}
+	if err != nil {
+		// the ID is free again
+		s.client.SRem(ctx, accountIDsKey, user.UserID)
+		return err
+	}
+	return nil
+}
+
+// GetUser gets the registered user by name.
+func (s *RedisStorage) GetUser(ctx context.Context, username string) (storage.User, error) {
+	data, err := s.client.Get(ctx, accountKey(username)).Bytes()
+	if errors.Is(err, goredis.Nil) {
+		return storage.User{}, storage.ErrUserNotFound
+	}
+	if err != nil {
+		return storage.User{}, err
+	}
+	var user storage.User
+	err = json.Unmarshal(data, &user)
+	return user, err
+}
+
+// AddAPIKey saves the API key of the user.
+func (s *RedisStorage) AddAPIKey(ctx context.Context, key storage.APIKey) error {
+	data, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, apiKeyKey(key.KeyHash), data, 0).Err()
+}
+
+// GetAPIKey gets the API key by its hash.
+func (s *RedisStorage) GetAPIKey(ctx context.Context, keyHash string) (storage.APIKey, error) {
+	data, err := s.client.Get(ctx, apiKeyKey(keyHash)).Bytes()
+	if errors.Is(err, goredis.Nil) {
+		return storage.APIKey{}, storage.ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return storage.APIKey{}, err
+	}
+	var key storage.APIKey
+	err = json.Unmarshal(data, &key)
+	return key, err
+}
+
+// MoveURLs passes the URLs owned by one user to another.
+// The URLs keep the time they were added.
+func (s *RedisStorage) MoveURLs(ctx context.Context, fromUserID, toUserID uint32) (int, error) {
+	moved := 0
+	txf := func(tx *goredis.Tx) error {
+		listed, err := tx.ZRangeWithScores(ctx, userKey(fromUserID), 0, -1).Result()
+		if err != nil || len(listed) == 0 {
+			return err
+		}
+		// the IDs of the URLs removed by Redis may belong to other users now
+		owned := make([]goredis.Z, 0, len(listed))
+		for _, z := range listed {
+			ok, err := tx.SIsMember(ctx, ownersKey(z.Member.(string)), fromUserID).Result()
+			if err != nil {
+				return err
+			}
+			if ok {
+				owned = append(owned, z)
+			}
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+			for _, z := range owned {
+				urlID := z.Member.(string)
+				pipe.SRem(ctx, ownersKey(urlID), fromUserID)
+				pipe.SAdd(ctx, ownersKey(urlID), toUserID)
+				pipe.ZAddNX(ctx, userKey(toUserID), z)
+			}
+			pipe.Del(ctx, userKey(fromUserID))
+			pipe.SAdd(ctx, usersKey, toUserID)
+			return nil
+		})
+		if err == nil {
+			moved = len(owned)
+		}
+		return err
+	}
+	if err := s.watch(ctx, txf, userKey(fromUserID)); err != nil {
+		return 0, err
+	}
+	log.Infof("Moved %v URLs of user %v to user %v\n", moved, fromUserID, toUserID)
+	return moved, nil
+}
+
+// setJSONField saves the value as JSON in the field of the existing record hash.
+func (s *RedisStorage) setJSONField(ctx context.Context, urlID, field string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	txf := func(tx *goredis.Tx) error {
+		n, err := tx.Exists(ctx, urlKey(urlID)).Result()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return storage.ErrURLWasNotFound
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+			pipe.HSet(ctx, urlKey(urlID), field, string(data))
+			return nil
+		})
+		return err
+	}
+	return s.watch(ctx, txf, urlKey(urlID))
+}
+
+// SetPreview saves the preview of the URL's destination.
+func (s *RedisStorage) SetPreview(ctx context.Context, urlID string, preview storage.Preview) error {
+	return s.setJSONField(ctx, urlID, "preview", preview)
+}
+
+// GetURLsToCheck gets the active URLs which haven't been checked since the time.
+func (s *RedisStorage) GetURLsToCheck(
+	ctx context.Context,
+	checkedBefore time.Time,
+	limit int,
+) ([]storage.Record, error) {
+	prefix := urlKey("")
+	results := make([]storage.Record, 0)
+	iter := s.client.Scan(ctx, 0, prefix+"*", 0).Iterator()
+	for iter.Next(ctx) {
+		rec, err := getRecord(ctx, s.client, strings.TrimPrefix(iter.Val(), prefix))
+		// the URL has been removed by Redis
+		if errors.Is(err, storage.ErrURLWasNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if rec.NeedsCheck(checkedBefore) {
+			results = append(results, rec)
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	return storage.OldestChecked(results, limit), nil
+}
+
+// SetHealth saves the result of the check of the URL's destination.
+func (s *RedisStorage) SetHealth(ctx context.Context, urlID string, health storage.Health) error {
+	return s.setJSONField(ctx, urlID, "health", health)
+}
+
+// UpdateURL changes the destination of the URL owned by the user
+// and saves the previous one to the history.
+func (s *RedisStorage) UpdateURL(
+	ctx context.Context,
+	urlID string,
+	userID uint32,
+	url, originalURL string,
+) error {
+	var prevURL string
+	txf := func(tx *goredis.Tx) error {
+		rec, err := getRecord(ctx, tx, urlID)
+		if err != nil {
+			return err
+		}
+		owners, err := tx.SMembers(ctx, ownersKey(urlID)).Result()
+		if err != nil {
+			return err
+		}
+		rec.Owners = make([]uint32, 0, len(owners))
+		for _, owner := range owners {
+			id, err := strconv.ParseUint(owner, 10, 32)
+			if err != nil {
+				return err
+			}
+			rec.Owners = append(rec.Owners, uint32(id))
+		}
+		if err := rec.CheckUpdate(userID); err != nil {
+			return err
+		}
+		// the destination is the same
+		if rec.URL == url {
+			return nil
+		}
+		// the new destination may be stored under another ID
+		ids, err := tx.SMembers(ctx, byURLKey(url)).Result()
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			other, err := getRecord(ctx, tx, id)
+			if errors.Is(err, storage.ErrURLWasNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if other.IsDeleted || other.IsExpired() {
+				continue
+			}
+			owned, err := tx.SIsMember(ctx, ownersKey(id), userID).Result()
+			if err != nil {
+				return err
+			}
+			if owned || s.shareURLs {
+				return &storage.DuplicateURLError{URL: url, URLID: id}
+			}
+		}
+		data, err := json.Marshal(storage.Revision{
+			URLID:     urlID,
+			URL:       rec.URL,
+			ChangedAt: time.Now(),
+			ChangedBy: userID,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+			pipe.RPush(ctx, historyKey(urlID), data)
+			// the list may have just been created
+			s.expire(ctx, pipe, urlID, rec.ExpiresAt)
+			pipe.SRem(ctx, byURLKey(rec.URL), urlID)
+			pipe.SAdd(ctx, byURLKey(url), urlID)
+			pipe.HSet(ctx, urlKey(urlID), map[string]any{
+				"url":          url,
+				"original_url": originalURL,
+				"preview":      "",
+				"health":       "",
+			})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		prevURL = rec.URL
+		return nil
+	}
+	if err := s.watch(ctx, txf, urlKey(urlID), ownersKey(urlID), byURLKey(url)); err != nil {
+		return err
+	}
+	if prevURL != "" {
+		log.Infof("Changed destination of %v from %v to %v\n", urlID, prevURL, url)
+	}
+	return nil
+}
+
+// GetURLHistory gets the previous destinations of a short URL ordered by time.
+func (s *RedisStorage) GetURLHistory(ctx context.Context, urlID string) ([]storage.Revision, error) {
+	items, err := s.client.LRange(ctx, historyKey(urlID), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	results := make([]storage.Revision, 0, len(items))
+	for _, item := range items {
+		var r storage.Revision
+		if err := json.Unmarshal([]byte(item), &r); err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+// NextSequence returns the next value of the counter used to generate IDs.
+func (s *RedisStorage) NextSequence(ctx context.Context) (uint64, error) {
+	n, err := s.client.HIncrBy(ctx, statsKey, idsCounter, 1).Result()
+	if err != nil {
+		return 0, err
+	}
+	return uint64(n - 1), nil
+}
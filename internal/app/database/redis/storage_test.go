@@ -0,0 +1,525 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/blokhinnv/shorty/internal/app/server/config"
+	"github.com/blokhinnv/shorty/internal/app/storage"
+)
+
+type RedisSuite struct {
+	suite.Suite
+	mr       *miniredis.Miniredis
+	redisCfg *RedisConfig
+}
+
+func (suite *RedisSuite) SetupTest() {
+	suite.mr = miniredis.RunT(suite.T())
+	serverCfg := &config.ServerConfig{
+		RedisURL:            "redis://" + suite.mr.Addr(),
+		RedisClearOnStart:   true,
+		ExpiredReapInterval: time.Minute,
+		ShareDuplicateURLs:  true,
+	}
+	suite.redisCfg = GetRedisConfig(serverCfg)
+}
+
+func (suite *RedisSuite) newStorage(cfg *RedisConfig) *RedisStorage {
+	s, err := NewRedisStorage(cfg)
+	suite.Require().NoError(err)
+	return s
+}
+
+func (suite *RedisSuite) TestNewRedisStorageUnavailable() {
+	cfg := *suite.redisCfg
+	suite.mr.Close()
+	_, err := NewRedisStorage(&cfg)
+	suite.Error(err)
+}
+
+func (suite *RedisSuite) TestAddURL() {
+	ctx := context.Background()
+	s := suite.newStorage(suite.redisCfg)
+	err := s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
+	suite.NoError(err)
+	s.Close(ctx)
+}
+
+func (suite *RedisSuite) TestAddURLTwice() {
+	ctx := context.Background()
+	s := suite.newStorage(suite.redisCfg)
+	err := s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
+	suite.NoError(err)
+
+	err = s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
+	suite.Error(err)
+	s.Close(ctx)
+}
+
+func (suite *RedisSuite) TestAddURLTakenID() {
+	ctx := context.Background()
+	s := suite.newStorage(suite.redisCfg)
+	err := s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
+	suite.NoError(err)
+
+	err = s.AddURL(ctx, "http://google.com", "qwerty", uint32(2), storage.LinkOptions{})
+	suite.ErrorIs(err, storage.ErrUniqueViolation)
+	suite.ErrorIs(err, storage.ErrURLIDTaken)
+	s.Close(ctx)
+}
+
+func (suite *RedisSuite) TestAddURLDuplicate() {
+	ctx := context.Background()
+	s := suite.newStorage(suite.redisCfg)
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
+	err := s.AddURL(ctx, "http://yandex.ru", "asdfgh", uint32(1), storage.LinkOptions{})
+	var dupErr *storage.DuplicateURLError
+	suite.ErrorAs(err, &dupErr)
+	suite.Equal("qwerty", dupErr.URLID)
+	suite.False(dupErr.NewOwner)
+	suite.ErrorIs(err, storage.ErrUniqueViolation)
+	s.Close(ctx)
+}
+
+func (suite *RedisSuite) TestAddURLShared() {
+	ctx := context.Background()
+	s := suite.newStorage(suite.redisCfg)
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
+	err := s.AddURL(ctx, "http://yandex.ru", "asdfgh", uint32(2), storage.LinkOptions{})
+	var dupErr *storage.DuplicateURLError
+	suite.ErrorAs(err, &dupErr)
+	suite.Equal("qwerty", dupErr.URLID)
+	suite.True(dupErr.NewOwner)
+	recs, err := s.GetURLsByUser(ctx, uint32(2))
+	suite.NoError(err)
+	suite.Len(recs, 1)
+	suite.Equal("qwerty", recs[0].URLID)
+	// the link is hidden only when all the owners have deleted it
+	s.DeleteMany(ctx, uint32(1), []string{"qwerty"})
+	_, err = s.GetURLByID(ctx, "qwerty")
+	suite.NoError(err)
+	_, err = s.GetURLsByUser(ctx, uint32(1))
+	suite.ErrorIs(err, storage.ErrURLWasNotFound)
+	s.DeleteMany(ctx, uint32(2), []string{"qwerty"})
+	_, err = s.GetURLByID(ctx, "qwerty")
+	suite.ErrorIs(err, storage.ErrURLWasDeleted)
+	s.Close(ctx)
+}
+
+func (suite *RedisSuite) TestAddURLNotShared() {
+	ctx := context.Background()
+	cfg := *suite.redisCfg
+	cfg.ShareURLs = false
+	s := suite.newStorage(&cfg)
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
+	err := s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(2), storage.LinkOptions{})
+	suite.ErrorIs(err, storage.ErrURLIDTaken)
+	err = s.AddURL(ctx, "http://yandex.ru", "asdfgh", uint32(2), storage.LinkOptions{})
+	suite.NoError(err)
+	recs, err := s.GetURLsByUser(ctx, uint32(2))
+	suite.NoError(err)
+	suite.Len(recs, 1)
+	suite.Equal("asdfgh", recs[0].URLID)
+	s.DeleteMany(ctx, uint32(1), []string{"qwerty"})
+	_, err = s.GetURLByID(ctx, "asdfgh")
+	suite.NoError(err)
+	s.Close(ctx)
+}
+
+func (suite *RedisSuite) TestAddURLDeletedNewID() {
+	ctx := context.Background()
+	s := suite.newStorage(suite.redisCfg)
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
+	s.DeleteMany(ctx, uint32(1), []string{"qwerty"})
+	err := s.AddURL(ctx, "http://yandex.ru", "asdfgh", uint32(2), storage.LinkOptions{})
+	suite.NoError(err)
+	rec, err := s.GetURLByID(ctx, "asdfgh")
+	suite.NoError(err)
+	suite.Equal("http://yandex.ru", rec.URL)
+	s.Close(ctx)
+}
+
+func (suite *RedisSuite) TestAddURLTakenDeletedID() {
+	ctx := context.Background()
+	s := suite.newStorage(suite.redisCfg)
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
+	s.DeleteMany(ctx, uint32(1), []string{"qwerty"})
+	err := s.AddURL(ctx, "http://google.com", "qwerty", uint32(2), storage.LinkOptions{})
+	suite.ErrorIs(err, storage.ErrUniqueViolation)
+	s.Close(ctx)
+}
+
+func (suite *RedisSuite) TestGetURLByIDOk() {
+	ctx := context.Background()
+	s := suite.newStorage(suite.redisCfg)
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
+	rec, err := s.GetURLByID(ctx, "qwerty")
+	suite.NoError(err)
+	suite.Equal("http://yandex.ru", rec.URL)
+	suite.Equal(uint32(1), rec.UserID)
+	suite.True(rec.ExpiresAt.IsZero())
+	s.Close(ctx)
+}
+
+func (suite *RedisSuite) TestGetURLByIDExpired() {
+	ctx := context.Background()
+	s := suite.newStorage(suite.redisCfg)
+	expired := storage.LinkOptions{ExpiresAt: time.Now().Add(-time.Second)}
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), expired)
+	_, err := s.GetURLByID(ctx, "qwerty")
+	suite.ErrorIs(err, storage.ErrURLExpired)
+	// shortening the same URL again brings the link back
+	err = s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
+	suite.NoError(err)
+	rec, err := s.GetURLByID(ctx, "qwerty")
+	suite.NoError(err)
+	suite.True(rec.ExpiresAt.IsZero())
+	suite.Equal(time.Duration(0), suite.mr.TTL(urlKey("qwerty")))
+	s.Close(ctx)
+}
+
+func (suite *RedisSuite) TestExpiredRemoved() {
+	ctx := context.Background()
+	s := suite.newStorage(suite.redisCfg)
+	soon := storage.LinkOptions{ExpiresAt: time.Now().Add(time.Minute)}
+	s.AddClicks(ctx, []storage.Click{{URLID: "qwerty", ClickedAt: time.Now()}})
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), soon)
+	s.AddURL(ctx, "http://google.com", "asdfgh", uint32(1), storage.LinkOptions{})
+	s.AddClicks(ctx, []storage.Click{{URLID: "qwerty", ClickedAt: time.Now()}})
+	suite.NoError(s.UpdateURL(ctx, "qwerty", uint32(1), "http://ya.ru", ""))
+	suite.True(suite.mr.TTL(urlKey("qwerty")) > time.Minute)
+	suite.mr.FastForward(3 * time.Minute)
+	_, err := s.GetURLByID(ctx, "qwerty")
+	suite.ErrorIs(err, storage.ErrURLWasNotFound)
+	// the visits and the history of the removed URL are gone with it
+	clicks, err := s.GetClicks(ctx, "qwerty")
+	suite.NoError(err)
+	suite.Empty(clicks)
+	history, err := s.GetURLHistory(ctx, "qwerty")
+	suite.NoError(err)
+	suite.Empty(history)
+	recs, err := s.GetURLsByUser(ctx, uint32(1))
+	suite.NoError(err)
+	suite.Len(recs, 1)
+	suite.Equal("asdfgh", recs[0].URLID)
+	// the removed URL can be shortened again
+	err = s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(2), storage.LinkOptions{})
+	suite.NoError(err)
+	s.Close(ctx)
+}
+
+func (suite *RedisSuite) TestAddURLRestoredClicks() {
+	ctx := context.Background()
+	s := suite.newStorage(suite.redisCfg)
+	expired := storage.LinkOptions{ExpiresAt: time.Now().Add(-time.Second)}
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
+	s.AddURL(ctx, "http://google.com", "asdfgh", uint32(1), expired)
+	s.AddClicks(ctx, []storage.Click{
+		{URLID: "qwerty", ClickedAt: time.Now(), Referrer: "http://ya.ru"},
+		{URLID: "asdfgh", ClickedAt: time.Now(), Country: "RU"},
+	})
+	suite.NoError(s.DeleteMany(ctx, uint32(1), []string{"qwerty"}))
+	// another user shortens the deleted and the expired URLs and gets the same IDs
+	err := s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(2), storage.LinkOptions{})
+	suite.NoError(err)
+	err = s.AddURLBatch(ctx, map[string]string{"http://google.com": "asdfgh"}, uint32(2))
+	suite.NoError(err)
+	// the visits of the previous owner aren't shown to the new one
+	for _, urlID := range []string{"qwerty", "asdfgh"} {
+		clicks, err := s.GetClicks(ctx, urlID)
+		suite.NoError(err)
+		suite.Empty(clicks)
+	}
+	s.Close(ctx)
+}
+
+func (suite *RedisSuite) TestAddURLRestoredHistory() {
+	ctx := context.Background()
+	s := suite.newStorage(suite.redisCfg)
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
+	suite.NoError(s.UpdateURL(ctx, "qwerty", uint32(1), "http://ya.ru", ""))
+	suite.NoError(s.DeleteMany(ctx, uint32(1), []string{"qwerty"}))
+	// another user shortens the deleted URL and gets the same ID
+	err := s.AddURL(ctx, "http://ya.ru", "qwerty", uint32(2), storage.LinkOptions{})
+	suite.NoError(err)
+	// the previous destinations of the previous owner aren't shown to the new one
+	history, err := s.GetURLHistory(ctx, "qwerty")
+	suite.NoError(err)
+	suite.Empty(history)
+	s.Close(ctx)
+}
+
+func (suite *RedisSuite) TestExpiredAliasReused() {
+	ctx := context.Background()
+	s := suite.newStorage(suite.redisCfg)
+	soon := storage.LinkOptions{ExpiresAt: time.Now().Add(time.Minute)}
+	s.AddURL(ctx, "http://yandex.ru", "alias", uint32(1), soon)
+	suite.mr.FastForward(3 * time.Minute)
+	// another user takes the ID of the removed URL
+	err := s.AddURL(ctx, "http://google.com", "alias", uint32(2), storage.LinkOptions{})
+	suite.NoError(err)
+	_, err = s.GetURLsByUser(ctx, uint32(1))
+	suite.ErrorIs(err, storage.ErrURLWasNotFound)
+	recs, err := s.GetURLsByUser(ctx, uint32(2))
+	suite.NoError(err)
+	suite.Len(recs, 1)
+	suite.Equal("http://google.com", recs[0].URL)
+	// nor can the URL be claimed by another user through the first one
+	n, err := s.MoveURLs(ctx, uint32(1), uint32(3))
+	suite.NoError(err)
+	suite.Equal(0, n)
+	_, err = s.GetURLsByUser(ctx, uint32(3))
+	suite.ErrorIs(err, storage.ErrURLWasNotFound)
+	s.Close(ctx)
+}
+
+func (suite *RedisSuite) TestClicks() {
+	ctx := context.Background()
+	s := suite.newStorage(suite.redisCfg)
+	first := time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC)
+	err := s.AddClicks(ctx, []storage.Click{
+		{URLID: "qwerty", ClickedAt: first.Add(time.Hour), Referrer: "http://ya.ru"},
+		{URLID: "asdfgh", ClickedAt: first},
+		{URLID: "qwerty", ClickedAt: first, UserAgent: "curl/7.81.0", Country: "RU"},
+	})
+	suite.NoError(err)
+	clicks, err := s.GetClicks(ctx, "qwerty")
+	suite.NoError(err)
+	suite.Len(clicks, 2)
+	suite.True(first.Equal(clicks[0].ClickedAt))
+	suite.Equal("curl/7.81.0", clicks[0].UserAgent)
+	suite.Equal("RU", clicks[0].Country)
+	suite.Equal("http://ya.ru", clicks[1].Referrer)
+	s.Clear(ctx)
+	clicks, err = s.GetClicks(ctx, "qwerty")
+	suite.NoError(err)
+	suite.Empty(clicks)
+	s.Close(ctx)
+}
+
+func (suite *RedisSuite) TestGetURLByIDEmpty() {
+	ctx := context.Background()
+	s := suite.newStorage(suite.redisCfg)
+	_, err := s.GetURLByID(ctx, "qwerty")
+	suite.ErrorIs(err, storage.ErrURLWasNotFound)
+	s.Close(ctx)
+}
+
+func (suite *RedisSuite) TestGetURLsByUserNotFound() {
+	ctx := context.Background()
+	s := suite.newStorage(suite.redisCfg)
+	_, err := s.GetURLsByUser(ctx, uint32(1))
+	suite.ErrorIs(err, storage.ErrURLWasNotFound)
+	s.Close(ctx)
+}
+
+func (suite *RedisSuite) TestGetURLsByUserFound() {
+	ctx := context.Background()
+	s := suite.newStorage(suite.redisCfg)
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
+	s.AddURL(ctx, "http://google.com", "asdfgh", uint32(1), storage.LinkOptions{})
+	res, err := s.GetURLsByUser(ctx, uint32(1))
+	suite.NoError(err)
+	suite.Len(res, 2)
+	suite.Equal("http://yandex.ru", res[0].URL)
+	suite.Equal("http://google.com", res[1].URL)
+	s.Close(ctx)
+}
+
+func (suite *RedisSuite) TestBatchOK() {
+	ctx := context.Background()
+	s := suite.newStorage(suite.redisCfg)
+
+	err := s.AddURLBatch(ctx, map[string]string{"http://yandex.ru": "qwerty"}, uint32(1))
+	suite.NoError(err)
+	s.Close(ctx)
+}
+
+func (suite *RedisSuite) TestBatchErr() {
+	ctx := context.Background()
+	s := suite.newStorage(suite.redisCfg)
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
+	err := s.AddURLBatch(ctx, map[string]string{"http://yandex.ru": "qwerty"}, uint32(2))
+	suite.ErrorIs(err, storage.ErrUniqueViolation)
+	// the batch doesn't add owners to the existing URLs
+	_, err = s.GetURLsByUser(ctx, uint32(2))
+	suite.ErrorIs(err, storage.ErrURLWasNotFound)
+	s.Close(ctx)
+}
+
+func (suite *RedisSuite) TestDelete() {
+	ctx := context.Background()
+	s := suite.newStorage(suite.redisCfg)
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
+	// only the owners can delete the URL
+	err := s.DeleteMany(ctx, uint32(2), []string{"qwerty"})
+	suite.NoError(err)
+	_, err = s.GetURLByID(ctx, "qwerty")
+	suite.NoError(err)
+	err = s.DeleteMany(ctx, uint32(1), []string{"qwerty"})
+	suite.NoError(err)
+	_, err = s.GetURLByID(ctx, "qwerty")
+	suite.ErrorIs(err, storage.ErrURLWasDeleted)
+	s.Close(ctx)
+}
+
+func (suite *RedisSuite) TestPing() {
+	ctx := context.Background()
+	s := suite.newStorage(suite.redisCfg)
+	ping := s.Ping(ctx)
+	suite.Equal(true, ping)
+	s.Close(ctx)
+}
+
+func (suite *RedisSuite) TestClear() {
+	ctx := context.Background()
+	s := suite.newStorage(suite.redisCfg)
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
+	// keys of other applications are left intact
+	suite.mr.Set("other", "value")
+	err := s.Clear(ctx)
+	suite.NoError(err)
+	suite.Equal([]string{"other"}, suite.mr.Keys())
+	s.Close(ctx)
+}
+
+func (suite *RedisSuite) TestStats() {
+	ctx := context.Background()
+	s := suite.newStorage(suite.redisCfg)
+	urls, users, err := s.GetStats(ctx)
+	suite.NoError(err)
+	suite.Equal(0, urls)
+	suite.Equal(0, users)
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
+	s.AddURL(ctx, "http://yandex.ru", "asdfgh", uint32(2), storage.LinkOptions{})
+	urls, users, err = s.GetStats(ctx)
+	suite.Equal(1, urls)
+	suite.Equal(2, users)
+	suite.NoError(err)
+	s.Close(ctx)
+}
+
+func (suite *RedisSuite) TestNextSequence() {
+	ctx := context.Background()
+	// the counter starts after the URLs shortened before it
+	suite.mr.HSet(statsKey, urlsCounter, "2")
+	cfg := *suite.redisCfg
+	cfg.ClearOnStart = false
+	s := suite.newStorage(&cfg)
+	n, err := s.NextSequence(ctx)
+	suite.NoError(err)
+	suite.Equal(uint64(2), n)
+	n, err = s.NextSequence(ctx)
+	suite.NoError(err)
+	suite.Equal(uint64(3), n)
+	s.Close(ctx)
+	// the values aren't issued again after a restart
+	s = suite.newStorage(&cfg)
+	n, err = s.NextSequence(ctx)
+	suite.NoError(err)
+	suite.Equal(uint64(4), n)
+	s.Close(ctx)
+}
+
+func (suite *RedisSuite) TestUsers() {
+	ctx := context.Background()
+	s := suite.newStorage(suite.redisCfg)
+	user := storage.User{UserID: 1, Username: "user", PasswordHash: "hash"}
+	suite.NoError(s.AddUser(ctx, user))
+	suite.ErrorIs(s.AddUser(ctx, user), storage.ErrUsernameTaken)
+	// the ID of another account
+	other := storage.User{UserID: 1, Username: "other", PasswordHash: "hash"}
+	suite.ErrorIs(s.AddUser(ctx, other), storage.ErrUserIDTaken)
+	// the ID of a user without an account
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(2), storage.LinkOptions{})
+	other.UserID = 2
+	suite.ErrorIs(s.AddUser(ctx, other), storage.ErrUserIDTaken)
+	other.UserID = 3
+	suite.NoError(s.AddUser(ctx, other))
+	res, err := s.GetUser(ctx, "user")
+	suite.NoError(err)
+	suite.Equal(uint32(1), res.UserID)
+	suite.Equal("hash", res.PasswordHash)
+	_, err = s.GetUser(ctx, "nobody")
+	suite.ErrorIs(err, storage.ErrUserNotFound)
+
+	suite.NoError(s.AddAPIKey(ctx, storage.APIKey{KeyHash: "key", UserID: 1}))
+	key, err := s.GetAPIKey(ctx, "key")
+	suite.NoError(err)
+	suite.Equal(uint32(1), key.UserID)
+	_, err = s.GetAPIKey(ctx, "nokey")
+	suite.ErrorIs(err, storage.ErrAPIKeyNotFound)
+	s.Close(ctx)
+}
+
+func (suite *RedisSuite) TestMoveURLs() {
+	ctx := context.Background()
+	s := suite.newStorage(suite.redisCfg)
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
+	s.AddURL(ctx, "http://mail.ru", "asdfgh", uint32(1), storage.LinkOptions{})
+	s.AddURL(ctx, "http://mail.ru", "asdfgh", uint32(2), storage.LinkOptions{})
+	n, err := s.MoveURLs(ctx, uint32(1), uint32(2))
+	suite.NoError(err)
+	suite.Equal(2, n)
+	res, err := s.GetURLsByUser(ctx, uint32(2))
+	suite.NoError(err)
+	suite.Len(res, 2)
+	_, err = s.GetURLsByUser(ctx, uint32(1))
+	suite.Error(err)
+	s.Close(ctx)
+}
+
+func (suite *RedisSuite) TestUpdateURL() {
+	ctx := context.Background()
+	s := suite.newStorage(suite.redisCfg)
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
+	s.AddURL(ctx, "http://mail.ru", "asdfgh", uint32(1), storage.LinkOptions{})
+	err := s.UpdateURL(ctx, "qwerty", uint32(2), "http://ya.ru", "")
+	suite.ErrorIs(err, storage.ErrNotOwner)
+	err = s.UpdateURL(ctx, "zxcvbn", uint32(1), "http://ya.ru", "")
+	suite.ErrorIs(err, storage.ErrURLWasNotFound)
+	err = s.UpdateURL(ctx, "qwerty", uint32(1), "http://mail.ru", "")
+	var dupErr *storage.DuplicateURLError
+	suite.ErrorAs(err, &dupErr)
+	suite.Equal("asdfgh", dupErr.URLID)
+
+	err = s.UpdateURL(ctx, "qwerty", uint32(1), "http://ya.ru", "http://YA.ru")
+	suite.NoError(err)
+	rec, err := s.GetURLByID(ctx, "qwerty")
+	suite.NoError(err)
+	suite.Equal("http://ya.ru", rec.URL)
+	suite.Equal("http://YA.ru", rec.OriginalURL)
+	err = s.UpdateURL(ctx, "qwerty", uint32(1), "http://bing.com", "")
+	suite.NoError(err)
+	history, err := s.GetURLHistory(ctx, "qwerty")
+	suite.NoError(err)
+	suite.Require().Len(history, 2)
+	suite.Equal("http://yandex.ru", history[0].URL)
+	suite.Equal("http://ya.ru", history[1].URL)
+	suite.Equal(uint32(1), history[1].ChangedBy)
+	// the old destination can be shortened again
+	err = s.AddURL(ctx, "http://yandex.ru", "zxcvbn", uint32(1), storage.LinkOptions{})
+	suite.NoError(err)
+
+	// the link shared with another user can't be changed
+	s.AddURL(ctx, "http://mail.ru", "poiuyt", uint32(2), storage.LinkOptions{})
+	err = s.UpdateURL(ctx, "asdfgh", uint32(1), "http://google.com", "")
+	suite.ErrorIs(err, storage.ErrURLShared)
+	s.DeleteMany(ctx, uint32(1), []string{"qwerty"})
+	err = s.UpdateURL(ctx, "qwerty", uint32(1), "http://google.com", "")
+	suite.ErrorIs(err, storage.ErrURLWasDeleted)
+	s.Clear(ctx)
+	history, err = s.GetURLHistory(ctx, "qwerty")
+	suite.NoError(err)
+	suite.Empty(history)
+	s.Close(ctx)
+}
+
+func TestRedisSuite(t *testing.T) {
+	suite.Run(t, new(RedisSuite))
+}
@@ -1,15 +1,28 @@
 // Пакет sqlite реализует хранилище на основе SQLite.
 package sqlite
 
-import "github.com/blokhinnv/shorty/internal/app/server/config"
+import (
+	"time"
+
+	"github.com/blokhinnv/shorty/internal/app/server/config"
+)
 
 // SQLiteConfig - config for storage based on SQLite.
 type SQLiteConfig struct {
 	DBPath       string
 	ClearOnStart bool
+	// how often to purge expired URLs (0 disables purging)
+	ReapInterval time.Duration
+	// users who shorten the same URL share one link
+	ShareURLs bool
 }
 
 // GetSQLiteConfig - SQLite config constructor based on server config.
 func GetSQLiteConfig(cfg *config.ServerConfig) *SQLiteConfig {
-	return &SQLiteConfig{DBPath: cfg.SQLiteDBPath, ClearOnStart: cfg.SQLiteClearOnStart}
+	return &SQLiteConfig{
+		DBPath:       cfg.SQLiteDBPath,
+		ClearOnStart: cfg.SQLiteClearOnStart,
+		ReapInterval: cfg.ExpiredReapInterval,
+		ShareURLs:    cfg.ShareDuplicateURLs,
+	}
 }
@@ -0,0 +1,151 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"time"
+
+	"github.com/blokhinnv/shorty/internal/app/database/migrate"
+)
+
+// migrationsFS - schema migrations of the SQLite storage.
+//
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// SQL queries to keep track of the schema version.
+const (
+	createSchemaVersionSQL = `
+CREATE TABLE IF NOT EXISTS schema_version(
+	version INTEGER PRIMARY KEY,
+	name VARCHAR NOT NULL,
+	applied_at TIMESTAMP NOT NULL
+);`
+	selectSchemaVersionsSQL = "SELECT version, applied_at FROM schema_version"
+	insertSchemaVersionSQL  = "INSERT INTO schema_version(version, name, applied_at) VALUES (?, ?, ?)"
+	deleteSchemaVersionSQL  = "DELETE FROM schema_version WHERE version = ?"
+	hasExpiresAtSQL         = "SELECT COUNT(*) FROM pragma_table_info('Url') WHERE name = 'expires_at'"
+)
+
+// expiresAtVersion - the version which adds the expires_at column. Databases
+// created before the migrations have the schema up to this version or older.
+const expiresAtVersion = 3
+
+// migrationDriver runs migrations on SQLite.
+type migrationDriver struct {
+	db *sql.DB
+}
+
+// Init creates the schema_version table if it doesn't exist.
+func (d *migrationDriver) Init(ctx context.Context) error {
+	_, err := d.db.ExecContext(ctx, createSchemaVersionSQL)
+	return err
+}
+
+// Applied returns the applied versions along with the time they were applied.
+func (d *migrationDriver) Applied(ctx context.Context) (map[int]time.Time, error) {
+	rows, err := d.db.QueryContext(ctx, selectSchemaVersionsSQL)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
+
+// Apply runs the script and records (or forgets) the version in a single transaction.
+func (d *migrationDriver) Apply(
+	ctx context.Context,
+	m migrate.Migration,
+	script string,
+	up bool,
+) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if script != "" {
+		if _, err := tx.ExecContext(ctx, script); err != nil {
+			return err
+		}
+	}
+	if up {
+		_, err = tx.ExecContext(ctx, insertSchemaVersionSQL, m.Version, m.Name, time.Now().UTC())
+	} else {
+		_, err = tx.ExecContext(ctx, deleteSchemaVersionSQL, m.Version)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Baseline returns the version of a database created before the migrations.
+// SQLite can't add a column only if it doesn't exist, so the column
+// shows whether the expiration has been added.
+func (d *migrationDriver) Baseline(ctx context.Context) (int, error) {
+	var n int
+	if err := d.db.QueryRowContext(ctx, hasExpiresAtSQL).Scan(&n); err != nil {
+		return 0, err
+	}
+	if n > 0 {
+		return expiresAtVersion, nil
+	}
+	return 0, nil
+}
+
+// newMigrator - migrator constructor for the database.
+func newMigrator(db *sql.DB) (*migrate.Migrator, error) {
+	migrations, err := fs.Sub(migrationsFS, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	return migrate.New(&migrationDriver{db: db}, migrations)
+}
+
+// NewMigrator - migrator constructor for the database from the config.
+// The returned function closes the connection.
+func NewMigrator(conf *SQLiteConfig) (*migrate.Migrator, func(), error) {
+	db, err := sql.Open("sqlite3", conf.DBPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("can't access to DB %s: %v", conf.DBPath, err)
+	}
+	m, err := newMigrator(db)
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+	return m, func() { db.Close() }, nil
+}
+
+// initDB initializes the database structure for further work.
+func initDB(db *sql.DB, clearOnStart bool) error {
+	m, err := newMigrator(db)
+	if err != nil {
+		return err
+	}
+	if _, err := m.Up(context.Background()); err != nil {
+		return fmt.Errorf("can't migrate DB: %v", err)
+	}
+	if clearOnStart {
+		if _, err = db.Exec(clearSQL); err != nil {
+			return fmt.Errorf("can't clear DB: %v", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/blokhinnv/shorty/internal/app/storage"
+)
+
+// legacySQL - the schema created before the migrations were introduced.
+const legacySQL = `
+CREATE TABLE Url(
+	encoding_id INTEGER PRIMARY KEY AUTOINCREMENT,
+	url VARCHAR NOT NULL,
+	url_id VARCHAR NOT NULL,
+	user_id INT NOT NULL,
+	added VARCHAR DEFAULT (datetime('now','localtime')),
+	requested_at VARCHAR DEFAULT (datetime('now','localtime')),
+	is_deleted BOOLEAN DEFAULT FALSE,
+	expires_at TIMESTAMP DEFAULT NULL
+);
+CREATE UNIQUE INDEX idx_url ON Url(url);
+CREATE UNIQUE INDEX idx_url_id ON Url(url_id);
+INSERT INTO Url(url, url_id, user_id) VALUES ('http://yandex.ru', 'qwerty', 1);
+`
+
+type MigrateSuite struct {
+	suite.Suite
+	conf *SQLiteConfig
+}
+
+func (suite *MigrateSuite) SetupTest() {
+	suite.conf = &SQLiteConfig{DBPath: "test_migrate.sqlite3"}
+}
+
+func (suite *MigrateSuite) TearDownTest() {
+	os.Remove(suite.conf.DBPath)
+}
+
+func (suite *MigrateSuite) TestLegacyDB() {
+	ctx := context.Background()
+	db, _ := sql.Open("sqlite3", suite.conf.DBPath)
+	_, err := db.Exec(legacySQL)
+	suite.NoError(err)
+	db.Close()
+
+	s, err := NewSQLiteStorage(suite.conf)
+	suite.Require().NoError(err)
+	defer s.Close(ctx)
+	recs, err := s.GetURLsByUser(ctx, 1)
+	suite.NoError(err)
+	suite.Len(recs, 1)
+	// the counter of the generated IDs starts after the URLs shortened before
+	n, err := s.NextSequence(ctx)
+	suite.NoError(err)
+	suite.Equal(uint64(1), n)
+
+	m, closeDB, err := NewMigrator(suite.conf)
+	suite.Require().NoError(err)
+	defer closeDB()
+	status, err := m.Status(ctx)
+	suite.NoError(err)
+	for _, st := range status {
+		suite.True(st.Applied, st.Name)
+	}
+}
+
+func (suite *MigrateSuite) TestDownUp() {
+	ctx := context.Background()
+	m, closeDB, err := NewMigrator(suite.conf)
+	suite.Require().NoError(err)
+	defer closeDB()
+	n, err := m.Up(ctx)
+	suite.NoError(err)
+	for i := 0; i < n; i++ {
+		_, err = m.Down(ctx)
+		suite.Require().NoError(err)
+	}
+	status, err := m.Status(ctx)
+	suite.NoError(err)
+	for _, st := range status {
+		suite.False(st.Applied, st.Name)
+	}
+	_, err = m.Up(ctx)
+	suite.NoError(err)
+
+	s, err := NewSQLiteStorage(suite.conf)
+	suite.Require().NoError(err)
+	defer s.Close(ctx)
+	err = s.AddURL(ctx, "http://yandex.ru", "qwerty", 1, storage.LinkOptions{})
+	suite.NoError(err)
+}
+
+func TestMigrateSuite(t *testing.T) {
+	suite.Run(t, new(MigrateSuite))
+}
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS Url(
+	encoding_id INTEGER PRIMARY KEY AUTOINCREMENT,
+	url VARCHAR NOT NULL,
+	url_id VARCHAR NOT NULL,
+	user_id INT NOT NULL,
+	added VARCHAR DEFAULT (datetime('now','localtime')),
+	requested_at VARCHAR DEFAULT (datetime('now','localtime')),
+	is_deleted BOOLEAN DEFAULT FALSE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_url ON Url(url);
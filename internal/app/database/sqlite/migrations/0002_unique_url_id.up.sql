@@ -0,0 +1 @@
+CREATE UNIQUE INDEX IF NOT EXISTS idx_url_id ON Url(url_id);
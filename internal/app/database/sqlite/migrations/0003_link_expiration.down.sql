@@ -0,0 +1 @@
+ALTER TABLE Url DROP COLUMN expires_at;
@@ -0,0 +1 @@
+ALTER TABLE Url ADD COLUMN expires_at TIMESTAMP DEFAULT NULL;
@@ -0,0 +1,15 @@
+-- the same URL may be stored for different users
+CREATE TABLE IF NOT EXISTS UrlOwner(
+	encoding_id INTEGER NOT NULL,
+	user_id INT NOT NULL,
+	added VARCHAR DEFAULT (datetime('now','localtime')),
+	PRIMARY KEY (encoding_id, user_id)
+);
+CREATE INDEX IF NOT EXISTS idx_url_owner_user_id ON UrlOwner(user_id);
+DROP INDEX IF EXISTS idx_url;
+CREATE INDEX IF NOT EXISTS idx_url_url ON Url(url);
+INSERT INTO UrlOwner(encoding_id, user_id)
+SELECT encoding_id, user_id FROM Url
+WHERE is_deleted=FALSE AND NOT EXISTS (
+	SELECT 1 FROM UrlOwner o WHERE o.encoding_id = Url.encoding_id
+);
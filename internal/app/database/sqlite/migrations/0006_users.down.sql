@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS ApiKey;
+DROP TABLE IF EXISTS Account;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS Account(
+	user_id INT PRIMARY KEY,
+	username VARCHAR NOT NULL,
+	password_hash VARCHAR NOT NULL,
+	created_at TIMESTAMP NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_account_username ON Account(username);
+CREATE TABLE IF NOT EXISTS ApiKey(
+	key_hash VARCHAR PRIMARY KEY,
+	user_id INT NOT NULL,
+	created_at TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_api_key_user_id ON ApiKey(user_id);
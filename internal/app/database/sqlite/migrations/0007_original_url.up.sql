@@ -0,0 +1 @@
+ALTER TABLE Url ADD COLUMN original_url TEXT DEFAULT NULL;
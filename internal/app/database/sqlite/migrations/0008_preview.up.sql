@@ -0,0 +1 @@
+ALTER TABLE Url ADD COLUMN preview TEXT DEFAULT NULL;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_url_checked_at;
+ALTER TABLE Url DROP COLUMN check_error;
+ALTER TABLE Url DROP COLUMN check_failures;
+ALTER TABLE Url DROP COLUMN check_status;
+ALTER TABLE Url DROP COLUMN checked_at;
@@ -0,0 +1,5 @@
+ALTER TABLE Url ADD COLUMN checked_at TIMESTAMP DEFAULT NULL;
+ALTER TABLE Url ADD COLUMN check_status INT DEFAULT NULL;
+ALTER TABLE Url ADD COLUMN check_failures INT NOT NULL DEFAULT 0;
+ALTER TABLE Url ADD COLUMN check_error VARCHAR DEFAULT NULL;
+CREATE INDEX IF NOT EXISTS idx_url_checked_at ON Url(checked_at);
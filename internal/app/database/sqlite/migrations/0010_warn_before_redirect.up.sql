@@ -0,0 +1 @@
+ALTER TABLE Url ADD COLUMN warn_before_redirect BOOLEAN NOT NULL DEFAULT FALSE;
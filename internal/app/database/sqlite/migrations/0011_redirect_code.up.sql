@@ -0,0 +1 @@
+ALTER TABLE Url ADD COLUMN redirect_code INTEGER NOT NULL DEFAULT 0;
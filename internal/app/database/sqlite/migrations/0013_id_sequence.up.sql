@@ -0,0 +1,5 @@
+-- the counter of the generated IDs, it starts after the URLs shortened before
+CREATE TABLE IF NOT EXISTS IdSequence(
+	value INTEGER NOT NULL
+);
+INSERT INTO IdSequence(value) SELECT COUNT(*) FROM Url WHERE NOT EXISTS (SELECT 1 FROM IdSequence);
@@ -4,8 +4,10 @@ package sqlite
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/blokhinnv/shorty/internal/app/log"
 	"github.com/blokhinnv/shorty/internal/app/storage"
@@ -14,82 +16,344 @@ import (
 
 // SQL queries to implement the necessary logic.
 const (
-	selectByURLIDSQL  = "SELECT url, user_id, is_deleted FROM Url WHERE url_id = ?"
-	selectByUserIDSQL = "SELECT url, url_id, is_deleted FROM Url WHERE user_id = ?"
-	insertSQL         = "INSERT INTO Url(url, url_id, user_id) VALUES (?, ?, ?)"
-	deleteByURLIDSQL  = "UPDATE Url SET is_deleted=TRUE WHERE url_id=? AND user_id=? RETURNING url;"
-	clearSQL          = "DELETE FROM Url"
-	restoreSQL        = "UPDATE Url SET is_deleted=FALSE, user_id=? WHERE url_id=? AND is_deleted=TRUE;"
+	recordColumns           = "u.url, u.original_url, u.url_id, u.user_id, u.is_deleted, u.expires_at, u.preview, u.checked_at, u.check_status, u.check_failures, u.check_error, u.warn_before_redirect, u.redirect_code"
+	selectByURLIDSQL        = "SELECT " + recordColumns + " FROM Url u WHERE u.url_id = ?"
+	selectByUserIDSQL       = "SELECT " + recordColumns + " FROM Url u JOIN UrlOwner o ON o.encoding_id = u.encoding_id WHERE o.user_id = ?"
+	selectToCheckSQL        = "SELECT " + recordColumns + " FROM Url u WHERE u.is_deleted=FALSE AND (u.expires_at IS NULL OR u.expires_at >= ?) AND (u.checked_at IS NULL OR u.checked_at < ?) ORDER BY u.checked_at LIMIT ?"
+	selectOwnedByURLSQL     = "SELECT u.url_id FROM Url u JOIN UrlOwner o ON o.encoding_id = u.encoding_id WHERE u.url = ? AND o.user_id = ? AND u.is_deleted=FALSE AND (u.expires_at IS NULL OR u.expires_at >= ?)"
+	selectActiveByURLSQL    = "SELECT encoding_id, url_id FROM Url WHERE url = ? AND is_deleted=FALSE AND (expires_at IS NULL OR expires_at >= ?) LIMIT 1"
+	insertSQL               = "INSERT INTO Url(url, url_id, user_id, expires_at, original_url, warn_before_redirect, redirect_code) VALUES (?, ?, ?, ?, ?, ?, ?) RETURNING encoding_id"
+	insertOwnerSQL          = "INSERT INTO UrlOwner(encoding_id, user_id) VALUES (?, ?) ON CONFLICT DO NOTHING"
+	selectRestorableSQL     = "SELECT encoding_id, url_id FROM Url WHERE url = ? AND (is_deleted=TRUE OR expires_at < ?) LIMIT 1"
+	restoreSQL              = "UPDATE Url SET is_deleted=FALSE, user_id=?, expires_at=?, url_id=?, original_url=?, warn_before_redirect=?, redirect_code=?, preview=NULL, checked_at=NULL, check_status=NULL, check_failures=0, check_error=NULL WHERE encoding_id = ?"
+	deleteOwnersSQL         = "DELETE FROM UrlOwner WHERE encoding_id = ?"
+	deleteOwnerSQL          = "DELETE FROM UrlOwner WHERE user_id = ? AND encoding_id = (SELECT encoding_id FROM Url WHERE url_id = ?)"
+	deleteByURLIDSQL        = "UPDATE Url SET is_deleted=TRUE WHERE url_id=? AND is_deleted=FALSE AND NOT EXISTS (SELECT 1 FROM UrlOwner o WHERE o.encoding_id = Url.encoding_id) RETURNING url;"
+	deleteExpiredSQL        = "DELETE FROM Url WHERE expires_at < ?"
+	deleteExpiredClicksSQL  = "DELETE FROM Click WHERE url_id IN (SELECT url_id FROM Url WHERE expires_at < ?)"
+	deleteExpiredHistorySQL = "DELETE FROM UrlHistory WHERE url_id IN (SELECT url_id FROM Url WHERE expires_at < ?)"
+	deleteOrphanOwnersSQL   = "DELETE FROM UrlOwner WHERE encoding_id NOT IN (SELECT encoding_id FROM Url)"
+	deleteClicksSQL         = "DELETE FROM Click WHERE url_id = ?"
+	deleteHistorySQL        = "DELETE FROM UrlHistory WHERE url_id = ?"
+	insertClickSQL          = "INSERT INTO Click(url_id, clicked_at, referrer, user_agent, country) VALUES (?, ?, ?, ?, ?)"
+	selectClicksSQL         = "SELECT clicked_at, referrer, user_agent, country FROM Click WHERE url_id = ? ORDER BY clicked_at"
+	insertUserSQL           = "INSERT INTO Account(user_id, username, password_hash, created_at) SELECT ?1, ?2, ?3, ?4 WHERE NOT EXISTS (SELECT 1 FROM UrlOwner WHERE user_id = ?1)"
+	selectUserSQL           = "SELECT user_id, password_hash, created_at FROM Account WHERE username = ?"
+	insertAPIKeySQL         = "INSERT INTO ApiKey(key_hash, user_id, created_at) VALUES (?, ?, ?)"
+	selectAPIKeySQL         = "SELECT user_id, created_at FROM ApiKey WHERE key_hash = ?"
+	copyOwnedSQL            = "INSERT INTO UrlOwner(encoding_id, user_id) SELECT encoding_id, ? FROM UrlOwner WHERE user_id = ? ON CONFLICT DO NOTHING"
+	deleteOwnedSQL          = "DELETE FROM UrlOwner WHERE user_id = ?"
+	updatePreviewSQL        = "UPDATE Url SET preview = ? WHERE url_id = ?"
+	updateHealthSQL         = "UPDATE Url SET checked_at = ?, check_status = ?, check_failures = ?, check_error = ? WHERE url_id = ?"
+	selectOwnersSQL         = "SELECT o.user_id FROM UrlOwner o JOIN Url u ON u.encoding_id = o.encoding_id WHERE u.url_id = ?"
+	updateURLSQL            = "UPDATE Url SET url = ?, original_url = ?, preview=NULL, checked_at=NULL, check_status=NULL, check_failures=0, check_error=NULL WHERE url_id = ?"
+	insertRevisionSQL       = "INSERT INTO UrlHistory(url_id, url, changed_at, changed_by) VALUES (?, ?, ?, ?)"
+	selectRevisionsSQL      = "SELECT url, changed_at, changed_by FROM UrlHistory WHERE url_id = ? ORDER BY changed_at, revision_id"
+	nextSequenceSQL         = "UPDATE IdSequence SET value = value + 1 RETURNING value - 1"
+	clearSQL                = "DELETE FROM Url; DELETE FROM UrlOwner; DELETE FROM Click; DELETE FROM Account; DELETE FROM ApiKey; DELETE FROM UrlHistory; UPDATE IdSequence SET value = 0;"
 )
 
 // SQLiteStorage implements the Storage interface based on SQLite.
 type SQLiteStorage struct {
-	db *sql.DB
+	db        *sql.DB
+	shareURLs bool
+	quit      chan struct{}
+}
+
+// toNullTime converts time to the form which is stored in the database.
+// Times are kept in UTC, so they can be compared as strings.
+func toNullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t.UTC(), Valid: !t.IsZero()}
+}
+
+// toNullString converts the optional string to the form which is stored in the database.
+func toNullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
 }
 
 // NewSQLiteStorage - A constructor for a new URL storage.
 func NewSQLiteStorage(conf *SQLiteConfig) (*SQLiteStorage, error) {
-	err := initDB(conf.DBPath, conf.ClearOnStart)
-	if err != nil {
-		return nil, err
-	}
 	db, err := sql.Open("sqlite3", conf.DBPath)
 	if err != nil {
 		return nil, fmt.Errorf("can't access to DB %s: %v", conf.DBPath, err)
 	}
-	return &SQLiteStorage{db}, nil
+	if err := initDB(db, conf.ClearOnStart); err != nil {
+		db.Close()
+		return nil, err
+	}
+	s := &SQLiteStorage{db: db, shareURLs: conf.ShareURLs, quit: make(chan struct{})}
+	s.registerPurgeExpired(conf.ReapInterval)
+	return s, nil
 }
 
-// AddURL - method for adding a new URL to the database.
-func (s *SQLiteStorage) AddURL(ctx context.Context, url, urlID string, userID uint32) error {
-	res, err := s.db.ExecContext(ctx, restoreSQL, userID, urlID)
+// registerPurgeExpired starts purging expired URLs on a timer.
+func (s *SQLiteStorage) registerPurgeExpired(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := s.PurgeExpired(context.Background()); err != nil {
+					log.Infof("Error while purging expired URLs: %v", err)
+				}
+			case <-s.quit:
+				return
+			}
+		}
+	}()
+}
+
+// PurgeExpired removes expired URLs from the database.
+func (s *SQLiteStorage) PurgeExpired(ctx context.Context) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		log.Infof("Error while updating URL: %v", err)
-		return err
+		return 0, err
+	}
+	defer tx.Rollback()
+	now := toNullTime(time.Now())
+	// the IDs may be reused, so the visits and the history go along with the URLs
+	for _, query := range []string{deleteExpiredClicksSQL, deleteExpiredHistorySQL} {
+		if _, err := tx.ExecContext(ctx, query, now); err != nil {
+			return 0, err
+		}
+	}
+	res, err := tx.ExecContext(ctx, deleteExpiredSQL, now)
+	if err != nil {
+		return 0, err
 	}
 	n, err := res.RowsAffected()
 	if err != nil {
-		log.Infof("Error while updating URL: %v", err)
-		return err
+		return 0, err
 	}
-	// restored record => no need to add
 	if n > 0 {
-		return nil
+		if _, err := tx.ExecContext(ctx, deleteOrphanOwnersSQL); err != nil {
+			return 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	if n > 0 {
+		log.Infof("Purged %v expired URLs\n", n)
 	}
-	// must be added
-	_, err = s.db.ExecContext(ctx, insertSQL, url, urlID, userID)
+	return n, nil
+}
+
+// isUniqueViolation checks if the query has violated a unique index.
+func isUniqueViolation(err error) bool {
+	var sqlerr sqlite3.Error
+	return errors.As(err, &sqlerr) && sqlerr.Code == sqlite3.ErrConstraint
+}
+
+// addURL adds the URL for the user. If addOwner is set and the links are shared,
+// the user becomes one more owner of the URL stored for other users,
+// otherwise such a URL is reported as a duplicate.
+func (s *SQLiteStorage) addURL(
+	ctx context.Context,
+	url, urlID string,
+	userID uint32,
+	opts storage.LinkOptions,
+	addOwner bool,
+) error {
+	now := toNullTime(time.Now())
+	expiresAt := toNullTime(opts.ExpiresAt)
+	originalURL := toNullString(opts.OriginalURL)
+	var existingID string
+	var encodingID int64
+	// the URL and its owners change together
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		log.Infof("Error while adding URL: %v", err)
-		if sqlerr, ok := err.(sqlite3.Error); ok {
-			if sqlerr.Code == sqlite3.ErrConstraint {
-				return fmt.Errorf(
-					"%w: url=%v, urlID=%v, userID=%v",
-					storage.ErrUniqueViolation,
-					url,
-					urlID,
-					userID,
-				)
+		return err
+	}
+	defer tx.Rollback()
+	// the user has already shortened the URL
+	err = tx.QueryRowContext(ctx, selectOwnedByURLSQL, url, userID, now).Scan(&existingID)
+	if err == nil {
+		return &storage.DuplicateURLError{URL: url, URLID: existingID}
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if s.shareURLs {
+		err := tx.QueryRowContext(ctx, selectActiveByURLSQL, url, now).
+			Scan(&encodingID, &existingID)
+		switch {
+		case err == nil:
+			dupErr := &storage.DuplicateURLError{URL: url, URLID: existingID}
+			if !addOwner {
+				return dupErr
+			}
+			if _, err := tx.ExecContext(ctx, insertOwnerSQL, encodingID, userID); err != nil {
+				return err
+			}
+			if err := tx.Commit(); err != nil {
+				return err
 			}
+			log.Infof("Added user %v to owners of %v\n", userID, existingID)
+			dupErr.NewOwner = true
+			return dupErr
+		case !errors.Is(err, sql.ErrNoRows):
+			return err
+		}
+	}
+	// a deleted (or expired) URL gets back under the new ID
+	var prevID string
+	err = tx.QueryRowContext(ctx, selectRestorableSQL, url, now).Scan(&encodingID, &prevID)
+	restored := err == nil
+	switch {
+	case restored:
+		// the visits and the history belong to the previous owners
+		for _, query := range []string{deleteClicksSQL, deleteHistorySQL} {
+			if _, err := tx.ExecContext(ctx, query, prevID); err != nil {
+				return err
+			}
+		}
+		_, err = tx.ExecContext(
+			ctx,
+			restoreSQL,
+			userID,
+			expiresAt,
+			urlID,
+			originalURL,
+			opts.WarnBeforeRedirect,
+			opts.RedirectCode,
+			encodingID,
+		)
+	case errors.Is(err, sql.ErrNoRows):
+		// nothing to restore => must be added
+		err = tx.QueryRowContext(
+			ctx,
+			insertSQL,
+			url,
+			urlID,
+			userID,
+			expiresAt,
+			originalURL,
+			opts.WarnBeforeRedirect,
+			opts.RedirectCode,
+		).Scan(&encodingID)
+	}
+	if err != nil {
+		log.Infof("Error while adding URL: %v", err)
+		if isUniqueViolation(err) {
+			return fmt.Errorf(
+				"%w: url=%v, urlID=%v, userID=%v",
+				storage.ErrURLIDTaken,
+				url,
+				urlID,
+				userID,
+			)
 		}
 		return err
 	}
+	// the owners of an expired URL don't get it back
+	if restored {
+		if _, err := tx.ExecContext(ctx, deleteOwnersSQL, encodingID); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, insertOwnerSQL, encodingID, userID); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
 	log.Infof("Added %v=>%v to storage\n", url, urlID)
 	return nil
 }
 
+// AddURL - method for adding a new URL to the database.
+func (s *SQLiteStorage) AddURL(
+	ctx context.Context,
+	url, urlID string,
+	userID uint32,
+	opts storage.LinkOptions,
+) error {
+	return s.addURL(ctx, url, urlID, userID, opts, true)
+}
+
+// rowScanner - a row of the query result: *sql.Row or *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanRecord reads the record selected with recordColumns.
+func scanRecord(row rowScanner) (storage.Record, error) {
+	var rec storage.Record
+	var expiresAt, checkedAt sql.NullTime
+	var originalURL, checkError sql.NullString
+	var checkStatus sql.NullInt64
+	var checkFailures int
+	var preview []byte
+	err := row.Scan(
+		&rec.URL,
+		&originalURL,
+		&rec.URLID,
+		&rec.UserID,
+		&rec.IsDeleted,
+		&expiresAt,
+		&preview,
+		&checkedAt,
+		&checkStatus,
+		&checkFailures,
+		&checkError,
+		&rec.WarnBeforeRedirect,
+		&rec.RedirectCode,
+	)
+	if err != nil {
+		return storage.Record{}, err
+	}
+	rec.ExpiresAt = expiresAt.Time
+	rec.OriginalURL = originalURL.String
+	rec.Preview = toPreview(preview)
+	if checkedAt.Valid {
+		rec.Health = &storage.Health{
+			CheckedAt:  checkedAt.Time,
+			StatusCode: int(checkStatus.Int64),
+			Error:      checkError.String,
+			Failures:   checkFailures,
+		}
+	}
+	return rec, nil
+}
+
+// scanRecords reads the records selected with recordColumns skipping the deleted ones.
+func scanRecords(rows *sql.Rows) ([]storage.Record, error) {
+	results := make([]storage.Record, 0)
+	for rows.Next() {
+		rec, err := scanRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		if !rec.IsDeleted {
+			results = append(results, rec)
+		}
+	}
+	// After the loop, check the records for potential errors (break
+	// network connection to the database server in the process of getting query results)
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
 // GetURLByID returns a URL by its ID in the database.
 func (s *SQLiteStorage) GetURLByID(ctx context.Context, urlID string) (storage.Record, error) {
-	rec := storage.Record{URLID: urlID}
-	var isDeleted bool
-	err := s.db.QueryRowContext(ctx, selectByURLIDSQL, urlID).
-		Scan(&rec.URL, &rec.UserID, &isDeleted)
+	rec, err := scanRecord(s.db.QueryRowContext(ctx, selectByURLIDSQL, urlID))
 	// any error here (including ErrNoRows) means no result found
 	if err != nil {
 		return storage.Record{}, storage.ErrURLWasNotFound
 	}
-	if isDeleted {
+	if rec.IsDeleted {
 		return storage.Record{}, storage.ErrURLWasDeleted
 	}
+	if rec.IsExpired() {
+		return storage.Record{}, storage.ErrURLExpired
+	}
 	return rec, nil
 }
 
@@ -98,32 +362,23 @@ func (s *SQLiteStorage) GetURLsByUser(
 	ctx context.Context,
 	userID uint32,
 ) ([]storage.Record, error) {
-	results := make([]storage.Record, 0)
-
 	rows, err := s.db.QueryContext(ctx, selectByUserIDSQL, userID)
 	if err != nil {
 		return nil, err
 	}
 	// don't forget to close the object!
 	defer rows.Close()
-	for rows.Next() {
-		var isDeleted bool
-		rec := storage.Record{UserID: userID}
-		if err := rows.Scan(&rec.URL, &rec.URLID, &isDeleted); err != nil {
-			return nil, err
-		}
-		// After the loop, check the records for potential errors (break
-		// network connection to the database server in the process of getting query results)
-		if !isDeleted {
-			results = append(results, rec)
-		}
-	}
-	if err := rows.Err(); err != nil {
+	results, err := scanRecords(rows)
+	if err != nil {
 		return nil, err
 	}
 	if len(results) == 0 {
 		return nil, storage.ErrURLWasNotFound
 	}
+	// the URLs are listed as the user's ones
+	for i := range results {
+		results[i].UserID = userID
+	}
 	return results, nil
 }
 
@@ -134,66 +389,33 @@ func (s *SQLiteStorage) AddURLBatch(
 	userID uint32,
 ) error {
 	var violationErr error
-	stmtInsert, err := s.db.PrepareContext(ctx, insertSQL)
-	if err != nil {
-		return err
-	}
-	defer stmtInsert.Close()
-	stmtRestore, err := s.db.PrepareContext(ctx, restoreSQL)
-	if err != nil {
-		return err
-	}
-	defer stmtRestore.Close()
-
 	for url, urlID := range urlIDs {
-		// try to reset the deletion flag
-		res, err := stmtRestore.ExecContext(ctx, userID, urlID)
+		err := s.addURL(ctx, url, urlID, userID, storage.LinkOptions{}, false)
 		if err != nil {
-			log.Println("unable to update row: ", err)
-			return err
-		}
-		n, err := res.RowsAffected()
-		if err != nil {
-			log.Println("unable to get rows affected: ", err)
-			return err
-		}
-		// found a deleted record to restore => no need to add
-		if n > 0 {
-			continue
-		}
-		// did not find an entry to restore
-		if _, err := stmtInsert.ExecContext(ctx, url, urlID, userID); err != nil {
 			log.Println("unable to add row: ", err)
-			var sqlerr sqlite3.Error
-			// it could be, but not deleted, then there will be an index violation
-			if errors.As(err, &sqlerr) {
-				if sqlerr.Code == sqlite3.ErrConstraint {
-					violationErr = fmt.Errorf(
-						"%w: url=%v, urlID=%v, userID=%v",
-						storage.ErrUniqueViolation,
-						url,
-						urlID,
-						userID,
-					)
-				}
-			} else {
-				return err
+			// the URL (or its ID) is already stored
+			if errors.Is(err, storage.ErrUniqueViolation) {
+				violationErr = err
+				continue
 			}
+			return err
 		}
-		log.Println("added row: ", url, urlID, userID)
-	}
-	if violationErr != nil {
-		return violationErr
 	}
-	return nil
+	return violationErr
 }
 
-// DeleteMany flags the URL to be deleted.
+// DeleteMany removes the user from the owners of the URLs
+// and flags the URLs without owners as deleted.
 func (s *SQLiteStorage) DeleteMany(ctx context.Context, userID uint32, urlIDs []string) error {
 	tx, err := s.db.Begin()
 	if err != nil {
 		return err
 	}
+	stmtOwner, err := tx.PrepareContext(ctx, deleteOwnerSQL)
+	if err != nil {
+		return err
+	}
+	defer stmtOwner.Close()
 	stmt, err := tx.PrepareContext(ctx, deleteByURLIDSQL)
 	if err != nil {
 		return err
@@ -203,7 +425,18 @@ func (s *SQLiteStorage) DeleteMany(ctx context.Context, userID uint32, urlIDs []
 	for _, urlID := range urlIDs {
 		err = func() error {
 			var deletedURL string
-			rows, err := stmt.QueryContext(ctx, urlID, userID)
+			res, err := stmtOwner.ExecContext(ctx, userID, urlID)
+			if err != nil {
+				if errRollback := tx.Rollback(); errRollback != nil {
+					log.Fatalf("update drivers: unable to rollback: %v", errRollback)
+				}
+				return err
+			}
+			// the user doesn't own the URL
+			if n, err := res.RowsAffected(); err != nil || n == 0 {
+				return err
+			}
+			rows, err := stmt.QueryContext(ctx, urlID)
 			if err != nil {
 				if errRollback := tx.Rollback(); errRollback != nil {
 					log.Fatalf("update drivers: unable to rollback: %v", errRollback)
@@ -211,6 +444,7 @@ func (s *SQLiteStorage) DeleteMany(ctx context.Context, userID uint32, urlIDs []
 				return err
 			}
 			defer rows.Close()
+			// other owners remain
 			if !rows.Next() {
 				return nil
 			}
@@ -236,6 +470,7 @@ func (s *SQLiteStorage) DeleteMany(ctx context.Context, userID uint32, urlIDs []
 
 // Close closes the connection to SQLite.
 func (s *SQLiteStorage) Close(ctx context.Context) {
+	close(s.quit)
 	s.db.Close()
 }
 
@@ -260,10 +495,336 @@ func (s *SQLiteStorage) GetStats(ctx context.Context) (int, int, error) {
 	}
 
 	var users int
-	err = s.db.QueryRowContext(ctx, "SELECT COUNT(DISTINCT user_id) FROM Url").
+	err = s.db.QueryRowContext(ctx, "SELECT COUNT(DISTINCT user_id) FROM UrlOwner").
 		Scan(&users)
 	if err != nil {
 		return 0, 0, err
 	}
 	return urls, users, nil
 }
+
+// AddClicks saves a batch of short URL visits.
+func (s *SQLiteStorage) AddClicks(ctx context.Context, clicks []storage.Click) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.PrepareContext(ctx, insertClickSQL)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, c := range clicks {
+		_, err := stmt.ExecContext(
+			ctx,
+			c.URLID,
+			c.ClickedAt.UTC(),
+			c.Referrer,
+			c.UserAgent,
+			c.Country,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetClicks gets all visits of a short URL ordered by time.
+func (s *SQLiteStorage) GetClicks(ctx context.Context, urlID string) ([]storage.Click, error) {
+	rows, err := s.db.QueryContext(ctx, selectClicksSQL, urlID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	results := make([]storage.Click, 0)
+	for rows.Next() {
+		c := storage.Click{URLID: urlID}
+		if err := rows.Scan(&c.ClickedAt, &c.Referrer, &c.UserAgent, &c.Country); err != nil {
+			return nil, err
+		}
+		results = append(results, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// AddUser registers the user.
+func (s *SQLiteStorage) AddUser(ctx context.Context, user storage.User) error {
+	res, err := s.db.ExecContext(
+		ctx,
+		insertUserSQL,
+		user.UserID,
+		user.Username,
+		user.PasswordHash,
+		user.CreatedAt.UTC(),
+	)
+	switch {
+	case isUniqueViolation(err):
+		// either the name or the ID is used by another account
+		if _, err := s.GetUser(ctx, user.Username); err == nil {
+			return fmt.Errorf("%w: username=%v", storage.ErrUsernameTaken, user.Username)
+		}
+		return fmt.Errorf("%w: userID=%v", storage.ErrUserIDTaken, user.UserID)
+	case err != nil:
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	// the ID belongs to a user without an account
+	if n == 0 {
+		return fmt.Errorf("%w: userID=%v", storage.ErrUserIDTaken, user.UserID)
+	}
+	return nil
+}
+
+// GetUser gets the registered user by name.
+func (s *SQLiteStorage) GetUser(ctx context.Context, username string) (storage.User, error) {
+	user := storage.User{Username: username}
+	err := s.db.QueryRowContext(ctx, selectUserSQL, username).
+		Scan(&user.UserID, &user.PasswordHash, &user.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.User{}, storage.ErrUserNotFound
+	}
+	if err != nil {
+		return storage.User{}, err
+	}
+	return user, nil
+}
+
+// AddAPIKey saves the API key of the user.
+func (s *SQLiteStorage) AddAPIKey(ctx context.Context, key storage.APIKey) error {
+	_, err := s.db.ExecContext(ctx, insertAPIKeySQL, key.KeyHash, key.UserID, key.CreatedAt.UTC())
+	return err
+}
+
+// GetAPIKey gets the API key by its hash.
+func (s *SQLiteStorage) GetAPIKey(ctx context.Context, keyHash string) (storage.APIKey, error) {
+	key := storage.APIKey{KeyHash: keyHash}
+	err := s.db.QueryRowContext(ctx, selectAPIKeySQL, keyHash).Scan(&key.UserID, &key.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.APIKey{}, storage.ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return storage.APIKey{}, err
+	}
+	return key, nil
+}
+
+// MoveURLs passes the URLs owned by one user to another.
+func (s *SQLiteStorage) MoveURLs(ctx context.Context, fromUserID, toUserID uint32) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, copyOwnedSQL, toUserID, fromUserID); err != nil {
+		return 0, err
+	}
+	res, err := tx.ExecContext(ctx, deleteOwnedSQL, fromUserID)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	log.Infof("Moved %v URLs of user %v to user %v\n", n, fromUserID, toUserID)
+	return int(n), nil
+}
+
+// toPreview decodes the stored preview, the preview which can't be decoded is missing.
+func toPreview(data []byte) *storage.Preview {
+	if len(data) == 0 {
+		return nil
+	}
+	var preview storage.Preview
+	if err := json.Unmarshal(data, &preview); err != nil {
+		log.Warnf("Can't decode preview: %v", err)
+		return nil
+	}
+	return &preview
+}
+
+// SetPreview saves the preview of the URL's destination.
+func (s *SQLiteStorage) SetPreview(ctx context.Context, urlID string, preview storage.Preview) error {
+	data, err := json.Marshal(preview)
+	if err != nil {
+		return err
+	}
+	res, err := s.db.ExecContext(ctx, updatePreviewSQL, string(data), urlID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return storage.ErrURLWasNotFound
+	}
+	return nil
+}
+
+// GetURLsToCheck gets the active URLs which haven't been checked since the time.
+func (s *SQLiteStorage) GetURLsToCheck(
+	ctx context.Context,
+	checkedBefore time.Time,
+	limit int,
+) ([]storage.Record, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		selectToCheckSQL,
+		toNullTime(time.Now()),
+		toNullTime(checkedBefore),
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanRecords(rows)
+}
+
+// SetHealth saves the result of the check of the URL's destination.
+func (s *SQLiteStorage) SetHealth(ctx context.Context, urlID string, health storage.Health) error {
+	res, err := s.db.ExecContext(
+		ctx,
+		updateHealthSQL,
+		toNullTime(health.CheckedAt),
+		sql.NullInt64{Int64: int64(health.StatusCode), Valid: health.StatusCode != 0},
+		health.Failures,
+		toNullString(health.Error),
+		urlID,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return storage.ErrURLWasNotFound
+	}
+	return nil
+}
+
+// selectOwners gets the users who own the URL.
+func selectOwners(ctx context.Context, tx *sql.Tx, urlID string) ([]uint32, error) {
+	rows, err := tx.QueryContext(ctx, selectOwnersSQL, urlID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	owners := make([]uint32, 0)
+	for rows.Next() {
+		var owner uint32
+		if err := rows.Scan(&owner); err != nil {
+			return nil, err
+		}
+		owners = append(owners, owner)
+	}
+	return owners, rows.Err()
+}
+
+// UpdateURL changes the destination of the URL owned by the user
+// and saves the previous one to the history.
+func (s *SQLiteStorage) UpdateURL(
+	ctx context.Context,
+	urlID string,
+	userID uint32,
+	url, originalURL string,
+) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	rec, err := scanRecord(tx.QueryRowContext(ctx, selectByURLIDSQL, urlID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.ErrURLWasNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if rec.Owners, err = selectOwners(ctx, tx, urlID); err != nil {
+		return err
+	}
+	if err := rec.CheckUpdate(userID); err != nil {
+		return err
+	}
+	// the destination is the same
+	if rec.URL == url {
+		return nil
+	}
+	now := toNullTime(time.Now())
+	// the new destination may be stored under another ID
+	var existingID string
+	err = tx.QueryRowContext(ctx, selectOwnedByURLSQL, url, userID, now).Scan(&existingID)
+	if err == nil {
+		return &storage.DuplicateURLError{URL: url, URLID: existingID}
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if s.shareURLs {
+		var encodingID int64
+		err := tx.QueryRowContext(ctx, selectActiveByURLSQL, url, now).Scan(&encodingID, &existingID)
+		if err == nil {
+			return &storage.DuplicateURLError{URL: url, URLID: existingID}
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, insertRevisionSQL, urlID, rec.URL, now, userID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, updateURLSQL, url, toNullString(originalURL), urlID); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	log.Infof("Changed destination of %v from %v to %v\n", urlID, rec.URL, url)
+	return nil
+}
+
+// GetURLHistory gets the previous destinations of a short URL ordered by time.
+func (s *SQLiteStorage) GetURLHistory(ctx context.Context, urlID string) ([]storage.Revision, error) {
+	rows, err := s.db.QueryContext(ctx, selectRevisionsSQL, urlID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	results := make([]storage.Revision, 0)
+	for rows.Next() {
+		r := storage.Revision{URLID: urlID}
+		if err := rows.Scan(&r.URL, &r.ChangedAt, &r.ChangedBy); err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// NextSequence returns the next value of the counter used to generate IDs.
+func (s *SQLiteStorage) NextSequence(ctx context.Context) (uint64, error) {
+	var n int64
+	if err := s.db.QueryRowContext(ctx, nextSequenceSQL).Scan(&n); err != nil {
+		return 0, err
+	}
+	return uint64(n), nil
+}
@@ -3,9 +3,11 @@ package sqlite
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/blokhinnv/shorty/internal/app/log"
 	"github.com/blokhinnv/shorty/internal/app/server/config"
+	"github.com/blokhinnv/shorty/internal/app/storage"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -25,7 +27,7 @@ func BenchmarkSQLiteStorage(b *testing.B) {
 	b.ResetTimer()
 	b.Run("AddURL", func(b *testing.B) {
 		for i := 0; i < b.N; i++ {
-			s.AddURL(ctx, "http://yandex.ru", "zxcvbn", 2)
+			s.AddURL(ctx, "http://yandex.ru", "zxcvbn", 2, storage.LinkOptions{})
 		}
 	})
 	b.Run("GetURLByID", func(b *testing.B) {
@@ -59,6 +61,7 @@ func (suite *SQLiteSuite) SetupSuite() {
 	serverCfg := &config.ServerConfig{
 		SQLiteDBPath:       "test.sqlite3",
 		SQLiteClearOnStart: true,
+		ShareDuplicateURLs: true,
 	}
 	suite.sqliteCfg = GetSQLiteConfig(serverCfg)
 }
@@ -69,7 +72,7 @@ func (suite *SQLiteSuite) TearDownSuite() {
 func (suite *SQLiteSuite) TestAddURL() {
 	ctx := context.Background()
 	s, _ := NewSQLiteStorage(suite.sqliteCfg)
-	err := s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1))
+	err := s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
 	suite.NoError(err)
 	s.Close(ctx)
 }
@@ -77,24 +80,263 @@ func (suite *SQLiteSuite) TestAddURL() {
 func (suite *SQLiteSuite) TestAddURLTwice() {
 	ctx := context.Background()
 	s, _ := NewSQLiteStorage(suite.sqliteCfg)
-	err := s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1))
+	err := s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
 	suite.NoError(err)
 
-	err = s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1))
+	err = s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
 	suite.Error(err)
 	s.Close(ctx)
 }
 
+func (suite *SQLiteSuite) TestAddURLTakenID() {
+	ctx := context.Background()
+	s, _ := NewSQLiteStorage(suite.sqliteCfg)
+	err := s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
+	suite.NoError(err)
+
+	err = s.AddURL(ctx, "http://google.com", "qwerty", uint32(2), storage.LinkOptions{})
+	suite.ErrorIs(err, storage.ErrUniqueViolation)
+	suite.ErrorIs(err, storage.ErrURLIDTaken)
+	s.Close(ctx)
+}
+
+func (suite *SQLiteSuite) TestAddURLDuplicate() {
+	ctx := context.Background()
+	s, _ := NewSQLiteStorage(suite.sqliteCfg)
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
+	err := s.AddURL(ctx, "http://yandex.ru", "asdfgh", uint32(1), storage.LinkOptions{})
+	var dupErr *storage.DuplicateURLError
+	suite.ErrorAs(err, &dupErr)
+	suite.Equal("qwerty", dupErr.URLID)
+	suite.False(dupErr.NewOwner)
+	suite.ErrorIs(err, storage.ErrUniqueViolation)
+	s.Close(ctx)
+}
+
+func (suite *SQLiteSuite) TestAddURLShared() {
+	ctx := context.Background()
+	s, _ := NewSQLiteStorage(suite.sqliteCfg)
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
+	err := s.AddURL(ctx, "http://yandex.ru", "asdfgh", uint32(2), storage.LinkOptions{})
+	var dupErr *storage.DuplicateURLError
+	suite.ErrorAs(err, &dupErr)
+	suite.Equal("qwerty", dupErr.URLID)
+	suite.True(dupErr.NewOwner)
+	recs, err := s.GetURLsByUser(ctx, uint32(2))
+	suite.NoError(err)
+	suite.Len(recs, 1)
+	suite.Equal("qwerty", recs[0].URLID)
+	// the link is hidden only when all the owners have deleted it
+	s.DeleteMany(ctx, uint32(1), []string{"qwerty"})
+	_, err = s.GetURLByID(ctx, "qwerty")
+	suite.NoError(err)
+	_, err = s.GetURLsByUser(ctx, uint32(1))
+	suite.ErrorIs(err, storage.ErrURLWasNotFound)
+	s.DeleteMany(ctx, uint32(2), []string{"qwerty"})
+	_, err = s.GetURLByID(ctx, "qwerty")
+	suite.ErrorIs(err, storage.ErrURLWasDeleted)
+	s.Close(ctx)
+}
+
+func (suite *SQLiteSuite) TestAddURLNotShared() {
+	ctx := context.Background()
+	cfg := *suite.sqliteCfg
+	cfg.ShareURLs = false
+	s, _ := NewSQLiteStorage(&cfg)
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
+	err := s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(2), storage.LinkOptions{})
+	suite.ErrorIs(err, storage.ErrURLIDTaken)
+	err = s.AddURL(ctx, "http://yandex.ru", "asdfgh", uint32(2), storage.LinkOptions{})
+	suite.NoError(err)
+	recs, err := s.GetURLsByUser(ctx, uint32(2))
+	suite.NoError(err)
+	suite.Len(recs, 1)
+	suite.Equal("asdfgh", recs[0].URLID)
+	s.DeleteMany(ctx, uint32(1), []string{"qwerty"})
+	_, err = s.GetURLByID(ctx, "asdfgh")
+	suite.NoError(err)
+	s.Close(ctx)
+}
+
+func (suite *SQLiteSuite) TestAddURLDeletedNewID() {
+	ctx := context.Background()
+	s, _ := NewSQLiteStorage(suite.sqliteCfg)
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
+	s.DeleteMany(ctx, uint32(1), []string{"qwerty"})
+	err := s.AddURL(ctx, "http://yandex.ru", "asdfgh", uint32(2), storage.LinkOptions{})
+	suite.NoError(err)
+	rec, err := s.GetURLByID(ctx, "asdfgh")
+	suite.NoError(err)
+	suite.Equal("http://yandex.ru", rec.URL)
+	s.Close(ctx)
+}
+
+func (suite *SQLiteSuite) TestAddURLTakenDeletedID() {
+	ctx := context.Background()
+	s, _ := NewSQLiteStorage(suite.sqliteCfg)
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
+	s.DeleteMany(ctx, uint32(1), []string{"qwerty"})
+	err := s.AddURL(ctx, "http://google.com", "qwerty", uint32(2), storage.LinkOptions{})
+	suite.ErrorIs(err, storage.ErrUniqueViolation)
+	s.Close(ctx)
+}
+
 func (suite *SQLiteSuite) TestGetURLByIDOk() {
 	ctx := context.Background()
 	s, _ := NewSQLiteStorage(suite.sqliteCfg)
-	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1))
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
 	rec, err := s.GetURLByID(ctx, "qwerty")
 	suite.NoError(err)
 	suite.Equal("http://yandex.ru", rec.URL)
 	s.Close(ctx)
 }
 
+func (suite *SQLiteSuite) TestGetURLByIDExpired() {
+	ctx := context.Background()
+	s, _ := NewSQLiteStorage(suite.sqliteCfg)
+	expired := storage.LinkOptions{ExpiresAt: time.Now().Add(-time.Minute)}
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), expired)
+	_, err := s.GetURLByID(ctx, "qwerty")
+	suite.ErrorIs(err, storage.ErrURLExpired)
+	// shortening the same URL again brings the link back
+	err = s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
+	suite.NoError(err)
+	rec, err := s.GetURLByID(ctx, "qwerty")
+	suite.NoError(err)
+	suite.True(rec.ExpiresAt.IsZero())
+	s.Close(ctx)
+}
+
+func (suite *SQLiteSuite) TestPurgeExpired() {
+	ctx := context.Background()
+	s, _ := NewSQLiteStorage(suite.sqliteCfg)
+	expired := storage.LinkOptions{ExpiresAt: time.Now().Add(-time.Minute)}
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), expired)
+	s.AddURL(ctx, "http://google.com", "asdfgh", uint32(1), storage.LinkOptions{})
+	s.AddClicks(ctx, []storage.Click{
+		{URLID: "qwerty", ClickedAt: time.Now()},
+		{URLID: "asdfgh", ClickedAt: time.Now()},
+	})
+	n, err := s.PurgeExpired(ctx)
+	suite.NoError(err)
+	suite.Equal(int64(1), n)
+	// the visits of the purged URL are gone with it
+	clicks, err := s.GetClicks(ctx, "qwerty")
+	suite.NoError(err)
+	suite.Empty(clicks)
+	clicks, err = s.GetClicks(ctx, "asdfgh")
+	suite.NoError(err)
+	suite.Len(clicks, 1)
+	s.Close(ctx)
+}
+
+func (suite *SQLiteSuite) TestAddURLRestoredClicks() {
+	ctx := context.Background()
+	s, _ := NewSQLiteStorage(suite.sqliteCfg)
+	expired := storage.LinkOptions{ExpiresAt: time.Now().Add(-time.Second)}
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
+	s.AddURL(ctx, "http://google.com", "asdfgh", uint32(1), expired)
+	s.AddClicks(ctx, []storage.Click{
+		{URLID: "qwerty", ClickedAt: time.Now(), Referrer: "http://ya.ru"},
+		{URLID: "asdfgh", ClickedAt: time.Now(), Country: "RU"},
+	})
+	suite.NoError(s.DeleteMany(ctx, uint32(1), []string{"qwerty"}))
+	// another user shortens the deleted and the expired URLs and gets the same IDs
+	err := s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(2), storage.LinkOptions{})
+	suite.NoError(err)
+	err = s.AddURLBatch(ctx, map[string]string{"http://google.com": "asdfgh"}, uint32(2))
+	suite.NoError(err)
+	// the visits of the previous owner aren't shown to the new one
+	for _, urlID := range []string{"qwerty", "asdfgh"} {
+		clicks, err := s.GetClicks(ctx, urlID)
+		suite.NoError(err)
+		suite.Empty(clicks)
+	}
+	s.Close(ctx)
+}
+
+func (suite *SQLiteSuite) TestAddURLRestoredHistory() {
+	ctx := context.Background()
+	s, _ := NewSQLiteStorage(suite.sqliteCfg)
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
+	suite.NoError(s.UpdateURL(ctx, "qwerty", uint32(1), "http://ya.ru", ""))
+	suite.NoError(s.DeleteMany(ctx, uint32(1), []string{"qwerty"}))
+	// another user shortens the deleted URL and gets the same ID
+	err := s.AddURL(ctx, "http://ya.ru", "qwerty", uint32(2), storage.LinkOptions{})
+	suite.NoError(err)
+	// the previous destinations of the previous owner aren't shown to the new one
+	history, err := s.GetURLHistory(ctx, "qwerty")
+	suite.NoError(err)
+	suite.Empty(history)
+	s.Close(ctx)
+}
+
+func (suite *SQLiteSuite) TestPurgeHistory() {
+	ctx := context.Background()
+	s, _ := NewSQLiteStorage(suite.sqliteCfg)
+	soon := storage.LinkOptions{ExpiresAt: time.Now().Add(200 * time.Millisecond)}
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), soon)
+	err := s.UpdateURL(ctx, "qwerty", uint32(1), "http://ya.ru", "")
+	suite.NoError(err)
+	history, err := s.GetURLHistory(ctx, "qwerty")
+	suite.NoError(err)
+	suite.Len(history, 1)
+	time.Sleep(300 * time.Millisecond)
+	s.PurgeExpired(ctx)
+	// the history of the purged URL is gone with it
+	history, err = s.GetURLHistory(ctx, "qwerty")
+	suite.NoError(err)
+	suite.Empty(history)
+	s.Close(ctx)
+}
+
+func (suite *SQLiteSuite) TestNextSequence() {
+	ctx := context.Background()
+	s, _ := NewSQLiteStorage(suite.sqliteCfg)
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
+	s.AddURL(ctx, "http://ya.ru", "asdfgh", uint32(1), storage.LinkOptions{})
+	// the counter is reset by clearing the storage, not by the URLs
+	n, err := s.NextSequence(ctx)
+	suite.NoError(err)
+	suite.Equal(uint64(0), n)
+	n, err = s.NextSequence(ctx)
+	suite.NoError(err)
+	suite.Equal(uint64(1), n)
+	s.Close(ctx)
+	// the values aren't issued again after a restart
+	cfg := *suite.sqliteCfg
+	cfg.ClearOnStart = false
+	s, _ = NewSQLiteStorage(&cfg)
+	n, err = s.NextSequence(ctx)
+	suite.NoError(err)
+	suite.Equal(uint64(2), n)
+	s.Close(ctx)
+}
+
+func (suite *SQLiteSuite) TestClicks() {
+	ctx := context.Background()
+	s, _ := NewSQLiteStorage(suite.sqliteCfg)
+	first := time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC)
+	err := s.AddClicks(ctx, []storage.Click{
+		{URLID: "qwerty", ClickedAt: first.Add(time.Hour), Referrer: "http://ya.ru"},
+		{URLID: "asdfgh", ClickedAt: first},
+		{URLID: "qwerty", ClickedAt: first, UserAgent: "curl/7.81.0", Country: "RU"},
+	})
+	suite.NoError(err)
+	clicks, err := s.GetClicks(ctx, "qwerty")
+	suite.NoError(err)
+	suite.Len(clicks, 2)
+	suite.True(first.Equal(clicks[0].ClickedAt))
+	suite.Equal("curl/7.81.0", clicks[0].UserAgent)
+	suite.Equal("RU", clicks[0].Country)
+	suite.Equal("http://ya.ru", clicks[1].Referrer)
+	s.Clear(ctx)
+	clicks, err = s.GetClicks(ctx, "qwerty")
+	suite.NoError(err)
+	suite.Empty(clicks)
+	s.Close(ctx)
+}
+
 func (suite *SQLiteSuite) TestGetURLByIDEmpty() {
 	ctx := context.Background()
 	s, _ := NewSQLiteStorage(suite.sqliteCfg)
@@ -114,7 +356,7 @@ func (suite *SQLiteSuite) TestGetURLsByUserNotFound() {
 func (suite *SQLiteSuite) TestGetURLsByUserFound() {
 	ctx := context.Background()
 	s, _ := NewSQLiteStorage(suite.sqliteCfg)
-	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1))
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
 	res, err := s.GetURLsByUser(ctx, uint32(1))
 	suite.NoError(err)
 	suite.Equal("http://yandex.ru", res[0].URL)
@@ -133,7 +375,7 @@ func (suite *SQLiteSuite) TestBatchOK() {
 func (suite *SQLiteSuite) TestBatchErr() {
 	ctx := context.Background()
 	s, _ := NewSQLiteStorage(suite.sqliteCfg)
-	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1))
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
 	err := s.AddURLBatch(ctx, map[string]string{"http://yandex.ru": "qwerty"}, uint32(1))
 	suite.Error(err)
 	s.Close(ctx)
@@ -142,7 +384,7 @@ func (suite *SQLiteSuite) TestBatchErr() {
 func (suite *SQLiteSuite) TestDelete() {
 	ctx := context.Background()
 	s, _ := NewSQLiteStorage(suite.sqliteCfg)
-	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1))
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
 	err := s.DeleteMany(ctx, uint32(1), []string{"qwerty"})
 	suite.NoError(err)
 	s.Close(ctx)
@@ -167,7 +409,7 @@ func (suite *SQLiteSuite) TestClear() {
 func (suite *SQLiteSuite) TestStats() {
 	ctx := context.Background()
 	s, _ := NewSQLiteStorage(suite.sqliteCfg)
-	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1))
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
 	urls, users, err := s.GetStats(ctx)
 	suite.Equal(1, urls)
 	suite.Equal(1, users)
@@ -175,6 +417,100 @@ func (suite *SQLiteSuite) TestStats() {
 	s.Close(ctx)
 }
 
+func (suite *SQLiteSuite) TestUsers() {
+	ctx := context.Background()
+	s, _ := NewSQLiteStorage(suite.sqliteCfg)
+	user := storage.User{UserID: 1, Username: "user", PasswordHash: "hash"}
+	suite.NoError(s.AddUser(ctx, user))
+	suite.ErrorIs(s.AddUser(ctx, user), storage.ErrUsernameTaken)
+	// the ID of another account
+	other := storage.User{UserID: 1, Username: "other", PasswordHash: "hash"}
+	suite.ErrorIs(s.AddUser(ctx, other), storage.ErrUserIDTaken)
+	// the ID of a user without an account
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(2), storage.LinkOptions{})
+	other.UserID = 2
+	suite.ErrorIs(s.AddUser(ctx, other), storage.ErrUserIDTaken)
+	other.UserID = 3
+	suite.NoError(s.AddUser(ctx, other))
+	res, err := s.GetUser(ctx, "user")
+	suite.NoError(err)
+	suite.Equal(uint32(1), res.UserID)
+	suite.Equal("hash", res.PasswordHash)
+	_, err = s.GetUser(ctx, "nobody")
+	suite.ErrorIs(err, storage.ErrUserNotFound)
+
+	suite.NoError(s.AddAPIKey(ctx, storage.APIKey{KeyHash: "key", UserID: 1}))
+	key, err := s.GetAPIKey(ctx, "key")
+	suite.NoError(err)
+	suite.Equal(uint32(1), key.UserID)
+	_, err = s.GetAPIKey(ctx, "nokey")
+	suite.ErrorIs(err, storage.ErrAPIKeyNotFound)
+	s.Close(ctx)
+}
+
+func (suite *SQLiteSuite) TestMoveURLs() {
+	ctx := context.Background()
+	s, _ := NewSQLiteStorage(suite.sqliteCfg)
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
+	s.AddURL(ctx, "http://mail.ru", "asdfgh", uint32(1), storage.LinkOptions{})
+	s.AddURL(ctx, "http://mail.ru", "asdfgh", uint32(2), storage.LinkOptions{})
+	n, err := s.MoveURLs(ctx, uint32(1), uint32(2))
+	suite.NoError(err)
+	suite.Equal(2, n)
+	res, err := s.GetURLsByUser(ctx, uint32(2))
+	suite.NoError(err)
+	suite.Len(res, 2)
+	_, err = s.GetURLsByUser(ctx, uint32(1))
+	suite.Error(err)
+	s.Close(ctx)
+}
+
+func (suite *SQLiteSuite) TestUpdateURL() {
+	ctx := context.Background()
+	s, _ := NewSQLiteStorage(suite.sqliteCfg)
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
+	s.AddURL(ctx, "http://mail.ru", "asdfgh", uint32(1), storage.LinkOptions{})
+	err := s.UpdateURL(ctx, "qwerty", uint32(2), "http://ya.ru", "")
+	suite.ErrorIs(err, storage.ErrNotOwner)
+	err = s.UpdateURL(ctx, "zxcvbn", uint32(1), "http://ya.ru", "")
+	suite.ErrorIs(err, storage.ErrURLWasNotFound)
+	err = s.UpdateURL(ctx, "qwerty", uint32(1), "http://mail.ru", "")
+	var dupErr *storage.DuplicateURLError
+	suite.ErrorAs(err, &dupErr)
+	suite.Equal("asdfgh", dupErr.URLID)
+
+	err = s.UpdateURL(ctx, "qwerty", uint32(1), "http://ya.ru", "http://YA.ru")
+	suite.NoError(err)
+	rec, err := s.GetURLByID(ctx, "qwerty")
+	suite.NoError(err)
+	suite.Equal("http://ya.ru", rec.URL)
+	suite.Equal("http://YA.ru", rec.OriginalURL)
+	err = s.UpdateURL(ctx, "qwerty", uint32(1), "http://bing.com", "")
+	suite.NoError(err)
+	history, err := s.GetURLHistory(ctx, "qwerty")
+	suite.NoError(err)
+	suite.Require().Len(history, 2)
+	suite.Equal("http://yandex.ru", history[0].URL)
+	suite.Equal("http://ya.ru", history[1].URL)
+	suite.Equal(uint32(1), history[1].ChangedBy)
+	// the old destination can be shortened again
+	err = s.AddURL(ctx, "http://yandex.ru", "zxcvbn", uint32(1), storage.LinkOptions{})
+	suite.NoError(err)
+
+	// the link shared with another user can't be changed
+	s.AddURL(ctx, "http://mail.ru", "poiuyt", uint32(2), storage.LinkOptions{})
+	err = s.UpdateURL(ctx, "asdfgh", uint32(1), "http://google.com", "")
+	suite.ErrorIs(err, storage.ErrURLShared)
+	s.DeleteMany(ctx, uint32(1), []string{"qwerty"})
+	err = s.UpdateURL(ctx, "qwerty", uint32(1), "http://google.com", "")
+	suite.ErrorIs(err, storage.ErrURLWasDeleted)
+	s.Clear(ctx)
+	history, err = s.GetURLHistory(ctx, "qwerty")
+	suite.NoError(err)
+	suite.Empty(history)
+	s.Close(ctx)
+}
+
 func TestSQLiteSuite(t *testing.T) {
 	suite.Run(t, new(SQLiteSuite))
 }
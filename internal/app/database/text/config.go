@@ -12,6 +12,8 @@ type TextStorageConfig struct {
 	ClearOnStart    bool
 	TTLOnDisk       time.Duration
 	TTLInMemory     time.Duration
+	// users who shorten the same URL share one link
+	ShareURLs bool
 }
 
 // GetTextStorageConfig - text storage config constructor based on server config.
@@ -21,5 +23,6 @@ func GetTextStorageConfig(cfg *config.ServerConfig) *TextStorageConfig {
 		ClearOnStart:    cfg.FileStorageClearOnStart,
 		TTLOnDisk:       cfg.FileStorageTTLOnDisk,
 		TTLInMemory:     cfg.FileStorageTTLInMemory,
+		ShareURLs:       cfg.ShareDuplicateURLs,
 	}
 }
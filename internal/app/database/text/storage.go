@@ -8,6 +8,9 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -18,15 +21,24 @@ import (
 
 // TextStorage implements the Storage interface based on a text file.
 type TextStorage struct {
-	filePath  string
-	ttlOnDisk time.Duration
-	ttlInMem  time.Duration
-	db        []storage.Record
-	toUpdate  map[string]time.Time
-	buf       *bytes.Buffer
-	encoder   *json.Encoder
-	mu        sync.Mutex
-	quit      chan struct{}
+	filePath    string
+	clicksPath  string
+	usersPath   string
+	keysPath    string
+	historyPath string
+	seqPath     string
+	ttlOnDisk   time.Duration
+	ttlInMem    time.Duration
+	shareURLs   bool
+	db          []storage.Record
+	toUpdate    map[string]time.Time
+	buf         *bytes.Buffer
+	encoder     *json.Encoder
+	mu          sync.Mutex
+	clicksMu    sync.Mutex
+	usersMu     sync.Mutex
+	historyMu   sync.Mutex
+	quit        chan struct{}
 }
 
 // Settings for fetching data from a text file.
@@ -35,6 +47,7 @@ const (
 	ByURLID
 	ByURL
 	ByUserIDAndURLID
+	ByCheckedBefore
 )
 
 // TextStorageRequest - a structure for making a request to the storage.
@@ -45,22 +58,41 @@ type TextStorageRequest struct {
 	Size   int
 	How    int
 	URLIDs []string
+	// the active records which haven't been checked since the time are found
+	CheckedBefore time.Time
 }
 
 // NewTextStorage - constructor for a new URL storage.
 func NewTextStorage(conf *TextStorageConfig) (*TextStorage, error) {
+	// clicks and accounts are kept next to the URLs
+	clicksPath := conf.FileStoragePath + ".clicks"
+	usersPath := conf.FileStoragePath + ".users"
+	keysPath := conf.FileStoragePath + ".keys"
+	historyPath := conf.FileStoragePath + ".history"
+	seqPath := conf.FileStoragePath + ".seq"
 	if conf.ClearOnStart {
 		os.Remove(conf.FileStoragePath)
+		os.Remove(clicksPath)
+		os.Remove(usersPath)
+		os.Remove(keysPath)
+		os.Remove(historyPath)
+		os.Remove(seqPath)
 	}
 	buf := bytes.NewBuffer(make([]byte, 0))
 	s := &TextStorage{
-		filePath:  conf.FileStoragePath,
-		ttlOnDisk: conf.TTLOnDisk,
-		ttlInMem:  conf.TTLInMemory,
-		toUpdate:  make(map[string]time.Time),
-		buf:       buf,
-		encoder:   json.NewEncoder(buf),
-		quit:      make(chan struct{}),
+		filePath:    conf.FileStoragePath,
+		clicksPath:  clicksPath,
+		usersPath:   usersPath,
+		keysPath:    keysPath,
+		historyPath: historyPath,
+		seqPath:     seqPath,
+		ttlOnDisk:   conf.TTLOnDisk,
+		ttlInMem:    conf.TTLInMemory,
+		shareURLs:   conf.ShareURLs,
+		toUpdate:    make(map[string]time.Time),
+		buf:         buf,
+		encoder:     json.NewEncoder(buf),
+		quit:        make(chan struct{}),
 	}
 	file, err := os.OpenFile(s.filePath, os.O_CREATE, 0777)
 	if err != nil {
@@ -87,22 +119,26 @@ func (s *TextStorage) updateStorage() {
 
 	// read from disk, discard junk
 	newDB := make([]storage.Record, 0)
+	removed := make(map[string]bool)
 	var r storage.Record
 	decoder := json.NewDecoder(file)
 	for decoder.More() {
+		// missing fields must not be kept from the previous record
+		r = storage.Record{}
 		err = decoder.Decode(&r)
 		if err != nil {
 			log.Fatal(err)
 		}
-		if time.Since(r.Added) < s.ttlOnDisk {
-			if reqTime, ok := s.toUpdate[r.URL]; ok {
+		if time.Since(r.Added) < s.ttlOnDisk && !r.IsExpired() {
+			if reqTime, ok := s.toUpdate[r.URLID]; ok {
 				r.RequestedAt = reqTime
 				log.Infof("Updated last request time of %+v \n", r)
-				delete(s.toUpdate, r.URL)
+				delete(s.toUpdate, r.URLID)
 			}
 			newDB = append(newDB, r)
 		} else {
 			log.Infof("Removing %+v from disk \n", r)
+			removed[r.URLID] = true
 		}
 	}
 	file.Close()
@@ -123,6 +159,13 @@ func (s *TextStorage) updateStorage() {
 	// update storage in memory
 	s.db = newDB
 	s.deleteNotRequested()
+	// the IDs may be reused, so the visits and the history go along with the URLs
+	if err := s.purgeClicks(removed); err != nil {
+		log.Infof("Error while purging clicks: %v", err)
+	}
+	if err := s.purgeHistory(removed); err != nil {
+		log.Infof("Error while purging history: %v", err)
+	}
 
 }
 
@@ -174,9 +217,15 @@ func (s *TextStorage) appendFromBuffer() error {
 }
 
 // updateFile updates the file based on the entry map.
+// The records are replaced by their stored IDs.
 func (s *TextStorage) updateFile(newRecords map[string]storage.Record) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	return s.rewriteFile(newRecords)
+}
+
+// rewriteFile does the same as updateFile, the caller must hold the lock.
+func (s *TextStorage) rewriteFile(newRecords map[string]storage.Record) error {
 	// mark file as deleted
 	file, err := os.OpenFile(s.filePath, os.O_RDONLY, 0777)
 	if err != nil {
@@ -186,11 +235,12 @@ func (s *TextStorage) updateFile(newRecords map[string]storage.Record) error {
 	var rec storage.Record
 	decoder := json.NewDecoder(file)
 	for decoder.More() {
+		rec = storage.Record{}
 		err = decoder.Decode(&rec)
 		if err != nil {
 			log.Fatal(err)
 		}
-		if newRec, ok := newRecords[rec.URL]; ok {
+		if newRec, ok := newRecords[rec.URLID]; ok {
 			err = s.encoder.Encode(newRec)
 		} else {
 			err = s.encoder.Encode(rec)
@@ -218,20 +268,31 @@ func (s *TextStorage) updateFile(newRecords map[string]storage.Record) error {
 	return nil
 }
 
+// forgetInMem removes records with the given IDs from memory.
+func (s *TextStorage) forgetInMem(records map[string]storage.Record) {
+	newMem := make([]storage.Record, 0, len(s.db))
+	for _, rec := range s.db {
+		if _, ok := records[rec.URLID]; !ok {
+			newMem = append(newMem, rec)
+		}
+	}
+	s.db = newMem
+}
+
 // findInMem searches memory for a URL by urlID.
 func (s *TextStorage) findInMem(request TextStorageRequest) ([]storage.Record, error) {
 	results := make([]storage.Record, 0)
 
 	for _, rec := range s.db {
 		matchURLID := request.How == ByURLID && rec.URLID == request.URLID
-		matchUserID := request.How == ByUserID && rec.UserID == request.UserID
+		matchUserID := request.How == ByUserID && rec.HasOwner(request.UserID)
 		matchURL := request.How == ByURL && rec.URL == request.URL
 		// there will never be isDeleted records in memory
 		// (they are deleted in DeleteMany)
 		// but just in case, check
 		if (matchURLID || matchUserID || matchURL) && !rec.IsDeleted {
 			rec.RequestedAt = time.Now()
-			s.toUpdate[rec.URL] = time.Now()
+			s.toUpdate[rec.URLID] = time.Now()
 			results = append(results, rec)
 		}
 		if request.Size > 0 && len(results) == request.Size {
@@ -256,18 +317,23 @@ func (s *TextStorage) FindInFile(request TextStorageRequest) ([]storage.Record,
 	var rec storage.Record
 	decoder := json.NewDecoder(file)
 	for decoder.More() {
+		rec = storage.Record{}
 		err = decoder.Decode(&rec)
 		if err != nil {
 			log.Fatal(err)
 		}
 		matchURLID := request.How == ByURLID && rec.URLID == request.URLID
-		matchUserID := request.How == ByUserID && rec.UserID == request.UserID
+		matchUserID := request.How == ByUserID && rec.HasOwner(request.UserID)
 		matchURL := request.How == ByURL && rec.URL == request.URL
 		matchAnyURLIDAndUserID := request.How == ByUserIDAndURLID && request.URLIDs != nil &&
-			rec.UserID == request.UserID &&
+			rec.HasOwner(request.UserID) &&
 			slices.Contains(request.URLIDs, rec.URLID)
-		if matchURLID || matchUserID || matchURL || matchAnyURLIDAndUserID {
-			s.toUpdate[rec.URL] = time.Now()
+		matchCheckedBefore := request.How == ByCheckedBefore && rec.NeedsCheck(request.CheckedBefore)
+		if matchURLID || matchUserID || matchURL || matchAnyURLIDAndUserID || matchCheckedBefore {
+			// the checks of the destinations are not the requests of the URLs
+			if !matchCheckedBefore {
+				s.toUpdate[rec.URLID] = time.Now()
+			}
 			results = append(results, rec)
 		}
 		if request.Size > 0 && len(results) == request.Size {
@@ -280,43 +346,144 @@ func (s *TextStorage) FindInFile(request TextStorageRequest) ([]storage.Record,
 	return results, nil
 }
 
+// findConflicts searches a file for records that share the URL or the ID
+// with the new record.
+func (s *TextStorage) findConflicts(url, urlID string) ([]storage.Record, error) {
+	// the URL may be stored for several users
+	byURL, err := s.FindInFile(TextStorageRequest{URL: url, How: ByURL})
+	if err != nil && !errors.Is(err, storage.ErrURLWasNotFound) {
+		return nil, err
+	}
+	// the ID may be taken by another URL (e.g. with a custom alias)
+	byURLID, err := s.FindInFile(TextStorageRequest{URLID: urlID, Size: 1, How: ByURLID})
+	if err != nil && !errors.Is(err, storage.ErrURLWasNotFound) {
+		return nil, err
+	}
+	for _, rec := range byURLID {
+		if rec.URL != url {
+			byURL = append(byURL, rec)
+		}
+	}
+	return byURL, nil
+}
+
+// checkConflicts decides how the URL can be added for the user.
+// It returns a deleted (or expired) copy of the URL which can be restored
+// and a copy stored for other users which can be shared with the user.
+func (s *TextStorage) checkConflicts(
+	url, urlID string,
+	userID uint32,
+) (restore *storage.Record, shared *storage.Record, err error) {
+	result, err := s.findConflicts(url, urlID)
+	if err != nil {
+		return nil, nil, err
+	}
+	// the user has already shortened the URL
+	for _, rec := range result {
+		if rec.URL == url && !rec.IsDeleted && !rec.IsExpired() && rec.HasOwner(userID) {
+			return nil, nil, &storage.DuplicateURLError{URL: url, URLID: rec.URLID}
+		}
+	}
+	for i, rec := range result {
+		active := !rec.IsDeleted && !rec.IsExpired()
+		switch {
+		case rec.URL != url, active && !s.shareURLs && rec.URLID == urlID:
+			// the ID belongs to another URL or to a copy of another user
+			return nil, nil, fmt.Errorf(
+				"%w: url=%v, urlID=%v, userID=%v",
+				storage.ErrURLIDTaken,
+				url,
+				urlID,
+				userID,
+			)
+		case active && s.shareURLs:
+			shared = &result[i]
+		case !active && restore == nil:
+			restore = &result[i]
+		}
+	}
+	if shared != nil {
+		return nil, shared, nil
+	}
+	return restore, nil, nil
+}
+
+// restoreRecord resets the flag of a deleted (or expired) record
+// and moves it to the new ID. The record gets the user as its only owner.
+func restoreRecord(
+	rec storage.Record,
+	urlID string,
+	userID uint32,
+	opts storage.LinkOptions,
+) storage.Record {
+	rec.IsDeleted = false
+	rec.URLID = urlID
+	rec.UserID = userID
+	rec.Owners = []uint32{userID}
+	rec.ExpiresAt = opts.ExpiresAt
+	rec.OriginalURL = opts.OriginalURL
+	rec.WarnBeforeRedirect = opts.WarnBeforeRedirect
+	rec.RedirectCode = opts.RedirectCode
+	rec.Preview = nil
+	rec.Health = nil
+	return rec
+}
+
 // ------ Implementation of the Storage interface ---------
 
 // AddURL - method for adding a new URL to the file.
-func (s *TextStorage) AddURL(ctx context.Context, url, urlID string, userID uint32) error {
+func (s *TextStorage) AddURL(
+	ctx context.Context,
+	url, urlID string,
+	userID uint32,
+	opts storage.LinkOptions,
+) error {
 	// Let's try to find a record in the storage - if there is, then do not add
-	req := TextStorageRequest{URL: url, Size: 1, How: ByURL}
-	result, err := s.FindInFile(req)
-	if err != nil && !errors.Is(err, storage.ErrURLWasNotFound) {
+	restore, shared, err := s.checkConflicts(url, urlID, userID)
+	if err != nil {
 		return err
 	}
-	foundDeleted := make(map[string]storage.Record, 0)
-	for _, rec := range result {
-		// if you find a suitable Url that has isDeleted=True, you need to reset the flag
-		if rec.IsDeleted {
-			rec.IsDeleted = false
-			foundDeleted[rec.URL] = rec
+	// the URL gets one more owner, the memory keeps its outdated copy
+	if shared != nil {
+		rec := *shared
+		rec.Owners = append(slices.Clone(rec.OwnerIDs()), userID)
+		updated := map[string]storage.Record{rec.URLID: rec}
+		s.forgetInMem(updated)
+		if err := s.updateFile(updated); err != nil {
+			return err
 		}
+		log.Infof("Added user %v to owners of %v\n", userID, rec.URLID)
+		return &storage.DuplicateURLError{URL: url, URLID: rec.URLID, NewOwner: true}
 	}
-	// update the file
-	s.updateFile(foundDeleted)
-	// if such an entry is found, return an error
-	if len(result)-len(foundDeleted) > 0 {
-		return fmt.Errorf(
-			"%w: url=%v, urlID=%v, userID=%v",
-			storage.ErrUniqueViolation,
-			url,
-			urlID,
-			userID,
-		)
+	// restored record => no need to add,
+	// but the memory may keep its outdated copy
+	if restore != nil {
+		// the visits and the history belong to the previous owners
+		restoredIDs := map[string]bool{restore.URLID: true}
+		if err := s.purgeClicks(restoredIDs); err != nil {
+			return err
+		}
+		if err := s.purgeHistory(restoredIDs); err != nil {
+			return err
+		}
+		restored := map[string]storage.Record{
+			restore.URLID: restoreRecord(*restore, urlID, userID, opts),
+		}
+		s.forgetInMem(restored)
+		return s.updateFile(restored)
 	}
 
 	r := storage.Record{
-		URL:         url,
-		URLID:       urlID,
-		UserID:      userID,
-		Added:       time.Now(),
-		RequestedAt: time.Now(),
+		URL:                url,
+		OriginalURL:        opts.OriginalURL,
+		URLID:              urlID,
+		UserID:             userID,
+		Owners:             []uint32{userID},
+		Added:              time.Now(),
+		RequestedAt:        time.Now(),
+		ExpiresAt:          opts.ExpiresAt,
+		WarnBeforeRedirect: opts.WarnBeforeRedirect,
+		RedirectCode:       opts.RedirectCode,
 	}
 	err = s.encoder.Encode(r)
 	if err != nil {
@@ -349,6 +516,9 @@ func (s *TextStorage) GetURLByID(ctx context.Context, urlID string) (storage.Rec
 	if rec.IsDeleted {
 		return storage.Record{}, storage.ErrURLWasDeleted
 	}
+	if rec.IsExpired() {
+		return storage.Record{}, storage.ErrURLExpired
+	}
 	return rec, nil
 }
 
@@ -380,17 +550,25 @@ func (s *TextStorage) AddURLBatch(
 	var violationErr error
 	foundDeleted := make(map[string]storage.Record, 0)
 	for url, urlID := range urlIDs {
-		req := TextStorageRequest{URL: url, Size: 1, How: ByURL}
-		result, err := s.FindInFile(req)
-		if err != nil && !errors.Is(err, storage.ErrURLWasNotFound) {
+		restore, shared, err := s.checkConflicts(url, urlID, userID)
+		switch {
+		case errors.Is(err, storage.ErrUniqueViolation):
+			// it's not deleted => need to report a duplicate
+			violationErr = err
+		case err != nil:
 			return err
-		}
-		// no such url found: add
-		if len(result) == 0 {
+		case shared != nil:
+			violationErr = &storage.DuplicateURLError{URL: url, URLID: shared.URLID}
+		case restore != nil:
+			// it's deleted => should be marked as not deleted
+			foundDeleted[restore.URLID] = restoreRecord(*restore, urlID, userID, storage.LinkOptions{})
+		default:
+			// no such url found: add
 			r := storage.Record{
 				URL:         url,
 				URLID:       urlID,
 				UserID:      userID,
+				Owners:      []uint32{userID},
 				Added:       time.Now(),
 				RequestedAt: time.Now(),
 			}
@@ -401,46 +579,31 @@ func (s *TextStorage) AddURLBatch(
 				return err
 			}
 			log.Infof("Added %v=>%v to buffer\n", url, urlID)
-			continue
-		}
-		// found this url
-		rec := result[0]
-		if rec.IsDeleted {
-			// it's deleted => should be marked as not deleted
-			rec.IsDeleted = false
-			foundDeleted[rec.URL] = rec
-		} else {
-			// it's not deleted => need to report a duplicate
-			violationErr = fmt.Errorf(
-				"%w: url=%v, urlID=%v, userID=%v",
-				storage.ErrUniqueViolation,
-				url,
-				urlID,
-				userID,
-			)
 		}
 	}
 	s.updateFile(foundDeleted)
+	s.forgetInMem(foundDeleted)
 	// add to file
 	s.appendFromBuffer()
+	// the visits and the history belong to the previous owners
+	restoredIDs := make(map[string]bool, len(foundDeleted))
+	for id := range foundDeleted {
+		restoredIDs[id] = true
+	}
+	if err := s.purgeClicks(restoredIDs); err != nil {
+		return err
+	}
+	if err := s.purgeHistory(restoredIDs); err != nil {
+		return err
+	}
 	// // clear memory from old requests
 	// s.DeleteNotRequested()
-	if violationErr != nil {
-		return violationErr
-	}
-	return nil
+	return violationErr
 }
 
-// DeleteMany flags the URL to be deleted.
+// DeleteMany removes the user from the owners of the URLs
+// and flags the URLs without owners as deleted.
 func (s *TextStorage) DeleteMany(ctx context.Context, userID uint32, urlIDs []string) error {
-	// remove from memory
-	newMem := make([]storage.Record, 0, len(s.db))
-	for _, rec := range s.db {
-		if !(rec.UserID == userID && slices.Contains(urlIDs, rec.URLID)) {
-			newMem = append(newMem, rec)
-		}
-	}
-	s.db = newMem
 	req := TextStorageRequest{UserID: userID, URLIDs: urlIDs, How: ByUserIDAndURLID}
 	result, err := s.FindInFile(req)
 	if err != nil {
@@ -452,9 +615,18 @@ func (s *TextStorage) DeleteMany(ctx context.Context, userID uint32, urlIDs []st
 
 	toDelete := make(map[string]storage.Record, 0)
 	for _, rec := range result {
-		rec.IsDeleted = true
-		toDelete[rec.URL] = rec
+		owners := make([]uint32, 0, len(rec.OwnerIDs()))
+		for _, owner := range rec.OwnerIDs() {
+			if owner != userID {
+				owners = append(owners, owner)
+			}
+		}
+		rec.Owners = owners
+		rec.IsDeleted = len(owners) == 0
+		toDelete[rec.URLID] = rec
 	}
+	// remove from memory
+	s.forgetInMem(toDelete)
 	err = s.updateFile(toDelete)
 	if err != nil {
 		return err
@@ -485,6 +657,18 @@ func (s *TextStorage) Clear(ctx context.Context) error {
 		return err
 	}
 	f.Close()
+	s.clicksMu.Lock()
+	defer s.clicksMu.Unlock()
+	s.usersMu.Lock()
+	defer s.usersMu.Unlock()
+	s.historyMu.Lock()
+	defer s.historyMu.Unlock()
+	for _, path := range []string{s.clicksPath, s.usersPath, s.keysPath, s.historyPath, s.seqPath} {
+		err = os.Remove(path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -501,13 +685,415 @@ func (s *TextStorage) GetStats(ctx context.Context) (int, int, error) {
 	var urls int
 	users := make(map[uint32]struct{})
 	for decoder.More() {
+		rec = storage.Record{}
 		err = decoder.Decode(&rec)
 		if err != nil {
 			return 0, 0, err
 		}
 		urls += 1
-		users[rec.UserID] = struct{}{}
+		for _, owner := range rec.OwnerIDs() {
+			users[owner] = struct{}{}
+		}
 	}
 
 	return urls, len(users), nil
 }
+
+// AddClicks saves a batch of short URL visits.
+func (s *TextStorage) AddClicks(ctx context.Context, clicks []storage.Click) error {
+	s.clicksMu.Lock()
+	defer s.clicksMu.Unlock()
+	file, err := os.OpenFile(s.clicksPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0777)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	encoder := json.NewEncoder(file)
+	for _, c := range clicks {
+		if err := encoder.Encode(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetClicks gets all visits of a short URL ordered by time.
+func (s *TextStorage) GetClicks(ctx context.Context, urlID string) ([]storage.Click, error) {
+	s.clicksMu.Lock()
+	defer s.clicksMu.Unlock()
+	results := make([]storage.Click, 0)
+	file, err := os.OpenFile(s.clicksPath, os.O_RDONLY, 0777)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return results, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+	decoder := json.NewDecoder(file)
+	for decoder.More() {
+		var c storage.Click
+		if err := decoder.Decode(&c); err != nil {
+			return nil, err
+		}
+		if c.URLID == urlID {
+			results = append(results, c)
+		}
+	}
+	// batches may be written out of order
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].ClickedAt.Before(results[j].ClickedAt)
+	})
+	return results, nil
+}
+
+// purgeClicks removes the visits of the URLs with the IDs.
+func (s *TextStorage) purgeClicks(urlIDs map[string]bool) error {
+	if len(urlIDs) == 0 {
+		return nil
+	}
+	s.clicksMu.Lock()
+	defer s.clicksMu.Unlock()
+	return filterJSON(s.clicksPath, func(c storage.Click) bool {
+		return !urlIDs[c.URLID]
+	})
+}
+
+// purgeHistory removes the previous destinations of the URLs with the IDs.
+func (s *TextStorage) purgeHistory(urlIDs map[string]bool) error {
+	if len(urlIDs) == 0 {
+		return nil
+	}
+	s.historyMu.Lock()
+	defer s.historyMu.Unlock()
+	return filterJSON(s.historyPath, func(r storage.Revision) bool {
+		return !urlIDs[r.URLID]
+	})
+}
+
+// filterJSON rewrites the file of JSON lines keeping only the values which match.
+func filterJSON[T any](path string, keep func(T) bool) error {
+	file, err := os.OpenFile(path, os.O_RDONLY, 0777)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	decoder := json.NewDecoder(file)
+	for decoder.More() {
+		var v T
+		if err := decoder.Decode(&v); err != nil {
+			file.Close()
+			return err
+		}
+		if keep(v) {
+			if err := encoder.Encode(v); err != nil {
+				file.Close()
+				return err
+			}
+		}
+	}
+	file.Close()
+	return os.WriteFile(path, buf.Bytes(), 0777)
+}
+
+// appendJSON appends the value to the file of JSON lines.
+func appendJSON(path string, v any) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0777)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return json.NewEncoder(file).Encode(v)
+}
+
+// findJSON returns the first value in the file of JSON lines which matches.
+func findJSON[T any](path string, match func(T) bool) (T, bool, error) {
+	var v T
+	file, err := os.OpenFile(path, os.O_RDONLY, 0777)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return v, false, nil
+		}
+		return v, false, err
+	}
+	defer file.Close()
+	decoder := json.NewDecoder(file)
+	for decoder.More() {
+		v = *new(T)
+		if err := decoder.Decode(&v); err != nil {
+			return v, false, err
+		}
+		if match(v) {
+			return v, true, nil
+		}
+	}
+	return *new(T), false, nil
+}
+
+// AddUser registers the user.
+func (s *TextStorage) AddUser(ctx context.Context, user storage.User) error {
+	s.usersMu.Lock()
+	defer s.usersMu.Unlock()
+	other, found, err := findJSON(s.usersPath, func(u storage.User) bool {
+		return u.Username == user.Username || u.UserID == user.UserID
+	})
+	if err != nil {
+		return err
+	}
+	if found && other.Username == user.Username {
+		return fmt.Errorf("%w: username=%v", storage.ErrUsernameTaken, user.Username)
+	}
+	// the ID belongs to another account or to a user without an account
+	if !found {
+		_, err = s.FindInFile(TextStorageRequest{UserID: user.UserID, Size: 1, How: ByUserID})
+		switch {
+		case err == nil:
+			found = true
+		case !errors.Is(err, storage.ErrURLWasNotFound):
+			return err
+		}
+	}
+	if found {
+		return fmt.Errorf("%w: userID=%v", storage.ErrUserIDTaken, user.UserID)
+	}
+	return appendJSON(s.usersPath, user)
+}
+
+// GetUser gets the registered user by name.
+func (s *TextStorage) GetUser(ctx context.Context, username string) (storage.User, error) {
+	s.usersMu.Lock()
+	defer s.usersMu.Unlock()
+	user, found, err := findJSON(s.usersPath, func(u storage.User) bool {
+		return u.Username == username
+	})
+	if err != nil {
+		return storage.User{}, err
+	}
+	if !found {
+		return storage.User{}, storage.ErrUserNotFound
+	}
+	return user, nil
+}
+
+// AddAPIKey saves the API key of the user.
+func (s *TextStorage) AddAPIKey(ctx context.Context, key storage.APIKey) error {
+	s.usersMu.Lock()
+	defer s.usersMu.Unlock()
+	return appendJSON(s.keysPath, key)
+}
+
+// GetAPIKey gets the API key by its hash.
+func (s *TextStorage) GetAPIKey(ctx context.Context, keyHash string) (storage.APIKey, error) {
+	s.usersMu.Lock()
+	defer s.usersMu.Unlock()
+	key, found, err := findJSON(s.keysPath, func(k storage.APIKey) bool {
+		return k.KeyHash == keyHash
+	})
+	if err != nil {
+		return storage.APIKey{}, err
+	}
+	if !found {
+		return storage.APIKey{}, storage.ErrAPIKeyNotFound
+	}
+	return key, nil
+}
+
+// MoveURLs passes the URLs owned by one user to another.
+func (s *TextStorage) MoveURLs(ctx context.Context, fromUserID, toUserID uint32) (int, error) {
+	req := TextStorageRequest{UserID: fromUserID, How: ByUserID}
+	result, err := s.FindInFile(req)
+	if err != nil {
+		if errors.Is(err, storage.ErrURLWasNotFound) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	moved := make(map[string]storage.Record, len(result))
+	for _, rec := range result {
+		owners := make([]uint32, 0, len(rec.OwnerIDs())+1)
+		for _, owner := range rec.OwnerIDs() {
+			if owner != fromUserID && owner != toUserID {
+				owners = append(owners, owner)
+			}
+		}
+		rec.Owners = append(owners, toUserID)
+		moved[rec.URLID] = rec
+	}
+	s.forgetInMem(moved)
+	if err := s.updateFile(moved); err != nil {
+		return 0, err
+	}
+	log.Infof("Moved %v URLs of user %v to user %v\n", len(moved), fromUserID, toUserID)
+	return len(moved), nil
+}
+
+// errNotChanged - the update has left the record as it is.
+var errNotChanged = errors.New("record is not changed")
+
+// updateRecord changes the record with the ID in the file. The record
+// is read and written under the lock, so concurrent updates don't overwrite
+// each other. If the update fails, the record is kept as it is.
+func (s *TextStorage) updateRecord(urlID string, update func(rec *storage.Record) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result, err := s.FindInFile(TextStorageRequest{URLID: urlID, Size: 1, How: ByURLID})
+	if err != nil {
+		return err
+	}
+	rec := result[0]
+	if err := update(&rec); err != nil {
+		return err
+	}
+	updated := map[string]storage.Record{urlID: rec}
+	// the memory keeps the outdated copy
+	s.forgetInMem(updated)
+	return s.rewriteFile(updated)
+}
+
+// SetPreview saves the preview of the URL's destination.
+func (s *TextStorage) SetPreview(ctx context.Context, urlID string, preview storage.Preview) error {
+	return s.updateRecord(urlID, func(rec *storage.Record) error {
+		rec.Preview = &preview
+		return nil
+	})
+}
+
+// GetURLsToCheck gets the active URLs which haven't been checked since the time.
+func (s *TextStorage) GetURLsToCheck(
+	ctx context.Context,
+	checkedBefore time.Time,
+	limit int,
+) ([]storage.Record, error) {
+	// the file is sorted by the time the URLs were added,
+	// so all the matching records are read before they are ordered
+	req := TextStorageRequest{CheckedBefore: checkedBefore, How: ByCheckedBefore}
+	result, err := s.FindInFile(req)
+	if errors.Is(err, storage.ErrURLWasNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return storage.OldestChecked(result, limit), nil
+}
+
+// SetHealth saves the result of the check of the URL's destination.
+func (s *TextStorage) SetHealth(ctx context.Context, urlID string, health storage.Health) error {
+	return s.updateRecord(urlID, func(rec *storage.Record) error {
+		rec.Health = &health
+		return nil
+	})
+}
+
+// UpdateURL changes the destination of the URL owned by the user
+// and saves the previous one to the history.
+func (s *TextStorage) UpdateURL(
+	ctx context.Context,
+	urlID string,
+	userID uint32,
+	url, originalURL string,
+) error {
+	var revision storage.Revision
+	err := s.updateRecord(urlID, func(rec *storage.Record) error {
+		if err := rec.CheckUpdate(userID); err != nil {
+			return err
+		}
+		// the destination is the same
+		if rec.URL == url {
+			return errNotChanged
+		}
+		// the new destination may be stored under another ID
+		byURL, err := s.FindInFile(TextStorageRequest{URL: url, How: ByURL})
+		if err != nil && !errors.Is(err, storage.ErrURLWasNotFound) {
+			return err
+		}
+		for _, other := range byURL {
+			active := !other.IsDeleted && !other.IsExpired()
+			if active && (s.shareURLs || other.HasOwner(userID)) {
+				return &storage.DuplicateURLError{URL: url, URLID: other.URLID}
+			}
+		}
+		revision = storage.Revision{
+			URLID:     urlID,
+			URL:       rec.URL,
+			ChangedAt: time.Now(),
+			ChangedBy: userID,
+		}
+		s.historyMu.Lock()
+		defer s.historyMu.Unlock()
+		if err := appendJSON(s.historyPath, revision); err != nil {
+			return err
+		}
+		rec.URL = url
+		rec.OriginalURL = originalURL
+		rec.Preview = nil
+		rec.Health = nil
+		return nil
+	})
+	if errors.Is(err, errNotChanged) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	log.Infof("Changed destination of %v from %v to %v\n", urlID, revision.URL, url)
+	return nil
+}
+
+// GetURLHistory gets the previous destinations of a short URL ordered by time.
+func (s *TextStorage) GetURLHistory(ctx context.Context, urlID string) ([]storage.Revision, error) {
+	s.historyMu.Lock()
+	defer s.historyMu.Unlock()
+	results := make([]storage.Revision, 0)
+	file, err := os.OpenFile(s.historyPath, os.O_RDONLY, 0777)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return results, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+	decoder := json.NewDecoder(file)
+	for decoder.More() {
+		var r storage.Revision
+		if err := decoder.Decode(&r); err != nil {
+			return nil, err
+		}
+		if r.URLID == urlID {
+			results = append(results, r)
+		}
+	}
+	return results, nil
+}
+
+// NextSequence returns the next value of the counter used to generate IDs.
+func (s *TextStorage) NextSequence(ctx context.Context) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n uint64
+	data, err := os.ReadFile(s.seqPath)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		// the counter starts after the URLs shortened before it
+		urls, _, err := s.GetStats(ctx)
+		if err != nil {
+			return 0, err
+		}
+		n = uint64(urls)
+	case err != nil:
+		return 0, err
+	default:
+		n, err = strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+		if err != nil {
+			return 0, err
+		}
+	}
+	if err := os.WriteFile(s.seqPath, []byte(strconv.FormatUint(n+1, 10)), 0777); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
@@ -2,11 +2,13 @@ package text
 
 import (
 	"context"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/blokhinnv/shorty/internal/app/log"
 	"github.com/blokhinnv/shorty/internal/app/server/config"
+	"github.com/blokhinnv/shorty/internal/app/storage"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -27,7 +29,7 @@ func BenchmarkTextStorage(b *testing.B) {
 	b.ResetTimer()
 	b.Run("AddURL", func(b *testing.B) {
 		for i := 0; i < b.N; i++ {
-			s.AddURL(ctx, "http://yandex.ru", "zxcvbn", 2)
+			s.AddURL(ctx, "http://yandex.ru", "zxcvbn", 2, storage.LinkOptions{})
 		}
 	})
 	b.Run("GetURLByID", func(b *testing.B) {
@@ -63,6 +65,7 @@ func (suite *TextSuite) SetupSuite() {
 		FileStorageClearOnStart: true,
 		FileStorageTTLOnDisk:    3 * time.Second,
 		FileStorageTTLInMemory:  3 * time.Second,
+		ShareDuplicateURLs:      true,
 	}
 	suite.textCfg = GetTextStorageConfig(serverCfg)
 }
@@ -73,7 +76,7 @@ func (suite *TextSuite) TearDownSuite() {
 func (suite *TextSuite) TestAddURL() {
 	ctx := context.Background()
 	s, _ := NewTextStorage(suite.textCfg)
-	err := s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1))
+	err := s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
 	suite.NoError(err)
 	s.Close(ctx)
 }
@@ -81,24 +84,285 @@ func (suite *TextSuite) TestAddURL() {
 func (suite *TextSuite) TestAddURLTwice() {
 	ctx := context.Background()
 	s, _ := NewTextStorage(suite.textCfg)
-	err := s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1))
+	err := s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
 	suite.NoError(err)
 
-	err = s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1))
+	err = s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
 	suite.Error(err)
 	s.Close(ctx)
 }
 
+func (suite *TextSuite) TestAddURLTakenID() {
+	ctx := context.Background()
+	s, _ := NewTextStorage(suite.textCfg)
+	err := s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
+	suite.NoError(err)
+
+	err = s.AddURL(ctx, "http://google.com", "qwerty", uint32(2), storage.LinkOptions{})
+	suite.ErrorIs(err, storage.ErrUniqueViolation)
+	suite.ErrorIs(err, storage.ErrURLIDTaken)
+	s.Close(ctx)
+}
+
+func (suite *TextSuite) TestAddURLDuplicate() {
+	ctx := context.Background()
+	s, _ := NewTextStorage(suite.textCfg)
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
+	err := s.AddURL(ctx, "http://yandex.ru", "asdfgh", uint32(1), storage.LinkOptions{})
+	var dupErr *storage.DuplicateURLError
+	suite.ErrorAs(err, &dupErr)
+	suite.Equal("qwerty", dupErr.URLID)
+	suite.False(dupErr.NewOwner)
+	suite.ErrorIs(err, storage.ErrUniqueViolation)
+	s.Close(ctx)
+}
+
+func (suite *TextSuite) TestAddURLShared() {
+	ctx := context.Background()
+	s, _ := NewTextStorage(suite.textCfg)
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
+	err := s.AddURL(ctx, "http://yandex.ru", "asdfgh", uint32(2), storage.LinkOptions{})
+	var dupErr *storage.DuplicateURLError
+	suite.ErrorAs(err, &dupErr)
+	suite.Equal("qwerty", dupErr.URLID)
+	suite.True(dupErr.NewOwner)
+	recs, err := s.GetURLsByUser(ctx, uint32(2))
+	suite.NoError(err)
+	suite.Len(recs, 1)
+	suite.Equal("qwerty", recs[0].URLID)
+	// the link is hidden only when all the owners have deleted it
+	s.DeleteMany(ctx, uint32(1), []string{"qwerty"})
+	_, err = s.GetURLByID(ctx, "qwerty")
+	suite.NoError(err)
+	_, err = s.GetURLsByUser(ctx, uint32(1))
+	suite.ErrorIs(err, storage.ErrURLWasNotFound)
+	s.DeleteMany(ctx, uint32(2), []string{"qwerty"})
+	_, err = s.GetURLByID(ctx, "qwerty")
+	suite.ErrorIs(err, storage.ErrURLWasDeleted)
+	s.Close(ctx)
+}
+
+func (suite *TextSuite) TestAddURLNotShared() {
+	ctx := context.Background()
+	cfg := *suite.textCfg
+	cfg.ShareURLs = false
+	s, _ := NewTextStorage(&cfg)
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
+	err := s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(2), storage.LinkOptions{})
+	suite.ErrorIs(err, storage.ErrURLIDTaken)
+	err = s.AddURL(ctx, "http://yandex.ru", "asdfgh", uint32(2), storage.LinkOptions{})
+	suite.NoError(err)
+	recs, err := s.GetURLsByUser(ctx, uint32(2))
+	suite.NoError(err)
+	suite.Len(recs, 1)
+	suite.Equal("asdfgh", recs[0].URLID)
+	s.DeleteMany(ctx, uint32(1), []string{"qwerty"})
+	_, err = s.GetURLByID(ctx, "asdfgh")
+	suite.NoError(err)
+	s.Close(ctx)
+}
+
+func (suite *TextSuite) TestAddURLDeletedNewID() {
+	ctx := context.Background()
+	s, _ := NewTextStorage(suite.textCfg)
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
+	s.DeleteMany(ctx, uint32(1), []string{"qwerty"})
+	err := s.AddURL(ctx, "http://yandex.ru", "asdfgh", uint32(2), storage.LinkOptions{})
+	suite.NoError(err)
+	rec, err := s.GetURLByID(ctx, "asdfgh")
+	suite.NoError(err)
+	suite.Equal("http://yandex.ru", rec.URL)
+	s.Close(ctx)
+}
+
+func (suite *TextSuite) TestAddURLTakenDeletedID() {
+	ctx := context.Background()
+	s, _ := NewTextStorage(suite.textCfg)
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
+	s.DeleteMany(ctx, uint32(1), []string{"qwerty"})
+	err := s.AddURL(ctx, "http://google.com", "qwerty", uint32(2), storage.LinkOptions{})
+	suite.ErrorIs(err, storage.ErrUniqueViolation)
+	s.Close(ctx)
+}
+
 func (suite *TextSuite) TestGetURLByIDOk() {
 	ctx := context.Background()
 	s, _ := NewTextStorage(suite.textCfg)
-	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1))
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
 	rec, err := s.GetURLByID(ctx, "qwerty")
 	suite.NoError(err)
 	suite.Equal("http://yandex.ru", rec.URL)
 	s.Close(ctx)
 }
 
+func (suite *TextSuite) TestConcurrentUpdates() {
+	ctx := context.Background()
+	s, _ := NewTextStorage(suite.textCfg)
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.SetHealth(ctx, "qwerty", storage.Health{CheckedAt: time.Now(), StatusCode: 200})
+		}()
+	}
+	err := s.UpdateURL(ctx, "qwerty", uint32(1), "http://ya.ru", "")
+	suite.NoError(err)
+	wg.Wait()
+	// the checks of the old destination don't bring it back
+	rec, err := s.GetURLByID(ctx, "qwerty")
+	suite.NoError(err)
+	suite.Equal("http://ya.ru", rec.URL)
+	s.Close(ctx)
+}
+
+func (suite *TextSuite) TestPurgeExpired() {
+	ctx := context.Background()
+	s, _ := NewTextStorage(suite.textCfg)
+	expired := storage.LinkOptions{ExpiresAt: time.Now().Add(-time.Minute)}
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), expired)
+	s.AddURL(ctx, "http://google.com", "asdfgh", uint32(1), storage.LinkOptions{})
+	s.AddClicks(ctx, []storage.Click{
+		{URLID: "qwerty", ClickedAt: time.Now()},
+		{URLID: "asdfgh", ClickedAt: time.Now()},
+	})
+	s.updateStorage()
+	_, err := s.GetURLByID(ctx, "qwerty")
+	suite.ErrorIs(err, storage.ErrURLWasNotFound)
+	// the visits of the purged URL are gone with it
+	clicks, err := s.GetClicks(ctx, "qwerty")
+	suite.NoError(err)
+	suite.Empty(clicks)
+	clicks, err = s.GetClicks(ctx, "asdfgh")
+	suite.NoError(err)
+	suite.Len(clicks, 1)
+	s.Close(ctx)
+}
+
+func (suite *TextSuite) TestAddURLRestoredClicks() {
+	ctx := context.Background()
+	s, _ := NewTextStorage(suite.textCfg)
+	expired := storage.LinkOptions{ExpiresAt: time.Now().Add(-time.Second)}
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
+	s.AddURL(ctx, "http://google.com", "asdfgh", uint32(1), expired)
+	s.AddClicks(ctx, []storage.Click{
+		{URLID: "qwerty", ClickedAt: time.Now(), Referrer: "http://ya.ru"},
+		{URLID: "asdfgh", ClickedAt: time.Now(), Country: "RU"},
+	})
+	suite.NoError(s.DeleteMany(ctx, uint32(1), []string{"qwerty"}))
+	// another user shortens the deleted and the expired URLs and gets the same IDs
+	err := s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(2), storage.LinkOptions{})
+	suite.NoError(err)
+	err = s.AddURLBatch(ctx, map[string]string{"http://google.com": "asdfgh"}, uint32(2))
+	suite.NoError(err)
+	// the visits of the previous owner aren't shown to the new one
+	for _, urlID := range []string{"qwerty", "asdfgh"} {
+		clicks, err := s.GetClicks(ctx, urlID)
+		suite.NoError(err)
+		suite.Empty(clicks)
+	}
+	s.Close(ctx)
+}
+
+func (suite *TextSuite) TestAddURLRestoredHistory() {
+	ctx := context.Background()
+	s, _ := NewTextStorage(suite.textCfg)
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
+	suite.NoError(s.UpdateURL(ctx, "qwerty", uint32(1), "http://ya.ru", ""))
+	suite.NoError(s.DeleteMany(ctx, uint32(1), []string{"qwerty"}))
+	// another user shortens the deleted URL and gets the same ID
+	err := s.AddURL(ctx, "http://ya.ru", "qwerty", uint32(2), storage.LinkOptions{})
+	suite.NoError(err)
+	// the previous destinations of the previous owner aren't shown to the new one
+	history, err := s.GetURLHistory(ctx, "qwerty")
+	suite.NoError(err)
+	suite.Empty(history)
+	s.Close(ctx)
+}
+
+func (suite *TextSuite) TestPurgeHistory() {
+	ctx := context.Background()
+	s, _ := NewTextStorage(suite.textCfg)
+	soon := storage.LinkOptions{ExpiresAt: time.Now().Add(200 * time.Millisecond)}
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), soon)
+	err := s.UpdateURL(ctx, "qwerty", uint32(1), "http://ya.ru", "")
+	suite.NoError(err)
+	history, err := s.GetURLHistory(ctx, "qwerty")
+	suite.NoError(err)
+	suite.Len(history, 1)
+	time.Sleep(300 * time.Millisecond)
+	s.updateStorage()
+	// the history of the purged URL is gone with it
+	history, err = s.GetURLHistory(ctx, "qwerty")
+	suite.NoError(err)
+	suite.Empty(history)
+	s.Close(ctx)
+}
+
+func (suite *TextSuite) TestNextSequence() {
+	ctx := context.Background()
+	s, _ := NewTextStorage(suite.textCfg)
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
+	s.AddURL(ctx, "http://ya.ru", "asdfgh", uint32(1), storage.LinkOptions{})
+	// the counter starts after the URLs shortened before it
+	n, err := s.NextSequence(ctx)
+	suite.NoError(err)
+	suite.Equal(uint64(2), n)
+	n, err = s.NextSequence(ctx)
+	suite.NoError(err)
+	suite.Equal(uint64(3), n)
+	s.Close(ctx)
+	// the values aren't issued again after a restart
+	cfg := *suite.textCfg
+	cfg.ClearOnStart = false
+	s, _ = NewTextStorage(&cfg)
+	n, err = s.NextSequence(ctx)
+	suite.NoError(err)
+	suite.Equal(uint64(4), n)
+	s.Close(ctx)
+}
+
+func (suite *TextSuite) TestGetURLByIDExpired() {
+	ctx := context.Background()
+	s, _ := NewTextStorage(suite.textCfg)
+	expired := storage.LinkOptions{ExpiresAt: time.Now().Add(-time.Minute)}
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), expired)
+	_, err := s.GetURLByID(ctx, "qwerty")
+	suite.ErrorIs(err, storage.ErrURLExpired)
+	// shortening the same URL again brings the link back
+	err = s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
+	suite.NoError(err)
+	rec, err := s.GetURLByID(ctx, "qwerty")
+	suite.NoError(err)
+	suite.True(rec.ExpiresAt.IsZero())
+	s.Close(ctx)
+}
+
+func (suite *TextSuite) TestClicks() {
+	ctx := context.Background()
+	s, _ := NewTextStorage(suite.textCfg)
+	first := time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC)
+	err := s.AddClicks(ctx, []storage.Click{
+		{URLID: "qwerty", ClickedAt: first.Add(time.Hour), Referrer: "http://ya.ru"},
+		{URLID: "asdfgh", ClickedAt: first},
+		{URLID: "qwerty", ClickedAt: first, UserAgent: "curl/7.81.0", Country: "RU"},
+	})
+	suite.NoError(err)
+	clicks, err := s.GetClicks(ctx, "qwerty")
+	suite.NoError(err)
+	suite.Len(clicks, 2)
+	suite.True(first.Equal(clicks[0].ClickedAt))
+	suite.Equal("curl/7.81.0", clicks[0].UserAgent)
+	suite.Equal("RU", clicks[0].Country)
+	suite.Equal("http://ya.ru", clicks[1].Referrer)
+	s.Clear(ctx)
+	clicks, err = s.GetClicks(ctx, "qwerty")
+	suite.NoError(err)
+	suite.Empty(clicks)
+	s.Close(ctx)
+}
+
 func (suite *TextSuite) TestGetURLByIDEmpty() {
 	ctx := context.Background()
 	s, _ := NewTextStorage(suite.textCfg)
@@ -118,7 +382,7 @@ func (suite *TextSuite) TestGetURLsByUserNotFound() {
 func (suite *TextSuite) TestGetURLsByUserFound() {
 	ctx := context.Background()
 	s, _ := NewTextStorage(suite.textCfg)
-	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1))
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
 	res, err := s.GetURLsByUser(ctx, uint32(1))
 	suite.NoError(err)
 	suite.Equal("http://yandex.ru", res[0].URL)
@@ -137,7 +401,7 @@ func (suite *TextSuite) TestBatchOK() {
 func (suite *TextSuite) TestBatchErr() {
 	ctx := context.Background()
 	s, _ := NewTextStorage(suite.textCfg)
-	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1))
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
 	err := s.AddURLBatch(ctx, map[string]string{"http://yandex.ru": "qwerty"}, uint32(1))
 	suite.Error(err)
 	s.Close(ctx)
@@ -146,7 +410,7 @@ func (suite *TextSuite) TestBatchErr() {
 func (suite *TextSuite) TestDelete() {
 	ctx := context.Background()
 	s, _ := NewTextStorage(suite.textCfg)
-	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1))
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
 	err := s.DeleteMany(ctx, uint32(1), []string{"qwerty"})
 	suite.NoError(err)
 	s.Close(ctx)
@@ -171,7 +435,7 @@ func (suite *TextSuite) TestClear() {
 func (suite *TextSuite) TestUpdate() {
 	ctx := context.Background()
 	s, _ := NewTextStorage(suite.textCfg)
-	err := s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1))
+	err := s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
 	suite.NoError(err)
 	time.Sleep(4 * time.Second)
 	s.Close(ctx)
@@ -180,7 +444,7 @@ func (suite *TextSuite) TestUpdate() {
 func (suite *TextSuite) TestStats() {
 	ctx := context.Background()
 	s, _ := NewTextStorage(suite.textCfg)
-	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1))
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
 	urls, users, err := s.GetStats(ctx)
 	suite.Equal(1, urls)
 	suite.Equal(1, users)
@@ -188,6 +452,100 @@ func (suite *TextSuite) TestStats() {
 	s.Close(ctx)
 }
 
+func (suite *TextSuite) TestUsers() {
+	ctx := context.Background()
+	s, _ := NewTextStorage(suite.textCfg)
+	user := storage.User{UserID: 1, Username: "user", PasswordHash: "hash"}
+	suite.NoError(s.AddUser(ctx, user))
+	suite.ErrorIs(s.AddUser(ctx, user), storage.ErrUsernameTaken)
+	// the ID of another account
+	other := storage.User{UserID: 1, Username: "other", PasswordHash: "hash"}
+	suite.ErrorIs(s.AddUser(ctx, other), storage.ErrUserIDTaken)
+	// the ID of a user without an account
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(2), storage.LinkOptions{})
+	other.UserID = 2
+	suite.ErrorIs(s.AddUser(ctx, other), storage.ErrUserIDTaken)
+	other.UserID = 3
+	suite.NoError(s.AddUser(ctx, other))
+	res, err := s.GetUser(ctx, "user")
+	suite.NoError(err)
+	suite.Equal(uint32(1), res.UserID)
+	suite.Equal("hash", res.PasswordHash)
+	_, err = s.GetUser(ctx, "nobody")
+	suite.ErrorIs(err, storage.ErrUserNotFound)
+
+	suite.NoError(s.AddAPIKey(ctx, storage.APIKey{KeyHash: "key", UserID: 1}))
+	key, err := s.GetAPIKey(ctx, "key")
+	suite.NoError(err)
+	suite.Equal(uint32(1), key.UserID)
+	_, err = s.GetAPIKey(ctx, "nokey")
+	suite.ErrorIs(err, storage.ErrAPIKeyNotFound)
+	s.Close(ctx)
+}
+
+func (suite *TextSuite) TestMoveURLs() {
+	ctx := context.Background()
+	s, _ := NewTextStorage(suite.textCfg)
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
+	s.AddURL(ctx, "http://mail.ru", "asdfgh", uint32(1), storage.LinkOptions{})
+	s.AddURL(ctx, "http://mail.ru", "asdfgh", uint32(2), storage.LinkOptions{})
+	n, err := s.MoveURLs(ctx, uint32(1), uint32(2))
+	suite.NoError(err)
+	suite.Equal(2, n)
+	res, err := s.GetURLsByUser(ctx, uint32(2))
+	suite.NoError(err)
+	suite.Len(res, 2)
+	_, err = s.GetURLsByUser(ctx, uint32(1))
+	suite.Error(err)
+	s.Close(ctx)
+}
+
+func (suite *TextSuite) TestUpdateURL() {
+	ctx := context.Background()
+	s, _ := NewTextStorage(suite.textCfg)
+	s.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), storage.LinkOptions{})
+	s.AddURL(ctx, "http://mail.ru", "asdfgh", uint32(1), storage.LinkOptions{})
+	err := s.UpdateURL(ctx, "qwerty", uint32(2), "http://ya.ru", "")
+	suite.ErrorIs(err, storage.ErrNotOwner)
+	err = s.UpdateURL(ctx, "zxcvbn", uint32(1), "http://ya.ru", "")
+	suite.ErrorIs(err, storage.ErrURLWasNotFound)
+	err = s.UpdateURL(ctx, "qwerty", uint32(1), "http://mail.ru", "")
+	var dupErr *storage.DuplicateURLError
+	suite.ErrorAs(err, &dupErr)
+	suite.Equal("asdfgh", dupErr.URLID)
+
+	err = s.UpdateURL(ctx, "qwerty", uint32(1), "http://ya.ru", "http://YA.ru")
+	suite.NoError(err)
+	rec, err := s.GetURLByID(ctx, "qwerty")
+	suite.NoError(err)
+	suite.Equal("http://ya.ru", rec.URL)
+	suite.Equal("http://YA.ru", rec.OriginalURL)
+	err = s.UpdateURL(ctx, "qwerty", uint32(1), "http://bing.com", "")
+	suite.NoError(err)
+	history, err := s.GetURLHistory(ctx, "qwerty")
+	suite.NoError(err)
+	suite.Require().Len(history, 2)
+	suite.Equal("http://yandex.ru", history[0].URL)
+	suite.Equal("http://ya.ru", history[1].URL)
+	suite.Equal(uint32(1), history[1].ChangedBy)
+	// the old destination can be shortened again
+	err = s.AddURL(ctx, "http://yandex.ru", "zxcvbn", uint32(1), storage.LinkOptions{})
+	suite.NoError(err)
+
+	// the link shared with another user can't be changed
+	s.AddURL(ctx, "http://mail.ru", "poiuyt", uint32(2), storage.LinkOptions{})
+	err = s.UpdateURL(ctx, "asdfgh", uint32(1), "http://google.com", "")
+	suite.ErrorIs(err, storage.ErrURLShared)
+	s.DeleteMany(ctx, uint32(1), []string{"qwerty"})
+	err = s.UpdateURL(ctx, "qwerty", uint32(1), "http://google.com", "")
+	suite.ErrorIs(err, storage.ErrURLWasDeleted)
+	s.Clear(ctx)
+	history, err = s.GetURLHistory(ctx, "qwerty")
+	suite.NoError(err)
+	suite.Empty(history)
+	s.Close(ctx)
+}
+
 func TestTextSuite(t *testing.T) {
 	suite.Run(t, new(TextSuite))
 }
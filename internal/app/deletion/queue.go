@@ -0,0 +1,126 @@
+// Package deletion batches the requests to delete URLs and passes them to the storage.
+package deletion
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/blokhinnv/shorty/internal/app/log"
+	"github.com/blokhinnv/shorty/internal/app/storage"
+)
+
+// Job - a URL the user asked to delete.
+type Job struct {
+	URLID  string
+	UserID uint32
+}
+
+// Queue collects deletion jobs and flushes them to the storage on a timer.
+// It's shared by all the servers of the process.
+type Queue struct {
+	s        storage.Storage
+	jobs     chan Job
+	interval time.Duration
+	quit     chan struct{}
+	done     chan struct{}
+	// DeleteMany calls which are still running
+	wg sync.WaitGroup
+}
+
+// NewQueue - Queue constructor. The jobs are flushed every interval.
+func NewQueue(s storage.Storage, bufSize int, interval time.Duration) *Queue {
+	q := &Queue{
+		s:        s,
+		jobs:     make(chan Job, bufSize),
+		interval: interval,
+		quit:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go q.loop()
+	return q
+}
+
+// Add queues the URLs of the user for deletion.
+// It blocks while the queue is full and drops the jobs once the queue is closed.
+func (q *Queue) Add(userID uint32, urlIDs ...string) {
+	for i, urlID := range urlIDs {
+		// a closed queue is never read, even if there is room in the buffer
+		select {
+		case <-q.quit:
+			log.Warnf("Deletion queue is closed, dropped %v URLs", len(urlIDs)-i)
+			return
+		default:
+		}
+		select {
+		case q.jobs <- Job{URLID: urlID, UserID: userID}:
+		case <-q.quit:
+			log.Warnf("Deletion queue is closed, dropped %v URLs", len(urlIDs)-i)
+			return
+		}
+	}
+}
+
+// Len returns the number of URLs waiting to be deleted.
+func (q *Queue) Len() int {
+	return len(q.jobs)
+}
+
+// Close flushes the queued jobs and waits until they are deleted.
+func (q *Queue) Close() {
+	close(q.quit)
+	<-q.done
+}
+
+// flush passes the batch to the storage.
+func (q *Queue) flush(jobs []Job) {
+	if len(jobs) == 0 {
+		return
+	}
+	jobsByUser := make(map[uint32][]string)
+	for _, job := range jobs {
+		jobsByUser[job.UserID] = append(jobsByUser[job.UserID], job.URLID)
+	}
+	for userID, urlIDs := range jobsByUser {
+		q.wg.Add(1)
+		go func(userID uint32, urlIDs []string) {
+			defer q.wg.Done()
+			err := q.s.DeleteMany(context.Background(), userID, urlIDs)
+			if err != nil {
+				log.Printf("Error while deleting urls: %v\n", err)
+			}
+		}(userID, urlIDs)
+	}
+}
+
+// loop - the main loop which collects the jobs.
+func (q *Queue) loop() {
+	jobs := make([]Job, 0)
+	ticker := time.NewTicker(q.interval)
+	defer ticker.Stop()
+out:
+	for {
+		select {
+		case job := <-q.jobs:
+			jobs = append(jobs, job)
+		case <-ticker.C:
+			q.flush(jobs)
+			jobs = make([]Job, 0)
+		case <-q.quit:
+			break out
+		}
+	}
+	log.Info("Finishing deleting...")
+	for {
+		select {
+		case job := <-q.jobs:
+			jobs = append(jobs, job)
+			continue
+		default:
+		}
+		break
+	}
+	q.flush(jobs)
+	q.wg.Wait()
+	close(q.done)
+}
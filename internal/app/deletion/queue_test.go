@@ -0,0 +1,62 @@
+package deletion
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/blokhinnv/shorty/internal/app/storage"
+)
+
+func TestQueueBatchesByUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s := storage.NewMockStorage(ctrl)
+	s.EXPECT().DeleteMany(gomock.Any(), uint32(1), []string{"qwe", "rty"}).Return(nil)
+	s.EXPECT().DeleteMany(gomock.Any(), uint32(2), []string{"asd"}).Return(fmt.Errorf("error..."))
+
+	// the ticker never fires, so the jobs are flushed by Close
+	q := NewQueue(s, 10, time.Hour)
+	q.Add(1, "qwe")
+	q.Add(2, "asd")
+	q.Add(1, "rty")
+	q.Close()
+	assert.Equal(t, 0, q.Len())
+}
+
+func TestQueueFlushesOnTimer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s := storage.NewMockStorage(ctrl)
+	deleted := make(chan struct{})
+	s.EXPECT().
+		DeleteMany(gomock.Any(), uint32(1), []string{"qwe"}).
+		DoAndReturn(func(context.Context, uint32, []string) error {
+			close(deleted)
+			return nil
+		})
+
+	q := NewQueue(s, 10, time.Millisecond)
+	defer q.Close()
+	q.Add(1, "qwe")
+	select {
+	case <-deleted:
+	case <-time.After(time.Second):
+		t.Fatal("the queue was not flushed")
+	}
+}
+
+func TestQueueAddAfterClose(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s := storage.NewMockStorage(ctrl)
+
+	q := NewQueue(s, 10, time.Hour)
+	q.Close()
+	// doesn't block and doesn't reach the storage
+	q.Add(1, "qwe")
+}
@@ -0,0 +1,226 @@
+// Package linkcheck checks the destinations of the short URLs in the background.
+package linkcheck
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/blokhinnv/shorty/internal/app/log"
+	"github.com/blokhinnv/shorty/internal/app/policy"
+	"github.com/blokhinnv/shorty/internal/app/server/config"
+	"github.com/blokhinnv/shorty/internal/app/storage"
+)
+
+const (
+	// userAgent - how the checker introduces itself to the destinations.
+	userAgent = "shorty-linkcheck/1.0"
+	// maxRedirects - the destination which redirects more is broken.
+	maxRedirects = 10
+)
+
+// Config - link checker config.
+type Config struct {
+	// how often the URLs which are due are checked
+	Interval time.Duration
+	// how long the result of a check is fresh
+	RecheckAfter time.Duration
+	// how many URLs are checked at a time
+	BatchSize int
+	// how many hosts are checked at the same time
+	Concurrency int
+	// the pause between the requests to the same host
+	HostDelay time.Duration
+	Timeout   time.Duration
+	// how many failed checks in a row make the destination broken
+	BrokenAfter int
+	// the destinations in the internal network are not checked
+	RejectPrivateIPs bool
+}
+
+// GetConfig - link checker config constructor based on server config.
+func GetConfig(cfg *config.ServerConfig) *Config {
+	return &Config{
+		Interval:         cfg.LinkCheckInterval,
+		RecheckAfter:     cfg.LinkCheckRecheckAfter,
+		BatchSize:        cfg.LinkCheckBatchSize,
+		Concurrency:      cfg.LinkCheckConcurrency,
+		HostDelay:        cfg.LinkCheckHostDelay,
+		Timeout:          cfg.LinkCheckTimeout,
+		BrokenAfter:      cfg.LinkCheckBrokenAfter,
+		RejectPrivateIPs: cfg.PolicyRejectPrivateIPs,
+	}
+}
+
+// Checker periodically requests the destinations of the stored URLs
+// and saves the results to the storage.
+type Checker struct {
+	s      storage.Storage
+	client *http.Client
+	conf   *Config
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewChecker - Checker constructor. The checks run every conf.Interval,
+// a non-positive interval leaves them to the Check calls.
+func NewChecker(s storage.Storage, conf *Config) *Checker {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &Checker{
+		s:      s,
+		client: policy.NewClient(conf.Timeout, maxRedirects, conf.RejectPrivateIPs),
+		conf:   conf,
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go c.loop()
+	return c
+}
+
+// loop checks the URLs on a timer until the checker is closed.
+func (c *Checker) loop() {
+	defer close(c.done)
+	if c.conf.Interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(c.conf.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			n, err := c.Check(c.ctx)
+			if err != nil && c.ctx.Err() == nil {
+				log.Warnf("Error while checking links: %v", err)
+			} else if n > 0 {
+				log.Infof("Checked %v links\n", n)
+			}
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
+
+// hostOf returns the host the URL is requested from.
+// The URLs which can't be parsed are kept apart.
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// Check checks the destinations of a batch of the URLs which are due and
+// returns how many of them were checked. The hosts are checked concurrently,
+// the URLs of the same host one by one with a pause between them.
+func (c *Checker) Check(ctx context.Context) (int, error) {
+	records, err := c.s.GetURLsToCheck(ctx, time.Now().Add(-c.conf.RecheckAfter), c.conf.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	byHost := make(map[string][]storage.Record)
+	for _, rec := range records {
+		host := hostOf(rec.URL)
+		byHost[host] = append(byHost[host], rec)
+	}
+	var checked atomic.Int64
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, c.conf.Concurrency)
+	for _, hostRecords := range byHost {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return int(checked.Load()), ctx.Err()
+		}
+		wg.Add(1)
+		go func(hostRecords []storage.Record) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			checked.Add(int64(c.checkHost(ctx, hostRecords)))
+		}(hostRecords)
+	}
+	wg.Wait()
+	return int(checked.Load()), ctx.Err()
+}
+
+// checkHost checks the URLs of the same host and returns how many of them were checked.
+func (c *Checker) checkHost(ctx context.Context, records []storage.Record) int {
+	for i, rec := range records {
+		if i > 0 {
+			select {
+			case <-time.After(c.conf.HostDelay):
+			case <-ctx.Done():
+				return i
+			}
+		}
+		code, err := c.check(ctx, rec.URL)
+		// the interrupted check says nothing about the destination
+		if ctx.Err() != nil {
+			return i
+		}
+		health := nextHealth(rec.Health, code, err, time.Now())
+		if err := c.s.SetHealth(ctx, rec.URLID, health); err != nil {
+			log.Warnf("Can't save health of %v: %v", rec.URLID, err)
+		}
+	}
+	return len(records)
+}
+
+// check requests the URL and returns the status code of the response.
+// A HEAD request is sent first, the servers which don't support it get a GET.
+func (c *Checker) check(ctx context.Context, url string) (int, error) {
+	code, err := c.request(ctx, http.MethodHead, url)
+	if err == nil && (code == http.StatusMethodNotAllowed || code == http.StatusNotImplemented) {
+		return c.request(ctx, http.MethodGet, url)
+	}
+	return code, err
+}
+
+// request sends the request without reading the body of the response.
+func (c *Checker) request(ctx context.Context, method, url string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// nextHealth returns the health of the destination after the check.
+// The errors and the error responses are failures, except for 429:
+// the host asks to slow down, which says nothing about the destination.
+func nextHealth(prev *storage.Health, code int, err error, now time.Time) storage.Health {
+	failures := 0
+	if prev != nil {
+		failures = prev.Failures
+	}
+	health := storage.Health{CheckedAt: now, StatusCode: code}
+	switch {
+	case err != nil:
+		health.Error = err.Error()
+		health.Failures = failures + 1
+	case code == http.StatusTooManyRequests:
+		health.Failures = failures
+	case code >= http.StatusBadRequest:
+		health.Failures = failures + 1
+	}
+	return health
+}
+
+// Close interrupts the checks in progress and stops the checker.
+func (c *Checker) Close() {
+	c.cancel()
+	<-c.done
+}
@@ -0,0 +1,232 @@
+package linkcheck
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blokhinnv/shorty/internal/app/policy"
+	"github.com/blokhinnv/shorty/internal/app/storage"
+)
+
+// testConfig - checker settings used in tests. The test servers
+// listen on the loopback, so the private addresses are allowed.
+var testConfig = &Config{
+	RecheckAfter: time.Hour,
+	BatchSize:    10,
+	Concurrency:  2,
+	HostDelay:    50 * time.Millisecond,
+	Timeout:      time.Second,
+	BrokenAfter:  2,
+}
+
+// newDestination starts a site which remembers when its pages were requested.
+func newDestination(t *testing.T) (*httptest.Server, func() []time.Time) {
+	var m sync.Mutex
+	requested := make([]time.Time, 0)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		m.Lock()
+		requested = append(requested, time.Now())
+		m.Unlock()
+		w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("/get_only", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ok", http.StatusFound)
+	})
+	mux.HandleFunc("/slow_down", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	return ts, func() []time.Time {
+		m.Lock()
+		defer m.Unlock()
+		return append([]time.Time{}, requested...)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	ts, _ := newDestination(t)
+	client := policy.NewClient(testConfig.Timeout, maxRedirects, testConfig.RejectPrivateIPs)
+	c := &Checker{client: client, conf: testConfig}
+	tests := []struct {
+		name string
+		path string
+		want int
+	}{
+		{name: "ok", path: "/ok", want: http.StatusOK},
+		{name: "head_not_allowed", path: "/get_only", want: http.StatusOK},
+		{name: "redirect", path: "/redirect", want: http.StatusOK},
+		{name: "not_found", path: "/missing", want: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.check(context.Background(), ts.URL+tt.path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+	t.Run("private_address", func(t *testing.T) {
+		conf := *testConfig
+		conf.RejectPrivateIPs = true
+		client := policy.NewClient(conf.Timeout, maxRedirects, conf.RejectPrivateIPs)
+		c := &Checker{client: client, conf: &conf}
+		_, err := c.check(context.Background(), ts.URL+"/ok")
+		assert.ErrorIs(t, err, policy.ErrPrivateAddress)
+	})
+	t.Run("unreachable", func(t *testing.T) {
+		closed := httptest.NewServer(http.NotFoundHandler())
+		closed.Close()
+		_, err := c.check(context.Background(), closed.URL)
+		assert.Error(t, err)
+	})
+}
+
+func TestNextHealth(t *testing.T) {
+	now := time.Now()
+	failed := &storage.Health{CheckedAt: now.Add(-time.Hour), StatusCode: 500, Failures: 2}
+	tests := []struct {
+		name string
+		prev *storage.Health
+		code int
+		err  error
+		want storage.Health
+	}{
+		{
+			name: "first_ok",
+			code: http.StatusOK,
+			want: storage.Health{CheckedAt: now, StatusCode: http.StatusOK},
+		},
+		{
+			name: "recovered",
+			prev: failed,
+			code: http.StatusNoContent,
+			want: storage.Health{CheckedAt: now, StatusCode: http.StatusNoContent},
+		},
+		{
+			name: "failed_again",
+			prev: failed,
+			code: http.StatusNotFound,
+			want: storage.Health{CheckedAt: now, StatusCode: http.StatusNotFound, Failures: 3},
+		},
+		{
+			name: "unreachable",
+			prev: failed,
+			err:  errors.New("connection refused"),
+			want: storage.Health{CheckedAt: now, Error: "connection refused", Failures: 3},
+		},
+		{
+			name: "too_many_requests",
+			prev: failed,
+			code: http.StatusTooManyRequests,
+			want: storage.Health{CheckedAt: now, StatusCode: http.StatusTooManyRequests, Failures: 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, nextHealth(tt.prev, tt.code, tt.err, now))
+		})
+	}
+}
+
+func TestChecker(t *testing.T) {
+	ts, requested := newDestination(t)
+	// the same server under two host names
+	otherHost := strings.Replace(ts.URL, "127.0.0.1", "localhost", 1)
+	records := []storage.Record{
+		{URLID: "first", URL: ts.URL + "/ok"},
+		{URLID: "second", URL: ts.URL + "/ok"},
+		{URLID: "missing", URL: otherHost + "/missing", Health: &storage.Health{Failures: 1}},
+		{URLID: "slow_down", URL: otherHost + "/slow_down"},
+	}
+	ctrl := gomock.NewController(t)
+	s := storage.NewMockStorage(ctrl)
+	s.EXPECT().GetURLsToCheck(gomock.Any(), gomock.Any(), testConfig.BatchSize).Return(records, nil)
+	var m sync.Mutex
+	saved := make(map[string]storage.Health)
+	s.EXPECT().
+		SetHealth(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, urlID string, health storage.Health) error {
+			m.Lock()
+			defer m.Unlock()
+			saved[urlID] = health
+			return nil
+		}).
+		Times(len(records))
+
+	c := NewChecker(s, testConfig)
+	defer c.Close()
+	n, err := c.Check(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, len(records), n)
+
+	assert.Equal(t, http.StatusOK, saved["first"].StatusCode)
+	assert.Zero(t, saved["second"].Failures)
+	assert.Equal(t, http.StatusNotFound, saved["missing"].StatusCode)
+	assert.Equal(t, 2, saved["missing"].Failures)
+	assert.Zero(t, saved["slow_down"].Failures)
+	// the requests to the same host are spaced out
+	times := requested()
+	require.Len(t, times, 2)
+	assert.GreaterOrEqual(t, times[1].Sub(times[0]), testConfig.HostDelay)
+}
+
+func TestCheckerClose(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := storage.NewMockStorage(ctrl)
+	conf := *testConfig
+	conf.Interval = 10 * time.Millisecond
+	checked := make(chan struct{})
+	var once sync.Once
+	s.EXPECT().
+		GetURLsToCheck(gomock.Any(), gomock.Any(), conf.BatchSize).
+		DoAndReturn(func(ctx context.Context, checkedBefore time.Time, limit int) ([]storage.Record, error) {
+			once.Do(func() { close(checked) })
+			return nil, nil
+		}).
+		MinTimes(1)
+	c := NewChecker(s, &conf)
+	select {
+	case <-checked:
+	case <-time.After(time.Second):
+		t.Fatal("links were not checked")
+	}
+	require.NotPanics(t, c.Close)
+}
+
+func TestStatus(t *testing.T) {
+	for _, s := range []string{"", "ok", "broken"} {
+		_, err := ParseStatus(s)
+		assert.NoError(t, err)
+	}
+	_, err := ParseStatus("dead")
+	assert.ErrorIs(t, err, ErrUnknownStatus)
+
+	unchecked := storage.Record{}
+	ok := storage.Record{Health: &storage.Health{Failures: 1}}
+	broken := storage.Record{Health: &storage.Health{Failures: 2}}
+	assert.True(t, Any.Matches(unchecked, 2))
+	assert.False(t, OK.Matches(unchecked, 2))
+	assert.False(t, Broken.Matches(unchecked, 2))
+	assert.True(t, OK.Matches(ok, 2))
+	assert.False(t, Broken.Matches(ok, 2))
+	assert.True(t, Broken.Matches(broken, 2))
+	assert.False(t, OK.Matches(broken, 2))
+}
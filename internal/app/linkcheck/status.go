@@ -0,0 +1,47 @@
+package linkcheck
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/blokhinnv/shorty/internal/app/storage"
+)
+
+// ErrUnknownStatus - the requested link status is not supported.
+var ErrUnknownStatus = errors.New("unknown link status")
+
+// Status - the state of the destination of a URL the URLs are filtered by.
+type Status string
+
+// Supported statuses.
+const (
+	Any    Status = ""
+	OK     Status = "ok"
+	Broken Status = "broken"
+)
+
+// ParseStatus converts a string to Status. All the URLs have the empty status.
+func ParseStatus(s string) (Status, error) {
+	switch Status(s) {
+	case Any, OK, Broken:
+		return Status(s), nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownStatus, s)
+}
+
+// IsBroken checks if the destination has failed brokenAfter checks in a row.
+func IsBroken(health *storage.Health, brokenAfter int) bool {
+	return health != nil && health.Failures >= brokenAfter
+}
+
+// Matches checks if the destination of the record has the status.
+// The URLs which haven't been checked yet are neither ok nor broken.
+func (s Status) Matches(rec storage.Record, brokenAfter int) bool {
+	switch s {
+	case OK:
+		return rec.Health != nil && !IsBroken(rec.Health, brokenAfter)
+	case Broken:
+		return IsBroken(rec.Health, brokenAfter)
+	}
+	return true
+}
@@ -0,0 +1,97 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/blokhinnv/shorty/internal/app/storage"
+)
+
+func TestHTTPMiddleware(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(HTTPMiddleware)
+	r.Get("/{idURL}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTemporaryRedirect)
+	})
+	for _, path := range []string{"/abc", "/def", "/a/b"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	assert.Equal(t, 2.0, testutil.ToFloat64(httpRequests.WithLabelValues("/{idURL}", "GET", "307")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(httpRequests.WithLabelValues(unmatchedRoute, "GET", "404")))
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	info := &grpc.UnaryServerInfo{FullMethod: "/shorty.Shorty/Test"}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return nil, status.Error(codes.NotFound, "not found")
+	}
+	_, err := UnaryServerInterceptor(context.Background(), nil, info, handler)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Equal(t, 1.0, testutil.ToFloat64(grpcRequests.WithLabelValues(info.FullMethod, "NotFound")))
+}
+
+func TestInstrumentedStorage(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	m := storage.NewMockStorage(ctrl)
+	m.EXPECT().GetURLByID(ctx, "qwerty").Return(storage.Record{}, storage.ErrURLWasNotFound)
+
+	s := NewInstrumentedStorage(m, "test")
+	_, err := s.GetURLByID(ctx, "qwerty")
+	assert.ErrorIs(t, err, storage.ErrURLWasNotFound)
+	var metric dto.Metric
+	err = storageDuration.WithLabelValues("test", "GetURLByID", "rejected").(prometheus.Histogram).Write(&metric)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), metric.GetHistogram().GetSampleCount())
+}
+
+func TestStateCollector(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	m := storage.NewMockStorage(ctrl)
+	m.EXPECT().GetStats(gomock.Any()).Return(3, 2, nil)
+
+	WatchStorage(m)
+	WatchDeletionQueue(func() int { return 5 })
+	defer func() {
+		WatchStorage(nil)
+		WatchDeletionQueue(nil)
+	}()
+	expected := `
+# HELP shorty_deletion_queue_length Number of URLs waiting to be deleted.
+# TYPE shorty_deletion_queue_length gauge
+shorty_deletion_queue_length 5
+# HELP shorty_storage_urls Number of stored URLs.
+# TYPE shorty_storage_urls gauge
+shorty_storage_urls 3
+# HELP shorty_storage_users Number of users who own URLs.
+# TYPE shorty_storage_users gauge
+shorty_storage_users 2
+`
+	err := testutil.CollectAndCompare(state, strings.NewReader(expected))
+	assert.NoError(t, err)
+}
+
+func TestServer(t *testing.T) {
+	server := httptest.NewServer(NewServer("").Handler)
+	defer server.Close()
+	resp, err := http.Get(server.URL + "/metrics")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
@@ -0,0 +1,106 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/blokhinnv/shorty/internal/app/log"
+	"github.com/blokhinnv/shorty/internal/app/storage"
+)
+
+// how long the storage stats may be collected on scrape
+const statsTimeout = 5 * time.Second
+
+var (
+	deletionQueueDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "deletion", "queue_length"),
+		"Number of URLs waiting to be deleted.",
+		nil,
+		nil,
+	)
+	urlsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "storage", "urls"),
+		"Number of stored URLs.",
+		nil,
+		nil,
+	)
+	usersDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "storage", "users"),
+		"Number of users who own URLs.",
+		nil,
+		nil,
+	)
+	cacheHitsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "cache", "hits_total"),
+		"Number of URLs found in the cache.",
+		nil,
+		nil,
+	)
+	cacheMissesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "cache", "misses_total"),
+		"Number of URLs requested from the storage.",
+		nil,
+		nil,
+	)
+)
+
+// stateCollector reads the state of the application on scrape.
+type stateCollector struct {
+	mu    sync.Mutex
+	queue func() int
+	s     storage.Storage
+}
+
+var state = &stateCollector{}
+
+// WatchDeletionQueue reports the length of the deletion queue.
+func WatchDeletionQueue(length func() int) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.queue = length
+}
+
+// WatchStorage reports the stats of the storage.
+func WatchStorage(s storage.Storage) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.s = s
+}
+
+// Describe implements prometheus.Collector.
+func (c *stateCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- deletionQueueDesc
+	ch <- urlsDesc
+	ch <- usersDesc
+	ch <- cacheHitsDesc
+	ch <- cacheMissesDesc
+}
+
+// Collect implements prometheus.Collector.
+func (c *stateCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.queue != nil {
+		ch <- prometheus.MustNewConstMetric(deletionQueueDesc, prometheus.GaugeValue, float64(c.queue()))
+	}
+	if c.s == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), statsTimeout)
+	defer cancel()
+	urls, users, err := c.s.GetStats(ctx)
+	if err != nil {
+		log.Infof("Can't collect storage stats: %v", err)
+	} else {
+		ch <- prometheus.MustNewConstMetric(urlsDesc, prometheus.GaugeValue, float64(urls))
+		ch <- prometheus.MustNewConstMetric(usersDesc, prometheus.GaugeValue, float64(users))
+	}
+	if cached, ok := c.s.(*storage.CachedStorage); ok {
+		stats := cached.CacheStats()
+		ch <- prometheus.MustNewConstMetric(cacheHitsDesc, prometheus.CounterValue, float64(stats.Hits))
+		ch <- prometheus.MustNewConstMetric(cacheMissesDesc, prometheus.CounterValue, float64(stats.Misses))
+	}
+}
@@ -0,0 +1,187 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/blokhinnv/shorty/internal/app/storage"
+)
+
+// InstrumentedStorage measures the latency of the storage operations.
+type InstrumentedStorage struct {
+	s       storage.Storage
+	backend string
+}
+
+// NewInstrumentedStorage - InstrumentedStorage constructor.
+func NewInstrumentedStorage(s storage.Storage, backend string) *InstrumentedStorage {
+	return &InstrumentedStorage{s: s, backend: backend}
+}
+
+// observe records the latency of the operation. Expected outcomes
+// like a missing URL are not counted as errors.
+func (i *InstrumentedStorage) observe(operation string, start time.Time, err error) {
+	result := "ok"
+	switch {
+	case err == nil:
+	case errors.Is(err, storage.ErrURLWasNotFound),
+		errors.Is(err, storage.ErrURLWasDeleted),
+		errors.Is(err, storage.ErrURLExpired),
+		errors.Is(err, storage.ErrUniqueViolation),
+		errors.Is(err, storage.ErrUserNotFound),
+		errors.Is(err, storage.ErrAPIKeyNotFound):
+		result = "rejected"
+	default:
+		result = "error"
+	}
+	storageDuration.WithLabelValues(i.backend, operation, result).Observe(time.Since(start).Seconds())
+}
+
+// AddURL adds a URL to the store.
+func (i *InstrumentedStorage) AddURL(
+	ctx context.Context,
+	url, urlID string,
+	userID uint32,
+	opts storage.LinkOptions,
+) (err error) {
+	defer func(start time.Time) { i.observe("AddURL", start, err) }(time.Now())
+	return i.s.AddURL(ctx, url, urlID, userID, opts)
+}
+
+// AddURLBatch adds a batch of URLs to the store.
+func (i *InstrumentedStorage) AddURLBatch(
+	ctx context.Context,
+	urlIDs map[string]string,
+	userID uint32,
+) (err error) {
+	defer func(start time.Time) { i.observe("AddURLBatch", start, err) }(time.Now())
+	return i.s.AddURLBatch(ctx, urlIDs, userID)
+}
+
+// GetURLByID gets URL by ID.
+func (i *InstrumentedStorage) GetURLByID(ctx context.Context, urlID string) (rec storage.Record, err error) {
+	defer func(start time.Time) { i.observe("GetURLByID", start, err) }(time.Now())
+	return i.s.GetURLByID(ctx, urlID)
+}
+
+// GetURLsByUser gets URLs owned by the user.
+func (i *InstrumentedStorage) GetURLsByUser(ctx context.Context, userID uint32) (recs []storage.Record, err error) {
+	defer func(start time.Time) { i.observe("GetURLsByUser", start, err) }(time.Now())
+	return i.s.GetURLsByUser(ctx, userID)
+}
+
+// DeleteMany removes the user from the owners of the URLs.
+func (i *InstrumentedStorage) DeleteMany(ctx context.Context, userID uint32, urlIDs []string) (err error) {
+	defer func(start time.Time) { i.observe("DeleteMany", start, err) }(time.Now())
+	return i.s.DeleteMany(ctx, userID, urlIDs)
+}
+
+// Ping checks the connection to the repository.
+func (i *InstrumentedStorage) Ping(ctx context.Context) bool {
+	return i.s.Ping(ctx)
+}
+
+// Clear clears the storage.
+func (i *InstrumentedStorage) Clear(ctx context.Context) (err error) {
+	defer func(start time.Time) { i.observe("Clear", start, err) }(time.Now())
+	return i.s.Clear(ctx)
+}
+
+// Close closes the store.
+func (i *InstrumentedStorage) Close(ctx context.Context) {
+	i.s.Close(ctx)
+}
+
+// GetStats returns DB stats.
+func (i *InstrumentedStorage) GetStats(ctx context.Context) (urls int, users int, err error) {
+	defer func(start time.Time) { i.observe("GetStats", start, err) }(time.Now())
+	return i.s.GetStats(ctx)
+}
+
+// AddClicks saves a batch of short URL visits.
+func (i *InstrumentedStorage) AddClicks(ctx context.Context, clicks []storage.Click) (err error) {
+	defer func(start time.Time) { i.observe("AddClicks", start, err) }(time.Now())
+	return i.s.AddClicks(ctx, clicks)
+}
+
+// GetClicks gets all visits of a short URL ordered by time.
+func (i *InstrumentedStorage) GetClicks(ctx context.Context, urlID string) (clicks []storage.Click, err error) {
+	defer func(start time.Time) { i.observe("GetClicks", start, err) }(time.Now())
+	return i.s.GetClicks(ctx, urlID)
+}
+
+// AddUser registers the user.
+func (i *InstrumentedStorage) AddUser(ctx context.Context, user storage.User) (err error) {
+	defer func(start time.Time) { i.observe("AddUser", start, err) }(time.Now())
+	return i.s.AddUser(ctx, user)
+}
+
+// GetUser gets the registered user by name.
+func (i *InstrumentedStorage) GetUser(ctx context.Context, username string) (user storage.User, err error) {
+	defer func(start time.Time) { i.observe("GetUser", start, err) }(time.Now())
+	return i.s.GetUser(ctx, username)
+}
+
+// AddAPIKey saves the API key of the user.
+func (i *InstrumentedStorage) AddAPIKey(ctx context.Context, key storage.APIKey) (err error) {
+	defer func(start time.Time) { i.observe("AddAPIKey", start, err) }(time.Now())
+	return i.s.AddAPIKey(ctx, key)
+}
+
+// GetAPIKey gets the API key by its hash.
+func (i *InstrumentedStorage) GetAPIKey(ctx context.Context, keyHash string) (key storage.APIKey, err error) {
+	defer func(start time.Time) { i.observe("GetAPIKey", start, err) }(time.Now())
+	return i.s.GetAPIKey(ctx, keyHash)
+}
+
+// MoveURLs passes the URLs owned by one user to another.
+func (i *InstrumentedStorage) MoveURLs(ctx context.Context, fromUserID, toUserID uint32) (n int, err error) {
+	defer func(start time.Time) { i.observe("MoveURLs", start, err) }(time.Now())
+	return i.s.MoveURLs(ctx, fromUserID, toUserID)
+}
+
+// SetPreview saves the preview of the URL's destination.
+func (i *InstrumentedStorage) SetPreview(ctx context.Context, urlID string, preview storage.Preview) (err error) {
+	defer func(start time.Time) { i.observe("SetPreview", start, err) }(time.Now())
+	return i.s.SetPreview(ctx, urlID, preview)
+}
+
+// GetURLsToCheck gets the URLs which destinations need to be checked.
+func (i *InstrumentedStorage) GetURLsToCheck(
+	ctx context.Context,
+	checkedBefore time.Time,
+	limit int,
+) (recs []storage.Record, err error) {
+	defer func(start time.Time) { i.observe("GetURLsToCheck", start, err) }(time.Now())
+	return i.s.GetURLsToCheck(ctx, checkedBefore, limit)
+}
+
+// SetHealth saves the result of the check of the URL's destination.
+func (i *InstrumentedStorage) SetHealth(ctx context.Context, urlID string, health storage.Health) (err error) {
+	defer func(start time.Time) { i.observe("SetHealth", start, err) }(time.Now())
+	return i.s.SetHealth(ctx, urlID, health)
+}
+
+// UpdateURL changes the destination of the URL owned by the user.
+func (i *InstrumentedStorage) UpdateURL(
+	ctx context.Context,
+	urlID string,
+	userID uint32,
+	url, originalURL string,
+) (err error) {
+	defer func(start time.Time) { i.observe("UpdateURL", start, err) }(time.Now())
+	return i.s.UpdateURL(ctx, urlID, userID, url, originalURL)
+}
+
+// GetURLHistory gets the previous destinations of a short URL.
+func (i *InstrumentedStorage) GetURLHistory(ctx context.Context, urlID string) (revs []storage.Revision, err error) {
+	defer func(start time.Time) { i.observe("GetURLHistory", start, err) }(time.Now())
+	return i.s.GetURLHistory(ctx, urlID)
+}
+
+// NextSequence returns the next value of the counter used to generate IDs.
+func (i *InstrumentedStorage) NextSequence(ctx context.Context) (n uint64, err error) {
+	defer func(start time.Time) { i.observe("NextSequence", start, err) }(time.Now())
+	return i.s.NextSequence(ctx)
+}
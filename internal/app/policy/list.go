@@ -0,0 +1,212 @@
+package policy
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blokhinnv/shorty/internal/app/log"
+)
+
+// ErrInvalidDomain - the string can't be added to a list.
+var ErrInvalidDomain = errors.New("invalid domain")
+
+// domainRe - the form of the domains in the lists.
+var domainRe = regexp.MustCompile(`^[a-z0-9_-]+(\.[a-z0-9_-]+)*$`)
+
+// normalizeDomain lowercases the domain and drops the wildcard and the trailing dot.
+// The subdomains of a listed domain are matched anyway.
+func normalizeDomain(s string) (string, error) {
+	domain := strings.ToLower(strings.TrimSpace(s))
+	domain = strings.TrimPrefix(domain, "*.")
+	domain = strings.TrimSuffix(domain, ".")
+	if !domainRe.MatchString(domain) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidDomain, s)
+	}
+	return domain, nil
+}
+
+// List - a set of domains. If the list has a file, it's loaded from the file,
+// reloaded when the file changes and saved to the file on updates.
+type List struct {
+	mu      sync.RWMutex
+	path    string
+	domains map[string]struct{}
+	modTime time.Time
+}
+
+// newList creates the list and loads it from the file if there is one.
+// A missing file is an empty list, the file is created on the first update.
+func newList(path string) (*List, error) {
+	l := &List{path: path, domains: make(map[string]struct{})}
+	if path == "" {
+		return l, nil
+	}
+	if _, err := l.reload(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// readDomains reads the domains from the file, one per line.
+// Empty lines and lines starting with # are skipped.
+func readDomains(path string) (map[string]struct{}, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	domains := make(map[string]struct{})
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		domain, err := normalizeDomain(line)
+		if err != nil {
+			log.Warnf("Skipped a line of %v: %v", path, err)
+			continue
+		}
+		domains[domain] = struct{}{}
+	}
+	return domains, scanner.Err()
+}
+
+// reload reads the file if it has changed since the last read.
+// It reports whether the list was reloaded.
+func (l *List) reload() (bool, error) {
+	info, err := os.Stat(l.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	l.mu.RLock()
+	changed := !info.ModTime().Equal(l.modTime)
+	l.mu.RUnlock()
+	if !changed {
+		return false, nil
+	}
+	domains, err := readDomains(l.path)
+	if err != nil {
+		return false, err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.domains = domains
+	l.modTime = info.ModTime()
+	return true, nil
+}
+
+// Contains checks if the host or one of its parent domains is in the list.
+func (l *List) Contains(host string) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for {
+		if _, ok := l.domains[host]; ok {
+			return true
+		}
+		i := strings.IndexByte(host, '.')
+		if i < 0 {
+			return false
+		}
+		host = host[i+1:]
+	}
+}
+
+// Domains returns the sorted domains of the list.
+func (l *List) Domains() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	domains := make([]string, 0, len(l.domains))
+	for domain := range l.domains {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+	return domains
+}
+
+// Update adds and removes the domains and saves the list to the file.
+// Nothing is changed if some domain is invalid.
+func (l *List) Update(add, remove []string) error {
+	toAdd := make([]string, 0, len(add))
+	for _, s := range add {
+		domain, err := normalizeDomain(s)
+		if err != nil {
+			return err
+		}
+		toAdd = append(toAdd, domain)
+	}
+	toRemove := make([]string, 0, len(remove))
+	for _, s := range remove {
+		domain, err := normalizeDomain(s)
+		if err != nil {
+			return err
+		}
+		toRemove = append(toRemove, domain)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	domains := make(map[string]struct{}, len(l.domains)+len(toAdd))
+	for domain := range l.domains {
+		domains[domain] = struct{}{}
+	}
+	for _, domain := range toAdd {
+		domains[domain] = struct{}{}
+	}
+	for _, domain := range toRemove {
+		delete(domains, domain)
+	}
+	if l.path != "" {
+		modTime, err := writeDomains(l.path, domains)
+		if err != nil {
+			return err
+		}
+		l.modTime = modTime
+	}
+	l.domains = domains
+	return nil
+}
+
+// writeDomains replaces the file with the sorted domains and returns its modification time.
+func writeDomains(path string, domains map[string]struct{}) (time.Time, error) {
+	sorted := make([]string, 0, len(domains))
+	for domain := range domains {
+		sorted = append(sorted, domain)
+	}
+	sort.Strings(sorted)
+	// the file is replaced at once, so the reload never sees a half-written list
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer os.Remove(tmp.Name())
+	w := bufio.NewWriter(tmp)
+	for _, domain := range sorted {
+		fmt.Fprintln(w, domain)
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return time.Time{}, err
+	}
+	if err := tmp.Close(); err != nil {
+		return time.Time{}, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return time.Time{}, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
@@ -0,0 +1,287 @@
+// Package policy decides which URLs may be shortened.
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/blokhinnv/shorty/internal/app/log"
+	"github.com/blokhinnv/shorty/internal/app/server/config"
+)
+
+// Errors of the policy.
+var (
+	ErrRejected    = errors.New("url is rejected")
+	ErrUnknownList = errors.New("unknown list")
+	// ErrPrivateAddress - a connection to the internal network is refused.
+	ErrPrivateAddress = errors.New("private address")
+)
+
+// Reason - why the URL is rejected.
+type Reason string
+
+// Reasons of the rejections.
+const (
+	ReasonInvalidURL     Reason = "invalid_url"
+	ReasonScheme         Reason = "scheme_not_allowed"
+	ReasonBlockedDomain  Reason = "blocked_domain"
+	ReasonPrivateAddress Reason = "private_address"
+)
+
+// RejectedError - the URL is rejected by the policy. It matches ErrRejected.
+type RejectedError struct {
+	URL    string
+	Reason Reason
+	Detail string
+}
+
+// Error returns the message of the rejection.
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("%v: %v (%v)", ErrRejected, e.Reason, e.Detail)
+}
+
+// Is reports whether the target is ErrRejected.
+func (e *RejectedError) Is(target error) bool {
+	return target == ErrRejected
+}
+
+// reject returns the rejection of the URL.
+func reject(rawURL string, reason Reason, format string, args ...any) error {
+	return &RejectedError{URL: rawURL, Reason: reason, Detail: fmt.Sprintf(format, args...)}
+}
+
+// ListName - the name of a list of domains.
+type ListName string
+
+// Lists of the domains. The allowed domains skip the other checks
+// of the host, the URLs of the blocked domains are rejected.
+const (
+	AllowList ListName = "allow"
+	BlockList ListName = "block"
+)
+
+// Resolver looks up the addresses of the hosts. *net.Resolver is a Resolver.
+type Resolver interface {
+	LookupIP(ctx context.Context, network, host string) ([]net.IP, error)
+}
+
+// Config - policy config.
+type Config struct {
+	Schemes           []string
+	AllowListPath     string
+	BlockListPath     string
+	ReloadInterval    time.Duration
+	RejectPrivateIPs  bool
+	ResolutionTimeout time.Duration
+}
+
+// GetConfig - policy config constructor based on server config.
+func GetConfig(cfg *config.ServerConfig) *Config {
+	return &Config{
+		Schemes:           cfg.PolicySchemes,
+		AllowListPath:     cfg.PolicyAllowListPath,
+		BlockListPath:     cfg.PolicyBlockListPath,
+		ReloadInterval:    cfg.PolicyReloadInterval,
+		RejectPrivateIPs:  cfg.PolicyRejectPrivateIPs,
+		ResolutionTimeout: 2 * time.Second,
+	}
+}
+
+// Policy checks the URLs before they are shortened.
+type Policy struct {
+	schemes          map[string]struct{}
+	rejectPrivateIPs bool
+	resolver         Resolver
+	resolveTimeout   time.Duration
+	lists            map[ListName]*List
+	quit             chan struct{}
+	done             chan struct{}
+}
+
+// New - Policy constructor. The lists with files are reloaded every conf.ReloadInterval.
+func New(conf *Config, resolver Resolver) (*Policy, error) {
+	p := &Policy{
+		schemes:          make(map[string]struct{}),
+		rejectPrivateIPs: conf.RejectPrivateIPs,
+		resolver:         resolver,
+		resolveTimeout:   conf.ResolutionTimeout,
+		lists:            make(map[ListName]*List),
+		quit:             make(chan struct{}),
+		done:             make(chan struct{}),
+	}
+	for _, scheme := range conf.Schemes {
+		p.schemes[strings.ToLower(strings.TrimSpace(scheme))] = struct{}{}
+	}
+	for name, path := range map[ListName]string{
+		AllowList: conf.AllowListPath,
+		BlockList: conf.BlockListPath,
+	} {
+		list, err := newList(path)
+		if err != nil {
+			return nil, fmt.Errorf("can't load %v list: %w", name, err)
+		}
+		p.lists[name] = list
+	}
+	if conf.ReloadInterval > 0 && (conf.AllowListPath != "" || conf.BlockListPath != "") {
+		go p.loop(conf.ReloadInterval)
+	} else {
+		close(p.done)
+	}
+	return p, nil
+}
+
+// Check returns a *RejectedError if the URL may not be shortened.
+func (p *Policy) Check(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return reject(rawURL, ReasonInvalidURL, "%v", err)
+	}
+	scheme := strings.ToLower(u.Scheme)
+	if _, ok := p.schemes[scheme]; !ok {
+		return reject(rawURL, ReasonScheme, "scheme %q is not allowed", scheme)
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "" {
+		return reject(rawURL, ReasonInvalidURL, "no host")
+	}
+	if p.lists[AllowList].Contains(host) {
+		return nil
+	}
+	if p.lists[BlockList].Contains(host) {
+		return reject(rawURL, ReasonBlockedDomain, "host %q is blocked", host)
+	}
+	if p.rejectPrivateIPs {
+		return p.checkAddresses(ctx, rawURL, host)
+	}
+	return nil
+}
+
+// IsPrivate checks if the address is not reachable from the internet.
+func IsPrivate(ip net.IP) bool {
+	return ip.IsPrivate() ||
+		ip.IsLoopback() ||
+		ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast()
+}
+
+// RejectPrivate refuses to connect to the addresses of the internal network.
+// It's meant to be the Control of a net.Dialer: it's called after the name
+// is resolved, so the redirects of the HTTP clients are covered too.
+func RejectPrivate(network, address string, c syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip != nil && IsPrivate(ip) {
+		return fmt.Errorf("%w: %v", ErrPrivateAddress, ip)
+	}
+	return nil
+}
+
+// NewClient returns the HTTP client which follows at most maxRedirects redirects.
+// If rejectPrivateIPs is set, it can't connect to the internal network.
+func NewClient(timeout time.Duration, maxRedirects int, rejectPrivateIPs bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if rejectPrivateIPs {
+		dialer.Control = RejectPrivate
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > maxRedirects {
+				return fmt.Errorf("stopped after %v redirects", maxRedirects)
+			}
+			return nil
+		},
+	}
+}
+
+// checkAddresses rejects the host if it has a private address.
+// The hosts which can't be resolved are not rejected, they may be down for a while.
+func (p *Policy) checkAddresses(ctx context.Context, rawURL string, host string) error {
+	ips := []net.IP{net.ParseIP(host)}
+	if ips[0] == nil {
+		ctx, cancel := context.WithTimeout(ctx, p.resolveTimeout)
+		defer cancel()
+		var err error
+		ips, err = p.resolver.LookupIP(ctx, "ip", host)
+		if err != nil {
+			log.Infof("Can't resolve %v: %v", host, err)
+			return nil
+		}
+	}
+	for _, ip := range ips {
+		if IsPrivate(ip) {
+			return reject(rawURL, ReasonPrivateAddress, "host %q has a private address %v", host, ip)
+		}
+	}
+	return nil
+}
+
+// Lists returns the domains of the lists.
+func (p *Policy) Lists() map[ListName][]string {
+	lists := make(map[ListName][]string, len(p.lists))
+	for name, list := range p.lists {
+		lists[name] = list.Domains()
+	}
+	return lists
+}
+
+// UpdateList adds and removes the domains of the list.
+func (p *Policy) UpdateList(name ListName, add, remove []string) error {
+	list, ok := p.lists[name]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownList, name)
+	}
+	return list.Update(add, remove)
+}
+
+// reload reloads the lists which files have changed.
+func (p *Policy) reload() {
+	for name, list := range p.lists {
+		if list.path == "" {
+			continue
+		}
+		reloaded, err := list.reload()
+		if err != nil {
+			log.Errorf("Can't reload %v list: %v", name, err)
+			continue
+		}
+		if reloaded {
+			log.Infof("Reloaded %v list from %v", name, list.path)
+		}
+	}
+}
+
+// loop reloads the lists every interval.
+func (p *Policy) loop(interval time.Duration) {
+	defer close(p.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.reload()
+		case <-p.quit:
+			return
+		}
+	}
+}
+
+// Close stops reloading the lists.
+func (p *Policy) Close() {
+	close(p.quit)
+	<-p.done
+}
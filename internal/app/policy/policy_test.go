@@ -0,0 +1,140 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeResolver resolves the hosts from the map.
+type fakeResolver map[string][]net.IP
+
+func (r fakeResolver) LookupIP(ctx context.Context, network, host string) ([]net.IP, error) {
+	ips, ok := r[host]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	return ips, nil
+}
+
+var resolver = fakeResolver{
+	"mail.ru":       {net.ParseIP("94.100.180.200")},
+	"intranet.corp": {net.ParseIP("10.0.0.10")},
+	"localhost":     {net.ParseIP("127.0.0.1"), net.ParseIP("::1")},
+	"mixed.com":     {net.ParseIP("94.100.180.201"), net.ParseIP("192.168.1.1")},
+}
+
+func newTestPolicy(t *testing.T, conf *Config) *Policy {
+	if conf.Schemes == nil {
+		conf.Schemes = []string{"http", "https"}
+	}
+	p, err := New(conf, resolver)
+	require.NoError(t, err)
+	t.Cleanup(p.Close)
+	return p
+}
+
+func TestCheck(t *testing.T) {
+	p := newTestPolicy(t, &Config{RejectPrivateIPs: true, ResolutionTimeout: time.Second})
+	require.NoError(t, p.UpdateList(BlockList, []string{"phishing.com", "corp"}, nil))
+	require.NoError(t, p.UpdateList(AllowList, []string{"intranet.corp"}, nil))
+	ctx := context.Background()
+	tests := []struct {
+		url    string
+		reason Reason
+	}{
+		{"https://mail.ru/", ""},
+		{"HTTPS://MAIL.RU./", ""},
+		{"https://unknown.com/", ""},
+		{"javascript:alert(1)", ReasonScheme},
+		{"file:///etc/passwd", ReasonScheme},
+		{"ftp://mail.ru/", ReasonScheme},
+		{"http:///path", ReasonInvalidURL},
+		{"http://%zz", ReasonInvalidURL},
+		{"https://phishing.com/login", ReasonBlockedDomain},
+		{"https://secure.PHISHING.com/login", ReasonBlockedDomain},
+		{"https://notphishing.com/", ""},
+		{"http://printer.corp/", ReasonBlockedDomain},
+		{"http://intranet.corp/", ""},
+		{"http://10.1.2.3/", ReasonPrivateAddress},
+		{"http://[::1]:8080/", ReasonPrivateAddress},
+		{"http://169.254.169.254/latest/meta-data", ReasonPrivateAddress},
+		{"http://0.0.0.0/", ReasonPrivateAddress},
+		{"http://localhost:8080/", ReasonPrivateAddress},
+		{"https://mixed.com/", ReasonPrivateAddress},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			err := p.Check(ctx, tt.url)
+			if tt.reason == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrRejected)
+			var rejected *RejectedError
+			require.True(t, errors.As(err, &rejected))
+			assert.Equal(t, tt.reason, rejected.Reason)
+			assert.Equal(t, tt.url, rejected.URL)
+		})
+	}
+}
+
+func TestCheckPrivateAllowed(t *testing.T) {
+	p := newTestPolicy(t, &Config{Schemes: []string{"HTTP"}})
+	assert.NoError(t, p.Check(context.Background(), "http://localhost:8080/"))
+	assert.ErrorIs(t, p.Check(context.Background(), "https://mail.ru/"), ErrRejected)
+}
+
+func TestUpdateList(t *testing.T) {
+	p := newTestPolicy(t, &Config{})
+	require.NoError(t, p.UpdateList(BlockList, []string{"*.Phishing.com.", "spam.com"}, nil))
+	require.NoError(t, p.UpdateList(BlockList, []string{"scam.com"}, []string{"spam.com"}))
+	assert.Equal(t, map[ListName][]string{
+		AllowList: {},
+		BlockList: {"phishing.com", "scam.com"},
+	}, p.Lists())
+
+	err := p.UpdateList(BlockList, []string{"ok.com", "not a domain"}, nil)
+	assert.ErrorIs(t, err, ErrInvalidDomain)
+	assert.Equal(t, []string{"phishing.com", "scam.com"}, p.Lists()[BlockList])
+	assert.ErrorIs(t, p.UpdateList("grey", []string{"ok.com"}, nil), ErrUnknownList)
+}
+
+func TestListFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "block.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# phishing\nphishing.com\n\nnot a domain\nSPAM.com\n"), 0o644))
+	p := newTestPolicy(t, &Config{BlockListPath: path, ReloadInterval: 10 * time.Millisecond})
+	assert.Equal(t, []string{"phishing.com", "spam.com"}, p.Lists()[BlockList])
+
+	// the updates are saved to the file
+	require.NoError(t, p.UpdateList(BlockList, []string{"scam.com"}, []string{"spam.com"}))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "phishing.com\nscam.com\n", string(data))
+
+	// the changes of the file are picked up
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.WriteFile(path, []byte("fraud.com\n"), 0o644))
+	require.NoError(t, os.Chtimes(path, later, later))
+	assert.Eventually(t, func() bool {
+		return p.Check(context.Background(), "https://fraud.com/") != nil
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"fraud.com"}, p.Lists()[BlockList])
+}
+
+func TestMissingListFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "allow.txt")
+	p := newTestPolicy(t, &Config{AllowListPath: path})
+	assert.Empty(t, p.Lists()[AllowList])
+	require.NoError(t, p.UpdateList(AllowList, []string{"mail.ru"}, nil))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "mail.ru\n", string(data))
+}
@@ -0,0 +1,176 @@
+// Package preview fetches the metadata of the destinations of the short URLs.
+package preview
+
+import (
+	"context"
+	"io"
+	"mime"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/blokhinnv/shorty/internal/app/log"
+	"github.com/blokhinnv/shorty/internal/app/policy"
+	"github.com/blokhinnv/shorty/internal/app/server/config"
+	"github.com/blokhinnv/shorty/internal/app/storage"
+)
+
+// userAgent - how the fetcher introduces itself to the destinations.
+const userAgent = "shorty-preview/1.0"
+
+// Config - fetcher config.
+type Config struct {
+	Workers      int
+	QueueSize    int
+	Timeout      time.Duration
+	MaxBodySize  int64
+	MaxRedirects int
+	// the destinations in the internal network are not fetched
+	RejectPrivateIPs bool
+}
+
+// GetConfig - fetcher config constructor based on server config.
+func GetConfig(cfg *config.ServerConfig) *Config {
+	return &Config{
+		Workers:          cfg.PreviewWorkers,
+		QueueSize:        cfg.PreviewQueueSize,
+		Timeout:          cfg.PreviewTimeout,
+		MaxBodySize:      cfg.PreviewMaxBodySize,
+		MaxRedirects:     cfg.PreviewMaxRedirects,
+		RejectPrivateIPs: cfg.PolicyRejectPrivateIPs,
+	}
+}
+
+// job - a URL which preview is waiting to be fetched.
+type job struct {
+	urlID string
+	url   string
+}
+
+// Fetcher fetches the previews of the URLs in the background
+// and saves them to the storage.
+type Fetcher struct {
+	s       storage.Storage
+	client  *http.Client
+	conf    *Config
+	jobs    chan job
+	closed  bool
+	m       sync.RWMutex
+	ctx     context.Context
+	cancel  context.CancelFunc
+	workers sync.WaitGroup
+}
+
+// NewFetcher - Fetcher constructor.
+func NewFetcher(s storage.Storage, conf *Config) *Fetcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	f := &Fetcher{
+		s:      s,
+		client: policy.NewClient(conf.Timeout, conf.MaxRedirects, conf.RejectPrivateIPs),
+		conf:   conf,
+		jobs:   make(chan job, conf.QueueSize),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	for i := 0; i < conf.Workers; i++ {
+		f.workers.Add(1)
+		go f.work()
+	}
+	return f
+}
+
+// Fetch queues the URL. It never blocks: if the queue is full, the URL is dropped.
+// A nil fetcher drops all URLs.
+func (f *Fetcher) Fetch(urlID, url string) {
+	if f == nil {
+		return
+	}
+	f.m.RLock()
+	defer f.m.RUnlock()
+	if f.closed {
+		return
+	}
+	select {
+	case f.jobs <- job{urlID: urlID, url: url}:
+	default:
+		log.Warnf("Previews queue is full, dropping %v", urlID)
+	}
+}
+
+// work fetches the queued URLs until the fetcher is closed.
+func (f *Fetcher) work() {
+	defer f.workers.Done()
+	for j := range f.jobs {
+		// the jobs left in the queue are dropped on close
+		if f.ctx.Err() != nil {
+			continue
+		}
+		preview := f.fetch(f.ctx, j.url)
+		if f.ctx.Err() != nil {
+			continue
+		}
+		if err := f.s.SetPreview(context.Background(), j.urlID, preview); err != nil {
+			log.Warnf("Can't save preview of %v: %v", j.urlID, err)
+		}
+	}
+}
+
+// fetch requests the URL and reads the metadata of the page it ends up on.
+// The errors are reported in the preview.
+func (f *Fetcher) fetch(ctx context.Context, url string) storage.Preview {
+	preview := storage.Preview{FetchedAt: time.Now()}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		preview.Error = err.Error()
+		return preview
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9,*/*;q=0.8")
+	resp, err := f.client.Do(req)
+	if err != nil {
+		preview.Error = err.Error()
+		return preview
+	}
+	defer resp.Body.Close()
+	preview.StatusCode = resp.StatusCode
+	preview.FinalURL = resp.Request.URL.String()
+	contentType := resp.Header.Get("Content-Type")
+	if !isHTML(contentType) {
+		return preview
+	}
+	body := io.LimitReader(resp.Body, f.conf.MaxBodySize)
+	meta, err := parseHTML(body, contentType, resp.Request.URL)
+	if err != nil {
+		preview.Error = err.Error()
+		return preview
+	}
+	preview.Title = meta.title
+	preview.Description = meta.description
+	preview.FaviconURL = meta.favicon
+	return preview
+}
+
+// isHTML checks if the response is a page.
+func isHTML(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "text/html" || mediaType == "application/xhtml+xml"
+}
+
+// Close stops accepting URLs, interrupts the fetches in progress and waits
+// for the workers. The queued URLs are dropped.
+func (f *Fetcher) Close() {
+	if f == nil {
+		return
+	}
+	f.m.Lock()
+	if !f.closed {
+		f.closed = true
+		close(f.jobs)
+		f.cancel()
+	}
+	f.m.Unlock()
+	f.workers.Wait()
+}
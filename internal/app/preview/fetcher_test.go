@@ -0,0 +1,196 @@
+package preview
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blokhinnv/shorty/internal/app/policy"
+	"github.com/blokhinnv/shorty/internal/app/storage"
+)
+
+// testConfig - fetcher settings used in tests. The test servers
+// listen on the loopback, so the private addresses are allowed.
+var testConfig = &Config{
+	Workers:      2,
+	QueueSize:    10,
+	Timeout:      time.Second,
+	MaxBodySize:  1 << 20,
+	MaxRedirects: 3,
+}
+
+const page = `<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="utf-8">
+	<title>
+		Go &amp; Shorty
+	</title>
+	<meta name="description" content="Short links for everyone">
+	<link rel="shortcut icon" href="/static/icon.png">
+</head>
+<body><title>Not a title</title></body>
+</html>`
+
+// newDestination starts a site with pages, redirects and a picture.
+func newDestination(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(page))
+	})
+	mux.HandleFunc("/og", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=windows-1251")
+		// "Привет" in windows-1251
+		w.Write([]byte(`<html><head>` +
+			`<meta property="og:title" content="` + "\xcf\xf0\xe8\xe2\xe5\xf2" + `">` +
+			`<meta property="og:description" content="From Open Graph">` +
+			`</head></html>`))
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/page", http.StatusFound)
+	})
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	})
+	mux.HandleFunc("/image.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("\x89PNG"))
+	})
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestFetch(t *testing.T) {
+	ts := newDestination(t)
+	client := policy.NewClient(testConfig.Timeout, testConfig.MaxRedirects, testConfig.RejectPrivateIPs)
+	f := &Fetcher{client: client, conf: testConfig}
+	tests := []struct {
+		name string
+		path string
+		want storage.Preview
+	}{
+		{
+			name: "page",
+			path: "/page",
+			want: storage.Preview{
+				Title:       "Go & Shorty",
+				Description: "Short links for everyone",
+				FaviconURL:  ts.URL + "/static/icon.png",
+				FinalURL:    ts.URL + "/page",
+				StatusCode:  http.StatusOK,
+			},
+		},
+		{
+			name: "redirect",
+			path: "/redirect",
+			want: storage.Preview{
+				Title:       "Go & Shorty",
+				Description: "Short links for everyone",
+				FaviconURL:  ts.URL + "/static/icon.png",
+				FinalURL:    ts.URL + "/page",
+				StatusCode:  http.StatusOK,
+			},
+		},
+		{
+			name: "open_graph",
+			path: "/og",
+			want: storage.Preview{
+				Title:       "Привет",
+				Description: "From Open Graph",
+				FaviconURL:  ts.URL + "/favicon.ico",
+				FinalURL:    ts.URL + "/og",
+				StatusCode:  http.StatusOK,
+			},
+		},
+		{
+			name: "not_html",
+			path: "/image.png",
+			want: storage.Preview{
+				FinalURL:   ts.URL + "/image.png",
+				StatusCode: http.StatusOK,
+			},
+		},
+		{
+			name: "not_found",
+			path: "/missing",
+			want: storage.Preview{
+				FinalURL:   ts.URL + "/missing",
+				StatusCode: http.StatusNotFound,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := f.fetch(context.Background(), ts.URL+tt.path)
+			assert.False(t, got.FetchedAt.IsZero())
+			got.FetchedAt = time.Time{}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFetchErrors(t *testing.T) {
+	ts := newDestination(t)
+	client := policy.NewClient(testConfig.Timeout, testConfig.MaxRedirects, testConfig.RejectPrivateIPs)
+	f := &Fetcher{client: client, conf: testConfig}
+	t.Run("too_many_redirects", func(t *testing.T) {
+		got := f.fetch(context.Background(), ts.URL+"/loop")
+		assert.Contains(t, got.Error, "stopped after 3 redirects")
+		assert.Zero(t, got.StatusCode)
+	})
+	t.Run("private_address", func(t *testing.T) {
+		conf := *testConfig
+		conf.RejectPrivateIPs = true
+		client := policy.NewClient(conf.Timeout, conf.MaxRedirects, conf.RejectPrivateIPs)
+		f := &Fetcher{client: client, conf: &conf}
+		got := f.fetch(context.Background(), ts.URL+"/page")
+		assert.Contains(t, got.Error, policy.ErrPrivateAddress.Error())
+		assert.Empty(t, got.Title)
+	})
+	t.Run("unreachable", func(t *testing.T) {
+		closed := httptest.NewServer(http.NotFoundHandler())
+		closed.Close()
+		got := f.fetch(context.Background(), closed.URL)
+		assert.NotEmpty(t, got.Error)
+	})
+}
+
+func TestFetcher(t *testing.T) {
+	ts := newDestination(t)
+	ctrl := gomock.NewController(t)
+	s := storage.NewMockStorage(ctrl)
+	saved := make(chan storage.Preview, 1)
+	s.EXPECT().
+		SetPreview(gomock.Any(), "qwerty", gomock.Any()).
+		DoAndReturn(func(ctx context.Context, urlID string, preview storage.Preview) error {
+			saved <- preview
+			return nil
+		})
+	f := NewFetcher(s, testConfig)
+	f.Fetch("qwerty", ts.URL+"/page")
+	select {
+	case preview := <-saved:
+		assert.Equal(t, "Go & Shorty", preview.Title)
+	case <-time.After(time.Second):
+		t.Fatal("preview was not saved")
+	}
+	f.Close()
+	// the closed fetcher drops the URLs
+	f.Fetch("qwerty", ts.URL+"/page")
+	require.NotPanics(t, f.Close)
+
+	// a nil fetcher drops the URLs too
+	var nilFetcher *Fetcher
+	require.NotPanics(t, func() {
+		nilFetcher.Fetch("qwerty", ts.URL+"/page")
+		nilFetcher.Close()
+	})
+}
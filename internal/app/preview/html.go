@@ -0,0 +1,137 @@
+package preview
+
+import (
+	"errors"
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"golang.org/x/net/html/charset"
+)
+
+// maxTextLen - the texts of the page are cut to this number of characters.
+const maxTextLen = 300
+
+// metadata - what is taken from the head of a page.
+type metadata struct {
+	title       string
+	description string
+	favicon     string
+}
+
+// cleanText collapses the whitespace and cuts the text.
+func cleanText(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if runes := []rune(s); len(runes) > maxTextLen {
+		return string(runes[:maxTextLen])
+	}
+	return s
+}
+
+// attrs returns the attributes of the current tag.
+func attrs(z *html.Tokenizer) map[string]string {
+	result := make(map[string]string)
+	for {
+		key, val, more := z.TagAttr()
+		result[strings.ToLower(string(key))] = string(val)
+		if !more {
+			return result
+		}
+	}
+}
+
+// isIcon checks if the rel attribute of a link names an icon.
+func isIcon(rel string) bool {
+	for _, kind := range strings.Fields(strings.ToLower(rel)) {
+		if kind == "icon" {
+			return true
+		}
+	}
+	return false
+}
+
+// parseHTML reads the title, the description and the favicon from the head
+// of the page. The Open Graph tags are used if the page has no title or description.
+// Without an icon link the page gets /favicon.ico of its host.
+func parseHTML(r io.Reader, contentType string, base *url.URL) (metadata, error) {
+	r, err := charset.NewReader(r, contentType)
+	if err != nil {
+		return metadata{}, err
+	}
+	var meta metadata
+	var title strings.Builder
+	var ogTitle, ogDescription string
+	inTitle, seenTitle := false, false
+	z := html.NewTokenizer(r)
+loop:
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			// the body may be cut by the size limit
+			if !errors.Is(z.Err(), io.EOF) {
+				return metadata{}, z.Err()
+			}
+			break loop
+		case html.TextToken:
+			if inTitle {
+				title.Write(z.Text())
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			switch atom.Lookup(name) {
+			case atom.Title:
+				inTitle = false
+			case atom.Head:
+				break loop
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			switch atom.Lookup(name) {
+			case atom.Title:
+				inTitle = tt == html.StartTagToken && !seenTitle
+				seenTitle = true
+			case atom.Body:
+				break loop
+			case atom.Meta:
+				if !hasAttr {
+					continue
+				}
+				a := attrs(z)
+				switch {
+				case strings.EqualFold(a["name"], "description") && meta.description == "":
+					meta.description = a["content"]
+				case a["property"] == "og:title":
+					ogTitle = a["content"]
+				case a["property"] == "og:description":
+					ogDescription = a["content"]
+				}
+			case atom.Link:
+				if !hasAttr || meta.favicon != "" {
+					continue
+				}
+				a := attrs(z)
+				if !isIcon(a["rel"]) || a["href"] == "" {
+					continue
+				}
+				if href, err := base.Parse(a["href"]); err == nil {
+					meta.favicon = href.String()
+				}
+			}
+		}
+	}
+	meta.title = cleanText(title.String())
+	if meta.title == "" {
+		meta.title = cleanText(ogTitle)
+	}
+	meta.description = cleanText(meta.description)
+	if meta.description == "" {
+		meta.description = cleanText(ogDescription)
+	}
+	if meta.favicon == "" {
+		meta.favicon = (&url.URL{Scheme: base.Scheme, Host: base.Host, Path: "/favicon.ico"}).String()
+	}
+	return meta, nil
+}
@@ -0,0 +1,95 @@
+// Package ratelimit limits the requests of the users and of the client IPs with token buckets.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/blokhinnv/shorty/internal/app/log"
+	"github.com/blokhinnv/shorty/internal/app/server/config"
+)
+
+// ErrLimitExceeded - the user or the IP has run out of requests.
+var ErrLimitExceeded = errors.New("rate limit exceeded")
+
+// Category - a kind of requests with its own budget.
+type Category string
+
+// Categories of the requests.
+const (
+	Create   Category = "create"
+	Redirect Category = "redirect"
+	Delete   Category = "delete"
+	Login    Category = "login"
+)
+
+// Limit - the parameters of a token bucket. Rate tokens per second are added
+// to the bucket up to Burst tokens. A limit with no rate is disabled.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Enabled returns true if the requests are limited.
+func (l Limit) Enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// Config - limiter config.
+type Config struct {
+	Limits map[Category]Limit
+}
+
+// GetConfig - limiter config constructor based on server config.
+func GetConfig(cfg *config.ServerConfig) *Config {
+	return &Config{
+		Limits: map[Category]Limit{
+			Create:   {Rate: cfg.RateLimitCreate, Burst: cfg.RateLimitCreateBurst},
+			Redirect: {Rate: cfg.RateLimitRedirect, Burst: cfg.RateLimitRedirectBurst},
+			Delete:   {Rate: cfg.RateLimitDelete, Burst: cfg.RateLimitDeleteBurst},
+			Login:    {Rate: cfg.RateLimitLogin, Burst: cfg.RateLimitLoginBurst},
+		},
+	}
+}
+
+// Limiter checks the budgets of the users and of the IPs in the store.
+type Limiter struct {
+	store  Store
+	limits map[Category]Limit
+}
+
+// NewLimiter - Limiter constructor.
+func NewLimiter(store Store, conf *Config) *Limiter {
+	return &Limiter{store: store, limits: conf.Limits}
+}
+
+// Allow takes a token from the buckets of the user and of the IP for the category.
+// If one of them is empty, no tokens are taken and it returns ErrLimitExceeded
+// and the time to wait. The requests are not limited if the store fails.
+func (l *Limiter) Allow(
+	ctx context.Context,
+	category Category,
+	userID uint32,
+	ip net.IP,
+) (time.Duration, error) {
+	limit, ok := l.limits[category]
+	if !ok || !limit.Enabled() {
+		return 0, nil
+	}
+	keys := []string{fmt.Sprintf("%v:user:%v", category, userID)}
+	if ip != nil {
+		keys = append(keys, fmt.Sprintf("%v:ip:%v", category, ip))
+	}
+	allowed, retryAfter, err := l.store.Take(ctx, limit, keys...)
+	if err != nil {
+		log.Warnf("Rate limiter store error: %v", err)
+		return 0, nil
+	}
+	if !allowed {
+		return retryAfter, fmt.Errorf("%w: %v", ErrLimitExceeded, keys)
+	}
+	return 0, nil
+}
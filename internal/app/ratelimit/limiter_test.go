@@ -0,0 +1,133 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clock is a fake time source for the store.
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func newTestStore() (*MemoryStore, *clock) {
+	c := &clock{now: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)}
+	s := NewMemoryStore(time.Hour)
+	s.now = c.Now
+	return s, c
+}
+
+func TestTake(t *testing.T) {
+	s, c := newTestStore()
+	defer s.Close()
+	ctx := context.Background()
+	limit := Limit{Rate: 2, Burst: 3}
+	for i := 0; i < 3; i++ {
+		ok, _, err := s.Take(ctx, limit, "key")
+		require.NoError(t, err)
+		assert.True(t, ok)
+	}
+	ok, wait, err := s.Take(ctx, limit, "key")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, wait)
+
+	// the other keys have their own buckets
+	ok, _, err = s.Take(ctx, limit, "other")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// a token is added every half of a second
+	c.now = c.now.Add(500 * time.Millisecond)
+	ok, _, err = s.Take(ctx, limit, "key")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, _, err = s.Take(ctx, limit, "key")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// no more than burst tokens are kept
+	c.now = c.now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		ok, _, _ = s.Take(ctx, limit, "key")
+		assert.True(t, ok)
+	}
+	ok, _, _ = s.Take(ctx, limit, "key")
+	assert.False(t, ok)
+}
+
+func TestCleanup(t *testing.T) {
+	s, c := newTestStore()
+	defer s.Close()
+	ctx := context.Background()
+	limit := Limit{Rate: 1, Burst: 2}
+	s.Take(ctx, limit, "qwe")
+	s.Take(ctx, limit, "rty")
+	s.Take(ctx, limit, "rty")
+	assert.Equal(t, 2, s.Len())
+
+	// "qwe" is full in a second, "rty" in two seconds
+	c.now = c.now.Add(time.Second)
+	s.cleanup()
+	assert.Equal(t, 1, s.Len())
+	c.now = c.now.Add(time.Second)
+	s.cleanup()
+	assert.Equal(t, 0, s.Len())
+}
+
+// failingStore - a store which is not available.
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, Limit, ...string) (bool, time.Duration, error) {
+	return false, 0, errors.New("store is down")
+}
+
+func TestAllow(t *testing.T) {
+	s, _ := newTestStore()
+	defer s.Close()
+	ctx := context.Background()
+	l := NewLimiter(s, &Config{Limits: map[Category]Limit{
+		Create: {Rate: 1, Burst: 1},
+		Delete: {Rate: 0, Burst: 1},
+	}})
+	ip := net.ParseIP("10.0.0.1")
+
+	_, err := l.Allow(ctx, Create, 1, ip)
+	require.NoError(t, err)
+	retryAfter, err := l.Allow(ctx, Create, 1, net.ParseIP("10.0.0.2"))
+	assert.ErrorIs(t, err, ErrLimitExceeded, "user limit")
+	assert.Equal(t, time.Second, retryAfter)
+	_, err = l.Allow(ctx, Create, 2, ip)
+	assert.ErrorIs(t, err, ErrLimitExceeded, "IP limit")
+	_, err = l.Allow(ctx, Create, 3, nil)
+	assert.NoError(t, err, "no IP")
+
+	// the user is not charged for a request rejected by the IP limit
+	_, err = l.Allow(ctx, Create, 4, ip)
+	assert.ErrorIs(t, err, ErrLimitExceeded, "IP limit")
+	_, err = l.Allow(ctx, Create, 4, net.ParseIP("10.0.0.3"))
+	assert.NoError(t, err)
+
+	// the categories have their own budgets, a limit with no rate is disabled
+	for i := 0; i < 3; i++ {
+		_, err = l.Allow(ctx, Redirect, 1, ip)
+		assert.NoError(t, err)
+		_, err = l.Allow(ctx, Delete, 1, ip)
+		assert.NoError(t, err)
+	}
+
+	// the requests are not limited if the store fails
+	l = NewLimiter(failingStore{}, &Config{Limits: map[Category]Limit{Create: {Rate: 1, Burst: 1}}})
+	_, err = l.Allow(ctx, Create, 1, ip)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,136 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Store keeps the buckets of the limiter. The in-memory store serves a single process,
+// a shared store lets several processes use the same budgets.
+type Store interface {
+	// Take takes a token from each bucket of the keys. If one of the buckets is empty,
+	// no tokens are taken and it returns false and the time until all of them have a token.
+	Take(ctx context.Context, limit Limit, keys ...string) (bool, time.Duration, error)
+}
+
+// bucket - the state of a token bucket.
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	// when the bucket is full again if no tokens are taken
+	full time.Time
+}
+
+// MemoryStore keeps the buckets in memory. The buckets which are full again are
+// removed every cleanup interval.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	// the clock is replaced in the tests
+	now  func() time.Time
+	quit chan struct{}
+	done chan struct{}
+}
+
+// NewMemoryStore - MemoryStore constructor.
+func NewMemoryStore(cleanupInterval time.Duration) *MemoryStore {
+	s := &MemoryStore{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+		quit:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go s.loop(cleanupInterval)
+	return s
+}
+
+// refill adds the tokens earned since the last update.
+func (b *bucket) refill(limit Limit, now time.Time) {
+	elapsed := now.Sub(b.updated).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed*limit.Rate)
+	}
+	b.updated = now
+}
+
+// take takes a token and updates the time the bucket is full.
+func (b *bucket) take(limit Limit) {
+	b.tokens--
+	toFull := (float64(limit.Burst) - b.tokens) / limit.Rate
+	b.full = b.updated.Add(time.Duration(toFull * float64(time.Second)))
+}
+
+// Take is an implementation of Store.Take.
+func (s *MemoryStore) Take(ctx context.Context, limit Limit, keys ...string) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	buckets := make([]*bucket, 0, len(keys))
+	var wait time.Duration
+	for _, key := range keys {
+		b, ok := s.buckets[key]
+		if !ok {
+			b = &bucket{tokens: float64(limit.Burst), updated: now}
+			s.buckets[key] = b
+		}
+		b.refill(limit, now)
+		if b.tokens < 1 {
+			bucketWait := time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second))
+			if bucketWait > wait {
+				wait = bucketWait
+			}
+		}
+		buckets = append(buckets, b)
+	}
+	// the tokens are taken only if all the buckets have them
+	if wait > 0 {
+		return false, wait, nil
+	}
+	for _, b := range buckets {
+		b.take(limit)
+	}
+	return true, 0, nil
+}
+
+// Len returns the number of buckets in the store.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.buckets)
+}
+
+// cleanup removes the buckets which are full again.
+// A missing bucket is the same as a full one.
+func (s *MemoryStore) cleanup() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	for key, b := range s.buckets {
+		if !now.Before(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// loop removes the idle buckets every interval.
+func (s *MemoryStore) loop(interval time.Duration) {
+	defer close(s.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.cleanup()
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// Close stops the cleanup.
+func (s *MemoryStore) Close() {
+	close(s.quit)
+	<-s.done
+}
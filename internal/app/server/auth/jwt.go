@@ -0,0 +1,159 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/blokhinnv/shorty/internal/app/server/config"
+)
+
+// Errors of the token verification.
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrTokenExpired = fmt.Errorf("%w: token is expired", ErrInvalidToken)
+)
+
+// Keyring - the secrets the tokens are signed with. New tokens are signed
+// with the current secret, the previous ones are only used for verification,
+// so rotating the secret doesn't log the users out.
+type Keyring struct {
+	currentID string
+	keys      map[string][]byte
+}
+
+// KeyID returns the ID of the secret, the tokens carry it in the header.
+func KeyID(secret []byte) string {
+	sum := sha256.Sum256(secret)
+	return hex.EncodeToString(sum[:4])
+}
+
+// NewKeyring - Keyring constructor.
+func NewKeyring(current []byte, previous ...[]byte) *Keyring {
+	k := &Keyring{currentID: KeyID(current), keys: make(map[string][]byte)}
+	for _, secret := range previous {
+		k.keys[KeyID(secret)] = secret
+	}
+	k.keys[k.currentID] = current
+	return k
+}
+
+// Claims - the claims of the user token.
+type Claims struct {
+	UserID uint32 `json:"uid"`
+	jwt.RegisteredClaims
+	// the ID of the secret the token is signed with
+	keyID string
+}
+
+// TokensConfig - tokens config.
+type TokensConfig struct {
+	Keyring *Keyring
+	// how long the tokens are valid
+	TTL time.Duration
+	// the tokens which expire sooner are re-issued
+	RefreshBefore time.Duration
+}
+
+// GetTokensConfig - tokens config constructor based on server config.
+func GetTokensConfig(cfg *config.ServerConfig) *TokensConfig {
+	previous := make([][]byte, 0, len(cfg.PreviousSecretKeys))
+	for _, secret := range cfg.PreviousSecretKeys {
+		previous = append(previous, []byte(secret))
+	}
+	return &TokensConfig{
+		Keyring:       NewKeyring([]byte(cfg.SecretKey), previous...),
+		TTL:           cfg.TokenTTL,
+		RefreshBefore: cfg.TokenRefreshBefore,
+	}
+}
+
+// Tokens issues and verifies signed JWTs of the users.
+type Tokens struct {
+	conf *TokensConfig
+}
+
+// NewTokens - Tokens constructor.
+func NewTokens(conf *TokensConfig) *Tokens {
+	return &Tokens{conf: conf}
+}
+
+// Issue issues a token of the user signed with the current secret.
+func (t *Tokens) Issue(userID uint32) (string, error) {
+	now := time.Now()
+	claims := Claims{
+		UserID: userID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(t.conf.TTL)),
+		},
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = t.conf.Keyring.currentID
+	return token.SignedString(t.conf.Keyring.keys[t.conf.Keyring.currentID])
+}
+
+// New issues a token of a new user.
+func (t *Tokens) New() (string, uint32, error) {
+	userID, err := NewUserID()
+	if err != nil {
+		return "", 0, err
+	}
+	token, err := t.Issue(userID)
+	if err != nil {
+		return "", 0, err
+	}
+	return token, userID, nil
+}
+
+// Verify checks the signature and the expiry of the token and returns its claims.
+// The tokens of the older format without claims are accepted too.
+func (t *Tokens) Verify(token string) (*Claims, error) {
+	if !strings.Contains(token, ".") {
+		return t.verifyLegacy(token)
+	}
+	var claims Claims
+	_, err := jwt.ParseWithClaims(token, &claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		secret, ok := t.conf.Keyring.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key %q", kid)
+		}
+		claims.keyID = kid
+		return secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name}))
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrTokenExpired
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	return &claims, nil
+}
+
+// verifyLegacy verifies the hex token of the ID and its HMAC.
+func (t *Tokens) verifyLegacy(token string) (*Claims, error) {
+	data, err := hex.DecodeString(token)
+	if err != nil || len(data) <= nBytesForID {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidToken)
+	}
+	for kid, secret := range t.conf.Keyring.keys {
+		if VerifyToken(data, secret) {
+			return &Claims{UserID: ExtractID(data), keyID: kid}, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: wrong signature", ErrInvalidToken)
+}
+
+// NeedsRefresh reports whether the token should be re-issued: it expires soon,
+// it's signed with a previous secret or it's of the older format.
+func (t *Tokens) NeedsRefresh(claims *Claims) bool {
+	return claims.ExpiresAt == nil ||
+		time.Until(claims.ExpiresAt.Time) < t.conf.RefreshBefore ||
+		claims.keyID != t.conf.Keyring.currentID
+}
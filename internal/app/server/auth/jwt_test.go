@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTokens(ttl time.Duration, current string, previous ...string) *Tokens {
+	prev := make([][]byte, 0, len(previous))
+	for _, secret := range previous {
+		prev = append(prev, []byte(secret))
+	}
+	return NewTokens(&TokensConfig{
+		Keyring:       NewKeyring([]byte(current), prev...),
+		TTL:           ttl,
+		RefreshBefore: time.Minute,
+	})
+}
+
+// legacyToken makes a token of the older format.
+func legacyToken(userID uint32, secret string) string {
+	id := make([]byte, nBytesForID)
+	binary.BigEndian.PutUint32(id, userID)
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write(id)
+	return hex.EncodeToString(append(id, h.Sum(nil)...))
+}
+
+func TestIssueVerify(t *testing.T) {
+	tokens := newTestTokens(time.Hour, "new")
+	token, err := tokens.Issue(42)
+	require.NoError(t, err)
+	claims, err := tokens.Verify(token)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(42), claims.UserID)
+	assert.NotNil(t, claims.IssuedAt)
+	assert.False(t, tokens.NeedsRefresh(claims))
+
+	token, userID, err := tokens.New()
+	require.NoError(t, err)
+	claims, err = tokens.Verify(token)
+	require.NoError(t, err)
+	assert.Equal(t, userID, claims.UserID)
+}
+
+func TestVerifyInvalid(t *testing.T) {
+	tokens := newTestTokens(time.Hour, "new")
+	token, err := tokens.Issue(42)
+	require.NoError(t, err)
+	other, err := newTestTokens(time.Hour, "other").Issue(42)
+	require.NoError(t, err)
+	parts := strings.Split(token, ".")
+	for name, token := range map[string]string{
+		"empty":       "",
+		"garbage":     "qwerty",
+		"short hex":   "a5d0",
+		"unknown key": other,
+		"tampered":    parts[0] + "." + parts[1] + "x." + parts[2],
+		"legacy":      legacyToken(42, "other"),
+	} {
+		_, err := tokens.Verify(token)
+		assert.ErrorIs(t, err, ErrInvalidToken, name)
+	}
+}
+
+func TestVerifyExpired(t *testing.T) {
+	tokens := newTestTokens(-time.Second, "new")
+	token, err := tokens.Issue(42)
+	require.NoError(t, err)
+	_, err = tokens.Verify(token)
+	assert.ErrorIs(t, err, ErrTokenExpired)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestNeedsRefresh(t *testing.T) {
+	// expires soon
+	tokens := newTestTokens(30*time.Second, "new")
+	token, err := tokens.Issue(42)
+	require.NoError(t, err)
+	claims, err := tokens.Verify(token)
+	require.NoError(t, err)
+	assert.True(t, tokens.NeedsRefresh(claims))
+}
+
+func TestKeyRotation(t *testing.T) {
+	old, err := newTestTokens(time.Hour, "old").Issue(42)
+	require.NoError(t, err)
+	tokens := newTestTokens(time.Hour, "new", "old")
+	claims, err := tokens.Verify(old)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(42), claims.UserID)
+	// the tokens signed with the previous secret are re-issued
+	assert.True(t, tokens.NeedsRefresh(claims))
+
+	// the previous secrets are not used for signing
+	token, err := tokens.Issue(42)
+	require.NoError(t, err)
+	_, err = newTestTokens(time.Hour, "old").Verify(token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestLegacyToken(t *testing.T) {
+	tokens := newTestTokens(time.Hour, "new", "old")
+	for _, secret := range []string{"new", "old"} {
+		claims, err := tokens.Verify(legacyToken(42, secret))
+		require.NoError(t, err)
+		assert.Equal(t, uint32(42), claims.UserID)
+		assert.True(t, tokens.NeedsRefresh(claims))
+	}
+}
+
+func TestUserIDSpaces(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		userID, err := NewUserID()
+		require.NoError(t, err)
+		accountID, err := NewAccountID()
+		require.NoError(t, err)
+		// the users without an account never get the ID of an account
+		assert.Zero(t, userID&accountIDBit)
+		assert.NotZero(t, accountID&accountIDBit)
+	}
+}
@@ -6,7 +6,6 @@ import (
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/binary"
-	"encoding/hex"
 )
 
 // Amount of bytes used to store ID.
@@ -29,21 +28,31 @@ func generateHMAC(data []byte, secretKey []byte) []byte {
 	return h.Sum(nil)
 }
 
-// GenerateToken generates a token.
-func GenerateToken(secretKey []byte) (string, error) {
-	// 4 bytes - user ID (data)
+// accountIDBit - the bit which is set in the IDs of the registered users
+// and cleared in the IDs of the users identified by their cookies.
+const accountIDBit = 1 << 31
+
+// NewUserID generates a random ID of a user without an account.
+func NewUserID() (uint32, error) {
+	id, err := generateUserID(nBytesForID)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(id) &^ accountIDBit, nil
+}
+
+// NewAccountID generates a random ID of a registered user.
+// It never matches the IDs issued by NewUserID.
+func NewAccountID() (uint32, error) {
 	id, err := generateUserID(nBytesForID)
 	if err != nil {
-		return "", err
+		return 0, err
 	}
-	// Signature for user ID
-	sign := generateHMAC(id, secretKey)
-	// Token: 4 bytes ID signature for ID
-	token := append(id, sign...)
-	return hex.EncodeToString(token), nil
+	return binary.BigEndian.Uint32(id) | accountIDBit, nil
 }
 
-// verifyToken verifies cookies (token).
+// VerifyToken verifies the token of the older format:
+// 4 bytes of the user ID followed by their HMAC.
 func VerifyToken(token []byte, secretKey []byte) bool {
 	// first 4 bytes - user ID
 	// Get a signature for them with the server's secret key
@@ -52,7 +61,7 @@ func VerifyToken(token []byte, secretKey []byte) bool {
 	return hmac.Equal(sign, token[nBytesForID:])
 }
 
-// extractID extracts the ID from the cookie.
+// ExtractID extracts the ID from the token of the older format.
 func ExtractID(token []byte) uint32 {
 	id := binary.BigEndian.Uint32(token[:nBytesForID])
 	return id
@@ -16,20 +16,82 @@ import (
 
 // ServerConfig - structure for storing the server config.
 type ServerConfig struct {
-	ServerAddress           string        `env:"SERVER_ADDRESS"              envDefault:"http://localhost:8080" valid:"url" json:"server_address"`
-	BaseURL                 string        `env:"BASE_URL"                    envDefault:"http://localhost:8080" valid:"url" json:"base_url"`
-	SecretKey               string        `env:"SECRET_KEY"                                                                 json:"secret_key"` // I will not specify a default value for security
-	EnableHTTPS             bool          `env:"ENABLE_HTTPS"                envDefault:"false"                             json:"enable_https"`
+	ServerAddress           string        `env:"SERVER_ADDRESS"              envDefault:"http://localhost:8080"    valid:"url"               json:"server_address"`
+	BaseURL                 string        `env:"BASE_URL"                    envDefault:"http://localhost:8080"    valid:"url"               json:"base_url"`
+	SecretKey               string        `env:"SECRET_KEY"                                                                                  json:"secret_key"` // I will not specify a default value for security
+	PreviousSecretKeys      []string      `env:"PREVIOUS_SECRET_KEYS"                                                                        json:"previous_secret_keys"        envSeparator:","`
+	TokenTTL                time.Duration `env:"TOKEN_TTL"                   envDefault:"720h"                                               json:"token_ttl"`
+	TokenRefreshBefore      time.Duration `env:"TOKEN_REFRESH_BEFORE"        envDefault:"168h"                                               json:"token_refresh_before"`
+	EnableHTTPS             bool          `env:"ENABLE_HTTPS"                envDefault:"false"                                              json:"enable_https"`
+	Protocols               string        `env:"PROTOCOLS"                   envDefault:"http"                     valid:"in(http|grpc|all)" json:"protocols"`
+	GRPCAddress             string        `env:"GRPC_ADDRESS"                envDefault:":3200"                                              json:"grpc_address"`
+	GRPCMultiplex           bool          `env:"GRPC_MULTIPLEX"              envDefault:"false"                                              json:"grpc_multiplex"`
 	JSONConfigPath          string        `env:"CONFIG"                      envDefault:""`
 	TrustedSubnet           string        `env:"TRUSTED_SUBNET"`
-	PostgresDatabaseDSN     string        `env:"DATABASE_DSN"                                                               json:"postgres_database_dsn"`
-	PostgresClearOnStart    bool          `env:"PG_CLEAR_ON_START"           envDefault:"false"                             json:"postgres_clear_on_start"`
-	SQLiteDBPath            string        `env:"SQLITE_DB_PATH"              envDefault:"db.sqlite3"                        json:"sqlite_db_path"`
-	SQLiteClearOnStart      bool          `env:"SQLITE_CLEAR_ON_START"       envDefault:"false"                             json:"sqlite_clear_on_start"`
-	FileStoragePath         string        `env:"FILE_STORAGE_PATH"                                                          json:"file_storage_path"`
-	FileStorageClearOnStart bool          `env:"FILE_STORAGE_CLEAR_ON_START" envDefault:"false"                             json:"file_storage_clear_on_start"`
-	FileStorageTTLOnDisk    time.Duration `env:"FILE_STORAGE_TTL_ON_DISK"    envDefault:"1h"                                json:"file_storage_ttl_on_disk"`
-	FileStorageTTLInMemory  time.Duration `env:"FILE_STORAGE_TTL_IN_MEMORY"  envDefault:"15m"                               json:"file_storage_ttl_in_memory"`
+	TrustedProxies          []string      `env:"TRUSTED_PROXIES"                                                                             json:"trusted_proxies"             envSeparator:","`
+	PostgresDatabaseDSN     string        `env:"DATABASE_DSN"                                                                                json:"postgres_database_dsn"`
+	PostgresClearOnStart    bool          `env:"PG_CLEAR_ON_START"           envDefault:"false"                                              json:"postgres_clear_on_start"`
+	RedisURL                string        `env:"REDIS_URL"                                                                                   json:"redis_url"`
+	RedisClearOnStart       bool          `env:"REDIS_CLEAR_ON_START"        envDefault:"false"                                              json:"redis_clear_on_start"`
+	SQLiteDBPath            string        `env:"SQLITE_DB_PATH"              envDefault:"db.sqlite3"                                         json:"sqlite_db_path"`
+	SQLiteClearOnStart      bool          `env:"SQLITE_CLEAR_ON_START"       envDefault:"false"                                              json:"sqlite_clear_on_start"`
+	FileStoragePath         string        `env:"FILE_STORAGE_PATH"                                                                           json:"file_storage_path"`
+	FileStorageEngine       string        `env:"FILE_STORAGE_ENGINE"         envDefault:"text"                     valid:"in(text|kv)"       json:"file_storage_engine"`
+	FileStorageClearOnStart bool          `env:"FILE_STORAGE_CLEAR_ON_START" envDefault:"false"                                              json:"file_storage_clear_on_start"`
+	FileStorageTTLOnDisk    time.Duration `env:"FILE_STORAGE_TTL_ON_DISK"    envDefault:"1h"                                                 json:"file_storage_ttl_on_disk"`
+	FileStorageTTLInMemory  time.Duration `env:"FILE_STORAGE_TTL_IN_MEMORY"  envDefault:"15m"                                                json:"file_storage_ttl_in_memory"`
+	ExpiredReapInterval     time.Duration `env:"EXPIRED_REAP_INTERVAL"       envDefault:"1m"                                                 json:"expired_reap_interval"`
+	MetricsAddress          string        `env:"METRICS_ADDRESS"                                                                             json:"metrics_address"`
+	TracingExporter         string        `env:"TRACING_EXPORTER"                                                  valid:"in(stdout|otlp)"   json:"tracing_exporter"`
+	TracingOTLPEndpoint     string        `env:"TRACING_OTLP_ENDPOINT"       envDefault:"localhost:4317"                                     json:"tracing_otlp_endpoint"`
+	TracingSampleRatio      float64       `env:"TRACING_SAMPLE_RATIO"        envDefault:"1"                                                  json:"tracing_sample_ratio"`
+	CacheSize               int           `env:"CACHE_SIZE"                  envDefault:"0"                                                  json:"cache_size"`
+	CacheTTL                time.Duration `env:"CACHE_TTL"                   envDefault:"5m"                                                 json:"cache_ttl"`
+	CacheNegativeTTL        time.Duration `env:"CACHE_NEGATIVE_TTL"          envDefault:"10s"                                                json:"cache_negative_ttl"`
+	AliasAlphabet           string        `env:"ALIAS_ALPHABET"                                                                              json:"alias_alphabet"`
+	AliasMinLength          int           `env:"ALIAS_MIN_LENGTH"            envDefault:"3"                                                  json:"alias_min_length"`
+	AliasMaxLength          int           `env:"ALIAS_MAX_LENGTH"            envDefault:"64"                                                 json:"alias_max_length"`
+	ClicksBufferSize        int           `env:"CLICKS_BUFFER_SIZE"          envDefault:"1024"                                               json:"clicks_buffer_size"`
+	ClicksFlushInterval     time.Duration `env:"CLICKS_FLUSH_INTERVAL"       envDefault:"1s"                                                 json:"clicks_flush_interval"`
+	DeletionBufferSize      int           `env:"DELETION_BUFFER_SIZE"        envDefault:"100"                                                json:"deletion_buffer_size"`
+	DeletionFlushInterval   time.Duration `env:"DELETION_FLUSH_INTERVAL"     envDefault:"100ms"                                              json:"deletion_flush_interval"`
+	GeoIPPath               string        `env:"GEOIP_PATH"                                                                                  json:"geoip_path"`
+	IDGenerator             string        `env:"ID_GENERATOR"                envDefault:"hash"                                               json:"id_generator"`
+	IDLength                int           `env:"ID_LENGTH"                   envDefault:"7"                                                  json:"id_length"`
+	IDSalt                  string        `env:"ID_SALT"                                                                                     json:"id_salt"`
+	ShareDuplicateURLs      bool          `env:"SHARE_DUPLICATE_URLS"        envDefault:"true"                                               json:"share_duplicate_urls"`
+	RateLimitCreate         float64       `env:"RATE_LIMIT_CREATE"           envDefault:"10"                                                 json:"rate_limit_create"`
+	RateLimitCreateBurst    int           `env:"RATE_LIMIT_CREATE_BURST"     envDefault:"50"                                                 json:"rate_limit_create_burst"`
+	RateLimitRedirect       float64       `env:"RATE_LIMIT_REDIRECT"         envDefault:"100"                                                json:"rate_limit_redirect"`
+	RateLimitRedirectBurst  int           `env:"RATE_LIMIT_REDIRECT_BURST"   envDefault:"200"                                                json:"rate_limit_redirect_burst"`
+	RateLimitDelete         float64       `env:"RATE_LIMIT_DELETE"           envDefault:"5"                                                  json:"rate_limit_delete"`
+	RateLimitDeleteBurst    int           `env:"RATE_LIMIT_DELETE_BURST"     envDefault:"20"                                                 json:"rate_limit_delete_burst"`
+	RateLimitLogin          float64       `env:"RATE_LIMIT_LOGIN"            envDefault:"0.2"                                                json:"rate_limit_login"`
+	RateLimitLoginBurst     int           `env:"RATE_LIMIT_LOGIN_BURST"      envDefault:"10"                                                 json:"rate_limit_login_burst"`
+	PolicySchemes           []string      `env:"POLICY_SCHEMES"              envDefault:"http,https"                                         json:"policy_schemes"              envSeparator:","`
+	PolicyAllowListPath     string        `env:"POLICY_ALLOW_LIST_PATH"                                                                      json:"policy_allow_list_path"`
+	PolicyBlockListPath     string        `env:"POLICY_BLOCK_LIST_PATH"                                                                      json:"policy_block_list_path"`
+	PolicyReloadInterval    time.Duration `env:"POLICY_RELOAD_INTERVAL"      envDefault:"30s"                                                json:"policy_reload_interval"`
+	PolicyRejectPrivateIPs  bool          `env:"POLICY_REJECT_PRIVATE_IPS"   envDefault:"true"                                               json:"policy_reject_private_ips"`
+	CanonicalizeURLs        bool          `env:"CANONICALIZE_URLS"           envDefault:"true"                                               json:"canonicalize_urls"`
+	CanonicalStripParams    []string      `env:"CANONICAL_STRIP_PARAMS"      envDefault:"utm_*,fbclid,gclid,yclid"                           json:"canonical_strip_params"      envSeparator:","`
+	CanonicalSortQuery      bool          `env:"CANONICAL_SORT_QUERY"        envDefault:"true"                                               json:"canonical_sort_query"`
+	CanonicalFragment       string        `env:"CANONICAL_FRAGMENT"          envDefault:"keep"                     valid:"in(keep|strip)"    json:"canonical_fragment"`
+	CanonicalTrimSlash      bool          `env:"CANONICAL_TRIM_SLASH"        envDefault:"true"                                               json:"canonical_trim_slash"`
+	FetchPreviews           bool          `env:"FETCH_PREVIEWS"              envDefault:"true"                                               json:"fetch_previews"`
+	PreviewWorkers          int           `env:"PREVIEW_WORKERS"             envDefault:"4"                                                  json:"preview_workers"`
+	PreviewQueueSize        int           `env:"PREVIEW_QUEUE_SIZE"          envDefault:"1000"                                               json:"preview_queue_size"`
+	PreviewTimeout          time.Duration `env:"PREVIEW_TIMEOUT"             envDefault:"10s"                                                json:"preview_timeout"`
+	PreviewMaxBodySize      int64         `env:"PREVIEW_MAX_BODY_SIZE"       envDefault:"1048576"                                            json:"preview_max_body_size"`
+	PreviewMaxRedirects     int           `env:"PREVIEW_MAX_REDIRECTS"       envDefault:"10"                                                 json:"preview_max_redirects"`
+	CheckLinks              bool          `env:"CHECK_LINKS"                 envDefault:"true"                                               json:"check_links"`
+	LinkCheckInterval       time.Duration `env:"LINK_CHECK_INTERVAL"         envDefault:"10m"                                                json:"link_check_interval"`
+	LinkCheckRecheckAfter   time.Duration `env:"LINK_CHECK_RECHECK_AFTER"    envDefault:"24h"                                                json:"link_check_recheck_after"`
+	LinkCheckBatchSize      int           `env:"LINK_CHECK_BATCH_SIZE"       envDefault:"500"                                                json:"link_check_batch_size"`
+	LinkCheckConcurrency    int           `env:"LINK_CHECK_CONCURRENCY"      envDefault:"8"                                                  json:"link_check_concurrency"`
+	LinkCheckHostDelay      time.Duration `env:"LINK_CHECK_HOST_DELAY"       envDefault:"1s"                                                 json:"link_check_host_delay"`
+	LinkCheckTimeout        time.Duration `env:"LINK_CHECK_TIMEOUT"          envDefault:"10s"                                                json:"link_check_timeout"`
+	LinkCheckBrokenAfter    int           `env:"LINK_CHECK_BROKEN_AFTER"     envDefault:"3"                                                  json:"link_check_broken_after"`
 }
 
 // reflectUpdate updates base's fields from ref.
@@ -8,7 +8,12 @@ type FlagConfig struct {
 	FileStoragePath string
 	SecretKey       string
 	DatabaseDSN     string `cfgArg:"PostgresDatabaseDSN"`
+	RedisURL        string
 	JSONConfigPath  string
 	TrustedSubnet   string
+	MetricsAddress  string
+	Protocols       string
+	GRPCAddress     string
+	GRPCMultiplex   bool
 	StartGRPC       bool `cfgArg:"-"`
 }
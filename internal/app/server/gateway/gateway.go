@@ -0,0 +1,84 @@
+// Package gateway serves the gRPC service as a REST API generated from proto/shorty.proto.
+package gateway
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/blokhinnv/shorty/internal/app/log"
+	grpcserver "github.com/blokhinnv/shorty/internal/app/server/grpc"
+	"github.com/blokhinnv/shorty/internal/app/server/http/routes/middleware"
+	"github.com/blokhinnv/shorty/internal/app/server/realip"
+	"github.com/blokhinnv/shorty/internal/app/tracing"
+	pb "github.com/blokhinnv/shorty/proto"
+)
+
+// size of the in-memory connection buffer
+const bufSize = 1024 * 1024
+
+// Dial serves srv on an in-memory listener and connects to it. So the gateway
+// calls pass through the interceptors of the server without a network hop.
+// The listener is closed when srv stops.
+func Dial(ctx context.Context, srv *grpc.Server) (*grpc.ClientConn, error) {
+	lis := bufconn.Listen(bufSize)
+	go func() {
+		if err := srv.Serve(lis); err != nil {
+			log.Errorf("gateway listener: %v\n", err)
+		}
+	}()
+	return grpc.DialContext(
+		ctx,
+		"bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithChainUnaryInterceptor(tracing.UnaryClientInterceptor),
+		grpc.WithChainStreamInterceptor(tracing.StreamClientInterceptor),
+	)
+}
+
+// headerMatcher passes the headers used by the service to the metadata.
+// X-Real-IP of the client is replaced with the IP found by the router.
+func headerMatcher(key string) (string, bool) {
+	switch strings.ToLower(key) {
+	case "referer":
+		return strings.ToLower(key), true
+	case strings.ToLower(realip.Header):
+		return "", false
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// requestMetadata passes the token of the user from the cookie and the IP
+// of the client. They are set by the middlewares the gateway is mounted behind.
+func requestMetadata(ctx context.Context, r *http.Request) metadata.MD {
+	md := metadata.MD{}
+	if cookie, err := r.Cookie(middleware.UserTokenCookieName); err == nil {
+		md.Set(grpcserver.UserTokenMDName, cookie.Value)
+	}
+	if ip := middleware.ClientIP(r); ip != nil {
+		md.Set(realip.Header, ip.String())
+	}
+	return md
+}
+
+// NewHandler returns the REST API handler which calls the service over conn.
+func NewHandler(ctx context.Context, conn *grpc.ClientConn) (http.Handler, error) {
+	mux := runtime.NewServeMux(
+		runtime.WithIncomingHeaderMatcher(headerMatcher),
+		runtime.WithMetadata(requestMetadata),
+	)
+	if err := pb.RegisterShortyHandler(ctx, mux, conn); err != nil {
+		return nil, err
+	}
+	return mux, nil
+}
@@ -0,0 +1,168 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blokhinnv/shorty/internal/app/deletion"
+	"github.com/blokhinnv/shorty/internal/app/server/auth"
+	"github.com/blokhinnv/shorty/internal/app/server/config"
+	grpcserver "github.com/blokhinnv/shorty/internal/app/server/grpc"
+	"github.com/blokhinnv/shorty/internal/app/server/http/routes/middleware"
+	"github.com/blokhinnv/shorty/internal/app/service"
+	"github.com/blokhinnv/shorty/internal/app/shorten"
+	"github.com/blokhinnv/shorty/internal/app/storage"
+)
+
+// User data for testing.
+const userID uint32 = 2781908098
+
+// tokens - the tokens of the users in tests.
+var tokens = auth.NewTokens(&auth.TokensConfig{
+	Keyring:       auth.NewKeyring([]byte("shorty")),
+	TTL:           time.Hour,
+	RefreshBefore: time.Minute,
+})
+
+// newHandler starts the service on the mock storage and returns the gateway.
+func newHandler(t *testing.T, s storage.Storage) http.Handler {
+	queue := deletion.NewQueue(s, 10, time.Millisecond)
+	conf, err := service.GetConfig(&config.ServerConfig{
+		AliasMinLength: 3,
+		AliasMaxLength: 64,
+		TrustedSubnet:  "192.168.0.0/24",
+	})
+	require.NoError(t, err)
+	svc := service.NewShortener(s, shorten.HashGenerator{}, queue, nil, conf)
+	srv := grpcserver.NewServer(
+		grpcserver.NewShortyServer(
+			svc,
+			service.NewAccounts(s),
+			"http://localhost:8080",
+			tokens,
+			nil,
+		),
+		nil,
+	)
+	t.Cleanup(func() {
+		srv.Stop()
+		queue.Close()
+	})
+	conn, err := Dial(context.Background(), srv)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	h, err := NewHandler(context.Background(), conn)
+	require.NoError(t, err)
+	return h
+}
+
+// do sends the request of the user to the gateway.
+func do(h http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	userToken, _ := tokens.Issue(userID)
+	req.AddCookie(&http.Cookie{Name: middleware.UserTokenCookieName, Value: userToken})
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestRequestMetadata(t *testing.T) {
+	token, err := tokens.Issue(userID)
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodGet, "/v1/user/urls", nil)
+	req.AddCookie(&http.Cookie{Name: middleware.UserTokenCookieName, Value: token})
+	// X-Real-IP of an untrusted client is replaced with its address
+	req.Header.Set("X-Real-IP", "10.0.0.1")
+	md := requestMetadata(context.Background(), req)
+	assert.Equal(t, []string{token}, md.Get("usertoken"))
+	assert.Equal(t, []string{"192.0.2.1"}, md.Get("x-real-ip"))
+	_, ok := headerMatcher("X-Real-IP")
+	assert.False(t, ok)
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/user/urls", nil)
+	req.RemoteAddr = ""
+	assert.Empty(t, requestMetadata(context.Background(), req))
+}
+
+func TestGateway(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s := storage.NewMockStorage(ctrl)
+	h := newHandler(t, s)
+
+	t.Run("shorten", func(t *testing.T) {
+		s.EXPECT().
+			AddURL(gomock.Any(), "https://mail.ru/", "f3o7hcrcrupz1", userID, gomock.Any()).
+			Return(nil)
+		rr := do(h, http.MethodPost, "/v1/urls", `{"url": "https://mail.ru/"}`)
+		require.Equal(t, http.StatusOK, rr.Code)
+		var resp map[string]string
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, "http://localhost:8080/f3o7hcrcrupz1", resp["url"])
+	})
+
+	t.Run("invalid URL", func(t *testing.T) {
+		rr := do(h, http.MethodPost, "/v1/urls", `{"url": "mail"}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("batch conflict", func(t *testing.T) {
+		s.EXPECT().
+			AddURLBatch(gomock.Any(), gomock.Any(), userID).
+			Return(storage.ErrUniqueViolation)
+		s.EXPECT().
+			AddURL(gomock.Any(), "https://mail.ru/", "f3o7hcrcrupz1", userID, gomock.Any()).
+			Return(storage.ErrUniqueViolation)
+		rr := do(
+			h,
+			http.MethodPost,
+			"/v1/shorten/batch",
+			`[{"correlation_id": "1", "original_url": "https://mail.ru/"}]`,
+		)
+		// the same code as on the legacy path
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		s.EXPECT().
+			GetURLsByUser(gomock.Any(), userID).
+			Return([]storage.Record{{URL: "https://mail.ru/", URLID: "f3o7hcrcrupz1"}}, nil)
+		rr := do(h, http.MethodGet, "/v1/user/urls", "")
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"urlId":"f3o7hcrcrupz1"`)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		deleted := make(chan struct{})
+		s.EXPECT().
+			DeleteMany(gomock.Any(), userID, []string{"f3o7hcrcrupz1"}).
+			DoAndReturn(func(context.Context, uint32, []string) error {
+				close(deleted)
+				return nil
+			})
+		rr := do(h, http.MethodDelete, "/v1/user/urls", `["f3o7hcrcrupz1"]`)
+		require.Equal(t, http.StatusOK, rr.Code)
+		select {
+		case <-deleted:
+		case <-time.After(time.Second):
+			t.Fatal("the URL was not deleted")
+		}
+	})
+
+	t.Run("stats forbidden", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/v1/internal/stats", nil)
+		req.Header.Set("X-Real-IP", "10.0.0.1")
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+}
@@ -2,43 +2,80 @@ package grpc
 
 import (
 	"context"
-	"encoding/hex"
-	"fmt"
 	"strings"
 
-	"github.com/blokhinnv/shorty/internal/app/server/auth"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
-// UserTokenMDName is a metadata key containing user token.
+// UserTokenMDName is a metadata key containing user token. The clients send
+// it in the request metadata, new and re-issued tokens come in the header metadata.
 const UserTokenMDName = "UserToken"
 
-// getUserIDInteceptor returns uid or creates one from random user token.
-func getUserIDInteceptor(md metadata.MD, secretKey []byte) (uint32, error) {
-	// get userID (or generate new)
-	userTokenMD, ok := md[strings.ToLower(UserTokenMDName)]
-	var userTokenStr string
-	var err error
-	if !ok {
-		userTokenStr, err = auth.GenerateToken(secretKey)
+// authorizationMDName is a metadata key containing the API key of an account
+// in the "Bearer <key>" form. The gateway passes the Authorization header under it.
+const authorizationMDName = "authorization"
+
+// bearerPrefix precedes the API key in the authorization metadata.
+const bearerPrefix = "Bearer "
+
+// authenticate returns uid of the owner of the API key if there is one
+// in the metadata, otherwise uid of the user token.
+func (srv *ShortyServer) authenticate(ctx context.Context, md metadata.MD) (uint32, error) {
+	values := md.Get(authorizationMDName)
+	if len(values) > 0 && strings.HasPrefix(values[0], bearerPrefix) && srv.accounts != nil {
+		apiKey := strings.TrimSpace(values[0][len(bearerPrefix):])
+		userID, err := srv.accounts.Authenticate(ctx, apiKey)
+		if err != nil {
+			return 0, toStatus(err)
+		}
+		return userID, nil
+	}
+	return srv.getUserIDInteceptor(ctx, md)
+}
+
+// sendToken passes the token to the client in the header metadata.
+func sendToken(ctx context.Context, token string) error {
+	if err := grpc.SetHeader(ctx, metadata.Pairs(UserTokenMDName, token)); err != nil {
+		return status.Errorf(codes.Internal, "Unable to send token")
+	}
+	return nil
+}
+
+// getUserIDInteceptor returns uid from the user token. Without a token a new user
+// is created, invalid and expired tokens are rejected. New tokens and the tokens
+// which expire soon are sent to the client in the header metadata.
+func (srv *ShortyServer) getUserIDInteceptor(ctx context.Context, md metadata.MD) (uint32, error) {
+	values := md.Get(UserTokenMDName)
+	if len(values) == 0 {
+		token, userID, err := srv.tokens.New()
 		if err != nil {
 			return 0, status.Errorf(codes.Internal, "Unable to generate token")
 		}
-	} else {
-		userTokenStr = userTokenMD[0]
+		return userID, sendToken(ctx, token)
 	}
-	userToken, err := hex.DecodeString(userTokenStr)
+	claims, err := srv.tokens.Verify(values[0])
 	if err != nil {
-		return 0, status.Errorf(codes.Internal, "Unable to decode token")
+		return 0, status.Error(codes.Unauthenticated, err.Error())
+	}
+	if srv.tokens.NeedsRefresh(claims) {
+		token, err := srv.tokens.Issue(claims.UserID)
+		if err != nil {
+			return 0, status.Errorf(codes.Internal, "Unable to generate token")
+		}
+		if err := sendToken(ctx, token); err != nil {
+			return 0, err
+		}
 	}
-	userID := auth.ExtractID(userToken)
-	return userID, nil
+	return claims.UserID, nil
 }
 
-// userTokenInceptor is a server interceptor which updates the metadata with UID.
+// userIDCtxKey is the key of the user ID in the context of the handlers.
+type userIDCtxKey struct{}
+
+// userTokenInceptor is a server interceptor which passes the UID to the handler.
 func (srv *ShortyServer) userTokenInceptor(
 	ctx context.Context,
 	req any,
@@ -51,31 +88,26 @@ func (srv *ShortyServer) userTokenInceptor(
 	if !ok {
 		return 0, status.Errorf(codes.InvalidArgument, "Retrieving metadata is failed")
 	}
-	userID, err := getUserIDInteceptor(md, srv.secretKey)
+	userID, err := srv.authenticate(ctx, md)
 	if err != nil {
 		return nil, err
 	}
-	// update metadata
-	md.Set(UserTokenMDName, fmt.Sprint(userID))
-	newCtx := metadata.NewIncomingContext(ctx, md)
-	h, err := handler(newCtx, req)
-	return h, err
+	return handler(context.WithValue(ctx, userIDCtxKey{}, userID), req)
 }
 
-// userTokenServerStream is a type to implement metadata update for a stream interceptor.
+// userTokenServerStream is a type to pass the UID to a stream handler.
 type userTokenServerStream struct {
 	grpc.ServerStream
-	userID uint32
+	ctx context.Context
 }
 
 // Context is an implementation of userTokenServerStream.Context.
+// The metadata of the stream is kept.
 func (u *userTokenServerStream) Context() context.Context {
-	ctx := u.ServerStream.Context()
-	md := metadata.Pairs("UserToken", fmt.Sprint(u.userID))
-	return metadata.NewIncomingContext(ctx, md)
+	return u.ctx
 }
 
-// userTokenStreamInterceptor is a server stream interceptor which updates the metadata with UID.
+// userTokenStreamInterceptor is a server stream interceptor which passes the UID to the handler.
 func (srv *ShortyServer) userTokenStreamInterceptor(
 	srvImpl any,
 	stream grpc.ServerStream,
@@ -87,13 +119,10 @@ func (srv *ShortyServer) userTokenStreamInterceptor(
 	if !ok {
 		return status.Errorf(codes.InvalidArgument, "Retrieving metadata is failed")
 	}
-	userID, err := getUserIDInteceptor(md, srv.secretKey)
+	userID, err := srv.authenticate(stream.Context(), md)
 	if err != nil {
 		return err
 	}
-	err = handler(srv, &userTokenServerStream{stream, userID})
-	if err != nil {
-		return err
-	}
-	return nil
+	ctx := context.WithValue(stream.Context(), userIDCtxKey{}, userID)
+	return handler(srvImpl, &userTokenServerStream{ServerStream: stream, ctx: ctx})
 }
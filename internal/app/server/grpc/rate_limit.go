@@ -0,0 +1,91 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/blokhinnv/shorty/internal/app/ratelimit"
+	"github.com/blokhinnv/shorty/internal/app/server/realip"
+	pb "github.com/blokhinnv/shorty/proto"
+)
+
+// retryAfterMDName is a trailer metadata key with the number of seconds
+// to wait before the next request once the limit is exceeded.
+const retryAfterMDName = "retry-after"
+
+// rateLimitCategories maps the limited methods to their budgets.
+var rateLimitCategories = map[string]ratelimit.Category{
+	pb.Shorty_GetShortURL_FullMethodName:      ratelimit.Create,
+	pb.Shorty_GetShortURLJSON_FullMethodName:  ratelimit.Create,
+	pb.Shorty_GetShortURLBatch_FullMethodName: ratelimit.Create,
+	pb.Shorty_GetOriginalURL_FullMethodName:   ratelimit.Redirect,
+	pb.Shorty_DeleteURL_FullMethodName:        ratelimit.Delete,
+	pb.Shorty_DeleteURLs_FullMethodName:       ratelimit.Delete,
+}
+
+// checkRateLimit takes a token of the user and of the client IP if the method is limited.
+func checkRateLimit(
+	ctx context.Context,
+	limiter *ratelimit.Limiter,
+	proxies *realip.Proxies,
+	method string,
+) error {
+	category, ok := rateLimitCategories[method]
+	if !ok || limiter == nil {
+		return nil
+	}
+	userID, err := getUserID(ctx)
+	if err != nil {
+		return err
+	}
+	_, _, ip := clientInfo(ctx, proxies)
+	retryAfter, err := limiter.Allow(ctx, category, userID, ip)
+	if err != nil {
+		if !errors.Is(err, ratelimit.ErrLimitExceeded) {
+			return status.Error(codes.Internal, err.Error())
+		}
+		seconds := strconv.Itoa(int(math.Ceil(retryAfter.Seconds())))
+		grpc.SetTrailer(ctx, metadata.Pairs(retryAfterMDName, seconds))
+		return status.Errorf(codes.ResourceExhausted, "%v, retry after %vs", err, seconds)
+	}
+	return nil
+}
+
+// rateLimitInterceptor returns a server interceptor which limits the requests.
+// It must follow the user token interceptor.
+func rateLimitInterceptor(limiter *ratelimit.Limiter, proxies *realip.Proxies) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		if err := checkRateLimit(ctx, limiter, proxies, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// rateLimitStreamInterceptor returns a server stream interceptor which limits the streams.
+// A stream takes a single token.
+func rateLimitStreamInterceptor(limiter *ratelimit.Limiter, proxies *realip.Proxies) grpc.StreamServerInterceptor {
+	return func(
+		srvImpl any,
+		stream grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		if err := checkRateLimit(stream.Context(), limiter, proxies, info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srvImpl, stream)
+	}
+}
@@ -4,18 +4,20 @@ package grpc
 import (
 	"context"
 	"errors"
-	"fmt"
 	"io"
 	"net"
-	"regexp"
-	"sync"
-	"time"
 
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
-	"github.com/blokhinnv/shorty/internal/app/log"
+	"github.com/blokhinnv/shorty/internal/app/policy"
+	"github.com/blokhinnv/shorty/internal/app/server/auth"
+	"github.com/blokhinnv/shorty/internal/app/server/realip"
+	"github.com/blokhinnv/shorty/internal/app/service"
 	"github.com/blokhinnv/shorty/internal/app/shorten"
 	"github.com/blokhinnv/shorty/internal/app/storage"
 	pb "github.com/blokhinnv/shorty/proto"
@@ -26,42 +28,66 @@ type ShortyServer struct {
 	// one need to embed the type pb.Unimplemented<TypeName>
 	// for compatibility with future versions
 	pb.UnimplementedShortyServer
-	s              storage.Storage
-	baseURL        string
-	secretKey      []byte
-	trustedSubnet  *net.IPNet
-	deleteJobs     map[uint32][]string
-	m              sync.Mutex
-	expireDuration time.Duration
-	srvCloseCh     chan struct{}
+	svc      *service.Shortener
+	accounts *service.Accounts
+	baseURL  string
+	tokens   *auth.Tokens
+	proxies  *realip.Proxies
 }
 
 // NewShortyServer is a constructor for ShortyServer.
+// Without accounts the API keys are not accepted.
+// X-Real-IP is only trusted if it comes from the gateway or one of the proxies.
 func NewShortyServer(
-	s storage.Storage,
+	svc *service.Shortener,
+	accounts *service.Accounts,
 	baseURL string,
-	secretKey []byte,
-	trustedSubnet string,
-	expireDuration time.Duration,
-	srvCloseCh chan struct{},
+	tokens *auth.Tokens,
+	proxies *realip.Proxies,
 ) *ShortyServer {
-	srvImpl := ShortyServer{
-		s:              s,
-		baseURL:        baseURL,
-		secretKey:      secretKey,
-		expireDuration: expireDuration,
-		srvCloseCh:     srvCloseCh,
-	}
-	if trustedSubnet != "" {
-		_, ipv4Net, err := net.ParseCIDR(trustedSubnet)
-		if err != nil {
-			log.Fatalln(err)
+	return &ShortyServer{
+		svc:      svc,
+		accounts: accounts,
+		baseURL:  baseURL,
+		tokens:   tokens,
+		proxies:  proxies,
+	}
+}
+
+// errorDomain - the domain of the reasons in the error details.
+const errorDomain = "shorty"
+
+// toStatus converts the error of the service to the gRPC status.
+// The rejections of the policy carry the reason in the details.
+func toStatus(err error) error {
+	code := codes.Internal
+	switch {
+	case errors.Is(err, service.ErrInvalidArgument):
+		code = codes.InvalidArgument
+	case errors.Is(err, service.ErrNotFound):
+		code = codes.NotFound
+	case errors.Is(err, service.ErrGone):
+		code = codes.Unavailable
+	case errors.Is(err, service.ErrConflict):
+		code = codes.AlreadyExists
+	case errors.Is(err, service.ErrPermissionDenied):
+		code = codes.PermissionDenied
+	case errors.Is(err, service.ErrUnauthenticated):
+		code = codes.Unauthenticated
+	}
+	st := status.New(code, err.Error())
+	var rejected *policy.RejectedError
+	if errors.As(err, &rejected) {
+		detailed, detailsErr := st.WithDetails(&errdetails.ErrorInfo{
+			Reason:   string(rejected.Reason),
+			Domain:   errorDomain,
+			Metadata: map[string]string{"url": rejected.URL},
+		})
+		if detailsErr == nil {
+			st = detailed
 		}
-		srvImpl.trustedSubnet = ipv4Net
 	}
-	srvImpl.deleteJobs = make(map[uint32][]string)
-	go srvImpl.deleteURLLoop()
-	return &srvImpl
+	return st.Err()
 }
 
 // GetOriginalURL is a method to retrieve original URL.
@@ -69,22 +95,53 @@ func (srv *ShortyServer) GetOriginalURL(
 	ctx context.Context,
 	req *pb.GetOriginalURLRequest,
 ) (*pb.GetOriginalURLResponse, error) {
-	var response pb.GetOriginalURLResponse
-
-	re := regexp.MustCompile(`^\w+$`)
-	if !re.MatchString(req.UrlId) {
-		return nil, status.Errorf(codes.InvalidArgument, "Incorrect URL")
+	referrer, userAgent, ip := clientInfo(ctx, srv.proxies)
+	rec, err := srv.svc.Resolve(
+		ctx,
+		req.UrlId,
+		service.Visit{Referrer: referrer, UserAgent: userAgent, IP: ip},
+	)
+	if err != nil {
+		return nil, toStatus(err)
 	}
+	return &pb.GetOriginalURLResponse{
+		Url:                rec.DisplayURL(),
+		WarnBeforeRedirect: rec.WarnBeforeRedirect,
+		RedirectCode:       int32(shorten.RedirectCode(rec)),
+	}, nil
+}
 
-	rec, err := srv.s.GetURLByID(ctx, req.UrlId)
-	if err != nil {
-		if errors.Is(err, storage.ErrURLWasDeleted) {
-			return nil, status.Errorf(codes.Unavailable, err.Error())
+// gatewayNetwork - the network of the in-memory connection of the gateway.
+const gatewayNetwork = "bufconn"
+
+// clientInfo returns the referrer, user agent and IP of the client from the request context.
+// X-Real-IP is only trusted if it comes from the gateway or one of the proxies.
+func clientInfo(ctx context.Context, proxies *realip.Proxies) (string, string, net.IP) {
+	var referrer, userAgent, realIP string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("referer"); len(values) > 0 {
+			referrer = values[0]
+		}
+		// the gateway passes the user agent of the HTTP client under its own key
+		for _, key := range []string{"grpcgateway-user-agent", "user-agent"} {
+			if values := md.Get(key); len(values) > 0 {
+				userAgent = values[0]
+				break
+			}
+		}
+		if values := md.Get(realip.Header); len(values) > 0 {
+			realIP = values[0]
 		}
-		return nil, status.Errorf(codes.NotFound, err.Error())
 	}
-	response.Url = rec.URL
-	return &response, nil
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return referrer, userAgent, nil
+	}
+	// the gateway has already found out the IP of its client
+	if p.Addr.Network() == gatewayNetwork {
+		return referrer, userAgent, net.ParseIP(realIP)
+	}
+	return referrer, userAgent, proxies.ClientIP(p.Addr.String(), realIP)
 }
 
 // GetShortURL is a method to retrieve short URL.
@@ -96,22 +153,24 @@ func (srv *ShortyServer) GetShortURL(
 	if err != nil {
 		return nil, err
 	}
-	var response pb.GetShortURLResponse
-	shortURLID, shortenURL, err := shorten.GetShortURL(req.Url, uint32(userID), srv.baseURL)
-	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, err.Error())
+	opts := service.ShortenOptions{
+		Alias:              req.Alias,
+		TTL:                req.Ttl.AsDuration(),
+		WarnBeforeRedirect: req.WarnBeforeRedirect,
+		RedirectCode:       int(req.RedirectCode),
 	}
-	err = srv.s.AddURL(ctx, req.Url, shortURLID, uint32(userID))
+	if req.ExpiresAt != nil {
+		opts.ExpiresAt = req.ExpiresAt.AsTime()
+	}
+	urlID, err := srv.svc.Shorten(ctx, userID, req.Url, opts)
 	if err != nil {
-		if errors.Is(err, storage.ErrUniqueViolation) {
-			response.Url = shortenURL
-			return &response, status.Errorf(codes.AlreadyExists, err.Error())
-		} else {
-			return nil, status.Errorf(codes.Internal, err.Error())
+		// the user has already shortened the URL
+		if urlID != "" {
+			return &pb.GetShortURLResponse{Url: shorten.ShortURL(srv.baseURL, urlID)}, toStatus(err)
 		}
+		return nil, toStatus(err)
 	}
-	response.Url = shortenURL
-	return &response, nil
+	return &pb.GetShortURLResponse{Url: shorten.ShortURL(srv.baseURL, urlID)}, nil
 }
 
 // GetOriginalURLs is a method to all URL that was shortened by user.
@@ -124,14 +183,22 @@ func (srv *ShortyServer) GetOriginalURLs(
 	if err != nil {
 		return err
 	}
-	records, err := srv.s.GetURLsByUser(ctx, userID)
+	records, err := srv.svc.UserURLs(ctx, userID, req.Status)
 	if err != nil {
-		return status.Errorf(codes.NotFound, err.Error())
+		return toStatus(err)
 	}
 	for _, rec := range records {
-		resp := pb.GetOriginalURLsResponse{Url: rec.URL, UrlId: rec.URLID}
+		resp := pb.GetOriginalURLsResponse{
+			Url:     rec.DisplayURL(),
+			UrlId:   rec.URLID,
+			Preview: toPreview(rec.Preview),
+			Health:  toHealth(rec.Health),
+		}
+		if !rec.ExpiresAt.IsZero() {
+			resp.ExpiresAt = timestamppb.New(rec.ExpiresAt)
+		}
 		if err := out.Send(&resp); err != nil {
-			log.Fatal(err)
+			return err
 		}
 	}
 	return nil
@@ -146,22 +213,18 @@ func (srv *ShortyServer) GetShortURLJSON(
 	if err != nil {
 		return nil, err
 	}
-	var response pb.GetShortURLJSONResponse
-	shortURLID, shortenURL, err := shorten.GetShortURL(req.Item.Url, uint32(userID), srv.baseURL)
-	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, err.Error())
+	urlID, err := srv.svc.Shorten(ctx, userID, req.Item.Url, service.ShortenOptions{})
+	if err != nil && urlID == "" {
+		return nil, toStatus(err)
+	}
+	response := &pb.GetShortURLJSONResponse{
+		Item: &pb.GetShortURLJSONResponse_Item{Result: shorten.ShortURL(srv.baseURL, urlID)},
 	}
-	err = srv.s.AddURL(ctx, req.Item.Url, shortURLID, uint32(userID))
 	if err != nil {
-		if errors.Is(err, storage.ErrUniqueViolation) {
-			response.Item = &pb.GetShortURLJSONResponse_Item{Result: shortenURL}
-			return &response, status.Errorf(codes.AlreadyExists, err.Error())
-		} else {
-			return nil, status.Errorf(codes.Internal, err.Error())
-		}
+		// the user has already shortened the URL
+		return response, toStatus(err)
 	}
-	response.Item = &pb.GetShortURLJSONResponse_Item{Result: shortenURL}
-	return &response, nil
+	return response, nil
 }
 
 // GetShortURLBatch is a method to retrieve short URL for a batch.
@@ -173,38 +236,37 @@ func (srv *ShortyServer) GetShortURLBatch(
 	if err != nil {
 		return nil, err
 	}
-
-	urlIDs := make(map[string]string)
-	result := make([]*pb.GetShortURLBatchResponse_Item, 0, len(req.Batch))
+	items := make([]service.BatchItem, 0, len(req.Batch))
 	for _, item := range req.Batch {
-		shortURLID, shortenURL, err := shorten.GetShortURL(
-			item.OriginalUrl,
-			userID,
-			srv.baseURL,
+		items = append(
+			items,
+			service.BatchItem{CorrelationID: item.CorrelationId, URL: item.OriginalUrl},
 		)
-		if err != nil {
-			return nil, status.Errorf(codes.Internal, err.Error())
-		}
-		urlIDs[item.OriginalUrl] = shortURLID
+	}
+	added, err := srv.svc.ShortenBatch(ctx, userID, items)
+	if err != nil && added == nil {
+		return nil, toStatus(err)
+	}
+	result := make([]*pb.GetShortURLBatchResponse_Item, 0, len(added))
+	for _, item := range added {
 		result = append(
 			result,
 			&pb.GetShortURLBatchResponse_Item{
-				CorrelationId: item.CorrelationId,
-				ShortUrl:      shortenURL,
+				CorrelationId: item.CorrelationID,
+				ShortUrl:      shorten.ShortURL(srv.baseURL, item.URLID),
 			},
 		)
 	}
-	err = srv.s.AddURLBatch(ctx, urlIDs, userID)
+	response := &pb.GetShortURLBatchResponse{Batch: result}
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, err.Error())
+		// some URLs have already been shortened by the user
+		return response, toStatus(err)
 	}
-	return &pb.GetShortURLBatchResponse{Batch: result}, nil
+	return response, nil
 }
 
 // DeleteURL is a method to delete URLs.
 func (srv *ShortyServer) DeleteURL(stream pb.Shorty_DeleteURLServer) error {
-	srv.m.Lock()
-	defer srv.m.Unlock()
 	userID, err := getUserID(stream.Context())
 	if err != nil {
 		return err
@@ -218,42 +280,21 @@ func (srv *ShortyServer) DeleteURL(stream pb.Shorty_DeleteURLServer) error {
 		if err != nil {
 			return err
 		}
-		if _, ok := srv.deleteJobs[userID]; !ok {
-			srv.deleteJobs[userID] = make([]string, 0)
-		}
-		srv.deleteJobs[userID] = append(srv.deleteJobs[userID], req.Url)
+		srv.svc.Delete(userID, req.Url)
 	}
 }
 
-// deleteURLLoop is a method to organize URLs deleting.
-func (srv *ShortyServer) deleteURLLoop() {
-	ticker := time.NewTicker(srv.expireDuration)
-out:
-	for {
-		select {
-		case <-ticker.C:
-			srv.m.Lock()
-			for userID, userJobs := range srv.deleteJobs {
-				err := srv.s.DeleteMany(context.Background(), userID, userJobs)
-				if err != nil {
-					log.Printf("Error while deleting urls: %v\n", err)
-				}
-			}
-			srv.deleteJobs = make(map[uint32][]string)
-			srv.m.Unlock()
-		case <-srv.srvCloseCh:
-			break out
-		}
-	}
-	srv.m.Lock()
-	for userID, userJobs := range srv.deleteJobs {
-		err := srv.s.DeleteMany(context.Background(), userID, userJobs)
-		if err != nil {
-			log.Printf("Error while deleting urls: %v\n", err)
-		}
+// DeleteURLs is a method to delete a list of URLs in one call.
+func (srv *ShortyServer) DeleteURLs(
+	ctx context.Context,
+	req *pb.DeleteURLsRequest,
+) (*pb.DeleteURLResponse, error) {
+	userID, err := getUserID(ctx)
+	if err != nil {
+		return nil, err
 	}
-	srv.m.Unlock()
-	srv.srvCloseCh <- struct{}{}
+	srv.svc.Delete(userID, req.Urls...)
+	return &pb.DeleteURLResponse{}, nil
 }
 
 // GetStats is a method to retrieve DB stats.
@@ -261,37 +302,167 @@ func (srv *ShortyServer) GetStats(
 	ctx context.Context,
 	req *pb.GetStatsRequest,
 ) (*pb.GetStatsResponse, error) {
-	if srv.trustedSubnet == nil {
-		return nil, status.Errorf(codes.Internal, "trusted network is not set")
+	_, _, ip := clientInfo(ctx, srv.proxies)
+	stats, err := srv.svc.Stats(ctx, ip)
+	if err != nil {
+		// the client which can't be identified is not trusted
+		if errors.Is(err, service.ErrInvalidArgument) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		return nil, toStatus(err)
 	}
-	md, ok := metadata.FromIncomingContext(ctx)
-	if !ok {
-		return nil, status.Errorf(codes.Internal, "No metadata provided")
+	return &pb.GetStatsResponse{Users: uint32(stats.Users), Urls: uint32(stats.URLs)}, nil
+}
+
+// GetURLStats is a method to retrieve click stats of the user's short URL.
+func (srv *ShortyServer) GetURLStats(
+	ctx context.Context,
+	req *pb.GetURLStatsRequest,
+) (*pb.GetURLStatsResponse, error) {
+	userID, err := getUserID(ctx)
+	if err != nil {
+		return nil, err
 	}
-	ips := md.Get("X-Real-IP")
-	var ipStr string
-	if len(ips) > 0 {
-		ipStr = ips[0]
+	stats, err := srv.svc.URLStats(ctx, userID, req.UrlId, req.Granularity)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	response := pb.GetURLStatsResponse{
+		UrlId:       stats.URLID,
+		Clicks:      uint32(stats.Clicks),
+		Granularity: string(stats.Granularity),
+		Buckets:     make([]*pb.GetURLStatsResponse_Bucket, 0, len(stats.Buckets)),
+		Referrers:   toUint32Map(stats.Referrers),
+		UserAgents:  toUint32Map(stats.UserAgents),
+		Countries:   toUint32Map(stats.Countries),
 	}
-	ip := net.ParseIP(ipStr)
-	if ip == nil {
-		return nil, status.Errorf(codes.Internal, "failed parse ip from metadata")
+	if stats.FirstClick != nil {
+		response.FirstClick = timestamppb.New(*stats.FirstClick)
+		response.LastClick = timestamppb.New(*stats.LastClick)
 	}
-	if !srv.trustedSubnet.Contains(ip) {
-		return nil, status.Errorf(
-			codes.Internal,
-			fmt.Sprintf("ip %v is not in trusted network %+v", ip, srv.trustedSubnet),
+	for _, b := range stats.Buckets {
+		response.Buckets = append(
+			response.Buckets,
+			&pb.GetURLStatsResponse_Bucket{Start: timestamppb.New(b.Start), Clicks: uint32(b.Clicks)},
 		)
 	}
-	urls, users, err := srv.s.GetStats(ctx)
+	return &response, nil
+}
+
+// toPreview converts the preview to the form used in protobuf.
+func toPreview(preview *storage.Preview) *pb.Preview {
+	if preview == nil {
+		return nil
+	}
+	return &pb.Preview{
+		Title:       preview.Title,
+		Description: preview.Description,
+		FaviconUrl:  preview.FaviconURL,
+		FinalUrl:    preview.FinalURL,
+		StatusCode:  uint32(preview.StatusCode),
+		Error:       preview.Error,
+		FetchedAt:   timestamppb.New(preview.FetchedAt),
+	}
+}
+
+// toHealth converts the result of the check of the destination to the form used in protobuf.
+func toHealth(health *storage.Health) *pb.Health {
+	if health == nil {
+		return nil
+	}
+	return &pb.Health{
+		CheckedAt:  timestamppb.New(health.CheckedAt),
+		StatusCode: uint32(health.StatusCode),
+		Error:      health.Error,
+		Failures:   uint32(health.Failures),
+	}
+}
+
+// GetURLInfo is a method to retrieve the user's short URL with the preview
+// and the health of its destination.
+func (srv *ShortyServer) GetURLInfo(
+	ctx context.Context,
+	req *pb.GetURLInfoRequest,
+) (*pb.GetURLInfoResponse, error) {
+	userID, err := getUserID(ctx)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, err.Error())
+		return nil, err
+	}
+	rec, err := srv.svc.URLInfo(ctx, userID, req.UrlId)
+	if err != nil {
+		return nil, toStatus(err)
 	}
-	return &pb.GetStatsResponse{Users: uint32(users), Urls: uint32(urls)}, nil
+	return toURLInfo(rec), nil
+}
 
+// toURLInfo converts the record to the form used in protobuf.
+func toURLInfo(rec storage.Record) *pb.GetURLInfoResponse {
+	response := pb.GetURLInfoResponse{
+		UrlId:        rec.URLID,
+		Url:          rec.DisplayURL(),
+		CanonicalUrl: rec.URL,
+		Preview:      toPreview(rec.Preview),
+		Health:       toHealth(rec.Health),
+	}
+	if !rec.ExpiresAt.IsZero() {
+		response.ExpiresAt = timestamppb.New(rec.ExpiresAt)
+	}
+	return &response
+}
+
+// UpdateURL is a method to change the destination of the user's short URL.
+func (srv *ShortyServer) UpdateURL(
+	ctx context.Context,
+	req *pb.UpdateURLRequest,
+) (*pb.GetURLInfoResponse, error) {
+	userID, err := getUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rec, err := srv.svc.UpdateURL(ctx, userID, req.UrlId, req.Url)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toURLInfo(rec), nil
+}
+
+// GetURLHistory is a method to retrieve the previous destinations of the user's short URL.
+func (srv *ShortyServer) GetURLHistory(
+	ctx context.Context,
+	req *pb.GetURLHistoryRequest,
+) (*pb.GetURLHistoryResponse, error) {
+	userID, err := getUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	history, err := srv.svc.URLHistory(ctx, userID, req.UrlId)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	response := pb.GetURLHistoryResponse{
+		UrlId:     req.UrlId,
+		Revisions: make([]*pb.GetURLHistoryResponse_Revision, 0, len(history)),
+	}
+	for _, r := range history {
+		response.Revisions = append(response.Revisions, &pb.GetURLHistoryResponse_Revision{
+			Url:       r.URL,
+			ChangedAt: timestamppb.New(r.ChangedAt),
+			ChangedBy: r.ChangedBy,
+		})
+	}
+	return &response, nil
+}
+
+// toUint32Map converts counters to the form used in protobuf.
+func toUint32Map(m map[string]int) map[string]uint32 {
+	result := make(map[string]uint32, len(m))
+	for k, v := range m {
+		result[k] = uint32(v)
+	}
+	return result
 }
 
 // Ping is a method for checking DB status.
 func (srv *ShortyServer) Ping(ctx context.Context, req *pb.PingRequest) (*pb.PingResponse, error) {
-	return &pb.PingResponse{Pinged: srv.s.Ping(ctx)}, nil
+	return &pb.PingResponse{Pinged: srv.svc.Ping(ctx)}, nil
 }
@@ -6,26 +6,50 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"net/http"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
 	"google.golang.org/grpc/test/bufconn"
 
+	"github.com/blokhinnv/shorty/internal/app/deletion"
 	"github.com/blokhinnv/shorty/internal/app/log"
+	"github.com/blokhinnv/shorty/internal/app/policy"
+	"github.com/blokhinnv/shorty/internal/app/ratelimit"
+	"github.com/blokhinnv/shorty/internal/app/server/auth"
 	"github.com/blokhinnv/shorty/internal/app/server/config"
+	"github.com/blokhinnv/shorty/internal/app/server/realip"
+	"github.com/blokhinnv/shorty/internal/app/service"
+	"github.com/blokhinnv/shorty/internal/app/shorten"
 	"github.com/blokhinnv/shorty/internal/app/storage"
 	pb "github.com/blokhinnv/shorty/proto"
 	"github.com/golang/mock/gomock"
 )
 
+// tokensCfg - tokens settings used in tests.
+var tokensCfg = &auth.TokensConfig{
+	Keyring:       auth.NewKeyring([]byte("shorty")),
+	TTL:           time.Hour,
+	RefreshBefore: time.Minute,
+}
+
 type GRPCTestSuite struct {
 	suite.Suite
 	ctrl *gomock.Controller
 	db   *storage.MockStorage
+	// the requests are not limited without a limiter
+	limiter *ratelimit.Limiter
 }
 
 func (suite *GRPCTestSuite) SetupSuite() {
@@ -38,22 +62,29 @@ func (suite *GRPCTestSuite) server(ctx context.Context) (pb.ShortyClient, func()
 	buffer := 101024 * 1024
 	lis := bufconn.Listen(buffer)
 
-	srvCloseCh := make(chan struct{}, 1)
-	srvImpl := NewShortyServer(
+	conf, err := service.GetConfig(&config.ServerConfig{
+		AliasMinLength: 3,
+		AliasMaxLength: 64,
+		TrustedSubnet:  "192.168.0.0/24",
+		// the links are broken after 3 failed checks
+		LinkCheckBrokenAfter: 3,
+	})
+	suite.Require().NoError(err)
+	svc := service.NewShortener(
 		suite.db,
-		"http://localhost:8080",
-		[]byte("shorty"),
-		"192.168.0.0/24",
-		time.Millisecond,
-		srvCloseCh,
+		shorten.HashGenerator{},
+		deletion.NewQueue(suite.db, 100, time.Millisecond),
+		nil,
+		conf,
 	)
-
-	baseServer := grpc.NewServer(
-		withServerUnaryInterceptor(srvImpl),
-		withServerStreamInterceptor(srvImpl),
+	srvImpl := NewShortyServer(
+		svc,
+		service.NewAccounts(suite.db),
+		"http://localhost:8080",
+		auth.NewTokens(tokensCfg),
+		nil,
 	)
-
-	pb.RegisterShortyServer(baseServer, srvImpl)
+	baseServer := NewServer(srvImpl, suite.limiter)
 	go func() {
 		if err := baseServer.Serve(lis); err != nil {
 			log.Printf("error serving server: %v", err)
@@ -93,6 +124,28 @@ func (suite *GRPCTestSuite) TestGetOriginalURL() {
 		out, err := client.GetOriginalURL(ctx, in)
 		suite.NoError(err)
 		suite.Equal("shorty.com", out.Url)
+		suite.False(out.WarnBeforeRedirect)
+		suite.Equal(int32(http.StatusTemporaryRedirect), out.RedirectCode)
+	})
+
+	suite.T().Run("RedirectCode", func(t *testing.T) {
+		suite.db.EXPECT().
+			GetURLByID(gomock.Any(), "qwerty").
+			Return(storage.Record{URL: "shorty.com", RedirectCode: http.StatusMovedPermanently}, nil)
+		in := &pb.GetOriginalURLRequest{UrlId: "qwerty"}
+		out, err := client.GetOriginalURL(ctx, in)
+		suite.NoError(err)
+		suite.Equal(int32(http.StatusMovedPermanently), out.RedirectCode)
+	})
+
+	suite.T().Run("WarnBeforeRedirect", func(t *testing.T) {
+		suite.db.EXPECT().
+			GetURLByID(gomock.Any(), "qwerty").
+			Return(storage.Record{URL: "shorty.com", WarnBeforeRedirect: true}, nil)
+		in := &pb.GetOriginalURLRequest{UrlId: "qwerty"}
+		out, err := client.GetOriginalURL(ctx, in)
+		suite.NoError(err)
+		suite.True(out.WarnBeforeRedirect)
 	})
 
 	suite.T().Run("Deleted", func(t *testing.T) {
@@ -117,7 +170,7 @@ func (suite *GRPCTestSuite) TestGetShortURL() {
 	defer closer()
 	suite.T().Run("OK", func(t *testing.T) {
 		suite.db.EXPECT().
-			AddURL(gomock.Any(), "http://qwerty.com", gomock.Any(), gomock.Any()).
+			AddURL(gomock.Any(), "http://qwerty.com", gomock.Any(), gomock.Any(), gomock.Any()).
 			Return(nil)
 		in := &pb.GetShortURLRequest{Url: "http://qwerty.com"}
 		out, err := client.GetShortURL(ctx, in)
@@ -127,12 +180,34 @@ func (suite *GRPCTestSuite) TestGetShortURL() {
 
 	suite.T().Run("Error", func(t *testing.T) {
 		suite.db.EXPECT().
-			AddURL(gomock.Any(), "http://qwerty.com", gomock.Any(), gomock.Any()).
+			AddURL(gomock.Any(), "http://qwerty.com", gomock.Any(), gomock.Any(), gomock.Any()).
 			Return(storage.ErrUniqueViolation)
 		in := &pb.GetShortURLRequest{Url: "http://qwerty.com"}
 		_, err := client.GetShortURL(ctx, in)
 		suite.Error(err)
 	})
+
+	suite.T().Run("Alias", func(t *testing.T) {
+		suite.db.EXPECT().
+			AddURL(gomock.Any(), "http://qwerty.com", "qwerty", gomock.Any(), gomock.Any()).
+			Return(nil)
+		in := &pb.GetShortURLRequest{Url: "http://qwerty.com", Alias: "qwerty"}
+		out, err := client.GetShortURL(ctx, in)
+		suite.NoError(err)
+		suite.Equal("http://localhost:8080/qwerty", out.Url)
+	})
+
+	suite.T().Run("ReservedAlias", func(t *testing.T) {
+		in := &pb.GetShortURLRequest{Url: "http://qwerty.com", Alias: "ping"}
+		_, err := client.GetShortURL(ctx, in)
+		suite.Equal(codes.InvalidArgument, status.Code(err))
+	})
+
+	suite.T().Run("InvalidRedirectCode", func(t *testing.T) {
+		in := &pb.GetShortURLRequest{Url: "http://qwerty.com", RedirectCode: http.StatusOK}
+		_, err := client.GetShortURL(ctx, in)
+		suite.Equal(codes.InvalidArgument, status.Code(err))
+	})
 }
 
 func (suite *GRPCTestSuite) TestGetOriginalURLs() {
@@ -171,6 +246,38 @@ func (suite *GRPCTestSuite) TestGetOriginalURLs() {
 			suite.Equal(expected[i].URLID, o.UrlId)
 		}
 	})
+
+	suite.T().Run("Broken", func(t *testing.T) {
+		checkedAt := time.Date(2023, 3, 2, 10, 30, 0, 0, time.UTC)
+		records := []storage.Record{
+			{URL: "okURL", URLID: "okURLID", Health: &storage.Health{CheckedAt: checkedAt}},
+			{
+				URL:    "brokenURL",
+				URLID:  "brokenURLID",
+				Health: &storage.Health{CheckedAt: checkedAt, StatusCode: 404, Failures: 3},
+			},
+		}
+		suite.db.EXPECT().
+			GetURLsByUser(gomock.Any(), gomock.Any()).
+			Return(records, nil)
+		out, err := client.GetOriginalURLs(ctx, &pb.GetOriginalURLsRequest{Status: "broken"})
+		suite.Require().NoError(err)
+		o, err := out.Recv()
+		suite.Require().NoError(err)
+		suite.Equal("brokenURLID", o.UrlId)
+		suite.Equal(uint32(404), o.Health.StatusCode)
+		suite.Equal(uint32(3), o.Health.Failures)
+		suite.Equal(checkedAt, o.Health.CheckedAt.AsTime())
+		_, err = out.Recv()
+		suite.ErrorIs(err, io.EOF)
+	})
+
+	suite.T().Run("UnknownStatus", func(t *testing.T) {
+		out, err := client.GetOriginalURLs(ctx, &pb.GetOriginalURLsRequest{Status: "dead"})
+		suite.Require().NoError(err)
+		_, err = out.Recv()
+		suite.Equal(codes.InvalidArgument, status.Code(err))
+	})
 }
 
 func (suite *GRPCTestSuite) TestGetShortURLJSON() {
@@ -179,7 +286,7 @@ func (suite *GRPCTestSuite) TestGetShortURLJSON() {
 	defer closer()
 	suite.T().Run("OK", func(t *testing.T) {
 		suite.db.EXPECT().
-			AddURL(gomock.Any(), "http://qwerty.com", gomock.Any(), gomock.Any()).
+			AddURL(gomock.Any(), "http://qwerty.com", gomock.Any(), gomock.Any(), gomock.Any()).
 			Return(nil)
 		in := &pb.GetShortURLJSONRequest{
 			Item: &pb.GetShortURLJSONRequest_Item{Url: "http://qwerty.com"},
@@ -191,7 +298,7 @@ func (suite *GRPCTestSuite) TestGetShortURLJSON() {
 
 	suite.T().Run("Error", func(t *testing.T) {
 		suite.db.EXPECT().
-			AddURL(gomock.Any(), "http://qwerty.com", gomock.Any(), gomock.Any()).
+			AddURL(gomock.Any(), "http://qwerty.com", gomock.Any(), gomock.Any(), gomock.Any()).
 			Return(storage.ErrUniqueViolation)
 		in := &pb.GetShortURLJSONRequest{
 			Item: &pb.GetShortURLJSONRequest_Item{Url: "http://qwerty.com"},
@@ -219,15 +326,189 @@ func (suite *GRPCTestSuite) TestGetShortURLBatch() {
 		suite.Equal("http://localhost:8080/f3o7hcrcrupz1", out.Batch[0].ShortUrl)
 	})
 
-	suite.T().Run("Error", func(t *testing.T) {
+	suite.T().Run("Conflict", func(t *testing.T) {
 		suite.db.EXPECT().
 			AddURLBatch(gomock.Any(), gomock.Any(), gomock.Any()).
 			Return(storage.ErrUniqueViolation)
+		suite.db.EXPECT().
+			AddURL(gomock.Any(), "https://mail.ru/", "f3o7hcrcrupz1", gomock.Any(), gomock.Any()).
+			Return(storage.ErrUniqueViolation)
 		in := &pb.GetShortURLBatchRequest{Batch: []*pb.GetShortURLBatchRequest_Item{
 			{CorrelationId: "test1", OriginalUrl: "https://mail.ru/"},
 		}}
 		_, err := client.GetShortURLBatch(ctx, in)
-		suite.Error(err)
+		suite.Equal(codes.AlreadyExists, status.Code(err))
+	})
+
+	suite.T().Run("Error", func(t *testing.T) {
+		suite.db.EXPECT().
+			AddURLBatch(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(errors.New("connection refused"))
+		in := &pb.GetShortURLBatchRequest{Batch: []*pb.GetShortURLBatchRequest_Item{
+			{CorrelationId: "test1", OriginalUrl: "https://mail.ru/"},
+		}}
+		_, err := client.GetShortURLBatch(ctx, in)
+		suite.Equal(codes.Internal, status.Code(err))
+	})
+}
+
+func (suite *GRPCTestSuite) TestGetURLStats() {
+	ctx := context.Background()
+	client, closer := suite.server(ctx)
+	defer closer()
+	suite.T().Run("OK", func(t *testing.T) {
+		clickedAt := time.Date(2023, 3, 1, 10, 30, 0, 0, time.UTC)
+		suite.db.EXPECT().
+			GetURLsByUser(gomock.Any(), gomock.Any()).
+			Return([]storage.Record{{URL: "http://qwerty.com", URLID: "qwerty"}}, nil)
+		suite.db.EXPECT().
+			GetClicks(gomock.Any(), "qwerty").
+			Return([]storage.Click{{URLID: "qwerty", ClickedAt: clickedAt, Country: "RU"}}, nil)
+		in := &pb.GetURLStatsRequest{UrlId: "qwerty", Granularity: "hour"}
+		out, err := client.GetURLStats(ctx, in)
+		suite.NoError(err)
+		suite.Equal(uint32(1), out.Clicks)
+		suite.Equal(clickedAt, out.FirstClick.AsTime())
+		suite.Equal(time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC), out.Buckets[0].Start.AsTime())
+		suite.Equal(uint32(1), out.Countries["RU"])
+	})
+
+	suite.T().Run("NotOwner", func(t *testing.T) {
+		suite.db.EXPECT().
+			GetURLsByUser(gomock.Any(), gomock.Any()).
+			Return(nil, storage.ErrURLWasNotFound)
+		in := &pb.GetURLStatsRequest{UrlId: "qwerty"}
+		_, err := client.GetURLStats(ctx, in)
+		suite.Equal(codes.NotFound, status.Code(err))
+	})
+
+	suite.T().Run("BadGranularity", func(t *testing.T) {
+		in := &pb.GetURLStatsRequest{UrlId: "qwerty", Granularity: "week"}
+		_, err := client.GetURLStats(ctx, in)
+		suite.Equal(codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func (suite *GRPCTestSuite) TestGetURLInfo() {
+	ctx := context.Background()
+	client, closer := suite.server(ctx)
+	defer closer()
+	suite.T().Run("OK", func(t *testing.T) {
+		fetchedAt := time.Date(2023, 3, 1, 10, 30, 0, 0, time.UTC)
+		suite.db.EXPECT().
+			GetURLsByUser(gomock.Any(), gomock.Any()).
+			Return([]storage.Record{{
+				URL:         "http://qwerty.com/",
+				OriginalURL: "http://QWERTY.com",
+				URLID:       "qwerty",
+				Preview: &storage.Preview{
+					Title:      "Qwerty",
+					FinalURL:   "https://qwerty.com/",
+					StatusCode: 200,
+					FetchedAt:  fetchedAt,
+				},
+			}}, nil)
+		out, err := client.GetURLInfo(ctx, &pb.GetURLInfoRequest{UrlId: "qwerty"})
+		suite.Require().NoError(err)
+		suite.Equal("http://QWERTY.com", out.Url)
+		suite.Equal("http://qwerty.com/", out.CanonicalUrl)
+		suite.Equal("Qwerty", out.Preview.Title)
+		suite.Equal("https://qwerty.com/", out.Preview.FinalUrl)
+		suite.Equal(uint32(200), out.Preview.StatusCode)
+		suite.Equal(fetchedAt, out.Preview.FetchedAt.AsTime())
+	})
+
+	suite.T().Run("NotFetched", func(t *testing.T) {
+		suite.db.EXPECT().
+			GetURLsByUser(gomock.Any(), gomock.Any()).
+			Return([]storage.Record{{URL: "http://qwerty.com/", URLID: "qwerty"}}, nil)
+		out, err := client.GetURLInfo(ctx, &pb.GetURLInfoRequest{UrlId: "qwerty"})
+		suite.Require().NoError(err)
+		suite.Nil(out.Preview)
+	})
+
+	suite.T().Run("NotOwner", func(t *testing.T) {
+		suite.db.EXPECT().
+			GetURLsByUser(gomock.Any(), gomock.Any()).
+			Return(nil, storage.ErrURLWasNotFound)
+		_, err := client.GetURLInfo(ctx, &pb.GetURLInfoRequest{UrlId: "qwerty"})
+		suite.Equal(codes.NotFound, status.Code(err))
+	})
+
+	suite.T().Run("BadID", func(t *testing.T) {
+		_, err := client.GetURLInfo(ctx, &pb.GetURLInfoRequest{UrlId: "@!%"})
+		suite.Equal(codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func (suite *GRPCTestSuite) TestUpdateURL() {
+	ctx := context.Background()
+	client, closer := suite.server(ctx)
+	defer closer()
+	suite.T().Run("OK", func(t *testing.T) {
+		suite.db.EXPECT().
+			UpdateURL(gomock.Any(), "qwerty", gomock.Any(), "http://ya.ru/", gomock.Any()).
+			Return(nil)
+		suite.db.EXPECT().
+			GetURLsByUser(gomock.Any(), gomock.Any()).
+			Return([]storage.Record{{URL: "http://ya.ru/", URLID: "qwerty"}}, nil)
+		out, err := client.UpdateURL(ctx, &pb.UpdateURLRequest{UrlId: "qwerty", Url: "http://ya.ru/"})
+		suite.Require().NoError(err)
+		suite.Equal("qwerty", out.UrlId)
+		suite.Equal("http://ya.ru/", out.Url)
+	})
+
+	suite.T().Run("NotOwner", func(t *testing.T) {
+		suite.db.EXPECT().
+			UpdateURL(gomock.Any(), "qwerty", gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(storage.ErrNotOwner)
+		_, err := client.UpdateURL(ctx, &pb.UpdateURLRequest{UrlId: "qwerty", Url: "http://ya.ru/"})
+		suite.Equal(codes.NotFound, status.Code(err))
+	})
+
+	suite.T().Run("Shared", func(t *testing.T) {
+		suite.db.EXPECT().
+			UpdateURL(gomock.Any(), "qwerty", gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(storage.ErrURLShared)
+		_, err := client.UpdateURL(ctx, &pb.UpdateURLRequest{UrlId: "qwerty", Url: "http://ya.ru/"})
+		suite.Equal(codes.AlreadyExists, status.Code(err))
+	})
+
+	suite.T().Run("BadURL", func(t *testing.T) {
+		_, err := client.UpdateURL(ctx, &pb.UpdateURLRequest{UrlId: "qwerty", Url: "not a url"})
+		suite.Equal(codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func (suite *GRPCTestSuite) TestGetURLHistory() {
+	ctx := context.Background()
+	client, closer := suite.server(ctx)
+	defer closer()
+	suite.T().Run("OK", func(t *testing.T) {
+		changedAt := time.Date(2023, 3, 1, 10, 30, 0, 0, time.UTC)
+		suite.db.EXPECT().
+			GetURLsByUser(gomock.Any(), gomock.Any()).
+			Return([]storage.Record{{URL: "http://ya.ru/", URLID: "qwerty"}}, nil)
+		suite.db.EXPECT().
+			GetURLHistory(gomock.Any(), "qwerty").
+			Return([]storage.Revision{
+				{URLID: "qwerty", URL: "http://qwerty.com/", ChangedAt: changedAt, ChangedBy: 1},
+			}, nil)
+		out, err := client.GetURLHistory(ctx, &pb.GetURLHistoryRequest{UrlId: "qwerty"})
+		suite.Require().NoError(err)
+		suite.Equal("qwerty", out.UrlId)
+		suite.Require().Len(out.Revisions, 1)
+		suite.Equal("http://qwerty.com/", out.Revisions[0].Url)
+		suite.Equal(changedAt, out.Revisions[0].ChangedAt.AsTime())
+		suite.Equal(uint32(1), out.Revisions[0].ChangedBy)
+	})
+
+	suite.T().Run("NotOwner", func(t *testing.T) {
+		suite.db.EXPECT().
+			GetURLsByUser(gomock.Any(), gomock.Any()).
+			Return(nil, storage.ErrURLWasNotFound)
+		_, err := client.GetURLHistory(ctx, &pb.GetURLHistoryRequest{UrlId: "qwerty"})
+		suite.Equal(codes.NotFound, status.Code(err))
 	})
 }
 
@@ -253,7 +534,7 @@ func (suite *GRPCTestSuite) TestGetStats() {
 		mdCtx := metadata.NewOutgoingContext(context.Background(), md)
 		in := new(pb.GetStatsRequest)
 		_, err := client.GetStats(mdCtx, in)
-		suite.Error(err)
+		suite.Equal(codes.PermissionDenied, status.Code(err))
 	})
 
 	suite.T().Run("Bad IP", func(t *testing.T) {
@@ -304,14 +585,299 @@ func (suite *GRPCTestSuite) TestDeleteURL() {
 	})
 }
 
-func (suite *GRPCTestSuite) TestRunGRPCServer() {
-	cfg := &config.ServerConfig{
-		SQLiteDBPath:       "demo.sqlte3",
-		SQLiteClearOnStart: false,
+func (suite *GRPCTestSuite) TestDeleteURLs() {
+	ctx := context.Background()
+	client, closer := suite.server(ctx)
+	defer closer()
+	deleted := make(chan struct{})
+	suite.db.EXPECT().
+		DeleteMany(gomock.Any(), gomock.Any(), []string{"qwe", "rty"}).
+		DoAndReturn(func(context.Context, uint32, []string) error {
+			close(deleted)
+			return nil
+		})
+	_, err := client.DeleteURLs(ctx, &pb.DeleteURLsRequest{Urls: []string{"qwe", "rty"}})
+	suite.NoError(err)
+	select {
+	case <-deleted:
+	case <-time.After(time.Second):
+		suite.Fail("the URLs were not deleted")
+	}
+}
+
+func (suite *GRPCTestSuite) TestRateLimit() {
+	store := ratelimit.NewMemoryStore(time.Minute)
+	defer store.Close()
+	suite.limiter = ratelimit.NewLimiter(store, &ratelimit.Config{
+		Limits: map[ratelimit.Category]ratelimit.Limit{ratelimit.Create: {Rate: 0.1, Burst: 1}},
+	})
+	defer func() { suite.limiter = nil }()
+	ctx := context.Background()
+	client, closer := suite.server(ctx)
+	defer closer()
+	suite.db.EXPECT().
+		AddURL(gomock.Any(), "http://qwerty.com", gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil).
+		Times(2)
+	in := &pb.GetShortURLJSONRequest{
+		Item: &pb.GetShortURLJSONRequest_Item{Url: "http://qwerty.com"},
+	}
+	var header metadata.MD
+	_, err := client.GetShortURLJSON(ctx, in, grpc.Header(&header))
+	suite.Require().NoError(err)
+	token := header.Get(UserTokenMDName)
+	suite.Require().Len(token, 1)
+
+	// the same user is out of the budget
+	userCtx := metadata.AppendToOutgoingContext(ctx, UserTokenMDName, token[0])
+	var trailer metadata.MD
+	_, err = client.GetShortURLJSON(userCtx, in, grpc.Trailer(&trailer))
+	suite.Equal(codes.ResourceExhausted, status.Code(err))
+	suite.Equal([]string{"10"}, trailer.Get(retryAfterMDName))
+
+	// the other users and the other categories have their own budgets
+	_, err = client.GetShortURLJSON(ctx, in)
+	suite.NoError(err)
+	suite.db.EXPECT().Ping(gomock.Any()).Return(true)
+	_, err = client.Ping(userCtx, &pb.PingRequest{})
+	suite.NoError(err)
+}
+
+func (suite *GRPCTestSuite) TestAPIKey() {
+	ctx := context.Background()
+	client, closer := suite.server(ctx)
+	defer closer()
+	md := metadata.New(map[string]string{"authorization": "Bearer key"})
+	mdCtx := metadata.NewOutgoingContext(ctx, md)
+	suite.T().Run("OK", func(t *testing.T) {
+		suite.db.EXPECT().
+			GetAPIKey(gomock.Any(), auth.HashAPIKey("key")).
+			Return(storage.APIKey{UserID: 42}, nil)
+		suite.db.EXPECT().
+			AddURL(gomock.Any(), "http://qwerty.com", gomock.Any(), uint32(42), gomock.Any()).
+			Return(nil)
+		in := &pb.GetShortURLRequest{Url: "http://qwerty.com"}
+		_, err := client.GetShortURL(mdCtx, in)
+		suite.NoError(err)
+	})
+
+	suite.T().Run("Stream", func(t *testing.T) {
+		suite.db.EXPECT().
+			GetAPIKey(gomock.Any(), auth.HashAPIKey("key")).
+			Return(storage.APIKey{UserID: 42}, nil)
+		suite.db.EXPECT().
+			GetURLsByUser(gomock.Any(), uint32(42)).
+			Return([]storage.Record{{URL: "testURL", URLID: "testURLID"}}, nil)
+		out, err := client.GetOriginalURLs(mdCtx, &pb.GetOriginalURLsRequest{})
+		suite.Require().NoError(err)
+		o, err := out.Recv()
+		suite.NoError(err)
+		suite.Equal("testURLID", o.GetUrlId())
+	})
+
+	suite.T().Run("Invalid", func(t *testing.T) {
+		suite.db.EXPECT().
+			GetAPIKey(gomock.Any(), auth.HashAPIKey("key")).
+			Return(storage.APIKey{}, storage.ErrAPIKeyNotFound)
+		in := &pb.GetShortURLRequest{Url: "http://qwerty.com"}
+		_, err := client.GetShortURL(mdCtx, in)
+		suite.Equal(codes.Unauthenticated, status.Code(err))
+	})
+}
+
+func (suite *GRPCTestSuite) TestRefreshToken() {
+	ctx := context.Background()
+	client, closer := suite.server(ctx)
+	defer closer()
+	// the token expires soon
+	token, err := auth.NewTokens(&auth.TokensConfig{
+		Keyring: tokensCfg.Keyring,
+		TTL:     30 * time.Second,
+	}).Issue(42)
+	suite.Require().NoError(err)
+	md := metadata.New(map[string]string{"UserToken": token})
+	mdCtx := metadata.NewOutgoingContext(ctx, md)
+	suite.db.EXPECT().
+		AddURL(gomock.Any(), "http://qwerty.com", gomock.Any(), uint32(42), gomock.Any()).
+		Return(nil)
+	var header metadata.MD
+	_, err = client.GetShortURL(
+		mdCtx,
+		&pb.GetShortURLRequest{Url: "http://qwerty.com"},
+		grpc.Header(&header),
+	)
+	suite.Require().NoError(err)
+	refreshed := header.Get(UserTokenMDName)
+	suite.Require().Len(refreshed, 1)
+	claims, err := auth.NewTokens(tokensCfg).Verify(refreshed[0])
+	suite.Require().NoError(err)
+	suite.Equal(uint32(42), claims.UserID)
+}
+
+func (suite *GRPCTestSuite) TestUserToken() {
+	ctx := context.Background()
+	client, closer := suite.server(ctx)
+	defer closer()
+	tokens := auth.NewTokens(tokensCfg)
+
+	suite.T().Run("New", func(t *testing.T) {
+		var userID uint32
+		suite.db.EXPECT().
+			AddURL(gomock.Any(), "http://qwerty.com", gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _, _ string, id uint32, _ storage.LinkOptions) error {
+				userID = id
+				return nil
+			})
+		var header metadata.MD
+		_, err := client.GetShortURL(
+			ctx,
+			&pb.GetShortURLRequest{Url: "http://qwerty.com"},
+			grpc.Header(&header),
+		)
+		suite.Require().NoError(err)
+		issued := header.Get(UserTokenMDName)
+		suite.Require().Len(issued, 1)
+		claims, err := tokens.Verify(issued[0])
+		suite.Require().NoError(err)
+		suite.Equal(userID, claims.UserID)
+
+		// the issued token identifies the same user
+		suite.db.EXPECT().
+			GetURLsByUser(gomock.Any(), userID).
+			Return([]storage.Record{{URL: "http://qwerty.com", URLID: "qwerty"}}, nil)
+		mdCtx := metadata.AppendToOutgoingContext(ctx, UserTokenMDName, issued[0])
+		out, err := client.GetOriginalURLs(mdCtx, &pb.GetOriginalURLsRequest{})
+		suite.Require().NoError(err)
+		o, err := out.Recv()
+		suite.NoError(err)
+		suite.Equal("qwerty", o.GetUrlId())
+	})
+
+	suite.T().Run("Invalid", func(t *testing.T) {
+		expired, err := auth.NewTokens(&auth.TokensConfig{
+			Keyring: tokensCfg.Keyring,
+			TTL:     -time.Second,
+		}).Issue(42)
+		suite.Require().NoError(err)
+		forged, err := auth.NewTokens(&auth.TokensConfig{
+			Keyring: auth.NewKeyring([]byte("forged")),
+			TTL:     time.Hour,
+		}).Issue(42)
+		suite.Require().NoError(err)
+		for name, token := range map[string]string{
+			"garbage": "qwerty",
+			"expired": expired,
+			"forged":  forged,
+			// an ID with a wrong signature in the older format
+			"legacy": "0000002a" + strings.Repeat("00", 32),
+		} {
+			mdCtx := metadata.AppendToOutgoingContext(ctx, UserTokenMDName, token)
+			_, err := client.GetShortURL(mdCtx, &pb.GetShortURLRequest{Url: "http://qwerty.com"})
+			suite.Equal(codes.Unauthenticated, status.Code(err), name)
+
+			out, err := client.GetOriginalURLs(mdCtx, &pb.GetOriginalURLsRequest{})
+			suite.Require().NoError(err)
+			_, err = out.Recv()
+			suite.Equal(codes.Unauthenticated, status.Code(err), name)
+		}
+	})
+}
+
+// fakeServerStream is a server stream with the given context.
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (f *fakeServerStream) Context() context.Context {
+	return f.ctx
+}
+
+func TestStreamInterceptorKeepsMetadata(t *testing.T) {
+	tokens := auth.NewTokens(tokensCfg)
+	srv := NewShortyServer(nil, nil, "http://localhost:8080", tokens, nil)
+	token, err := tokens.Issue(42)
+	require.NoError(t, err)
+	md := metadata.Pairs(UserTokenMDName, token, "x-real-ip", "192.168.0.1")
+	stream := &fakeServerStream{ctx: metadata.NewIncomingContext(context.Background(), md)}
+	err = srv.userTokenStreamInterceptor(
+		srv,
+		stream,
+		&grpc.StreamServerInfo{},
+		func(_ any, stream grpc.ServerStream) error {
+			userID, err := getUserID(stream.Context())
+			require.NoError(t, err)
+			assert.Equal(t, uint32(42), userID)
+			md, _ := metadata.FromIncomingContext(stream.Context())
+			assert.Equal(t, []string{"192.168.0.1"}, md.Get("x-real-ip"))
+			return nil
+		},
+	)
+	assert.NoError(t, err)
+}
+
+func TestRejectedURL(t *testing.T) {
+	p, err := policy.New(&policy.Config{Schemes: []string{"http", "https"}}, net.DefaultResolver)
+	require.NoError(t, err)
+	defer p.Close()
+	require.NoError(t, p.UpdateList(policy.BlockList, []string{"phishing.com"}, nil))
+	svc := service.NewShortener(nil, shorten.HashGenerator{}, nil, nil, &service.Config{Policy: p})
+	srv := NewShortyServer(svc, nil, "http://localhost:8080", auth.NewTokens(tokensCfg), nil)
+	ctx := context.WithValue(context.Background(), userIDCtxKey{}, uint32(42))
+
+	_, err = srv.GetShortURL(ctx, &pb.GetShortURLRequest{Url: "https://login.phishing.com/"})
+	st := status.Convert(err)
+	assert.Equal(t, codes.InvalidArgument, st.Code())
+	require.Len(t, st.Details(), 1)
+	info, ok := st.Details()[0].(*errdetails.ErrorInfo)
+	require.True(t, ok)
+	assert.Equal(t, string(policy.ReasonBlockedDomain), info.Reason)
+	assert.Equal(t, "https://login.phishing.com/", info.Metadata["url"])
+}
+
+func TestClientInfo(t *testing.T) {
+	proxies := realip.NewProxies([]string{"10.0.0.0/8"})
+	md := metadata.Pairs("x-real-ip", "203.0.113.7")
+	withPeer := func(addr net.Addr) context.Context {
+		ctx := metadata.NewIncomingContext(context.Background(), md)
+		return peer.NewContext(ctx, &peer.Peer{Addr: addr})
+	}
+	tests := []struct {
+		name string
+		addr net.Addr
+		want string
+	}{
+		{"trusted proxy", &net.TCPAddr{IP: net.ParseIP("10.1.2.3"), Port: 5000}, "203.0.113.7"},
+		{"untrusted client", &net.TCPAddr{IP: net.ParseIP("198.51.100.1"), Port: 5000}, "198.51.100.1"},
+		{"gateway", bufconn.Listen(1).Addr(), "203.0.113.7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, ip := clientInfo(withPeer(tt.addr), proxies)
+			assert.Equal(t, tt.want, ip.String())
+		})
 	}
-	ctx, cancel := context.WithCancel(context.Background())
-	go RunGRPCServer(ctx, cfg)
-	cancel()
+}
+
+func TestGetStatsUntrustedPeer(t *testing.T) {
+	conf, err := service.GetConfig(&config.ServerConfig{TrustedSubnet: "192.168.0.0/24"})
+	require.NoError(t, err)
+	srv := NewShortyServer(
+		service.NewShortener(nil, shorten.HashGenerator{}, nil, nil, conf),
+		nil,
+		"http://localhost:8080",
+		auth.NewTokens(tokensCfg),
+		realip.NewProxies([]string{"10.0.0.0/8"}),
+	)
+	ctx := metadata.NewIncomingContext(
+		context.Background(),
+		metadata.Pairs("x-real-ip", "192.168.0.1"),
+	)
+	ctx = peer.NewContext(ctx, &peer.Peer{
+		Addr: &net.TCPAddr{IP: net.ParseIP("198.51.100.1"), Port: 5000},
+	})
+	_, err = srv.GetStats(ctx, new(pb.GetStatsRequest))
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
 }
 
 func TestGRPCTestSuite(t *testing.T) {
@@ -0,0 +1,161 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/blokhinnv/shorty/internal/app/server/http/routes/middleware"
+	"github.com/blokhinnv/shorty/internal/app/service"
+)
+
+// CredentialsJSONRequest - the body of the account requests.
+type CredentialsJSONRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// AccountJSONResponse - the account in the responses.
+type AccountJSONResponse struct {
+	UserID   uint32 `json:"user_id"`
+	Username string `json:"username"`
+	// how many URLs of the cookie user were passed to the account
+	Claimed *int `json:"claimed,omitempty"`
+}
+
+// APIKeyJSONResponse - the response with a new API key.
+type APIKeyJSONResponse struct {
+	APIKey string `json:"api_key"`
+}
+
+// Accounts is a structure for the handlers of the accounts.
+type Accounts struct {
+	accounts *service.Accounts
+	auth     *middleware.Auth
+}
+
+// NewAccounts - constructor for Accounts.
+func NewAccounts(accounts *service.Accounts, auth *middleware.Auth) *Accounts {
+	return &Accounts{accounts: accounts, auth: auth}
+}
+
+// decodeCredentials reads the credentials from the body of the request.
+func decodeCredentials(w http.ResponseWriter, r *http.Request) (CredentialsJSONRequest, bool) {
+	var req CredentialsJSONRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "can't decode body", http.StatusBadRequest)
+		return req, false
+	}
+	return req, true
+}
+
+// accountError writes the error of the accounts service.
+func accountError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidArgument):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, service.ErrUnauthenticated):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	case errors.Is(err, service.ErrConflict):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// writeJSON writes the response with the status.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// Register - implementation of the POST /api/user/register endpoint.
+func (h *Accounts) Register(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	req, ok := decodeCredentials(w, r)
+	if !ok {
+		return
+	}
+	user, err := h.accounts.Register(ctx, req.Username, req.Password)
+	if err != nil {
+		accountError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, AccountJSONResponse{UserID: user.UserID, Username: user.Username})
+}
+
+// Login - implementation of the POST /api/user/login endpoint.
+// Sets the cookie of the account.
+func (h *Accounts) Login(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	req, ok := decodeCredentials(w, r)
+	if !ok {
+		return
+	}
+	user, err := h.accounts.Login(ctx, req.Username, req.Password)
+	if err != nil {
+		accountError(w, err)
+		return
+	}
+	if err := h.auth.SignIn(w, user.UserID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, AccountJSONResponse{UserID: user.UserID, Username: user.Username})
+}
+
+// CreateAPIKey - implementation of the POST /api/user/keys endpoint.
+// The key is returned once, only its hash is stored.
+func (h *Accounts) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	req, ok := decodeCredentials(w, r)
+	if !ok {
+		return
+	}
+	key, err := h.accounts.CreateAPIKey(ctx, req.Username, req.Password)
+	if err != nil {
+		accountError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, APIKeyJSONResponse{APIKey: key})
+}
+
+// Claim - implementation of the POST /api/user/claim endpoint.
+// Passes the URLs of the cookie user to the account and sets the cookie of the account.
+func (h *Accounts) Claim(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	userID, ok := ctx.Value(middleware.UserIDCtxKey).(uint32)
+	if !ok {
+		http.Error(
+			w,
+			"no user id provided",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+	req, ok := decodeCredentials(w, r)
+	if !ok {
+		return
+	}
+	user, n, err := h.accounts.Claim(ctx, userID, req.Username, req.Password)
+	if err != nil {
+		accountError(w, err)
+		return
+	}
+	if err := h.auth.SignIn(w, user.UserID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(
+		w,
+		http.StatusOK,
+		AccountJSONResponse{UserID: user.UserID, Username: user.Username, Claimed: &n},
+	)
+}
@@ -0,0 +1,256 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/blokhinnv/shorty/internal/app/server/auth"
+	"github.com/blokhinnv/shorty/internal/app/server/config"
+	"github.com/blokhinnv/shorty/internal/app/server/http/routes/middleware"
+	"github.com/blokhinnv/shorty/internal/app/service"
+	"github.com/blokhinnv/shorty/internal/app/storage"
+)
+
+// the ID of the account in the tests
+const accountID uint32 = 42
+
+type AccountsSuite struct {
+	suite.Suite
+	ctrl   *gomock.Controller
+	db     *storage.MockStorage
+	tokens *auth.Tokens
+	router chi.Router
+}
+
+func (suite *AccountsSuite) SetupTest() {
+	suite.ctrl = gomock.NewController(suite.T())
+	suite.db = storage.NewMockStorage(suite.ctrl)
+	accounts := service.NewAccounts(suite.db)
+	suite.tokens = auth.NewTokens(&auth.TokensConfig{
+		Keyring:       auth.NewKeyring([]byte("shorty")),
+		TTL:           time.Hour,
+		RefreshBefore: time.Minute,
+	})
+	authentifier := middleware.NewAuth(suite.tokens, accounts)
+	h := NewAccounts(accounts, authentifier)
+	suite.router = chi.NewRouter()
+	suite.router.Use(middleware.BaseURLCtx(&config.ServerConfig{BaseURL: "http://localhost:8080"}))
+	suite.router.Use(authentifier.Handler)
+	suite.router.Post("/api/user/register", h.Register)
+	suite.router.Post("/api/user/login", h.Login)
+	suite.router.Post("/api/user/keys", h.CreateAPIKey)
+	suite.router.Post("/api/user/claim", h.Claim)
+	suite.router.Get("/api/user/urls", GetOriginalURLsHandlerFunc(newTestService(suite.db)))
+}
+
+func (suite *AccountsSuite) TearDownTest() {
+	suite.ctrl.Finish()
+}
+
+// account returns the stored account with the password.
+func (suite *AccountsSuite) account(password string) storage.User {
+	hash, err := auth.HashPassword(password)
+	suite.Require().NoError(err)
+	return storage.User{UserID: accountID, Username: "user", PasswordHash: hash}
+}
+
+func (suite *AccountsSuite) makeRequest(
+	method, target, body string,
+	header http.Header,
+) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	suite.router.ServeHTTP(rr, req)
+	return rr
+}
+
+func (suite *AccountsSuite) TestRegister() {
+	suite.db.EXPECT().AddUser(gomock.Any(), gomock.Any()).Return(nil)
+	rr := suite.makeRequest(
+		http.MethodPost,
+		"/api/user/register",
+		`{"username": "user", "password": "password"}`,
+		nil,
+	)
+	suite.Equal(http.StatusCreated, rr.Code)
+	var resp AccountJSONResponse
+	suite.NoError(json.NewDecoder(rr.Body).Decode(&resp))
+	suite.Equal("user", resp.Username)
+	suite.NotContains(rr.Body.String(), "password")
+}
+
+func (suite *AccountsSuite) TestRegisterTaken() {
+	suite.db.EXPECT().AddUser(gomock.Any(), gomock.Any()).Return(storage.ErrUsernameTaken)
+	rr := suite.makeRequest(
+		http.MethodPost,
+		"/api/user/register",
+		`{"username": "user", "password": "password"}`,
+		nil,
+	)
+	suite.Equal(http.StatusConflict, rr.Code)
+}
+
+func (suite *AccountsSuite) TestRegisterInvalid() {
+	for _, body := range []string{
+		`{"username": "user", "password": "short"}`,
+		`{"username": "u", "password": "password"}`,
+		`not a json`,
+	} {
+		rr := suite.makeRequest(http.MethodPost, "/api/user/register", body, nil)
+		suite.Equal(http.StatusBadRequest, rr.Code, body)
+	}
+}
+
+func (suite *AccountsSuite) TestLogin() {
+	suite.db.EXPECT().GetUser(gomock.Any(), "user").Return(suite.account("password"), nil)
+	rr := suite.makeRequest(
+		http.MethodPost,
+		"/api/user/login",
+		`{"username": "user", "password": "password"}`,
+		nil,
+	)
+	suite.Equal(http.StatusOK, rr.Code)
+	cookies := rr.Result().Cookies()
+	suite.Require().NotEmpty(cookies)
+	last := cookies[len(cookies)-1]
+	suite.Equal(middleware.UserTokenCookieName, last.Name)
+	claims, err := suite.tokens.Verify(last.Value)
+	suite.Require().NoError(err)
+	suite.Equal(accountID, claims.UserID)
+}
+
+func (suite *AccountsSuite) TestLoginWrongPassword() {
+	suite.db.EXPECT().GetUser(gomock.Any(), "user").Return(suite.account("password"), nil)
+	rr := suite.makeRequest(
+		http.MethodPost,
+		"/api/user/login",
+		`{"username": "user", "password": "wrong password"}`,
+		nil,
+	)
+	suite.Equal(http.StatusUnauthorized, rr.Code)
+
+	suite.db.EXPECT().GetUser(gomock.Any(), "nobody").Return(storage.User{}, storage.ErrUserNotFound)
+	rr = suite.makeRequest(
+		http.MethodPost,
+		"/api/user/login",
+		`{"username": "nobody", "password": "password"}`,
+		nil,
+	)
+	suite.Equal(http.StatusUnauthorized, rr.Code)
+}
+
+func (suite *AccountsSuite) TestCreateAPIKey() {
+	suite.db.EXPECT().GetUser(gomock.Any(), "user").Return(suite.account("password"), nil)
+	var stored storage.APIKey
+	suite.db.EXPECT().AddAPIKey(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ any, key storage.APIKey) error {
+			stored = key
+			return nil
+		},
+	)
+	rr := suite.makeRequest(
+		http.MethodPost,
+		"/api/user/keys",
+		`{"username": "user", "password": "password"}`,
+		nil,
+	)
+	suite.Equal(http.StatusCreated, rr.Code)
+	var resp APIKeyJSONResponse
+	suite.NoError(json.NewDecoder(rr.Body).Decode(&resp))
+	suite.NotEmpty(resp.APIKey)
+	// only the hash of the key is stored
+	suite.Equal(auth.HashAPIKey(resp.APIKey), stored.KeyHash)
+	suite.Equal(accountID, stored.UserID)
+}
+
+func (suite *AccountsSuite) TestClaim() {
+	token, err := suite.tokens.Issue(userID)
+	suite.Require().NoError(err)
+	suite.db.EXPECT().GetUser(gomock.Any(), "user").Return(suite.account("password"), nil)
+	suite.db.EXPECT().MoveURLs(gomock.Any(), userID, accountID).Return(3, nil)
+	rr := suite.makeRequest(
+		http.MethodPost,
+		"/api/user/claim",
+		`{"username": "user", "password": "password"}`,
+		http.Header{
+			"Cookie": {middleware.UserTokenCookieName + "=" + token},
+		},
+	)
+	suite.Equal(http.StatusOK, rr.Code)
+	var resp AccountJSONResponse
+	suite.NoError(json.NewDecoder(rr.Body).Decode(&resp))
+	suite.Require().NotNil(resp.Claimed)
+	suite.Equal(3, *resp.Claimed)
+	suite.Equal(accountID, resp.UserID)
+}
+
+func (suite *AccountsSuite) TestBearer() {
+	suite.db.EXPECT().
+		GetAPIKey(gomock.Any(), auth.HashAPIKey("key")).
+		Return(storage.APIKey{UserID: accountID}, nil)
+	suite.db.EXPECT().
+		GetURLsByUser(gomock.Any(), accountID).
+		Return([]storage.Record{{URL: "http://yandex.ru", URLID: "qwerty"}}, nil)
+	rr := suite.makeRequest(
+		http.MethodGet,
+		"/api/user/urls",
+		"",
+		http.Header{"Authorization": {"Bearer key"}},
+	)
+	suite.Equal(http.StatusOK, rr.Code)
+	// the API key replaces the cookie
+	suite.Empty(rr.Result().Cookies())
+}
+
+func (suite *AccountsSuite) TestBearerInvalid() {
+	suite.db.EXPECT().
+		GetAPIKey(gomock.Any(), auth.HashAPIKey("key")).
+		Return(storage.APIKey{}, storage.ErrAPIKeyNotFound)
+	rr := suite.makeRequest(
+		http.MethodGet,
+		"/api/user/urls",
+		"",
+		http.Header{"Authorization": {"Bearer key"}},
+	)
+	suite.Equal(http.StatusUnauthorized, rr.Code)
+}
+
+func (suite *AccountsSuite) TestRefreshToken() {
+	// the token expires soon
+	token, err := auth.NewTokens(&auth.TokensConfig{
+		Keyring: auth.NewKeyring([]byte("shorty")),
+		TTL:     30 * time.Second,
+	}).Issue(userID)
+	suite.Require().NoError(err)
+	suite.db.EXPECT().
+		GetURLsByUser(gomock.Any(), userID).
+		Return([]storage.Record{{URL: "http://yandex.ru", URLID: "qwerty"}}, nil)
+	rr := suite.makeRequest(
+		http.MethodGet,
+		"/api/user/urls",
+		"",
+		http.Header{"Cookie": {middleware.UserTokenCookieName + "=" + token}},
+	)
+	suite.Equal(http.StatusOK, rr.Code)
+	cookies := rr.Result().Cookies()
+	suite.Require().Len(cookies, 1)
+	claims, err := suite.tokens.Verify(cookies[0].Value)
+	suite.Require().NoError(err)
+	suite.Equal(userID, claims.UserID)
+	suite.False(suite.tokens.NeedsRefresh(claims))
+}
+
+func TestAccountsSuite(t *testing.T) {
+	suite.Run(t, new(AccountsSuite))
+}
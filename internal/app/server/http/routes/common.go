@@ -7,18 +7,42 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/blokhinnv/shorty/internal/app/policy"
 	"github.com/blokhinnv/shorty/internal/app/server/http/routes/middleware"
+	"github.com/blokhinnv/shorty/internal/app/service"
 	"github.com/blokhinnv/shorty/internal/app/shorten"
-	"github.com/blokhinnv/shorty/internal/app/storage"
 )
 
+// RejectionJSONResponse - the body of the response for a URL rejected by the policy.
+type RejectionJSONResponse struct {
+	Error  string        `json:"error"`
+	Reason policy.Reason `json:"reason"`
+	URL    string        `json:"url"`
+}
+
+// shortenError writes the error of shortening. The rejections of the policy
+// are written as JSON with the reason.
+func shortenError(w http.ResponseWriter, err error, status int) {
+	var rejected *policy.RejectedError
+	if errors.As(err, &rejected) {
+		writeJSON(w, status, RejectionJSONResponse{
+			Error:  rejected.Error(),
+			Reason: rejected.Reason,
+			URL:    rejected.URL,
+		})
+		return
+	}
+	http.Error(w, err.Error(), status)
+}
+
 // shortenURLLogic - general logic for URL shortening. Used in several
 // handlers.
 func shortenURLLogic(
 	ctx context.Context,
 	w http.ResponseWriter,
-	s storage.Storage,
+	svc *service.Shortener,
 	longURL string,
+	opts service.ShortenOptions,
 ) (string, int, error) {
 	baseURL, ok := ctx.Value(middleware.BaseURLCtxKey).(string)
 	if !ok {
@@ -43,19 +67,19 @@ func shortenURLLogic(
 		return "", http.StatusInternalServerError, fmt.Errorf("no user id provided")
 	}
 
-	shortURLID, shortenURL, err := shorten.GetShortURL(longURL, userID, baseURL)
-	if err != nil {
-		return "", http.StatusBadRequest, err
-	}
-	err = s.AddURL(ctx, longURL, shortURLID, userID)
+	shortURLID, err := svc.Shorten(ctx, userID, longURL, opts)
 	status := http.StatusCreated
 	if err != nil {
-		if errors.Is(err, storage.ErrUniqueViolation) {
+		switch {
+		// the alias may be taken by another URL,
+		// so the short URL can't be returned
+		case errors.Is(err, service.ErrConflict) && shortURLID != "":
 			status = http.StatusConflict
-		} else {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, service.ErrConflict):
+			return "", http.StatusConflict, err
+		default:
 			return "", http.StatusBadRequest, err
 		}
 	}
-	return shortenURL, status, nil
+	return shorten.ShortURL(baseURL, shortURLID), status, nil
 }
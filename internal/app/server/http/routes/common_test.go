@@ -7,10 +7,15 @@ import (
 	"net/http/httptest"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/blokhinnv/shorty/internal/app/deletion"
 	"github.com/blokhinnv/shorty/internal/app/log"
 
 	"github.com/blokhinnv/shorty/internal/app/server/config"
+	"github.com/blokhinnv/shorty/internal/app/service"
+	"github.com/blokhinnv/shorty/internal/app/shorten"
+	"github.com/blokhinnv/shorty/internal/app/storage"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-resty/resty/v2"
 	"github.com/joho/godotenv"
@@ -22,6 +27,9 @@ const (
 	userToken string = "a5d08c82f3815eefe7f496d9652d8a041031e6a7f89d6bb2c90e1dfc335826e5a22255c8"
 )
 
+// aliasCfg - alias settings used in tests.
+var aliasCfg = shorten.GetAliasConfig(&config.ServerConfig{AliasMinLength: 3, AliasMaxLength: 64})
+
 // Settings for blocking the redirect.
 var (
 	errRedirectBlocked = errors.New("HTTP redirect blocked")
@@ -92,6 +100,32 @@ func NewServerWithPort(r chi.Router, host, port string) *httptest.Server {
 	return ts
 }
 
+// newTestService - constructor for a service with the hash generator
+// and its own deletion queue.
+func newTestService(s storage.Storage) *service.Shortener {
+	return service.NewShortener(
+		s,
+		shorten.HashGenerator{},
+		deletion.NewQueue(s, 100, 100*time.Millisecond),
+		nil,
+		&service.Config{AliasCfg: aliasCfg},
+	)
+}
+
+// newTestRouter - constructor for a router with its own service.
+func newTestRouter(s storage.Storage, cfg *config.ServerConfig) chi.Router {
+	gen, err := shorten.NewIDGenerator(cfg, s)
+	if err != nil {
+		log.Fatal(err)
+	}
+	conf, err := service.GetConfig(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	svc := service.NewShortener(s, gen, deletion.NewQueue(s, 100, 100*time.Millisecond), nil, conf)
+	return NewRouter(svc, service.NewAccounts(s), nil, nil, cfg)
+}
+
 // IPToLocalhost is a helper function that replaces 127.0.0.1 with localhost.
 func IPToLocalhost(addr string) string {
 	return strings.Replace(addr, "127.0.0.1", "localhost", -1)
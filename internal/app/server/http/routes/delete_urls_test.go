@@ -11,7 +11,9 @@ import (
 	"time"
 
 	db "github.com/blokhinnv/shorty/internal/app/database"
+	"github.com/blokhinnv/shorty/internal/app/deletion"
 	"github.com/blokhinnv/shorty/internal/app/server/http/routes/middleware"
+	"github.com/blokhinnv/shorty/internal/app/service"
 	"github.com/blokhinnv/shorty/internal/app/shorten"
 	"github.com/blokhinnv/shorty/internal/app/storage"
 	"github.com/go-resty/resty/v2"
@@ -32,7 +34,7 @@ type DeleteURLSuite struct {
 func (suite *DeleteURLSuite) SetupSuite() {
 	suite.ctrl = gomock.NewController(suite.T())
 	suite.db = storage.NewMockStorage(suite.ctrl)
-	suite.handler = NewDeleteURLsHandler(suite.db, 100, make(chan struct{}))
+	suite.handler = NewDeleteURLsHandler(newTestService(suite.db))
 	suite.handlerFunc = suite.handler.Handler
 }
 
@@ -53,14 +55,17 @@ func (suite *DeleteURLSuite) IntTestLogic(testCfg TestConfig) {
 		s.Clear(context.Background())
 		s.Close(context.Background())
 	}()
-	r := NewRouter(s, testCfg.serverCfg, make(chan struct{}))
+	r := newTestRouter(s, testCfg.serverCfg)
 
 	ts := NewServerWithPort(r, testCfg.host, testCfg.port)
 	defer ts.Close()
 	// Preparation for the test: create storage, reduce
 	// one URL, check that everything passed without errors
 	longURL := "https://practicum.yandex.ru/learn/go-advanced/"
-	shortURLID, shortURL, err := shorten.GetShortURL(longURL, userID, testCfg.baseURL)
+	// the ID is derived from the canonical form of the URL
+	canonicalURL, err := shorten.GetCanonicalConfig(testCfg.serverCfg).Canonicalize(longURL)
+	require.NoError(t, err)
+	shortURLID, shortURL, err := shorten.GetShortURL(canonicalURL, userID, testCfg.baseURL)
 	require.NoError(t, err)
 
 	client := resty.New()
@@ -186,10 +191,33 @@ func (suite *DeleteURLSuite) TestIntText() {
 }
 
 func (suite *DeleteURLSuite) TestDeleteURLs() {
+	queue := deletion.NewQueue(suite.db, 100, 10*time.Millisecond)
+	defer queue.Close()
+	svc := service.NewShortener(
+		suite.db,
+		shorten.HashGenerator{},
+		queue,
+		nil,
+		&service.Config{AliasCfg: aliasCfg},
+	)
+	deleted := make(chan struct{})
 	suite.db.EXPECT().
 		DeleteMany(gomock.Any(), uint32(1), []string{"qwe"}).
-		Return(fmt.Errorf("error..."))
-	suite.handler.deleteURLs([]Job{{"qwe", uint32(1)}})
+		DoAndReturn(func(context.Context, uint32, []string) error {
+			close(deleted)
+			return fmt.Errorf("error...")
+		})
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodDelete, "/user/urls", strings.NewReader(`["qwe"]`))
+	ctx := context.WithValue(req.Context(), middleware.UserIDCtxKey, uint32(1))
+	NewDeleteURLsHandler(svc).Handler(rr, req.WithContext(ctx))
+	// the error of the storage doesn't reach the client
+	suite.Equal(http.StatusAccepted, rr.Code)
+	select {
+	case <-deleted:
+	case <-time.After(time.Second):
+		suite.Fail("the URLs were not deleted")
+	}
 }
 
 func (suite *DeleteURLSuite) TestUnreadable() {
@@ -229,7 +257,7 @@ func ExampleDeleteURLsHandler_Handler() {
 		Times(1).
 		Return(storage.Record{URL: "https://practicum.yandex.ru/learn/"}, nil)
 	// setup request ...
-	handler := NewDeleteURLsHandler(s, 10, make(chan struct{}))
+	handler := NewDeleteURLsHandler(newTestService(s))
 	rr := httptest.NewRecorder()
 	body := bytes.NewBuffer([]byte(`["rb1t0eupmn2_"]`))
 	req, _ := http.NewRequest(http.MethodDelete, "/user/urls", body)
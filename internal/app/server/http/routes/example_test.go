@@ -18,9 +18,9 @@ func Example() {
 	t := new(testing.T)
 	ctrl := gomock.NewController(t)
 	s := storage.NewMockStorage(ctrl)
-	s.EXPECT().AddURL(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(nil)
+	s.EXPECT().AddURL(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(nil)
 	// setup request ...
-	handler := GetShortURLHandlerFunc(s)
+	handler := GetShortURLHandlerFunc(newTestService(s))
 	rr := httptest.NewRecorder()
 	body := bytes.NewBuffer([]byte("https://practicum.yandex.ru/learn/"))
 	req, _ := http.NewRequest(http.MethodPost, "/", body)
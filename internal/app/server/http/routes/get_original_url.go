@@ -5,40 +5,91 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"regexp"
+	"strings"
 	"time"
 
+	"github.com/blokhinnv/shorty/internal/app/server/http/routes/middleware"
+	"github.com/blokhinnv/shorty/internal/app/service"
+	"github.com/blokhinnv/shorty/internal/app/shorten"
 	"github.com/blokhinnv/shorty/internal/app/storage"
 )
 
+const (
+	// previewSuffix - the suffix of the short URL ID which asks for the preview page.
+	previewSuffix = "+"
+	// permanentRedirectMaxAge - how long the clients may cache the permanent redirects.
+	permanentRedirectMaxAge = 5 * time.Minute
+)
+
+// redirectCacheControl returns the Cache-Control header of the redirect.
+// The permanent redirects are cached by the client for a short time and no longer
+// than the link lives, so a deleted or expired link stops working soon.
+// The temporary ones are not cached, so every visit gets to the server and is recorded.
+func redirectCacheControl(code int, expiresAt time.Time, now time.Time) string {
+	if !shorten.IsPermanent(code) {
+		return "no-store"
+	}
+	maxAge := permanentRedirectMaxAge
+	if !expiresAt.IsZero() && expiresAt.Sub(now) < maxAge {
+		maxAge = expiresAt.Sub(now)
+	}
+	if maxAge < 0 {
+		maxAge = 0
+	}
+	return fmt.Sprintf("private, max-age=%d", int(maxAge.Seconds()))
+}
+
 // GetOriginalURLHandlerFunc - implementation of the GET /{id} endpoint.
 // Accepts an identifier as a URL parameter
 // shortened URL and returns the response
-// with code 307 and original URL in Location HTTP header.
-func GetOriginalURLHandlerFunc(s storage.Storage) func(http.ResponseWriter, *http.Request) {
+// with the redirect code of the link (307 by default)
+// and original URL in Location HTTP header.
+// The ID ending with "+" and the links which warn before redirect
+// get the preview page instead of the redirect.
+// Each successful redirect is passed to the click recorder,
+// the HEAD requests are answered without a body and are not recorded.
+func GetOriginalURLHandlerFunc(svc *service.Shortener) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 		defer cancel()
-		// Check if the URL looks like it should
-		re := regexp.MustCompile(`^/\w+$`)
-		if !re.MatchString(r.URL.String()) {
-			http.Error(w, "Incorrent GET request", http.StatusBadRequest)
-			return
-		}
 		// Grab the URL ID from the address bar
-		urlID := r.URL.String()[1:]
-		rec, err := s.GetURLByID(ctx, urlID)
+		urlID := strings.TrimPrefix(r.URL.String(), "/")
+		preview := strings.HasSuffix(urlID, previewSuffix)
+		urlID = strings.TrimSuffix(urlID, previewSuffix)
+		var rec storage.Record
+		var err error
+		if r.Method == http.MethodHead {
+			rec, err = svc.Lookup(ctx, urlID)
+		} else {
+			rec, err = svc.Resolve(
+				ctx,
+				urlID,
+				service.Visit{Referrer: r.Referer(), UserAgent: r.UserAgent(), IP: middleware.ClientIP(r)},
+			)
+		}
 		if err != nil {
-			if errors.Is(err, storage.ErrURLWasDeleted) {
+			switch {
+			case errors.Is(err, service.ErrInvalidArgument):
+				http.Error(w, "Incorrent GET request", http.StatusBadRequest)
+			case errors.Is(err, service.ErrGone):
 				http.Error(w, err.Error(), http.StatusGone)
-				return
+			default:
+				http.Error(w, err.Error(), http.StatusNoContent)
 			}
-			http.Error(w, err.Error(), http.StatusNoContent)
 			return
 		}
-		w.Header().Set("Location", rec.URL)
+		if preview || rec.WarnBeforeRedirect {
+			writeInterstitial(w, r, rec)
+			return
+		}
+		code := shorten.RedirectCode(rec)
+		// the canonical form only identifies the URL, the visitors go where the user asked
+		w.Header().Set("Location", rec.DisplayURL())
+		w.Header().Set("Cache-Control", redirectCacheControl(code, rec.ExpiresAt, time.Now()))
 		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
-		w.WriteHeader(http.StatusTemporaryRedirect)
-		w.Write([]byte(fmt.Sprintf("Original URL was %v\n", rec.URL)))
+		w.WriteHeader(code)
+		if r.Method != http.MethodHead {
+			w.Write([]byte(fmt.Sprintf("Original URL was %v\n", rec.DisplayURL())))
+		}
 	}
 }
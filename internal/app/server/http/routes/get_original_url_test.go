@@ -1,13 +1,18 @@
 package routes
 
 import (
+	"compress/gzip"
 	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/blokhinnv/shorty/internal/app/analytics"
 	db "github.com/blokhinnv/shorty/internal/app/database"
+	"github.com/blokhinnv/shorty/internal/app/service"
 	"github.com/blokhinnv/shorty/internal/app/shorten"
 	"github.com/blokhinnv/shorty/internal/app/storage"
 	"github.com/go-resty/resty/v2"
@@ -27,7 +32,7 @@ type OriginalURLSuite struct {
 func (suite *OriginalURLSuite) SetupSuite() {
 	suite.ctrl = gomock.NewController(suite.T())
 	suite.db = storage.NewMockStorage(suite.ctrl)
-	suite.handler = GetOriginalURLHandlerFunc(suite.db)
+	suite.handler = GetOriginalURLHandlerFunc(newTestService(suite.db))
 }
 
 func (suite *OriginalURLSuite) TearDownSuite() {
@@ -45,7 +50,7 @@ func (suite *OriginalURLSuite) IntTestLogic(testCfg TestConfig) {
 		s.Clear(context.Background())
 		s.Close(context.Background())
 	}()
-	r := NewRouter(s, testCfg.serverCfg, make(chan struct{}))
+	r := newTestRouter(s, testCfg.serverCfg)
 	ts := NewServerWithPort(r, testCfg.host, testCfg.port)
 	defer ts.Close()
 
@@ -54,16 +59,28 @@ func (suite *OriginalURLSuite) IntTestLogic(testCfg TestConfig) {
 	longURL := "https://practicum.yandex.ru/learn/go-advanced/"
 	shortURLID, shortURL, err := shorten.GetShortURL(longURL, userID, testCfg.baseURL)
 	require.NoError(t, err)
-	s.AddURL(context.Background(), longURL, shortURLID, userID)
+	s.AddURL(context.Background(), longURL, shortURLID, userID, storage.LinkOptions{})
+	warnLongURL := "https://practicum.yandex.ru/learn/go-basics"
+	warnURLID, warnURL, err := shorten.GetShortURL(warnLongURL, userID, testCfg.baseURL)
+	require.NoError(t, err)
+	s.AddURL(
+		context.Background(),
+		warnLongURL,
+		warnURLID,
+		userID,
+		storage.LinkOptions{WarnBeforeRedirect: true},
+	)
 
 	type want struct {
 		statusCode  int
 		location    string
 		contentType string
+		body        string
 	}
 	tests := []struct {
 		name     string
 		shortURL string
+		accept   string
 		want     want
 	}{
 		{
@@ -76,6 +93,38 @@ func (suite *OriginalURLSuite) IntTestLogic(testCfg TestConfig) {
 				contentType: "text/plain; charset=utf-8",
 			},
 		},
+		{
+			// the preview page instead of the redirect
+			name:     "test_preview",
+			shortURL: shortURL + "+",
+			want: want{
+				statusCode:  http.StatusOK,
+				contentType: "text/html; charset=utf-8",
+				body:        `href="https://practicum.yandex.ru/learn/go-advanced/"`,
+			},
+		},
+		{
+			// the API clients get the preview as JSON
+			name:     "test_preview_json",
+			shortURL: shortURL + "+",
+			accept:   "application/json",
+			want: want{
+				statusCode:  http.StatusOK,
+				contentType: "application/json; charset=utf-8",
+				body:        `"host":"practicum.yandex.ru"`,
+			},
+		},
+		{
+			// the link warns before redirect
+			name:     "test_warn_before_redirect",
+			shortURL: warnURL,
+			accept:   "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+			want: want{
+				statusCode:  http.StatusOK,
+				contentType: "text/html; charset=utf-8",
+				body:        `href="https://practicum.yandex.ru/learn/go-basics"`,
+			},
+		},
 		{
 			// invalid URL shortener ID
 			name:     "test_bad_url",
@@ -102,15 +151,47 @@ func (suite *OriginalURLSuite) IntTestLogic(testCfg TestConfig) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			client := resty.New().SetRedirectPolicy(NoRedirectPolicy)
-			res, err := client.R().Get(tt.shortURL)
+			req := client.R()
+			if tt.accept != "" {
+				req.SetHeader("Accept", tt.accept)
+			}
+			res, err := req.Get(tt.shortURL)
 			if err != nil {
 				assert.ErrorIs(t, err, errRedirectBlocked)
 			}
 			assert.Equal(t, tt.want.statusCode, res.StatusCode())
 			assert.Equal(t, tt.want.contentType, res.Header().Get("Content-Type"))
 			assert.Equal(t, tt.want.location, res.Header().Get("Location"))
+			assert.Contains(t, res.String(), tt.want.body)
 		})
 	}
+
+	t.Run("test_head", func(t *testing.T) {
+		client := resty.New().SetRedirectPolicy(NoRedirectPolicy)
+		res, err := client.R().Head(shortURL)
+		if err != nil {
+			assert.ErrorIs(t, err, errRedirectBlocked)
+		}
+		assert.Equal(t, http.StatusTemporaryRedirect, res.StatusCode())
+		assert.Equal(t, longURL, res.Header().Get("Location"))
+		assert.Empty(t, res.Body())
+	})
+
+	t.Run("test_preview_gzip", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, shortURL+"+", nil)
+		require.NoError(t, err)
+		req.Header.Set("Accept-Encoding", "gzip")
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		require.Equal(t, "gzip", res.Header.Get("Content-Encoding"))
+		gz, err := gzip.NewReader(res.Body)
+		require.NoError(t, err)
+		page, err := io.ReadAll(gz)
+		require.NoError(t, err)
+		assert.Contains(t, string(page), "practicum.yandex.ru")
+	})
 }
 
 // TestIntSQLite - run tests for SQLite.
@@ -140,6 +221,181 @@ func (suite *OriginalURLSuite) TestDeleted() {
 	suite.Equal(http.StatusGone, rr.Code)
 }
 
+func (suite *OriginalURLSuite) TestExpired() {
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/qwerty", nil)
+	suite.db.EXPECT().
+		GetURLByID(gomock.Any(), "qwerty").
+		Return(storage.Record{}, storage.ErrURLExpired)
+	suite.handler.ServeHTTP(rr, req)
+	suite.Equal(http.StatusGone, rr.Code)
+}
+
+func (suite *OriginalURLSuite) TestRecordsClick() {
+	recorder, err := analytics.NewRecorder(
+		suite.db,
+		&analytics.RecorderConfig{BufferSize: 10, FlushInterval: time.Hour},
+	)
+	suite.Require().NoError(err)
+	svc := service.NewShortener(suite.db, shorten.HashGenerator{}, nil, recorder, &service.Config{})
+	handler := GetOriginalURLHandlerFunc(svc)
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/qwerty", nil)
+	req.Header.Set("Referer", "http://ya.ru")
+	suite.db.EXPECT().
+		GetURLByID(gomock.Any(), "qwerty").
+		Return(storage.Record{URL: "http://yandex.ru"}, nil)
+	suite.db.EXPECT().
+		AddClicks(gomock.Any(), gomock.Len(1)).
+		Return(nil)
+	handler(rr, req)
+	suite.Equal(http.StatusTemporaryRedirect, rr.Code)
+	recorder.Close()
+}
+
+func (suite *OriginalURLSuite) TestPreview() {
+	rec := storage.Record{
+		URL:     "https://example.com/a?b=<c>",
+		URLID:   "qwerty",
+		Preview: &storage.Preview{Title: "Example <Domain>"},
+	}
+	suite.db.EXPECT().GetURLByID(gomock.Any(), "qwerty").Return(rec, nil)
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/qwerty+", nil)
+	suite.handler.ServeHTTP(rr, req)
+	suite.Equal(http.StatusOK, rr.Code)
+	suite.Empty(rr.Header().Get("Location"))
+	suite.Equal("Accept", rr.Header().Get("Vary"))
+	suite.Contains(rr.Body.String(), "Example &lt;Domain&gt;")
+	suite.Contains(rr.Body.String(), `href="https://example.com/a?b=%3cc%3e"`)
+
+	suite.db.EXPECT().GetURLByID(gomock.Any(), "qwerty").Return(rec, nil)
+	rr = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/qwerty+", nil)
+	req.Header.Set("Accept", "text/html;q=0.5, application/json")
+	suite.handler.ServeHTTP(rr, req)
+	suite.Equal(http.StatusOK, rr.Code)
+	suite.JSONEq(
+		`{"url_id":"qwerty","url":"https://example.com/a?b=<c>","host":"example.com",`+
+			`"preview":{"title":"Example <Domain>","fetched_at":"0001-01-01T00:00:00Z"}}`,
+		rr.Body.String(),
+	)
+}
+
+func (suite *OriginalURLSuite) TestRedirectCode() {
+	tests := []struct {
+		code         int
+		want         int
+		cacheControl string
+	}{
+		{code: 0, want: http.StatusTemporaryRedirect, cacheControl: "no-store"},
+		{code: http.StatusFound, want: http.StatusFound, cacheControl: "no-store"},
+		{code: http.StatusMovedPermanently, want: http.StatusMovedPermanently, cacheControl: "private, max-age=300"},
+		{code: http.StatusPermanentRedirect, want: http.StatusPermanentRedirect, cacheControl: "private, max-age=300"},
+	}
+	for _, tt := range tests {
+		suite.db.EXPECT().
+			GetURLByID(gomock.Any(), "qwerty").
+			Return(storage.Record{URL: "http://yandex.ru", RedirectCode: tt.code}, nil)
+		rr := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/qwerty", nil)
+		suite.handler.ServeHTTP(rr, req)
+		suite.Equal(tt.want, rr.Code)
+		suite.Equal("http://yandex.ru", rr.Header().Get("Location"))
+		suite.Equal(tt.cacheControl, rr.Header().Get("Cache-Control"))
+	}
+}
+
+func (suite *OriginalURLSuite) TestRedirectCacheControl() {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		code      int
+		expiresAt time.Time
+		want      string
+	}{
+		{name: "temporary", code: http.StatusTemporaryRedirect, want: "no-store"},
+		{name: "no expiration", code: http.StatusMovedPermanently, want: "private, max-age=300"},
+		{
+			name:      "expires later",
+			code:      http.StatusMovedPermanently,
+			expiresAt: now.Add(time.Hour),
+			want:      "private, max-age=300",
+		},
+		{
+			name:      "expires soon",
+			code:      http.StatusPermanentRedirect,
+			expiresAt: now.Add(90 * time.Second),
+			want:      "private, max-age=90",
+		},
+		{
+			name:      "expired",
+			code:      http.StatusPermanentRedirect,
+			expiresAt: now.Add(-time.Second),
+			want:      "private, max-age=0",
+		},
+	}
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			suite.Equal(tt.want, redirectCacheControl(tt.code, tt.expiresAt, now))
+		})
+	}
+}
+
+func (suite *OriginalURLSuite) TestRedirectToOriginalURL() {
+	const original = "https://Example.com/a/?utm_source=mail&b=2&a=1"
+	suite.db.EXPECT().
+		GetURLByID(gomock.Any(), "qwerty").
+		Return(storage.Record{URL: "https://example.com/a?a=1&b=2", OriginalURL: original}, nil)
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/qwerty", nil)
+	suite.handler.ServeHTTP(rr, req)
+	suite.Equal(http.StatusTemporaryRedirect, rr.Code)
+	// the canonical form only identifies the URL
+	suite.Equal(original, rr.Header().Get("Location"))
+}
+
+func (suite *OriginalURLSuite) TestHead() {
+	recorder, err := analytics.NewRecorder(
+		suite.db,
+		&analytics.RecorderConfig{BufferSize: 10, FlushInterval: time.Hour},
+	)
+	suite.Require().NoError(err)
+	svc := service.NewShortener(suite.db, shorten.HashGenerator{}, nil, recorder, &service.Config{})
+	handler := GetOriginalURLHandlerFunc(svc)
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodHead, "/qwerty", nil)
+	suite.db.EXPECT().
+		GetURLByID(gomock.Any(), "qwerty").
+		Return(storage.Record{URL: "http://yandex.ru", RedirectCode: http.StatusMovedPermanently}, nil)
+	// no clicks are saved on close
+	suite.db.EXPECT().AddClicks(gomock.Any(), gomock.Any()).Times(0)
+	handler(rr, req)
+	suite.Equal(http.StatusMovedPermanently, rr.Code)
+	suite.Equal("http://yandex.ru", rr.Header().Get("Location"))
+	suite.Empty(rr.Body.String())
+	recorder.Close()
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{accept: "", want: contentTypeHTML},
+		{accept: "*/*", want: contentTypeHTML},
+		{accept: "application/json", want: contentTypeJSON},
+		{accept: "application/*", want: contentTypeJSON},
+		{accept: "text/html,application/xhtml+xml,*/*;q=0.8", want: contentTypeHTML},
+		{accept: "application/json, */*;q=0.1", want: contentTypeJSON},
+		{accept: "text/*;q=0.2, application/json;q=0.9", want: contentTypeJSON},
+		{accept: "image/png", want: contentTypeHTML},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, negotiate(tt.accept, contentTypeHTML, contentTypeJSON), tt.accept)
+	}
+}
+
 func TestOriginalURLSuite(t *testing.T) {
 	suite.Run(t, new(OriginalURLSuite))
 }
@@ -154,7 +410,7 @@ func ExampleGetOriginalURLHandlerFunc() {
 		Times(1).
 		Return(storage.Record{URL: "https://practicum.yandex.ru/learn/"}, nil)
 	// setup request ...
-	handler := GetOriginalURLHandlerFunc(s)
+	handler := GetOriginalURLHandlerFunc(newTestService(s))
 	rr := httptest.NewRecorder()
 	req, _ := http.NewRequest(http.MethodGet, "/rb1t0eupmn2_", nil)
 	// Run
@@ -3,35 +3,50 @@ package routes
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/blokhinnv/shorty/internal/app/server/http/routes/middleware"
+	"github.com/blokhinnv/shorty/internal/app/service"
 	"github.com/blokhinnv/shorty/internal/app/storage"
 )
 
 // ShortenedURLSAnswer - the structure for the response in the desired form.
 type ShortenedURLSAnswer struct {
-	URL   string `json:"original_url" valid:"url,required"`
-	URLID string `json:"short_url"    valid:"url,required"`
+	URL       string     `json:"original_url"         valid:"url,required"`
+	URLID     string     `json:"short_url"            valid:"url,required"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// the metadata of the destination, missing until it's fetched
+	Preview *storage.Preview `json:"preview,omitempty"`
+	// the result of the last check of the destination, missing until it's checked
+	Health *storage.Health `json:"health,omitempty"`
 }
 
 // prepareAnswer prepares the server response in the desired form.
 func prepareAnswer(records []storage.Record, baseURL string) []ShortenedURLSAnswer {
 	results := make([]ShortenedURLSAnswer, 0, len(records))
 	for _, r := range records {
-		results = append(
-			results,
-			ShortenedURLSAnswer{URL: r.URL, URLID: fmt.Sprintf("%v/%v", baseURL, r.URLID)},
-		)
+		answer := ShortenedURLSAnswer{
+			URL:     r.DisplayURL(),
+			URLID:   fmt.Sprintf("%v/%v", baseURL, r.URLID),
+			Preview: r.Preview,
+			Health:  r.Health,
+		}
+		if !r.ExpiresAt.IsZero() {
+			expiresAt := r.ExpiresAt
+			answer.ExpiresAt = &expiresAt
+		}
+		results = append(results, answer)
 	}
 	return results
 }
 
 // GetOriginalURLsHandlerFunc - implementation of the GET handler /api/user/urls.
-// It will be able to return to the user all the URLs it has ever shortened
-func GetOriginalURLsHandlerFunc(s storage.Storage) func(http.ResponseWriter, *http.Request) {
+// It will be able to return to the user all the URLs it has ever shortened.
+// The status query parameter ("ok" or "broken") filters them by the state of the destinations.
+func GetOriginalURLsHandlerFunc(svc *service.Shortener) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 		defer cancel()
@@ -55,8 +70,12 @@ func GetOriginalURLsHandlerFunc(s storage.Storage) func(http.ResponseWriter, *ht
 			return
 		}
 
-		records, err := s.GetURLsByUser(ctx, userID)
+		records, err := svc.UserURLs(ctx, userID, r.URL.Query().Get("status"))
 		if err != nil {
+			if errors.Is(err, service.ErrInvalidArgument) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
 			http.Error(w, err.Error(), http.StatusNoContent)
 			return
 		}
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	db "github.com/blokhinnv/shorty/internal/app/database"
 	"github.com/blokhinnv/shorty/internal/app/server/http/routes/middleware"
@@ -32,9 +33,30 @@ func addRecords(
 	for idx, longURL := range longURLs {
 		shortURLID, shortURL, err := shorten.GetShortURL(longURL, userID, baseURL)
 		require.NoError(t, err)
-		s.AddURL(context.Background(), longURL, shortURLID, userID)
+		s.AddURL(context.Background(), longURL, shortURLID, userID, storage.LinkOptions{})
 		answer[idx] = ShortenedURLSAnswer{URL: longURL, URLID: shortURL}
 	}
+	// the preview of the first URL is fetched
+	preview := storage.Preview{
+		Title:      "SQL Online",
+		FinalURL:   "https://sqliteonline.com/",
+		StatusCode: http.StatusOK,
+		FetchedAt:  time.Date(2023, 3, 1, 10, 30, 0, 0, time.UTC),
+	}
+	shortURLID, _, err := shorten.GetShortURL(longURLs[0], userID, baseURL)
+	require.NoError(t, err)
+	require.NoError(t, s.SetPreview(context.Background(), shortURLID, preview))
+	answer[0].Preview = &preview
+	// the destination of the second URL is gone
+	health := storage.Health{
+		CheckedAt:  time.Date(2023, 3, 2, 10, 30, 0, 0, time.UTC),
+		StatusCode: http.StatusNotFound,
+		Failures:   3,
+	}
+	shortURLID, _, err = shorten.GetShortURL(longURLs[1], userID, baseURL)
+	require.NoError(t, err)
+	require.NoError(t, s.SetHealth(context.Background(), shortURLID, health))
+	answer[1].Health = &health
 	return answer
 }
 
@@ -48,7 +70,7 @@ type OriginalURLsSuite struct {
 func (suite *OriginalURLsSuite) SetupSuite() {
 	suite.ctrl = gomock.NewController(suite.T())
 	suite.db = storage.NewMockStorage(suite.ctrl)
-	suite.handler = GetOriginalURLsHandlerFunc(suite.db)
+	suite.handler = GetOriginalURLsHandlerFunc(newTestService(suite.db))
 }
 
 func (suite *OriginalURLsSuite) TearDownSuite() {
@@ -66,7 +88,7 @@ func (suite *OriginalURLsSuite) IntTestLogic(testCfg TestConfig) {
 		s.Clear(context.Background())
 		s.Close(context.Background())
 	}()
-	r := NewRouter(s, testCfg.serverCfg, make(chan struct{}))
+	r := newTestRouter(s, testCfg.serverCfg)
 	ts := NewServerWithPort(r, testCfg.host, testCfg.port)
 	defer ts.Close()
 
@@ -128,6 +150,23 @@ func (suite *OriginalURLsSuite) IntTestLogic(testCfg TestConfig) {
 		assert.NoError(t, err)
 		assert.Equal(t, answer, v)
 	})
+
+	t.Run("test_broken", func(t *testing.T) {
+		res, err := client.R().SetQueryParam("status", "broken").Get(reqURL)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, res.StatusCode())
+		var v []ShortenedURLSAnswer
+		err = json.Unmarshal(res.Body(), &v)
+		assert.NoError(t, err)
+		assert.Equal(t, answer[1:], v)
+	})
+
+	t.Run("test_unknown_status", func(t *testing.T) {
+		res, err := client.R().SetQueryParam("status", "dead").Get(reqURL)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode())
+	})
 }
 
 // TestIntSQLite - run tests for SQLite.
@@ -167,7 +206,7 @@ func ExampleGetOriginalURLsHandlerFunc() {
 	answer := []storage.Record{{URL: "https://practicum.yandex.ru/learn/", URLID: "rb1t0eupmn2_"}}
 	s.EXPECT().GetURLsByUser(gomock.Any(), uint32(1)).Times(1).Return(answer, nil)
 	// setup request ...
-	handler := GetOriginalURLsHandlerFunc(s)
+	handler := GetOriginalURLsHandlerFunc(newTestService(s))
 	rr := httptest.NewRecorder()
 	req, _ := http.NewRequest(http.MethodGet, "/user/urls", nil)
 	// setup context ...
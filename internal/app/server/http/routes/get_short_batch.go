@@ -11,15 +11,16 @@ import (
 
 	"github.com/asaskevich/govalidator"
 	"github.com/blokhinnv/shorty/internal/app/server/http/routes/middleware"
+	"github.com/blokhinnv/shorty/internal/app/service"
 	"github.com/blokhinnv/shorty/internal/app/shorten"
-	"github.com/blokhinnv/shorty/internal/app/storage"
 )
 
 // Structures for the body of the request and response.
 type (
 	ShortBatchRequestJSONItem struct {
 		CorrelationID string `json:"correlation_id"`
-		OriginalURL   string `json:"original_url"   valid:"url,required"`
+		OriginalURL   string `json:"original_url"    valid:"url,required"`
+		Alias         string `json:"alias,omitempty"`
 	}
 	ShortBatchResponseJSONItem struct {
 		CorrelationID string `json:"correlation_id"`
@@ -29,12 +30,12 @@ type (
 
 // GetShortURLsBatchHandler - Structure for handler implementation.
 type GetShortURLsBatchHandler struct {
-	s storage.Storage
+	svc *service.Shortener
 }
 
 // NewGetShortURLsBatchHandler - GetShortURLsBatchHandler constructor.
-func NewGetShortURLsBatchHandler(s storage.Storage) *GetShortURLsBatchHandler {
-	return &GetShortURLsBatchHandler{s}
+func NewGetShortURLsBatchHandler(svc *service.Shortener) *GetShortURLsBatchHandler {
+	return &GetShortURLsBatchHandler{svc: svc}
 }
 
 // addURLs prepares the data and causes the package to be added.
@@ -44,32 +45,39 @@ func (h *GetShortURLsBatchHandler) addURLs(
 	userID uint32,
 	baseURL string,
 ) ([]ShortBatchResponseJSONItem, int, error) {
-	urlIDs := make(map[string]string)
-	result := make([]ShortBatchResponseJSONItem, 0, len(data))
+	items := make([]service.BatchItem, 0, len(data))
 	for _, item := range data {
-		shortURLID, shortenURL, err := shorten.GetShortURL(
-			item.OriginalURL,
-			userID,
-			baseURL,
-		)
-		if err != nil {
-			return nil, http.StatusBadRequest, err
-		}
-		urlIDs[item.OriginalURL] = shortURLID
-		result = append(
-			result,
-			ShortBatchResponseJSONItem{CorrelationID: item.CorrelationID, ShortURL: shortenURL},
+		items = append(
+			items,
+			service.BatchItem{
+				CorrelationID: item.CorrelationID,
+				URL:           item.OriginalURL,
+				Alias:         item.Alias,
+			},
 		)
 	}
-	err := h.s.AddURLBatch(ctx, urlIDs, userID)
+	added, err := h.svc.ShortenBatch(ctx, userID, items)
 	status := http.StatusCreated
 	if err != nil {
-		if errors.Is(err, storage.ErrUniqueViolation) {
+		switch {
+		case errors.Is(err, service.ErrConflict) && added != nil:
 			status = http.StatusConflict
-		} else {
+		case errors.Is(err, service.ErrConflict):
+			return nil, http.StatusConflict, err
+		default:
 			return nil, http.StatusBadRequest, err
 		}
 	}
+	result := make([]ShortBatchResponseJSONItem, 0, len(added))
+	for _, item := range added {
+		result = append(
+			result,
+			ShortBatchResponseJSONItem{
+				CorrelationID: item.CorrelationID,
+				ShortURL:      shorten.ShortURL(baseURL, item.URLID),
+			},
+		)
+	}
 	return result, status, nil
 }
 
@@ -137,7 +145,8 @@ func (h *GetShortURLsBatchHandler) Handler(w http.ResponseWriter, r *http.Reques
 	}
 	result, status, err := h.addURLs(ctx, bodyDecoded, userID, baseURL)
 	if err != nil {
-		http.Error(w, http.StatusText(status), status)
+		shortenError(w, err, status)
+		return
 	}
 	// Encode the result as JSON ...
 	resultEncoded, err := json.Marshal(result)
@@ -28,7 +28,7 @@ type BatchTestSuite struct {
 func (suite *BatchTestSuite) SetupSuite() {
 	suite.ctrl = gomock.NewController(suite.T())
 	suite.db = storage.NewMockStorage(suite.ctrl)
-	suite.handler = NewGetShortURLsBatchHandler(suite.db).Handler
+	suite.handler = NewGetShortURLsBatchHandler(newTestService(suite.db)).Handler
 }
 
 func (suite *BatchTestSuite) TearDownSuite() {
@@ -48,7 +48,7 @@ func (suite *BatchTestSuite) IntTestLogic(testCfg TestConfig) {
 		s.Clear(context.Background())
 		s.Close(context.Background())
 	}()
-	r := NewRouter(s, testCfg.serverCfg, make(chan struct{}))
+	r := newTestRouter(s, testCfg.serverCfg)
 	ts := NewServerWithPort(r, testCfg.host, testCfg.port)
 	defer ts.Close()
 	reqURL := "http://localhost:8080/api/shorten/batch"
@@ -211,8 +211,56 @@ func (suite *BatchTestSuite) TestErrUniqueViolation() {
 	suite.db.EXPECT().
 		AddURLBatch(gomock.Any(), gomock.Any(), gomock.Any()).
 		Return(fmt.Errorf("%w", storage.ErrUniqueViolation))
+	suite.db.EXPECT().
+		AddURL(gomock.Any(), "https://mail.ru/", gomock.Any(), uint32(123), gomock.Any()).
+		Return(&storage.DuplicateURLError{URL: "https://mail.ru/", URLID: "mail"})
+	suite.handler.ServeHTTP(rr, req.WithContext(ctx))
+	suite.Equal(http.StatusConflict, rr.Code)
+	suite.Contains(rr.Body.String(), ".../mail")
+}
+
+func (suite *BatchTestSuite) TestSharedWithOtherUser() {
+	rr := httptest.NewRecorder()
+	body := []byte(`[{"correlation_id":"test1","original_url":"https://mail.ru/"}]`)
+	req, _ := http.NewRequest(http.MethodGet, "/shorten/batch", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	ctx := context.WithValue(context.Background(), middleware.BaseURLCtxKey, "...")
+	ctx = context.WithValue(ctx, middleware.UserIDCtxKey, uint32(123))
+	suite.db.EXPECT().
+		AddURLBatch(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&storage.DuplicateURLError{URL: "https://mail.ru/", URLID: "mail"})
+	suite.db.EXPECT().
+		AddURL(gomock.Any(), "https://mail.ru/", gomock.Any(), uint32(123), gomock.Any()).
+		Return(&storage.DuplicateURLError{URL: "https://mail.ru/", URLID: "mail", NewOwner: true})
+	suite.handler.ServeHTTP(rr, req.WithContext(ctx))
+	suite.Equal(http.StatusCreated, rr.Code)
+	suite.Contains(rr.Body.String(), ".../mail")
+}
+
+func (suite *BatchTestSuite) TestAliasTaken() {
+	rr := httptest.NewRecorder()
+	body := []byte(`[{"correlation_id":"test1","original_url":"https://mail.ru/","alias":"mail"}]`)
+	req, _ := http.NewRequest(http.MethodGet, "/shorten/batch", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	ctx := context.WithValue(context.Background(), middleware.BaseURLCtxKey, "...")
+	ctx = context.WithValue(ctx, middleware.UserIDCtxKey, uint32(123))
+	suite.db.EXPECT().
+		GetURLByID(gomock.Any(), "mail").
+		Return(storage.Record{URL: "https://yandex.ru/", URLID: "mail"}, nil)
 	suite.handler.ServeHTTP(rr, req.WithContext(ctx))
 	suite.Equal(http.StatusConflict, rr.Code)
+	suite.NotContains(rr.Body.String(), "short_url")
+}
+
+func (suite *BatchTestSuite) TestBadAlias() {
+	rr := httptest.NewRecorder()
+	body := []byte(`[{"correlation_id":"test1","original_url":"https://mail.ru/","alias":"m@il"}]`)
+	req, _ := http.NewRequest(http.MethodGet, "/shorten/batch", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	ctx := context.WithValue(context.Background(), middleware.BaseURLCtxKey, "...")
+	ctx = context.WithValue(ctx, middleware.UserIDCtxKey, uint32(123))
+	suite.handler.ServeHTTP(rr, req.WithContext(ctx))
+	suite.Equal(http.StatusBadRequest, rr.Code)
 }
 
 func (suite *BatchTestSuite) TestEmptyBody() {
@@ -253,7 +301,7 @@ func ExampleGetShortURLsBatchHandler_Handler() {
 	s := storage.NewMockStorage(ctrl)
 	s.EXPECT().AddURLBatch(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(nil)
 	// setup request ...
-	handler := NewGetShortURLsBatchHandler(s)
+	handler := NewGetShortURLsBatchHandler(newTestService(s))
 	rr := httptest.NewRecorder()
 	body := bytes.NewBuffer(
 		[]byte(
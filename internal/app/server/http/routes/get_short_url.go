@@ -9,14 +9,14 @@ import (
 	"strings"
 	"time"
 
-	"github.com/blokhinnv/shorty/internal/app/storage"
+	"github.com/blokhinnv/shorty/internal/app/service"
 )
 
 // GetShortURLHandlerFunc - implementation of the POST endpoint /.
 // Accepts a URL string in the request body
 // for shortening and returns a response with code 201 and
 // shortened URL as a text string in the body.
-func GetShortURLHandlerFunc(s storage.Storage) func(http.ResponseWriter, *http.Request) {
+func GetShortURLHandlerFunc(svc *service.Shortener) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 		defer cancel()
@@ -35,13 +35,10 @@ func GetShortURLHandlerFunc(s storage.Storage) func(http.ResponseWriter, *http.R
 		if longURL == "" {
 			longURL = string(query)
 		}
-		shortenURL, status, err := shortenURLLogic(ctx, w, s, longURL)
+		shortenURL, status, err := shortenURLLogic(ctx, w, svc, longURL, service.ShortenOptions{})
 		if err != nil {
-			http.Error(
-				w,
-				err.Error(),
-				status,
-			)
+			shortenError(w, err, status)
+			return
 		}
 		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 		w.WriteHeader(status)
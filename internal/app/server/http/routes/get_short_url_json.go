@@ -9,23 +9,55 @@ import (
 	"time"
 
 	"github.com/asaskevich/govalidator"
-	"github.com/blokhinnv/shorty/internal/app/storage"
+	"github.com/blokhinnv/shorty/internal/app/service"
+	"github.com/blokhinnv/shorty/internal/app/shorten"
 )
 
 // Structures for the body of the request and response.
 type (
 	ShortJSONRequest struct {
-		URL string `json:"url" valid:"url,required"`
+		URL       string     `json:"url"                  valid:"url,required"`
+		Alias     string     `json:"alias,omitempty"`
+		ExpiresAt *time.Time `json:"expires_at,omitempty"`
+		TTL       string     `json:"ttl,omitempty"`
+		// the visitors see the destination before they are redirected
+		WarnBeforeRedirect bool `json:"warn_before_redirect,omitempty"`
+		// the status code of the redirect: 301, 302, 307 (default) or 308
+		RedirectCode int `json:"redirect_code,omitempty"`
 	}
 	ShortJSONResponse struct {
 		Result string `json:"result"`
 	}
 )
 
+// shortenOptions returns the options for the requested link.
+func (r ShortJSONRequest) shortenOptions() (service.ShortenOptions, error) {
+	opts := service.ShortenOptions{
+		Alias:              r.Alias,
+		WarnBeforeRedirect: r.WarnBeforeRedirect,
+		RedirectCode:       r.RedirectCode,
+	}
+	if r.TTL != "" {
+		ttl, err := time.ParseDuration(r.TTL)
+		if err != nil {
+			return service.ShortenOptions{}, fmt.Errorf("%w: %v", shorten.ErrInvalidExpiry, err)
+		}
+		opts.TTL = ttl
+	}
+	if r.ExpiresAt != nil {
+		opts.ExpiresAt = *r.ExpiresAt
+	}
+	return opts, nil
+}
+
 // GetShortURLAPIHandlerFunc - new POST endpoint /api/shorten.
 // It takes a JSON object {"url":"<some_url>"} in the request body and returns
-// in response object {"result":"<shorten_url>"}.
-func GetShortURLAPIHandlerFunc(s storage.Storage) func(http.ResponseWriter, *http.Request) {
+// in response object {"result":"<shorten_url>"}. An optional "alias" field
+// sets a custom ID for the short URL. The link expires at "expires_at" (RFC 3339)
+// or after "ttl" (e.g. "24h") if one of them is set. The links with
+// "warn_before_redirect" show their destination before the redirect,
+// "redirect_code" sets the status code of the redirect.
+func GetShortURLAPIHandlerFunc(svc *service.Shortener) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 		defer cancel()
@@ -55,15 +87,17 @@ func GetShortURLAPIHandlerFunc(s storage.Storage) func(http.ResponseWriter, *htt
 			http.Error(w, fmt.Sprintf("Body is not valid: %v", err.Error()), http.StatusBadRequest)
 			return
 		}
+		opts, err := bodyDecoded.shortenOptions()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		// Shorten the URL
 		longURL := bodyDecoded.URL
-		shortenURL, status, err := shortenURLLogic(ctx, w, s, longURL)
+		shortenURL, status, err := shortenURLLogic(ctx, w, svc, longURL, opts)
 		if err != nil {
-			http.Error(
-				w,
-				err.Error(),
-				status,
-			)
+			shortenError(w, err, status)
+			return
 		}
 		// Encode the result as JSON ...
 		shortenURLEncoded, err := json.Marshal(ShortJSONResponse{shortenURL})
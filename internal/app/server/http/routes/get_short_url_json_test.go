@@ -9,6 +9,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	db "github.com/blokhinnv/shorty/internal/app/database"
 	"github.com/blokhinnv/shorty/internal/app/log"
@@ -32,7 +33,7 @@ type ShortenJSONTestSuite struct {
 func (suite *ShortenJSONTestSuite) SetupSuite() {
 	suite.ctrl = gomock.NewController(suite.T())
 	suite.db = storage.NewMockStorage(suite.ctrl)
-	suite.handler = GetShortURLAPIHandlerFunc(suite.db)
+	suite.handler = GetShortURLAPIHandlerFunc(newTestService(suite.db))
 }
 
 func (suite *ShortenJSONTestSuite) TearDownSuite() {
@@ -81,12 +82,112 @@ func (suite *ShortenJSONTestSuite) TestAddError() {
 	ctx := context.WithValue(context.Background(), middleware.BaseURLCtxKey, "...")
 	ctx = context.WithValue(ctx, middleware.UserIDCtxKey, uint32(1))
 	suite.db.EXPECT().
-		AddURL(gomock.Any(), "http://yandex.ru", gomock.Any(), uint32(1)).
+		AddURL(gomock.Any(), "http://yandex.ru", gomock.Any(), uint32(1), gomock.Any()).
 		Return(fmt.Errorf("error..."))
 	suite.handler.ServeHTTP(rr, req.WithContext(ctx))
 	suite.Equal(http.StatusBadRequest, rr.Code)
 }
 
+func (suite *ShortenJSONTestSuite) TestAlias() {
+	body := []byte(`{"url":"http://yandex.ru","alias":"q3-report"}`)
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/shorten", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	ctx := context.WithValue(context.Background(), middleware.BaseURLCtxKey, "http://localhost:8080")
+	ctx = context.WithValue(ctx, middleware.UserIDCtxKey, uint32(1))
+	suite.db.EXPECT().
+		AddURL(gomock.Any(), "http://yandex.ru", "q3-report", uint32(1), gomock.Any()).
+		Return(nil)
+	suite.handler.ServeHTTP(rr, req.WithContext(ctx))
+	suite.Equal(http.StatusCreated, rr.Code)
+	suite.Equal(`{"result":"http://localhost:8080/q3-report"}`, rr.Body.String())
+}
+
+func (suite *ShortenJSONTestSuite) TestAliasTaken() {
+	body := []byte(`{"url":"http://yandex.ru","alias":"q3-report"}`)
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/shorten", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	ctx := context.WithValue(context.Background(), middleware.BaseURLCtxKey, "http://localhost:8080")
+	ctx = context.WithValue(ctx, middleware.UserIDCtxKey, uint32(1))
+	suite.db.EXPECT().
+		AddURL(gomock.Any(), "http://yandex.ru", "q3-report", uint32(1), gomock.Any()).
+		Return(fmt.Errorf("%w", storage.ErrUniqueViolation))
+	suite.handler.ServeHTTP(rr, req.WithContext(ctx))
+	suite.Equal(http.StatusConflict, rr.Code)
+	suite.NotContains(rr.Body.String(), "result")
+}
+
+func (suite *ShortenJSONTestSuite) TestReservedAlias() {
+	body := []byte(`{"url":"http://yandex.ru","alias":"api"}`)
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/shorten", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	ctx := context.WithValue(context.Background(), middleware.BaseURLCtxKey, "http://localhost:8080")
+	ctx = context.WithValue(ctx, middleware.UserIDCtxKey, uint32(1))
+	suite.handler.ServeHTTP(rr, req.WithContext(ctx))
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *ShortenJSONTestSuite) TestTTL() {
+	body := []byte(`{"url":"http://yandex.ru","ttl":"24h"}`)
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/shorten", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	ctx := context.WithValue(context.Background(), middleware.BaseURLCtxKey, "http://localhost:8080")
+	ctx = context.WithValue(ctx, middleware.UserIDCtxKey, uint32(1))
+	suite.db.EXPECT().
+		AddURL(gomock.Any(), "http://yandex.ru", gomock.Any(), uint32(1), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _, _ string, _ uint32, opts storage.LinkOptions) error {
+			suite.WithinDuration(time.Now().Add(24*time.Hour), opts.ExpiresAt, time.Minute)
+			return nil
+		})
+	suite.handler.ServeHTTP(rr, req.WithContext(ctx))
+	suite.Equal(http.StatusCreated, rr.Code)
+}
+
+func (suite *ShortenJSONTestSuite) TestBadExpiry() {
+	for _, body := range []string{
+		`{"url":"http://yandex.ru","ttl":"day"}`,
+		`{"url":"http://yandex.ru","ttl":"-1h"}`,
+		`{"url":"http://yandex.ru","expires_at":"2001-01-01T00:00:00Z"}`,
+		`{"url":"http://yandex.ru","ttl":"1h","expires_at":"2101-01-01T00:00:00Z"}`,
+	} {
+		rr := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/shorten", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		ctx := context.WithValue(context.Background(), middleware.BaseURLCtxKey, "http://localhost:8080")
+		ctx = context.WithValue(ctx, middleware.UserIDCtxKey, uint32(1))
+		suite.handler.ServeHTTP(rr, req.WithContext(ctx))
+		suite.Equal(http.StatusBadRequest, rr.Code, body)
+	}
+}
+
+func (suite *ShortenJSONTestSuite) TestRedirectCode() {
+	body := []byte(`{"url":"http://yandex.ru","redirect_code":308}`)
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/shorten", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	ctx := context.WithValue(context.Background(), middleware.BaseURLCtxKey, "http://localhost:8080")
+	ctx = context.WithValue(ctx, middleware.UserIDCtxKey, uint32(1))
+	suite.db.EXPECT().
+		AddURL(gomock.Any(), "http://yandex.ru", gomock.Any(), uint32(1), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _, _ string, _ uint32, opts storage.LinkOptions) error {
+			suite.Equal(http.StatusPermanentRedirect, opts.RedirectCode)
+			return nil
+		})
+	suite.handler.ServeHTTP(rr, req.WithContext(ctx))
+	suite.Equal(http.StatusCreated, rr.Code)
+
+	// only the redirects are allowed
+	body = []byte(`{"url":"http://yandex.ru","redirect_code":200}`)
+	rr = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/shorten", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	suite.handler.ServeHTTP(rr, req.WithContext(ctx))
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
 // IntTestLogic - test logic for a new POST request.
 func (suite *ShortenJSONTestSuite) IntTestLogic(testCfg TestConfig) {
 	// If you start the server cmd/shortener/main,
@@ -100,7 +201,7 @@ func (suite *ShortenJSONTestSuite) IntTestLogic(testCfg TestConfig) {
 		s.Clear(context.Background())
 		s.Close(context.Background())
 	}()
-	r := NewRouter(s, testCfg.serverCfg, make(chan struct{}))
+	r := newTestRouter(s, testCfg.serverCfg)
 	ts := NewServerWithPort(r, testCfg.host, testCfg.port)
 	defer ts.Close()
 
@@ -108,7 +209,10 @@ func (suite *ShortenJSONTestSuite) IntTestLogic(testCfg TestConfig) {
 	// one URL, check that everything passed without errors
 	longURL := "https://practicum.yandex.ru/learn/go-advanced/"
 	longURLEncoded := []byte(fmt.Sprintf(`{"url":"%v"}`, longURL))
-	_, shortURL, err := shorten.GetShortURL(longURL, userID, testCfg.baseURL)
+	// the ID is derived from the canonical form of the URL
+	canonicalURL, err := shorten.GetCanonicalConfig(testCfg.serverCfg).Canonicalize(longURL)
+	require.NoError(t, err)
+	_, shortURL, err := shorten.GetShortURL(canonicalURL, userID, testCfg.baseURL)
 	require.NoError(t, err)
 
 	shortURLEncoded := []byte(fmt.Sprintf(`{"result":"%v"}`, shortURL))
@@ -259,9 +363,9 @@ func ExampleGetShortURLAPIHandlerFunc() {
 	t := new(testing.T)
 	ctrl := gomock.NewController(t)
 	s := storage.NewMockStorage(ctrl)
-	s.EXPECT().AddURL(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(nil)
+	s.EXPECT().AddURL(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(nil)
 	// setup request ...
-	handler := GetShortURLAPIHandlerFunc(s)
+	handler := GetShortURLAPIHandlerFunc(newTestService(s))
 	rr := httptest.NewRecorder()
 	body := bytes.NewBuffer([]byte(`{"url":"https://practicum.yandex.ru/learn/"}`))
 	req, _ := http.NewRequest(http.MethodPost, "/shorten", body)
@@ -37,14 +37,17 @@ func (suite *ShortenTestSuite) IntTestLogic(testCfg TestConfig) {
 		s.Clear(context.Background())
 		s.Close(context.Background())
 	}()
-	r := NewRouter(s, testCfg.serverCfg, make(chan struct{}))
+	r := newTestRouter(s, testCfg.serverCfg)
 
 	ts := NewServerWithPort(r, testCfg.host, testCfg.port)
 	defer ts.Close()
 	// Preparation for the test: create storage, reduce
 	// one URL, check that everything passed without errors
 	longURL := "https://practicum.yandex.ru/learn/go-advanced/"
-	_, shortURL, err := shorten.GetShortURL(longURL, userID, testCfg.baseURL)
+	// the ID is derived from the canonical form of the URL
+	canonicalURL, err := shorten.GetCanonicalConfig(testCfg.serverCfg).Canonicalize(longURL)
+	require.NoError(t, err)
+	_, shortURL, err := shorten.GetShortURL(canonicalURL, userID, testCfg.baseURL)
 	require.NoError(t, err)
 
 	type want struct {
@@ -160,9 +163,9 @@ func ExampleGetShortURLHandlerFunc() {
 	t := new(testing.T)
 	ctrl := gomock.NewController(t)
 	s := storage.NewMockStorage(ctrl)
-	s.EXPECT().AddURL(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(nil)
+	s.EXPECT().AddURL(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(nil)
 	// setup request ...
-	handler := GetShortURLHandlerFunc(s)
+	handler := GetShortURLHandlerFunc(newTestService(s))
 	rr := httptest.NewRecorder()
 	body := bytes.NewBuffer([]byte("https://practicum.yandex.ru/learn/"))
 	req, _ := http.NewRequest(http.MethodPost, "/", body)
@@ -0,0 +1,50 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/blokhinnv/shorty/internal/app/server/http/routes/middleware"
+	"github.com/blokhinnv/shorty/internal/app/service"
+)
+
+// GetURLStatsHandlerFunc - implementation of the GET /api/user/urls/{id}/stats endpoint.
+// Returns click statistics of the user's short URL. The optional "granularity"
+// query parameter ("hour" or "day") sets the size of time series buckets.
+func GetURLStatsHandlerFunc(svc *service.Shortener) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+		defer cancel()
+		userID, ok := ctx.Value(middleware.UserIDCtxKey).(uint32)
+		if !ok {
+			http.Error(
+				w,
+				"no user id provided",
+				http.StatusInternalServerError,
+			)
+			return
+		}
+		urlID := chi.URLParam(r, "idURL")
+		stats, err := svc.URLStats(ctx, userID, urlID, r.URL.Query().Get("granularity"))
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrInvalidArgument):
+				http.Error(w, err.Error(), http.StatusBadRequest)
+			case errors.Is(err, service.ErrNotFound):
+				http.Error(w, err.Error(), http.StatusNotFound)
+			default:
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		encoder := json.NewEncoder(w)
+		encoder.Encode(stats)
+	}
+}
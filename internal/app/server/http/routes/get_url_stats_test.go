@@ -0,0 +1,81 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/blokhinnv/shorty/internal/app/analytics"
+	"github.com/blokhinnv/shorty/internal/app/server/http/routes/middleware"
+	"github.com/blokhinnv/shorty/internal/app/storage"
+)
+
+type URLStatsSuite struct {
+	suite.Suite
+	ctrl   *gomock.Controller
+	db     *storage.MockStorage
+	router chi.Router
+}
+
+func (suite *URLStatsSuite) SetupSuite() {
+	suite.ctrl = gomock.NewController(suite.T())
+	suite.db = storage.NewMockStorage(suite.ctrl)
+	suite.router = chi.NewRouter()
+	suite.router.Get("/api/user/urls/{idURL}/stats", GetURLStatsHandlerFunc(newTestService(suite.db)))
+}
+
+func (suite *URLStatsSuite) TearDownSuite() {
+	suite.ctrl.Finish()
+}
+
+func (suite *URLStatsSuite) makeRequest(target string) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, target, nil)
+	ctx := context.WithValue(context.Background(), middleware.UserIDCtxKey, uint32(1))
+	suite.router.ServeHTTP(rr, req.WithContext(ctx))
+	return rr
+}
+
+func (suite *URLStatsSuite) TestOK() {
+	clickedAt := time.Date(2023, 3, 1, 10, 30, 0, 0, time.UTC)
+	suite.db.EXPECT().
+		GetURLsByUser(gomock.Any(), uint32(1)).
+		Return([]storage.Record{{URL: "http://yandex.ru", URLID: "qwerty"}}, nil)
+	suite.db.EXPECT().
+		GetClicks(gomock.Any(), "qwerty").
+		Return([]storage.Click{{URLID: "qwerty", ClickedAt: clickedAt}}, nil)
+	rr := suite.makeRequest("/api/user/urls/qwerty/stats?granularity=hour")
+	suite.Equal(http.StatusOK, rr.Code)
+	var stats analytics.Stats
+	suite.NoError(json.NewDecoder(rr.Body).Decode(&stats))
+	suite.Equal(1, stats.Clicks)
+	suite.Equal(analytics.Hourly, stats.Granularity)
+	suite.Equal(
+		[]analytics.Bucket{{Start: time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC), Clicks: 1}},
+		stats.Buckets,
+	)
+}
+
+func (suite *URLStatsSuite) TestNotOwner() {
+	suite.db.EXPECT().
+		GetURLsByUser(gomock.Any(), uint32(1)).
+		Return([]storage.Record{{URL: "http://yandex.ru", URLID: "qwerty"}}, nil)
+	rr := suite.makeRequest("/api/user/urls/asdfgh/stats")
+	suite.Equal(http.StatusNotFound, rr.Code)
+}
+
+func (suite *URLStatsSuite) TestBadGranularity() {
+	rr := suite.makeRequest("/api/user/urls/qwerty/stats?granularity=week")
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func TestURLStatsSuite(t *testing.T) {
+	suite.Run(t, new(URLStatsSuite))
+}
@@ -0,0 +1,55 @@
+package routes
+
+import (
+	"bytes"
+	"embed"
+	"html/template"
+	"net/http"
+	"net/url"
+
+	"github.com/blokhinnv/shorty/internal/app/storage"
+)
+
+//go:embed templates/*.html
+var templatesFS embed.FS
+
+// templates - the pages served by the handlers.
+var templates = template.Must(template.ParseFS(templatesFS, "templates/*.html"))
+
+// InterstitialAnswer - the destination of a short URL shown before the redirect.
+type InterstitialAnswer struct {
+	URLID   string           `json:"url_id"`
+	URL     string           `json:"url"`
+	Host    string           `json:"host"`
+	Preview *storage.Preview `json:"preview,omitempty"`
+}
+
+// newInterstitialAnswer describes the destination of the record.
+func newInterstitialAnswer(rec storage.Record) InterstitialAnswer {
+	answer := InterstitialAnswer{URLID: rec.URLID, URL: rec.DisplayURL(), Preview: rec.Preview}
+	if u, err := url.Parse(answer.URL); err == nil {
+		answer.Host = u.Host
+	}
+	return answer
+}
+
+// writeInterstitial writes the page which shows the destination of the record
+// and lets the visitor continue to it. The clients which prefer JSON get JSON.
+func writeInterstitial(w http.ResponseWriter, r *http.Request, rec storage.Record) {
+	answer := newInterstitialAnswer(rec)
+	w.Header().Set("Vary", "Accept")
+	if negotiate(r.Header.Get("Accept"), contentTypeHTML, contentTypeJSON) == contentTypeJSON {
+		writeJSON(w, http.StatusOK, answer)
+		return
+	}
+	var page bytes.Buffer
+	if err := templates.ExecuteTemplate(&page, "interstitial.html", answer); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	// the page loads nothing but its own styles
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'")
+	w.WriteHeader(http.StatusOK)
+	w.Write(page.Bytes())
+}
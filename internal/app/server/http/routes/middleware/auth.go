@@ -3,90 +3,131 @@ package middleware
 
 import (
 	"context"
-	"encoding/hex"
 	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/blokhinnv/shorty/internal/app/log"
 	"github.com/blokhinnv/shorty/internal/app/server/auth"
+	"github.com/blokhinnv/shorty/internal/app/service"
 )
 
 // Constants for middleware operation
 const (
 	UserTokenCookieName = "UserToken"
 	UserIDCtxKey        = ContextStringKey("UserID")
-	nBytesForID         = 4
+	bearerPrefix        = "Bearer "
 )
 
-// Auth - structure for authorization middleware.
+// Auth - structure for authorization middleware. The users of the accounts
+// may send an API key in the Authorization header instead of the cookie.
 type Auth struct {
-	secretKey []byte
+	tokens   *auth.Tokens
+	accounts *service.Accounts
 }
 
-// NewAuth - Auth middleware constructor.
-func NewAuth(key []byte) *Auth {
-	return &Auth{secretKey: key}
+// NewAuth - Auth middleware constructor. Without accounts only the cookies are accepted.
+func NewAuth(tokens *auth.Tokens, accounts *service.Accounts) *Auth {
+	return &Auth{tokens: tokens, accounts: accounts}
 }
 
-// setCookie sets a cookie based on the signature.
-func (m *Auth) setCookie(w http.ResponseWriter, r *http.Request) *http.Cookie {
-	userToken, err := auth.GenerateToken(m.secretKey)
+// SignIn sets the cookie of the known user, e.g. the owner of an account.
+func (m *Auth) SignIn(w http.ResponseWriter, userID uint32) error {
+	token, err := m.tokens.Issue(userID)
 	if err != nil {
-		http.Error(w, "server error", http.StatusInternalServerError)
+		return err
 	}
+	http.SetCookie(w, &http.Cookie{Name: UserTokenCookieName, Value: token})
+	log.Printf("Signed in userID=%v", userID)
+	return nil
+}
+
+// setCookie sets the cookie with the token both in the response and in the request,
+// so the handlers behind the middleware see the same token as the client.
+func setCookie(w http.ResponseWriter, r *http.Request, token string) {
 	cookie := http.Cookie{
 		Name:  UserTokenCookieName,
-		Value: userToken,
+		Value: token,
 	}
 	http.SetCookie(w, &cookie)
+	cookies := r.Cookies()
+	r.Header.Del("Cookie")
+	for _, c := range cookies {
+		if c.Name != UserTokenCookieName {
+			r.AddCookie(c)
+		}
+	}
 	r.AddCookie(&cookie)
 	log.Printf("Set new cookie %s=%s", cookie.Name, cookie.Value)
-	return &cookie
 }
 
-// verifyCookie verifies cookies (token).
-func (m *Auth) verifyCookie(w http.ResponseWriter, cookie *http.Cookie) bool {
-	data, err := hex.DecodeString(cookie.Value)
-	if err != nil {
-		http.Error(w, "server error", http.StatusInternalServerError)
-		return false
+// bearerToken returns the API key from the Authorization header.
+func bearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, bearerPrefix) {
+		return "", false
 	}
-	return auth.VerifyToken(data, m.secretKey)
+	return strings.TrimSpace(header[len(bearerPrefix):]), true
+}
+
+// withUserID passes the user ID to the next handler.
+func withUserID(next http.Handler, w http.ResponseWriter, r *http.Request, userID uint32) {
+	log.Printf("Added userID=%v to the context", userID)
+	ctx := context.WithValue(
+		r.Context(),
+		UserIDCtxKey,
+		userID,
+	)
+	next.ServeHTTP(w, r.WithContext(ctx))
 }
 
-// extractID extracts the ID from the cookie.
-func (m *Auth) extractID(w http.ResponseWriter, cookie *http.Cookie) uint32 {
-	data, err := hex.DecodeString(cookie.Value)
+// userID returns the ID of the user from the cookie. A new user is created if
+// the token is missing, invalid or expired. The token is re-issued if it expires soon.
+func (m *Auth) userID(w http.ResponseWriter, r *http.Request) (uint32, error) {
+	if cookie, err := r.Cookie(UserTokenCookieName); err == nil {
+		claims, err := m.tokens.Verify(cookie.Value)
+		if err == nil {
+			log.Printf("Authentification is successful")
+			if m.tokens.NeedsRefresh(claims) {
+				token, err := m.tokens.Issue(claims.UserID)
+				if err != nil {
+					return 0, err
+				}
+				setCookie(w, r, token)
+			}
+			return claims.UserID, nil
+		}
+		log.Printf("Authentification is not successful: %v", err)
+	}
+	token, userID, err := m.tokens.New()
 	if err != nil {
-		http.Error(w, "server error", http.StatusInternalServerError)
-		return 0
+		return 0, err
 	}
-	return auth.ExtractID(data)
+	setCookie(w, r, token)
+	return userID, nil
 }
 
 // Handler returns a middleware handler.
 func (m *Auth) Handler(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		cookie, err := r.Cookie(UserTokenCookieName)
-
-		if err == nil {
-			verified := m.verifyCookie(w, cookie)
-			if verified {
-				log.Printf("Authentification is successful")
-			} else {
-				log.Printf("Authentification is not successful")
-				cookie = m.setCookie(w, r)
+		if apiKey, ok := bearerToken(r); ok && m.accounts != nil {
+			userID, err := m.accounts.Authenticate(r.Context(), apiKey)
+			if err != nil {
+				if errors.Is(err, service.ErrUnauthenticated) {
+					http.Error(w, "invalid api key", http.StatusUnauthorized)
+				} else {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+				}
+				return
 			}
-		} else if errors.Is(err, http.ErrNoCookie) {
-			cookie = m.setCookie(w, r)
+			withUserID(next, w, r, userID)
+			return
+		}
+		userID, err := m.userID(w, r)
+		if err != nil {
+			http.Error(w, "server error", http.StatusInternalServerError)
+			return
 		}
-		userID := m.extractID(w, cookie)
-		log.Printf("Added userID=%v to the context", userID)
-		ctx := context.WithValue(
-			r.Context(),
-			UserIDCtxKey,
-			userID,
-		)
-		next.ServeHTTP(w, r.WithContext(ctx))
+		withUserID(next, w, r, userID)
 	})
 }
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/blokhinnv/shorty/internal/app/ratelimit"
+)
+
+// RateLimit limits the requests of the category by the user and by the client IP.
+// The requests over the limit get 429 with the number of seconds to wait in Retry-After.
+// It must follow the auth middleware. A nil limiter lets all the requests through.
+func RateLimit(limiter *ratelimit.Limiter, category ratelimit.Category) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if limiter == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			userID, _ := r.Context().Value(UserIDCtxKey).(uint32)
+			retryAfter, err := limiter.Allow(r.Context(), category, userID, ClientIP(r))
+			if err != nil {
+				if errors.Is(err, ratelimit.ErrLimitExceeded) {
+					seconds := int(math.Ceil(retryAfter.Seconds()))
+					w.Header().Set("Retry-After", strconv.Itoa(seconds))
+					http.Error(w, err.Error(), http.StatusTooManyRequests)
+				} else {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+				}
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
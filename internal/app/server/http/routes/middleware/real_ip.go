@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/blokhinnv/shorty/internal/app/server/realip"
+)
+
+// ClientIPCtxKey is the key of the client IP in the context.
+const ClientIPCtxKey = ContextStringKey("clientIP")
+
+// RealIP adds the IP of the client to the context. X-Real-IP is only
+// trusted if the request comes from one of the proxies.
+func RealIP(proxies *realip.Proxies) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := proxies.ClientIP(r.RemoteAddr, r.Header.Get(realip.Header))
+			ctx := context.WithValue(r.Context(), ClientIPCtxKey, ip)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// ClientIP returns the IP of the client which made the request.
+// Without the RealIP middleware it's the IP of the peer.
+func ClientIP(r *http.Request) net.IP {
+	if ip, ok := r.Context().Value(ClientIPCtxKey).(net.IP); ok {
+		return ip
+	}
+	return realip.ParseAddr(r.RemoteAddr)
+}
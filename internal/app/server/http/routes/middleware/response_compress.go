@@ -44,6 +44,10 @@ func (gzw *gzipWriter) Write(b []byte) (int, error) {
 	return gzw.buf.Write(b)
 }
 
+// Flush implements http.Flusher, so streaming handlers work behind the middleware.
+// The response is buffered anyway, so it's sent when the handler completes.
+func (gzw *gzipWriter) Flush() {}
+
 // writeResponse detects the need for compression and replaces
 // gzw.Writer and needed.
 func (gzw *gzipWriter) writeResponse() {
@@ -110,7 +114,8 @@ func (gzw *gzipWriter) Close() {
 // NewGzipWriter - gzipWriter constructor.
 func NewGzipWriter(w http.ResponseWriter) *gzipWriter {
 	buf := new(bytes.Buffer)
-	return &gzipWriter{ResponseWriter: w, Writer: w, buf: buf}
+	// handlers may write the body without calling WriteHeader
+	return &gzipWriter{ResponseWriter: w, Writer: w, buf: buf, statusCode: http.StatusOK}
 }
 
 // ResponseGZipCompess returns the middleware handler.
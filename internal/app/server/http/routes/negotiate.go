@@ -0,0 +1,61 @@
+package routes
+
+import (
+	"mime"
+	"strconv"
+	"strings"
+)
+
+// Media types the handlers can answer with.
+const (
+	contentTypeHTML = "text/html"
+	contentTypeJSON = "application/json"
+)
+
+// negotiate returns the offered media type the client accepts most.
+// The offers accepted equally are preferred in their order, so the first
+// one is returned for a client without the Accept header.
+func negotiate(accept string, offers ...string) string {
+	best, bestQ := offers[0], -1.0
+	for _, offer := range offers {
+		if q := quality(accept, offer); q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+// quality returns the quality the Accept header gives the media type.
+// The most specific matching range wins: "text/html" over "text/*" over "*/*".
+func quality(accept, mediaType string) float64 {
+	if strings.TrimSpace(accept) == "" {
+		return 1
+	}
+	typ, _, _ := strings.Cut(mediaType, "/")
+	q, specificity := 0.0, 0
+	for _, part := range strings.Split(accept, ",") {
+		rng, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		s := 0
+		switch rng {
+		case mediaType:
+			s = 3
+		case typ + "/*":
+			s = 2
+		case "*/*":
+			s = 1
+		}
+		if s <= specificity {
+			continue
+		}
+		specificity, q = s, 1
+		if v, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+	}
+	return q
+}
@@ -3,13 +3,13 @@ package routes
 import (
 	"net/http"
 
-	"github.com/blokhinnv/shorty/internal/app/storage"
+	"github.com/blokhinnv/shorty/internal/app/service"
 )
 
 // PingHandlerFunc - implementation of the /ping endpoint.
-func PingHandlerFunc(s storage.Storage) func(http.ResponseWriter, *http.Request) {
+func PingHandlerFunc(svc *service.Shortener) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if !s.Ping(r.Context()) {
+		if !svc.Ping(r.Context()) {
 			http.Error(
 				w,
 				"connection is lost",
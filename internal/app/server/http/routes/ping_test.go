@@ -21,7 +21,7 @@ type PingTestSuite struct {
 func (suite *PingTestSuite) SetupSuite() {
 	suite.ctrl = gomock.NewController(suite.T())
 	suite.db = storage.NewMockStorage(suite.ctrl)
-	suite.handler = PingHandlerFunc(suite.db)
+	suite.handler = PingHandlerFunc(newTestService(suite.db))
 }
 
 func (suite *PingTestSuite) TearDownSuite() {
@@ -0,0 +1,76 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/blokhinnv/shorty/internal/app/policy"
+	"github.com/blokhinnv/shorty/internal/app/server/http/routes/middleware"
+	"github.com/blokhinnv/shorty/internal/app/service"
+)
+
+// PolicyUpdateJSONRequest - the body of the request to update a list of the policy.
+type PolicyUpdateJSONRequest struct {
+	Add    []string `json:"add,omitempty"`
+	Remove []string `json:"remove,omitempty"`
+}
+
+// Policy is a structure for the handlers which manage the lists of the URL policy.
+// Only the clients from the trusted subnet have access.
+type Policy struct {
+	svc *service.Shortener
+}
+
+// NewPolicy - constructor for Policy.
+func NewPolicy(svc *service.Shortener) *Policy {
+	return &Policy{svc: svc}
+}
+
+// policyError writes the error of the policy administration.
+func policyError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrPermissionDenied):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, service.ErrInvalidArgument):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, service.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Lists - implementation of the GET /api/internal/policy endpoint.
+// Returns the domains of the lists, e.g. {"allow":[],"block":["phishing.com"]}.
+func (h *Policy) Lists(w http.ResponseWriter, r *http.Request) {
+	lists, err := h.svc.PolicyLists(middleware.ClientIP(r))
+	if err != nil {
+		policyError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, lists)
+}
+
+// UpdateList - implementation of the PATCH /api/internal/policy/{list} endpoint.
+// Accepts {"add":[...],"remove":[...]} and returns the updated lists.
+func (h *Policy) UpdateList(w http.ResponseWriter, r *http.Request) {
+	var req PolicyUpdateJSONRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "can't decode body", http.StatusBadRequest)
+		return
+	}
+	lists, err := h.svc.UpdatePolicyList(
+		middleware.ClientIP(r),
+		policy.ListName(chi.URLParam(r, "list")),
+		req.Add,
+		req.Remove,
+	)
+	if err != nil {
+		policyError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, lists)
+}
@@ -0,0 +1,140 @@
+package routes
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blokhinnv/shorty/internal/app/policy"
+	"github.com/blokhinnv/shorty/internal/app/server/auth"
+	"github.com/blokhinnv/shorty/internal/app/server/config"
+	"github.com/blokhinnv/shorty/internal/app/server/http/routes/middleware"
+	"github.com/blokhinnv/shorty/internal/app/server/realip"
+	"github.com/blokhinnv/shorty/internal/app/service"
+	"github.com/blokhinnv/shorty/internal/app/shorten"
+	"github.com/blokhinnv/shorty/internal/app/storage"
+)
+
+func TestPolicy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	db := storage.NewMockStorage(ctrl)
+	p, err := policy.New(&policy.Config{Schemes: []string{"http", "https"}}, net.DefaultResolver)
+	require.NoError(t, err)
+	defer p.Close()
+	_, subnet, err := net.ParseCIDR("192.168.0.0/24")
+	require.NoError(t, err)
+	svc := service.NewShortener(
+		db,
+		shorten.HashGenerator{},
+		nil,
+		nil,
+		&service.Config{AliasCfg: aliasCfg, TrustedSubnet: subnet, Policy: p},
+	)
+	tokens := auth.NewTokens(&auth.TokensConfig{
+		Keyring:       auth.NewKeyring([]byte("shorty")),
+		TTL:           time.Hour,
+		RefreshBefore: time.Minute,
+	})
+	h := NewPolicy(svc)
+	router := chi.NewRouter()
+	router.Use(middleware.RealIP(realip.NewProxies([]string{"192.0.2.1"})))
+	router.Use(middleware.BaseURLCtx(&config.ServerConfig{BaseURL: "http://localhost:8080"}))
+	router.Use(middleware.NewAuth(tokens, nil).Handler)
+	router.Post("/", GetShortURLHandlerFunc(svc))
+	router.Post("/api/shorten", GetShortURLAPIHandlerFunc(svc))
+	router.Get("/api/internal/policy", h.Lists)
+	router.Patch("/api/internal/policy/{list}", h.UpdateList)
+
+	doFrom := func(peer, method, path, contentType, body, ip string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		r.RemoteAddr = peer
+		r.Header.Set("Content-Type", contentType)
+		r.Header.Set("X-Real-IP", ip)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+	do := func(method, path, contentType, body, ip string) *httptest.ResponseRecorder {
+		return doFrom("192.0.2.1:1234", method, path, contentType, body, ip)
+	}
+
+	t.Run("Admin", func(t *testing.T) {
+		const update = `{"add":["phishing.com","spam.com"],"remove":["spam.com"]}`
+		w := do(http.MethodPatch, "/api/internal/policy/block", "application/json", update, "10.0.0.1")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		w = do(http.MethodPatch, "/api/internal/policy/grey", "application/json", update, "192.168.0.1")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		w = do(http.MethodPatch, "/api/internal/policy/block", "application/json", "{", "192.168.0.1")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w = do(http.MethodPatch, "/api/internal/policy/block", "application/json", update, "192.168.0.1")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"allow":[],"block":["phishing.com"]}`, w.Body.String())
+
+		w = do(http.MethodGet, "/api/internal/policy", "", "", "192.168.0.1")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"allow":[],"block":["phishing.com"]}`, w.Body.String())
+	})
+
+	t.Run("Untrusted peer", func(t *testing.T) {
+		w := doFrom("10.0.0.1:1234", http.MethodGet, "/api/internal/policy", "", "", "192.168.0.1")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		w = doFrom(
+			"10.0.0.1:1234",
+			http.MethodPatch,
+			"/api/internal/policy/allow",
+			"application/json",
+			`{"add":["localhost"]}`,
+			"192.168.0.1",
+		)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Rejected", func(t *testing.T) {
+		tests := []struct {
+			name        string
+			path        string
+			contentType string
+			body        string
+			url         string
+			reason      policy.Reason
+		}{
+			{
+				name:        "text",
+				path:        "/",
+				contentType: "text/plain",
+				body:        "javascript:alert(1)",
+				url:         "javascript:alert(1)",
+				reason:      policy.ReasonScheme,
+			},
+			{
+				name:        "JSON",
+				path:        "/api/shorten",
+				contentType: "application/json",
+				body:        `{"url":"https://login.phishing.com/"}`,
+				url:         "https://login.phishing.com/",
+				reason:      policy.ReasonBlockedDomain,
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				w := do(http.MethodPost, tt.path, tt.contentType, tt.body, "10.0.0.1")
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				var resp RejectionJSONResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Equal(t, tt.reason, resp.Reason)
+				assert.Equal(t, tt.url, resp.URL)
+				assert.NotEmpty(t, resp.Error)
+			})
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blokhinnv/shorty/internal/app/ratelimit"
+	"github.com/blokhinnv/shorty/internal/app/server/auth"
+	"github.com/blokhinnv/shorty/internal/app/server/http/routes/middleware"
+	"github.com/blokhinnv/shorty/internal/app/server/realip"
+)
+
+func TestRateLimit(t *testing.T) {
+	tokens := auth.NewTokens(&auth.TokensConfig{
+		Keyring:       auth.NewKeyring([]byte("shorty")),
+		TTL:           time.Hour,
+		RefreshBefore: time.Minute,
+	})
+	store := ratelimit.NewMemoryStore(time.Minute)
+	defer store.Close()
+	limiter := ratelimit.NewLimiter(store, &ratelimit.Config{
+		Limits: map[ratelimit.Category]ratelimit.Limit{ratelimit.Create: {Rate: 0.5, Burst: 2}},
+	})
+	router := chi.NewRouter()
+	// X-Real-IP is only trusted if it comes from the proxy
+	router.Use(middleware.RealIP(realip.NewProxies([]string{"192.0.2.1"})))
+	router.Use(middleware.NewAuth(tokens, nil).Handler)
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	router.With(middleware.RateLimit(limiter, ratelimit.Create)).Post("/", ok)
+	router.With(middleware.RateLimit(limiter, ratelimit.Redirect)).Get("/", ok)
+
+	peer := "192.0.2.1:1234"
+	do := func(method string, userID uint32, ip string) *http.Response {
+		token, err := tokens.Issue(userID)
+		require.NoError(t, err)
+		r := httptest.NewRequest(method, "/", nil)
+		r.RemoteAddr = peer
+		r.AddCookie(&http.Cookie{Name: middleware.UserTokenCookieName, Value: token})
+		r.Header.Set("X-Real-IP", ip)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w.Result()
+	}
+
+	for i := 0; i < 2; i++ {
+		resp := do(http.MethodPost, 1, "10.0.0.1")
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+	tests := []struct {
+		name       string
+		method     string
+		userID     uint32
+		ip         string
+		wantStatus int
+	}{
+		{"same user", http.MethodPost, 1, "10.0.0.1", http.StatusTooManyRequests},
+		{"same user, other IP", http.MethodPost, 1, "10.0.0.2", http.StatusTooManyRequests},
+		{"other user, same IP", http.MethodPost, 2, "10.0.0.1", http.StatusTooManyRequests},
+		{"other user, other IP", http.MethodPost, 2, "10.0.0.2", http.StatusOK},
+		{"not limited", http.MethodGet, 1, "10.0.0.1", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := do(tt.method, tt.userID, tt.ip)
+			defer resp.Body.Close()
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			if tt.wantStatus == http.StatusTooManyRequests {
+				assert.Equal(t, "2", resp.Header.Get("Retry-After"))
+			}
+		})
+	}
+	// the client which is not the proxy is limited by its own address
+	peer = "198.51.100.1:1234"
+	for i := 0; i < 2; i++ {
+		resp := do(http.MethodPost, 3, "10.0.0.3")
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+	resp := do(http.MethodPost, 4, "10.0.0.4")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+}
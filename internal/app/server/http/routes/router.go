@@ -1,39 +1,71 @@
 package routes
 
 import (
+	"net/http"
+
+	"github.com/blokhinnv/shorty/internal/app/metrics"
+	"github.com/blokhinnv/shorty/internal/app/ratelimit"
+	"github.com/blokhinnv/shorty/internal/app/server/auth"
 	"github.com/blokhinnv/shorty/internal/app/server/config"
 	m "github.com/blokhinnv/shorty/internal/app/server/http/routes/middleware"
-	"github.com/blokhinnv/shorty/internal/app/storage"
+	"github.com/blokhinnv/shorty/internal/app/server/realip"
+	"github.com/blokhinnv/shorty/internal/app/service"
+	"github.com/blokhinnv/shorty/internal/app/tracing"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 )
 
-// NewRouter - constructor for a new router.
+// NewRouter - constructor for a new router. Without a limiter the requests are not limited.
 func NewRouter(
-	storage storage.Storage,
+	svc *service.Shortener,
+	accounts *service.Accounts,
+	limiter *ratelimit.Limiter,
+	api http.Handler,
 	cfg *config.ServerConfig,
-	routerCloseCh chan struct{},
 ) chi.Router {
-	authentifier := m.NewAuth([]byte(cfg.SecretKey))
+	authentifier := m.NewAuth(auth.NewTokens(auth.GetTokensConfig(cfg)), accounts)
+	deleteHandler := NewDeleteURLsHandler(svc)
+	accountsHandler := NewAccounts(accounts, authentifier)
+	policyHandler := NewPolicy(svc)
+	limit := func(category ratelimit.Category) func(http.Handler) http.Handler {
+		return tracing.Middleware("rate_limit", m.RateLimit(limiter, category))
+	}
 	r := chi.NewRouter()
-	r.Use(middleware.Logger)
+	r.Use(metrics.HTTPMiddleware)
+	r.Use(tracing.HTTPServer)
+	r.Use(tracing.Middleware("real_ip", m.RealIP(realip.GetProxies(cfg))))
+	r.Use(tracing.Middleware("logger", middleware.Logger))
 	r.Mount("/debug", middleware.Profiler())
 
 	r.Route("/", func(r chi.Router) {
-		r.Use(m.BaseURLCtx(cfg))
-		r.Use(authentifier.Handler)
-		r.Use(m.RequestGZipDecompress)
-		r.Use(m.ResponseGZipCompess)
-		r.Post("/", GetShortURLHandlerFunc(storage))          // + +
-		r.Get("/{idURL}", GetOriginalURLHandlerFunc(storage)) // + +
+		r.Use(tracing.Middleware("base_url", m.BaseURLCtx(cfg)))
+		r.Use(tracing.Middleware("auth", authentifier.Handler))
+		r.Use(tracing.Middleware("gzip_decompress", m.RequestGZipDecompress))
+		r.Use(tracing.Middleware("gzip_compress", m.ResponseGZipCompess))
+		r.With(limit(ratelimit.Create)).Post("/", GetShortURLHandlerFunc(svc))            // + +
+		r.With(limit(ratelimit.Redirect)).Get("/{idURL}", GetOriginalURLHandlerFunc(svc)) // + +
+		r.With(limit(ratelimit.Redirect)).Head("/{idURL}", GetOriginalURLHandlerFunc(svc))
 		r.Route("/api", func(r chi.Router) {
-			r.Get("/user/urls", GetOriginalURLsHandlerFunc(storage)) // + +
-			r.Delete("/user/urls", NewDeleteURLsHandler(storage, 100, routerCloseCh).Handler)
-			r.Post("/shorten", GetShortURLAPIHandlerFunc(storage))                 // + +
-			r.Post("/shorten/batch", NewGetShortURLsBatchHandler(storage).Handler) // + +
-			r.Get("/internal/stats", NewGetStats(storage, cfg.TrustedSubnet).Handler)
+			r.Get("/user/urls", GetOriginalURLsHandlerFunc(svc)) // + +
+			r.Get("/user/urls/{idURL}/stats", GetURLStatsHandlerFunc(svc))
+			r.Get("/user/urls/{idURL}/history", GetURLHistoryHandlerFunc(svc))
+			r.Patch("/user/urls/{idURL}", UpdateURLHandlerFunc(svc))
+			r.With(limit(ratelimit.Delete)).Delete("/user/urls", deleteHandler.Handler)
+			r.With(limit(ratelimit.Login)).Post("/user/register", accountsHandler.Register)
+			r.With(limit(ratelimit.Login)).Post("/user/login", accountsHandler.Login)
+			r.With(limit(ratelimit.Login)).Post("/user/keys", accountsHandler.CreateAPIKey)
+			r.With(limit(ratelimit.Login)).Post("/user/claim", accountsHandler.Claim)
+			r.With(limit(ratelimit.Create)).Post("/shorten", GetShortURLAPIHandlerFunc(svc))                 // + +
+			r.With(limit(ratelimit.Create)).Post("/shorten/batch", NewGetShortURLsBatchHandler(svc).Handler) // + +
+			r.Get("/internal/stats", NewGetStats(svc).Handler)
+			r.Get("/internal/policy", policyHandler.Lists)
+			r.Patch("/internal/policy/{list}", policyHandler.UpdateList)
 		})
+		// the REST API generated from the proto, the paths above are kept for compatibility
+		if api != nil {
+			r.Mount("/v1", api)
+		}
 	})
-	r.Get("/ping", PingHandlerFunc(storage))
+	r.Get("/ping", PingHandlerFunc(svc))
 	return r
 }
@@ -3,80 +3,40 @@ package routes
 import (
 	"context"
 	"encoding/json"
-	"fmt"
-	"log"
-	"net"
+	"errors"
 	"net/http"
 	"time"
 
-	"github.com/blokhinnv/shorty/internal/app/storage"
+	"github.com/blokhinnv/shorty/internal/app/server/http/routes/middleware"
+	"github.com/blokhinnv/shorty/internal/app/service"
 )
 
-// statsResponse is a struct to marshal response into.
-type statsResponse struct {
-	URLs  int `json:"urls"`
-	Users int `json:"users"`
-}
-
 // GetStats is a structure for handler implementation.
 type GetStats struct {
-	s             storage.Storage
-	trustedSubnet *net.IPNet // example: 192.168.0.1 in 192.168.0.0/24
+	svc *service.Shortener
 }
 
 // NewGetStats - constructor for GetStats.
-func NewGetStats(s storage.Storage, trustedSubnet string) *GetStats {
-	if trustedSubnet == "" {
-		return &GetStats{s: s, trustedSubnet: nil}
-	}
-
-	_, ipv4Net, err := net.ParseCIDR(trustedSubnet)
-	if err != nil {
-		log.Fatalln(err)
-	}
-	return &GetStats{s: s, trustedSubnet: ipv4Net}
+func NewGetStats(svc *service.Shortener) *GetStats {
+	return &GetStats{svc: svc}
 }
 
 // Handler - handler implementation.
 func (h *GetStats) Handler(w http.ResponseWriter, r *http.Request) {
-	if h.trustedSubnet == nil {
-		http.Error(
-			w,
-			"trusted network is not set",
-			http.StatusForbidden,
-		)
-		return
-	}
-	ipStr := r.Header.Get("X-Real-IP")
-	ip := net.ParseIP(ipStr)
-	if ip == nil {
-		http.Error(
-			w,
-			"failed parse ip from http header",
-			http.StatusInternalServerError,
-		)
-		return
-	}
-	if !h.trustedSubnet.Contains(ip) {
-		http.Error(
-			w,
-			fmt.Sprintf("ip %v is not in trusted network %+v", ip, h.trustedSubnet),
-			http.StatusForbidden,
-		)
-		return
-	}
 	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
-	urls, users, err := h.s.GetStats(ctx)
+	result, err := h.svc.Stats(ctx, middleware.ClientIP(r))
 	if err != nil {
-		http.Error(
-			w,
-			err.Error(),
-			http.StatusInternalServerError,
-		)
+		switch {
+		case errors.Is(err, service.ErrPermissionDenied):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case errors.Is(err, service.ErrInvalidArgument):
+			http.Error(w, "failed parse ip from http header", http.StatusInternalServerError)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
 		return
 	}
-	result := statsResponse{URLs: urls, Users: users}
 	resultEncoded, err := json.Marshal(result)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
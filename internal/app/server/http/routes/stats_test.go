@@ -6,6 +6,11 @@ import (
 	"testing"
 
 	"github.com/blokhinnv/shorty/internal/app/log"
+	"github.com/blokhinnv/shorty/internal/app/server/config"
+	"github.com/blokhinnv/shorty/internal/app/server/http/routes/middleware"
+	"github.com/blokhinnv/shorty/internal/app/server/realip"
+	"github.com/blokhinnv/shorty/internal/app/service"
+	"github.com/blokhinnv/shorty/internal/app/shorten"
 	"github.com/blokhinnv/shorty/internal/app/storage"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/suite"
@@ -31,12 +36,27 @@ func (suite *StatsTestSuite) makeRequest(
 	testName string,
 	trustedSubnet string,
 	realIP string,
+) *httptest.ResponseRecorder {
+	return suite.makeRequestFrom(testName, "192.0.2.1:1234", trustedSubnet, realIP)
+}
+
+func (suite *StatsTestSuite) makeRequestFrom(
+	testName string,
+	peer string,
+	trustedSubnet string,
+	realIP string,
 ) *httptest.ResponseRecorder {
 	rr := httptest.NewRecorder()
 	req, _ := http.NewRequest(http.MethodGet, "/internal/stats", nil)
+	req.RemoteAddr = peer
 	req.Header.Set("X-Real-IP", realIP)
-	stats := NewGetStats(suite.db, trustedSubnet)
-	handler := http.HandlerFunc(stats.Handler)
+	conf, err := service.GetConfig(&config.ServerConfig{TrustedSubnet: trustedSubnet})
+	suite.Require().NoError(err)
+	svc := service.NewShortener(suite.db, shorten.HashGenerator{}, nil, nil, conf)
+	stats := NewGetStats(svc)
+	handler := middleware.RealIP(realip.NewProxies([]string{"192.0.2.1"}))(
+		http.HandlerFunc(stats.Handler),
+	)
 	handler.ServeHTTP(rr, req)
 	log.Printf("[%v]: %v", testName, rr.Body.String())
 	return rr
@@ -48,10 +68,23 @@ func (suite *StatsTestSuite) TestNoSubnet() {
 }
 
 func (suite *StatsTestSuite) TestBadIP() {
-	rr := suite.makeRequest("TestNoSubnet", "192.168.0.0/24", "192.168")
+	// the IP of the proxy itself is used instead of the header which can't be parsed
+	rr := suite.makeRequest("TestBadIP", "192.168.0.0/24", "192.168")
+	suite.Equal(http.StatusForbidden, rr.Code)
+	rr = suite.makeRequestFrom("TestBadIP", "", "192.168.0.0/24", "")
 	suite.Equal(http.StatusInternalServerError, rr.Code)
 }
 
+func (suite *StatsTestSuite) TestUntrustedPeer() {
+	rr := suite.makeRequestFrom(
+		"TestUntrustedPeer",
+		"10.0.0.1:1234",
+		"192.168.0.0/24",
+		"192.168.0.1",
+	)
+	suite.Equal(http.StatusForbidden, rr.Code)
+}
+
 func (suite *StatsTestSuite) TestNotContains() {
 	rr := suite.makeRequest("TestNoSubnet", "192.168.0.0/24", "192.168.10.10")
 	suite.Equal(http.StatusForbidden, rr.Code)
@@ -0,0 +1,99 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/blokhinnv/shorty/internal/app/server/http/routes/middleware"
+	"github.com/blokhinnv/shorty/internal/app/service"
+	"github.com/blokhinnv/shorty/internal/app/storage"
+)
+
+// UpdateURLJSONRequest - the body of the request to change the destination of a short URL.
+type UpdateURLJSONRequest struct {
+	URL string `json:"url"`
+}
+
+// updateURLError writes the error of the change of the destination.
+func updateURLError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidArgument):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, service.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrGone):
+		http.Error(w, err.Error(), http.StatusGone)
+	case errors.Is(err, service.ErrConflict):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// UpdateURLHandlerFunc - implementation of the PATCH /api/user/urls/{id} endpoint.
+// Accepts {"url":"..."} and changes the destination of the user's short URL.
+// Returns the short URL in the form of GET /api/user/urls.
+func UpdateURLHandlerFunc(svc *service.Shortener) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+		defer cancel()
+		baseURL, ok := ctx.Value(middleware.BaseURLCtxKey).(string)
+		if !ok {
+			http.Error(
+				w,
+				http.StatusText(http.StatusInternalServerError),
+				http.StatusInternalServerError,
+			)
+			return
+		}
+		userID, ok := ctx.Value(middleware.UserIDCtxKey).(uint32)
+		if !ok {
+			http.Error(
+				w,
+				"no user id provided",
+				http.StatusInternalServerError,
+			)
+			return
+		}
+		var req UpdateURLJSONRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "can't decode body", http.StatusBadRequest)
+			return
+		}
+		rec, err := svc.UpdateURL(ctx, userID, chi.URLParam(r, "idURL"), req.URL)
+		if err != nil {
+			updateURLError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, prepareAnswer([]storage.Record{rec}, baseURL)[0])
+	}
+}
+
+// GetURLHistoryHandlerFunc - implementation of the GET /api/user/urls/{id}/history endpoint.
+// Returns the previous destinations of the user's short URL from the oldest one.
+func GetURLHistoryHandlerFunc(svc *service.Shortener) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+		defer cancel()
+		userID, ok := ctx.Value(middleware.UserIDCtxKey).(uint32)
+		if !ok {
+			http.Error(
+				w,
+				"no user id provided",
+				http.StatusInternalServerError,
+			)
+			return
+		}
+		history, err := svc.URLHistory(ctx, userID, chi.URLParam(r, "idURL"))
+		if err != nil {
+			updateURLError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, history)
+	}
+}
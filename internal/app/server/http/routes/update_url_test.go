@@ -0,0 +1,130 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/blokhinnv/shorty/internal/app/server/http/routes/middleware"
+	"github.com/blokhinnv/shorty/internal/app/storage"
+)
+
+type UpdateURLSuite struct {
+	suite.Suite
+	ctrl   *gomock.Controller
+	db     *storage.MockStorage
+	router chi.Router
+}
+
+func (suite *UpdateURLSuite) SetupSuite() {
+	suite.ctrl = gomock.NewController(suite.T())
+	suite.db = storage.NewMockStorage(suite.ctrl)
+	svc := newTestService(suite.db)
+	suite.router = chi.NewRouter()
+	suite.router.Patch("/api/user/urls/{idURL}", UpdateURLHandlerFunc(svc))
+	suite.router.Get("/api/user/urls/{idURL}/history", GetURLHistoryHandlerFunc(svc))
+}
+
+func (suite *UpdateURLSuite) TearDownSuite() {
+	suite.ctrl.Finish()
+}
+
+func (suite *UpdateURLSuite) makeRequest(method, target, body string) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, target, strings.NewReader(body))
+	ctx := context.WithValue(context.Background(), middleware.UserIDCtxKey, uint32(1))
+	ctx = context.WithValue(ctx, middleware.BaseURLCtxKey, "http://localhost:8080")
+	suite.router.ServeHTTP(rr, req.WithContext(ctx))
+	return rr
+}
+
+func (suite *UpdateURLSuite) TestOK() {
+	suite.db.EXPECT().
+		UpdateURL(gomock.Any(), "qwerty", uint32(1), "http://ya.ru", "").
+		Return(nil)
+	suite.db.EXPECT().
+		GetURLsByUser(gomock.Any(), uint32(1)).
+		Return([]storage.Record{{URL: "http://ya.ru", URLID: "qwerty"}}, nil)
+	rr := suite.makeRequest(http.MethodPatch, "/api/user/urls/qwerty", `{"url":"http://ya.ru"}`)
+	suite.Equal(http.StatusOK, rr.Code)
+	var answer ShortenedURLSAnswer
+	suite.NoError(json.NewDecoder(rr.Body).Decode(&answer))
+	suite.Equal(
+		ShortenedURLSAnswer{URL: "http://ya.ru", URLID: "http://localhost:8080/qwerty"},
+		answer,
+	)
+}
+
+func (suite *UpdateURLSuite) TestErrors() {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{name: "not owner", err: storage.ErrNotOwner, status: http.StatusNotFound},
+		{name: "not found", err: storage.ErrURLWasNotFound, status: http.StatusNotFound},
+		{name: "deleted", err: storage.ErrURLWasDeleted, status: http.StatusGone},
+		{name: "shared", err: storage.ErrURLShared, status: http.StatusConflict},
+		{
+			name:   "duplicate",
+			err:    &storage.DuplicateURLError{URL: "http://ya.ru", URLID: "asdfgh"},
+			status: http.StatusConflict,
+		},
+	}
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			suite.db.EXPECT().
+				UpdateURL(gomock.Any(), "qwerty", uint32(1), "http://ya.ru", "").
+				Return(tt.err)
+			rr := suite.makeRequest(
+				http.MethodPatch,
+				"/api/user/urls/qwerty",
+				`{"url":"http://ya.ru"}`,
+			)
+			suite.Equal(tt.status, rr.Code)
+		})
+	}
+}
+
+func (suite *UpdateURLSuite) TestBadRequest() {
+	rr := suite.makeRequest(http.MethodPatch, "/api/user/urls/qwerty", `{"url":`)
+	suite.Equal(http.StatusBadRequest, rr.Code)
+	rr = suite.makeRequest(http.MethodPatch, "/api/user/urls/qwerty", `{"url":"not a url"}`)
+	suite.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func (suite *UpdateURLSuite) TestHistory() {
+	changedAt := time.Date(2023, 3, 1, 10, 30, 0, 0, time.UTC)
+	history := []storage.Revision{
+		{URLID: "qwerty", URL: "http://yandex.ru", ChangedAt: changedAt, ChangedBy: 1},
+	}
+	suite.db.EXPECT().
+		GetURLsByUser(gomock.Any(), uint32(1)).
+		Return([]storage.Record{{URL: "http://ya.ru", URLID: "qwerty"}}, nil)
+	suite.db.EXPECT().GetURLHistory(gomock.Any(), "qwerty").Return(history, nil)
+	rr := suite.makeRequest(http.MethodGet, "/api/user/urls/qwerty/history", "")
+	suite.Equal(http.StatusOK, rr.Code)
+	var got []storage.Revision
+	suite.NoError(json.NewDecoder(rr.Body).Decode(&got))
+	suite.Equal(history, got)
+}
+
+func (suite *UpdateURLSuite) TestHistoryNotOwner() {
+	suite.db.EXPECT().
+		GetURLsByUser(gomock.Any(), uint32(1)).
+		Return([]storage.Record{{URL: "http://ya.ru", URLID: "qwerty"}}, nil)
+	rr := suite.makeRequest(http.MethodGet, "/api/user/urls/asdfgh/history", "")
+	suite.Equal(http.StatusNotFound, rr.Code)
+}
+
+func TestUpdateURLSuite(t *testing.T) {
+	suite.Run(t, new(UpdateURLSuite))
+}
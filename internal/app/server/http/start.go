@@ -2,22 +2,16 @@
 package http
 
 import (
-	"context"
+	"errors"
 	"net/http"
-	"os"
-	"time"
 
-	"github.com/go-chi/chi/v5"
 	"golang.org/x/crypto/acme/autocert"
 
-	"github.com/blokhinnv/shorty/internal/app/database"
-	"github.com/blokhinnv/shorty/internal/app/log"
 	"github.com/blokhinnv/shorty/internal/app/server/config"
-	"github.com/blokhinnv/shorty/internal/app/server/http/routes"
 )
 
 // Creates a http.Server object ready to support HTTPS.
-func prepareHTTPS(r chi.Router, serverAddress string) *http.Server {
+func prepareHTTPS(h http.Handler, serverAddress string) *http.Server {
 	manager := &autocert.Manager{
 		// directory to store certificates
 		Cache: autocert.DirCache("cache-dir"),
@@ -30,51 +24,33 @@ func prepareHTTPS(r chi.Router, serverAddress string) *http.Server {
 	}
 	server := &http.Server{
 		Addr:      serverAddress,
-		Handler:   r,
+		Handler:   h,
 		TLSConfig: manager.TLSConfig(),
 	}
 	return server
 }
 
-// RunHTTPServer creates the store and starts the server.
-func RunHTTPServer(ctx context.Context, cfg *config.ServerConfig) {
-	s, err := database.NewDBStorage(cfg)
-	if err != nil {
-		log.Fatal(err.Error())
+// NewServer creates a http.Server for the handler. HTTPS is enabled by the config.
+func NewServer(h http.Handler, cfg *config.ServerConfig) *http.Server {
+	if cfg.EnableHTTPS {
+		return prepareHTTPS(h, cfg.ServerAddress)
+	}
+	return &http.Server{
+		Addr:    cfg.ServerAddress,
+		Handler: h,
 	}
-	defer s.Close(ctx)
-	routerCloseCh := make(chan struct{}, 1)
-	r := routes.NewRouter(s, cfg, routerCloseCh)
-	log.Printf("Starting http server with config %+v\n", cfg)
+}
 
-	var server *http.Server
+// ListenAndServe starts the server created by NewServer.
+func ListenAndServe(server *http.Server, cfg *config.ServerConfig) error {
+	var err error
 	if cfg.EnableHTTPS {
-		server = prepareHTTPS(r, cfg.ServerAddress)
+		err = server.ListenAndServeTLS("", "")
 	} else {
-		server = &http.Server{
-			Addr:    cfg.ServerAddress,
-			Handler: r,
-		}
+		err = server.ListenAndServe()
 	}
-
-	go func() {
-		if cfg.EnableHTTPS {
-			server.ListenAndServeTLS("", "")
-		} else {
-			server.ListenAndServe()
-		}
-	}()
-	<-ctx.Done()
-	// signal to finish deleting goroutines
-	routerCloseCh <- struct{}{}
-	log.Println("Shutting down server gracefully...")
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	if err := server.Shutdown(shutdownCtx); err != nil {
-		log.Errorf("shutdown error: %v\n", err)
-		os.Exit(1)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
 	}
-	// ensure goroutines are finished
-	<-routerCloseCh
-	log.Println("Bye!")
+	return err
 }
@@ -0,0 +1,89 @@
+// Package realip finds out the IP of the client behind the trusted proxies.
+package realip
+
+import (
+	"net"
+	"strings"
+
+	"github.com/blokhinnv/shorty/internal/app/log"
+	"github.com/blokhinnv/shorty/internal/app/server/config"
+)
+
+// Header - the header the proxies pass the IP of the client in.
+const Header = "X-Real-IP"
+
+// Proxies - the networks of the proxies whose Header is trusted.
+// A nil Proxies trusts nobody.
+type Proxies struct {
+	nets []*net.IPNet
+}
+
+// NewProxies - Proxies constructor. Every entry is a CIDR or a single IP.
+// The entries which can't be parsed are skipped, so they aren't trusted.
+func NewProxies(entries []string) *Proxies {
+	p := &Proxies{}
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				log.Warnf("can't parse trusted proxy %q, skipping", entry)
+				continue
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			p.nets = append(p.nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, subnet, err := net.ParseCIDR(entry)
+		if err != nil {
+			log.Warnf("can't parse trusted proxy %q, skipping: %v", entry, err)
+			continue
+		}
+		p.nets = append(p.nets, subnet)
+	}
+	return p
+}
+
+// GetProxies - Proxies constructor based on server config.
+func GetProxies(cfg *config.ServerConfig) *Proxies {
+	return NewProxies(cfg.TrustedProxies)
+}
+
+// Trusts checks if the peer is a trusted proxy.
+func (p *Proxies) Trusts(ip net.IP) bool {
+	if p == nil || ip == nil {
+		return false
+	}
+	for _, subnet := range p.nets {
+		if subnet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseAddr returns the IP of the "host:port" or "host" address.
+func ParseAddr(addr string) net.IP {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	return net.ParseIP(addr)
+}
+
+// ClientIP returns the IP the trusted proxy passed in the header,
+// otherwise the IP of the peer itself.
+func (p *Proxies) ClientIP(peerAddr string, header string) net.IP {
+	ip := ParseAddr(peerAddr)
+	if p.Trusts(ip) {
+		if forwarded := net.ParseIP(strings.TrimSpace(header)); forwarded != nil {
+			return forwarded
+		}
+	}
+	return ip
+}
@@ -0,0 +1,33 @@
+package realip
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientIP(t *testing.T) {
+	proxies := NewProxies([]string{"10.0.0.0/8", " 192.168.1.1 ", "::1", "not a proxy"})
+	tests := []struct {
+		name     string
+		proxies  *Proxies
+		peerAddr string
+		header   string
+		want     net.IP
+	}{
+		{"trusted subnet", proxies, "10.1.2.3:5000", "203.0.113.7", net.ParseIP("203.0.113.7")},
+		{"trusted ip", proxies, "192.168.1.1:5000", "203.0.113.7", net.ParseIP("203.0.113.7")},
+		{"trusted ipv6", proxies, "[::1]:5000", "203.0.113.7", net.ParseIP("203.0.113.7")},
+		{"untrusted", proxies, "192.168.1.2:5000", "203.0.113.7", net.ParseIP("192.168.1.2")},
+		{"no proxies", nil, "10.1.2.3:5000", "203.0.113.7", net.ParseIP("10.1.2.3")},
+		{"bad header", proxies, "10.1.2.3:5000", "localhost", net.ParseIP("10.1.2.3")},
+		{"no port", proxies, "198.51.100.1", "", net.ParseIP("198.51.100.1")},
+		{"bad peer", proxies, "bufnet", "203.0.113.7", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.proxies.ClientIP(tt.peerAddr, tt.header))
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc"
+
+	"github.com/blokhinnv/shorty/internal/app/analytics"
+	"github.com/blokhinnv/shorty/internal/app/database"
+	"github.com/blokhinnv/shorty/internal/app/deletion"
+	"github.com/blokhinnv/shorty/internal/app/linkcheck"
+	"github.com/blokhinnv/shorty/internal/app/log"
+	"github.com/blokhinnv/shorty/internal/app/metrics"
+	"github.com/blokhinnv/shorty/internal/app/policy"
+	"github.com/blokhinnv/shorty/internal/app/preview"
+	"github.com/blokhinnv/shorty/internal/app/ratelimit"
+	"github.com/blokhinnv/shorty/internal/app/server/auth"
+	"github.com/blokhinnv/shorty/internal/app/server/config"
+	"github.com/blokhinnv/shorty/internal/app/server/gateway"
+	grpcserver "github.com/blokhinnv/shorty/internal/app/server/grpc"
+	httpserver "github.com/blokhinnv/shorty/internal/app/server/http"
+	"github.com/blokhinnv/shorty/internal/app/server/http/routes"
+	"github.com/blokhinnv/shorty/internal/app/server/realip"
+	"github.com/blokhinnv/shorty/internal/app/service"
+	"github.com/blokhinnv/shorty/internal/app/shorten"
+	"github.com/blokhinnv/shorty/internal/app/tracing"
+)
+
+// Protocols served by the process.
+const (
+	HTTP = "http"
+	GRPC = "grpc"
+	All  = "all"
+)
+
+// how long the servers may finish the requests on shutdown
+const shutdownTimeout = 5 * time.Second
+
+// multiplex passes gRPC requests to the gRPC server and the rest to h.
+// gRPC needs HTTP/2, so h2c is used when there is no TLS.
+func multiplex(grpcServer *grpc.Server, h http.Handler, enableHTTPS bool) http.Handler {
+	mux := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			grpcServer.ServeHTTP(w, r)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+	if enableHTTPS {
+		return mux
+	}
+	return h2c.NewHandler(mux, &http2.Server{})
+}
+
+// Run creates the service shared by the servers
+// and starts the servers set by cfg.Protocols. It blocks until ctx is done
+// or a server fails and then shuts everything down.
+func Run(ctx context.Context, cfg *config.ServerConfig) error {
+	shutdownTracing, err := tracing.Setup(ctx, tracing.GetTracingConfig(cfg))
+	if err != nil {
+		return err
+	}
+	defer shutdownTracing(context.Background())
+	s, err := database.NewDBStorage(cfg)
+	if err != nil {
+		return err
+	}
+	defer s.Close(context.Background())
+	metrics.WatchStorage(s)
+	if cfg.MetricsAddress != "" {
+		metricsServer := metrics.Start(cfg.MetricsAddress)
+		defer metricsServer.Close()
+	}
+	recorder, err := analytics.NewRecorder(s, analytics.GetRecorderConfig(cfg))
+	if err != nil {
+		return err
+	}
+	defer recorder.Close()
+	gen, err := shorten.NewIDGenerator(cfg, s)
+	if err != nil {
+		return err
+	}
+	queue := deletion.NewQueue(s, cfg.DeletionBufferSize, cfg.DeletionFlushInterval)
+	// the queue is closed after the servers, so the accepted jobs are not lost
+	defer queue.Close()
+	metrics.WatchDeletionQueue(queue.Len)
+	conf, err := service.GetConfig(cfg)
+	if err != nil {
+		return err
+	}
+	urlPolicy, err := policy.New(policy.GetConfig(cfg), net.DefaultResolver)
+	if err != nil {
+		return err
+	}
+	defer urlPolicy.Close()
+	conf.Policy = urlPolicy
+	if cfg.FetchPreviews {
+		previews := preview.NewFetcher(s, preview.GetConfig(cfg))
+		defer previews.Close()
+		conf.Previews = previews
+	}
+	if cfg.CheckLinks {
+		checker := linkcheck.NewChecker(s, linkcheck.GetConfig(cfg))
+		defer checker.Close()
+	}
+	svc := service.NewShortener(s, gen, queue, recorder, conf)
+	accounts := service.NewAccounts(s)
+	limits := ratelimit.NewMemoryStore(time.Minute)
+	defer limits.Close()
+	limiter := ratelimit.NewLimiter(limits, ratelimit.GetConfig(cfg))
+
+	serveHTTP := cfg.Protocols != GRPC
+	serveGRPC := cfg.Protocols != HTTP
+	errCh := make(chan error, 2)
+
+	// the gRPC service also backs the REST gateway, so it's created anyway
+	grpcServer := grpcserver.NewServer(
+		grpcserver.NewShortyServer(
+			svc,
+			accounts,
+			cfg.BaseURL,
+			auth.NewTokens(auth.GetTokensConfig(cfg)),
+			realip.GetProxies(cfg),
+		),
+		limiter,
+	)
+	if serveGRPC && (!serveHTTP || !cfg.GRPCMultiplex) {
+		listen, err := net.Listen("tcp", cfg.GRPCAddress)
+		if err != nil {
+			return err
+		}
+		log.Printf("Starting gRPC server at %v\n", cfg.GRPCAddress)
+		go func() {
+			errCh <- grpcServer.Serve(listen)
+		}()
+	}
+
+	var httpServer *http.Server
+	if serveHTTP {
+		conn, err := gateway.Dial(ctx, grpcServer)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		api, err := gateway.NewHandler(ctx, conn)
+		if err != nil {
+			return err
+		}
+		var h http.Handler = routes.NewRouter(svc, accounts, limiter, api, cfg)
+		if serveGRPC && cfg.GRPCMultiplex {
+			h = multiplex(grpcServer, h, cfg.EnableHTTPS)
+		}
+		httpServer = httpserver.NewServer(h, cfg)
+		log.Printf("Starting http server with config %+v\n", cfg)
+		go func() {
+			errCh <- httpserver.ListenAndServe(httpServer, cfg)
+		}()
+	}
+
+	select {
+	case <-ctx.Done():
+	case err = <-errCh:
+		log.Errorf("Server failed: %v\n", err)
+	}
+	log.Println("Shutting down servers gracefully...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if httpServer != nil {
+		if shutdownErr := httpServer.Shutdown(shutdownCtx); shutdownErr != nil {
+			log.Errorf("http shutdown error: %v\n", shutdownErr)
+			if err == nil {
+				err = shutdownErr
+			}
+		}
+	}
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-shutdownCtx.Done():
+		grpcServer.Stop()
+	}
+	return err
+}
@@ -0,0 +1,143 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/blokhinnv/shorty/internal/app/server/config"
+	pb "github.com/blokhinnv/shorty/proto"
+)
+
+// freeAddress returns an address nobody listens at.
+func freeAddress(t *testing.T) string {
+	l, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	defer l.Close()
+	return l.Addr().String()
+}
+
+// startServers runs the servers until the test ends.
+func startServers(t *testing.T, env map[string]string) {
+	env["FILE_STORAGE_PATH"] = filepath.Join(t.TempDir(), "storage.jsonl")
+	// the destinations are not requested from the tests
+	env["FETCH_PREVIEWS"] = "false"
+	env["CHECK_LINKS"] = "false"
+	for k, v := range env {
+		t.Setenv(k, v)
+	}
+	cfg, err := config.NewServerConfig(&config.FlagConfig{})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- Run(ctx, cfg)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		select {
+		case err := <-done:
+			assert.NoError(t, err)
+		case <-time.After(2 * shutdownTimeout):
+			t.Error("servers were not stopped")
+		}
+	})
+}
+
+// shortenURL adds the URL over HTTP and returns its ID.
+func shortenURL(t *testing.T, httpAddress, url string) string {
+	var resp *http.Response
+	var err error
+	// the server may be not started yet
+	require.Eventually(t, func() bool {
+		resp, err = http.Post("http://"+httpAddress, "text/plain", strings.NewReader(url))
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	parts := strings.Split(strings.TrimSpace(string(body)), "/")
+	return parts[len(parts)-1]
+}
+
+// getOriginalURL gets the URL over gRPC.
+func getOriginalURL(t *testing.T, grpcAddress, urlID string) string {
+	conn, err := grpc.Dial(grpcAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	resp, err := pb.NewShortyClient(conn).GetOriginalURL(
+		context.Background(),
+		&pb.GetOriginalURLRequest{UrlId: urlID},
+	)
+	require.NoError(t, err)
+	return resp.Url
+}
+
+func TestRun(t *testing.T) {
+	const url = "https://practicum.yandex.ru/learn/go-advanced/"
+
+	t.Run("separate ports", func(t *testing.T) {
+		httpAddress, grpcAddress := freeAddress(t), freeAddress(t)
+		startServers(t, map[string]string{
+			"SERVER_ADDRESS": "http://" + httpAddress,
+			"BASE_URL":       "http://" + httpAddress,
+			"PROTOCOLS":      All,
+			"GRPC_ADDRESS":   grpcAddress,
+		})
+		urlID := shortenURL(t, httpAddress, url)
+		// both servers use the same storage
+		assert.Equal(t, url, getOriginalURL(t, grpcAddress, urlID))
+		// the REST gateway is served next to the legacy paths
+		resp, err := http.Get(fmt.Sprintf("http://%v/v1/urls/%v", httpAddress, urlID))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.JSONEq(t, fmt.Sprintf(`{"url": %q, "warnBeforeRedirect": false, "redirectCode": 307}`, url), string(body))
+	})
+
+	t.Run("multiplexed", func(t *testing.T) {
+		httpAddress := freeAddress(t)
+		startServers(t, map[string]string{
+			"SERVER_ADDRESS": "http://" + httpAddress,
+			"BASE_URL":       "http://" + httpAddress,
+			"PROTOCOLS":      All,
+			"GRPC_MULTIPLEX": "true",
+		})
+		urlID := shortenURL(t, httpAddress, url)
+		assert.Equal(t, url, getOriginalURL(t, httpAddress, urlID))
+	})
+
+	t.Run("grpc only", func(t *testing.T) {
+		httpAddress, grpcAddress := freeAddress(t), freeAddress(t)
+		startServers(t, map[string]string{
+			"SERVER_ADDRESS": "http://" + httpAddress,
+			"PROTOCOLS":      GRPC,
+			"GRPC_ADDRESS":   grpcAddress,
+		})
+		conn, err := grpc.Dial(grpcAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		require.NoError(t, err)
+		defer conn.Close()
+		_, err = pb.NewShortyClient(conn).GetShortURL(
+			context.Background(),
+			&pb.GetShortURLRequest{Url: url},
+		)
+		require.NoError(t, err)
+		_, err = http.Get(fmt.Sprintf("http://%v/ping", httpAddress))
+		assert.Error(t, err)
+	})
+}
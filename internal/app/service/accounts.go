@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/blokhinnv/shorty/internal/app/server/auth"
+	"github.com/blokhinnv/shorty/internal/app/storage"
+)
+
+// usernameRe - the form of the usernames.
+var usernameRe = regexp.MustCompile(`^[\w.-]{3,32}$`)
+
+// Limits of the password length. bcrypt ignores the bytes after the 72nd.
+const (
+	minPasswordLen = 8
+	maxPasswordLen = 72
+)
+
+// errBadCredentials is returned for both unknown users and wrong passwords,
+// so the clients can't find out which usernames are registered.
+var errBadCredentials = errors.New("wrong username or password")
+
+// dummyPasswordHash - the hash the password is checked against if the user
+// is unknown, so the login takes as long as for the registered users.
+const dummyPasswordHash = "$2a$10$qs1R8a6GKV0nnE8N405lHe4jXojtrGErOneo43EW9Njj2BCOCLahG"
+
+// maxAccountIDAttempts - how many random IDs are tried for a new account.
+const maxAccountIDAttempts = 5
+
+// Accounts manages the registered users and their API keys.
+// The users without an account are identified by their cookies.
+type Accounts struct {
+	s storage.Storage
+}
+
+// NewAccounts - Accounts constructor.
+func NewAccounts(s storage.Storage) *Accounts {
+	return &Accounts{s: s}
+}
+
+// validateCredentials checks the form of the username and the password.
+func validateCredentials(username, password string) error {
+	if !usernameRe.MatchString(username) {
+		return newError(ErrInvalidArgument, fmt.Errorf("invalid username %q", username))
+	}
+	if len(password) < minPasswordLen || len(password) > maxPasswordLen {
+		return newError(
+			ErrInvalidArgument,
+			fmt.Errorf("password must be %v to %v bytes long", minPasswordLen, maxPasswordLen),
+		)
+	}
+	return nil
+}
+
+// Register creates an account. ErrConflict is returned if the username is taken.
+func (a *Accounts) Register(ctx context.Context, username, password string) (storage.User, error) {
+	if err := validateCredentials(username, password); err != nil {
+		return storage.User{}, err
+	}
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return storage.User{}, err
+	}
+	user := storage.User{
+		Username:     username,
+		PasswordHash: hash,
+		CreatedAt:    time.Now(),
+	}
+	// the IDs of the accounts don't match the IDs of the users without an account,
+	// but they may match the IDs issued before they were separated
+	for i := 0; i < maxAccountIDAttempts; i++ {
+		user.UserID, err = auth.NewAccountID()
+		if err != nil {
+			return storage.User{}, err
+		}
+		err = a.s.AddUser(ctx, user)
+		if !errors.Is(err, storage.ErrUserIDTaken) {
+			break
+		}
+	}
+	if err != nil {
+		if errors.Is(err, storage.ErrUsernameTaken) {
+			return storage.User{}, newError(ErrConflict, err)
+		}
+		return storage.User{}, err
+	}
+	return user, nil
+}
+
+// Login checks the password of the user.
+func (a *Accounts) Login(ctx context.Context, username, password string) (storage.User, error) {
+	user, err := a.s.GetUser(ctx, username)
+	known := err == nil
+	if errors.Is(err, storage.ErrUserNotFound) {
+		user.PasswordHash = dummyPasswordHash
+	} else if err != nil {
+		return storage.User{}, err
+	}
+	ok, err := auth.CheckPassword(user.PasswordHash, password)
+	if err != nil {
+		return storage.User{}, err
+	}
+	if !ok || !known {
+		return storage.User{}, newError(ErrUnauthenticated, errBadCredentials)
+	}
+	return user, nil
+}
+
+// CreateAPIKey issues a new API key for the user. The key can't be recovered later.
+func (a *Accounts) CreateAPIKey(ctx context.Context, username, password string) (string, error) {
+	user, err := a.Login(ctx, username, password)
+	if err != nil {
+		return "", err
+	}
+	key, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		return "", err
+	}
+	err = a.s.AddAPIKey(ctx, storage.APIKey{KeyHash: hash, UserID: user.UserID, CreatedAt: time.Now()})
+	if err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// Authenticate returns the ID of the owner of the API key.
+func (a *Accounts) Authenticate(ctx context.Context, apiKey string) (uint32, error) {
+	key, err := a.s.GetAPIKey(ctx, auth.HashAPIKey(apiKey))
+	if err != nil {
+		if errors.Is(err, storage.ErrAPIKeyNotFound) {
+			return 0, newError(ErrUnauthenticated, err)
+		}
+		return 0, err
+	}
+	return key.UserID, nil
+}
+
+// Claim passes the URLs of the anonymous user to the account
+// and returns the account along with the number of the URLs.
+func (a *Accounts) Claim(
+	ctx context.Context,
+	fromUserID uint32,
+	username, password string,
+) (storage.User, int, error) {
+	user, err := a.Login(ctx, username, password)
+	if err != nil {
+		return storage.User{}, 0, err
+	}
+	if user.UserID == fromUserID {
+		return user, 0, nil
+	}
+	n, err := a.s.MoveURLs(ctx, fromUserID, user.UserID)
+	if err != nil {
+		return storage.User{}, 0, err
+	}
+	return user, n, nil
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blokhinnv/shorty/internal/app/storage"
+)
+
+func (suite *ShortenerSuite) TestAccounts() {
+	ctx := context.Background()
+	accounts := NewAccounts(suite.s)
+	user, err := accounts.Register(ctx, "user", "password")
+	suite.Require().NoError(err)
+	_, err = accounts.Register(ctx, "user", "password")
+	suite.ErrorIs(err, ErrConflict)
+	_, err = accounts.Register(ctx, "u", "password")
+	suite.ErrorIs(err, ErrInvalidArgument)
+
+	logged, err := accounts.Login(ctx, "user", "password")
+	suite.NoError(err)
+	suite.Equal(user.UserID, logged.UserID)
+	_, err = accounts.Login(ctx, "user", "wrong password")
+	suite.ErrorIs(err, ErrUnauthenticated)
+	_, err = accounts.Login(ctx, "nobody", "password")
+	suite.ErrorIs(err, ErrUnauthenticated)
+	// unknown users are checked against the dummy hash, but never let in
+	_, err = accounts.Login(ctx, "nobody", "shorty dummy password")
+	suite.ErrorIs(err, ErrUnauthenticated)
+
+	key, err := accounts.CreateAPIKey(ctx, "user", "password")
+	suite.Require().NoError(err)
+	userID, err := accounts.Authenticate(ctx, key)
+	suite.NoError(err)
+	suite.Equal(user.UserID, userID)
+	_, err = accounts.Authenticate(ctx, "not a key")
+	suite.ErrorIs(err, ErrUnauthenticated)
+}
+
+func (suite *ShortenerSuite) TestClaim() {
+	ctx := context.Background()
+	accounts := NewAccounts(suite.s)
+	user, err := accounts.Register(ctx, "user", "password")
+	suite.Require().NoError(err)
+	urlID, err := suite.svc.Shorten(ctx, 1, "https://mail.ru/", ShortenOptions{})
+	suite.Require().NoError(err)
+
+	_, n, err := accounts.Claim(ctx, 1, "user", "wrong password")
+	suite.ErrorIs(err, ErrUnauthenticated)
+	suite.Zero(n)
+	_, n, err = accounts.Claim(ctx, 1, "user", "password")
+	suite.Require().NoError(err)
+	suite.Equal(1, n)
+	records, err := suite.svc.UserURLs(ctx, user.UserID, "")
+	suite.Require().NoError(err)
+	suite.Require().Len(records, 1)
+	suite.Equal(urlID, records[0].URLID)
+	_, err = suite.svc.UserURLs(ctx, 1, "")
+	suite.ErrorIs(err, ErrNotFound)
+	// the account owns the URL now
+	suite.svc.Delete(user.UserID, urlID)
+	suite.Eventually(func() bool {
+		_, err := suite.svc.Resolve(ctx, urlID, Visit{})
+		return errors.Is(err, ErrGone)
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestRegisterTakenID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s := storage.NewMockStorage(ctrl)
+	accounts := NewAccounts(s)
+	ctx := context.Background()
+
+	var tried []uint32
+	gomock.InOrder(
+		s.EXPECT().AddUser(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, user storage.User) error {
+				tried = append(tried, user.UserID)
+				return fmt.Errorf("%w: userID=%v", storage.ErrUserIDTaken, user.UserID)
+			},
+		),
+		s.EXPECT().AddUser(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, user storage.User) error {
+				tried = append(tried, user.UserID)
+				return nil
+			},
+		),
+	)
+	// another ID is tried if the first one is taken
+	user, err := accounts.Register(ctx, "user", "password")
+	require.NoError(t, err)
+	require.Len(t, tried, 2)
+	assert.Equal(t, tried[1], user.UserID)
+
+	s.EXPECT().AddUser(gomock.Any(), gomock.Any()).
+		Return(storage.ErrUserIDTaken).
+		Times(maxAccountIDAttempts)
+	_, err = accounts.Register(ctx, "user", "password")
+	assert.ErrorIs(t, err, storage.ErrUserIDTaken)
+	assert.NotErrorIs(t, err, ErrConflict)
+}
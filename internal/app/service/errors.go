@@ -0,0 +1,41 @@
+package service
+
+import "errors"
+
+// Kinds of the errors returned by the service. The transports map them to their codes,
+// any other error is an internal one.
+var (
+	ErrInvalidArgument  = errors.New("invalid argument")
+	ErrNotFound         = errors.New("not found")
+	ErrGone             = errors.New("gone")
+	ErrConflict         = errors.New("conflict")
+	ErrPermissionDenied = errors.New("permission denied")
+	ErrUnauthenticated  = errors.New("unauthenticated")
+)
+
+// Error - an error of the service. It matches both its kind
+// and its cause with errors.Is.
+type Error struct {
+	Kind error
+	Err  error
+}
+
+// Error returns the message of the cause.
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the cause.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the error is of the target kind.
+func (e *Error) Is(target error) bool {
+	return e.Kind == target
+}
+
+// newError wraps the cause into an error of the kind.
+func newError(kind error, err error) error {
+	return &Error{Kind: kind, Err: err}
+}
@@ -0,0 +1,621 @@
+// Package service contains the logic of the shortener shared by the HTTP and gRPC servers.
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"time"
+
+	"github.com/blokhinnv/shorty/internal/app/analytics"
+	"github.com/blokhinnv/shorty/internal/app/deletion"
+	"github.com/blokhinnv/shorty/internal/app/linkcheck"
+	"github.com/blokhinnv/shorty/internal/app/policy"
+	"github.com/blokhinnv/shorty/internal/app/preview"
+	"github.com/blokhinnv/shorty/internal/app/server/config"
+	"github.com/blokhinnv/shorty/internal/app/shorten"
+	"github.com/blokhinnv/shorty/internal/app/storage"
+)
+
+// urlIDRe - the form of the IDs of short URLs.
+var urlIDRe = regexp.MustCompile(`^[\w-]+$`)
+
+// Config - service config.
+type Config struct {
+	AliasCfg      *shorten.AliasConfig
+	TrustedSubnet *net.IPNet // example: 192.168.0.1 in 192.168.0.0/24
+	// the URLs are stored as they are without a canonical config
+	CanonicalCfg *shorten.CanonicalConfig
+	// the URLs are not checked without a policy
+	Policy *policy.Policy
+	// the previews are not fetched without a fetcher
+	Previews *preview.Fetcher
+	// how many failed checks in a row make the destination broken
+	BrokenAfter int
+}
+
+// GetConfig - service config constructor based on server config.
+func GetConfig(cfg *config.ServerConfig) (*Config, error) {
+	conf := Config{
+		AliasCfg:     shorten.GetAliasConfig(cfg),
+		CanonicalCfg: shorten.GetCanonicalConfig(cfg),
+		BrokenAfter:  cfg.LinkCheckBrokenAfter,
+	}
+	if cfg.TrustedSubnet != "" {
+		_, subnet, err := net.ParseCIDR(cfg.TrustedSubnet)
+		if err != nil {
+			return nil, err
+		}
+		conf.TrustedSubnet = subnet
+	}
+	return &conf, nil
+}
+
+// Shortener shortens URLs, resolves them back and manages the URLs of the users.
+type Shortener struct {
+	s        storage.Storage
+	gen      shorten.IDGenerator
+	queue    *deletion.Queue
+	recorder *analytics.Recorder
+	conf     *Config
+}
+
+// NewShortener - Shortener constructor. The recorder may be nil.
+func NewShortener(
+	s storage.Storage,
+	gen shorten.IDGenerator,
+	queue *deletion.Queue,
+	recorder *analytics.Recorder,
+	conf *Config,
+) *Shortener {
+	return &Shortener{s: s, gen: gen, queue: queue, recorder: recorder, conf: conf}
+}
+
+// ShortenOptions - optional settings of a short URL. ExpiresAt and TTL
+// are mutually exclusive.
+type ShortenOptions struct {
+	Alias     string
+	ExpiresAt time.Time
+	TTL       time.Duration
+	// the visitors see the destination before they are redirected
+	WarnBeforeRedirect bool
+	// the status code of the redirect, zero means the default one
+	RedirectCode int
+}
+
+// kindOf returns the kind of the error of shortening.
+func kindOf(err error) error {
+	switch {
+	case errors.Is(err, shorten.ErrInvalidURL),
+		errors.Is(err, policy.ErrRejected),
+		errors.Is(err, shorten.ErrInvalidAlias),
+		errors.Is(err, shorten.ErrReservedAlias),
+		errors.Is(err, shorten.ErrInvalidExpiry),
+		errors.Is(err, shorten.ErrInvalidRedirect),
+		errors.Is(err, shorten.ErrInvalidID):
+		return ErrInvalidArgument
+	case errors.Is(err, storage.ErrUniqueViolation):
+		return ErrConflict
+	}
+	return nil
+}
+
+// wrap turns the error into a service error if its kind is known.
+func wrap(err error) error {
+	if kind := kindOf(err); kind != nil {
+		return newError(kind, err)
+	}
+	return err
+}
+
+// canonicalize returns the canonical form of the URL and the URL itself
+// if it differs from the canonical one.
+func (sh *Shortener) canonicalize(url string) (string, string, error) {
+	canonical, err := sh.conf.CanonicalCfg.Canonicalize(url)
+	if err != nil {
+		return "", "", wrap(err)
+	}
+	if canonical == url {
+		return canonical, "", nil
+	}
+	return canonical, url, nil
+}
+
+// checkURL checks the URL against the policy.
+func (sh *Shortener) checkURL(ctx context.Context, url string) error {
+	if sh.conf.Policy == nil {
+		return nil
+	}
+	if err := sh.conf.Policy.Check(ctx, url); err != nil {
+		return wrap(err)
+	}
+	return nil
+}
+
+// Shorten adds the URL of the user and returns the ID of the short URL.
+// The URLs with the same canonical form get the same ID.
+// If the user has already shortened the URL, its ID is returned along
+// with ErrConflict. If the alias is taken, ErrConflict is returned without an ID.
+func (sh *Shortener) Shorten(
+	ctx context.Context,
+	userID uint32,
+	url string,
+	opts ShortenOptions,
+) (string, error) {
+	url, originalURL, err := sh.canonicalize(url)
+	if err != nil {
+		return "", err
+	}
+	if err := sh.checkURL(ctx, url); err != nil {
+		return "", err
+	}
+	expiresAt, err := shorten.GetExpiresAt(opts.TTL, opts.ExpiresAt)
+	if err != nil {
+		return "", newError(ErrInvalidArgument, err)
+	}
+	if err := shorten.ValidateRedirectCode(opts.RedirectCode); err != nil {
+		return "", newError(ErrInvalidArgument, err)
+	}
+	linkOpts := storage.LinkOptions{
+		ExpiresAt:          expiresAt,
+		OriginalURL:        originalURL,
+		WarnBeforeRedirect: opts.WarnBeforeRedirect,
+		RedirectCode:       opts.RedirectCode,
+	}
+	if opts.Alias != "" {
+		urlID, err := shorten.SaveAlias(ctx, sh.s, url, opts.Alias, sh.conf.AliasCfg, userID, linkOpts)
+		if err != nil {
+			return "", wrap(err)
+		}
+		sh.conf.Previews.Fetch(urlID, url)
+		return urlID, nil
+	}
+	urlID, err := shorten.SaveURL(ctx, sh.s, sh.gen, url, userID, linkOpts)
+	if err != nil {
+		if errors.Is(err, storage.ErrUniqueViolation) {
+			return urlID, newError(ErrConflict, err)
+		}
+		return "", wrap(err)
+	}
+	sh.conf.Previews.Fetch(urlID, url)
+	return urlID, nil
+}
+
+// BatchItem - a URL to shorten in a batch.
+type BatchItem struct {
+	CorrelationID string
+	URL           string
+	Alias         string
+}
+
+// BatchResult - the ID of a URL shortened in a batch.
+type BatchResult struct {
+	CorrelationID string
+	URLID         string
+}
+
+// ShortenBatch adds the URLs of the user in one call. If the user has already
+// shortened some of the URLs, the results are returned along with ErrConflict.
+// If some alias is taken, ErrConflict is returned without the results.
+func (sh *Shortener) ShortenBatch(
+	ctx context.Context,
+	userID uint32,
+	items []BatchItem,
+) ([]BatchResult, error) {
+	if len(items) == 0 {
+		return nil, newError(ErrInvalidArgument, fmt.Errorf("nothing to add"))
+	}
+	urlIDs := make(map[string]string)
+	// the canonical URLs of the items
+	canonical := make([]string, len(items))
+	originals := make(map[string]string)
+	aliases := make(map[string]bool)
+	for i, item := range items {
+		url, originalURL, err := sh.canonicalize(item.URL)
+		if err != nil {
+			return nil, err
+		}
+		if err := sh.checkURL(ctx, url); err != nil {
+			return nil, err
+		}
+		var urlID string
+		if item.Alias != "" {
+			urlID, err = item.Alias, sh.conf.AliasCfg.ValidateAlias(item.Alias)
+			aliases[url] = true
+		} else {
+			urlID, err = shorten.GenerateID(sh.gen, url)
+		}
+		if err != nil {
+			return nil, newError(ErrInvalidArgument, err)
+		}
+		canonical[i] = url
+		urlIDs[url] = urlID
+		if originalURL != "" {
+			originals[url] = originalURL
+		}
+	}
+	// the batch isn't added atomically, so a taken alias
+	// must fail it before any URL is added
+	if err := sh.checkAliases(ctx, urlIDs, aliases); err != nil {
+		return nil, err
+	}
+	var err error
+	conflict := false
+	if len(originals) > 0 || len(aliases) > 0 {
+		// the batch can't keep the original URLs
+		// and can't tell a taken alias from a URL shortened before
+		conflict, err = sh.addOneByOne(ctx, urlIDs, aliases, originals, userID)
+		if err != nil {
+			return nil, err
+		}
+	} else if err = sh.s.AddURLBatch(ctx, urlIDs, userID); err != nil {
+		if !errors.Is(err, storage.ErrUniqueViolation) {
+			return nil, err
+		}
+		// some URLs may be stored under other IDs or some IDs
+		// may be taken, so add the URLs one by one
+		conflict, err = sh.addOneByOne(ctx, urlIDs, aliases, originals, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	results := make([]BatchResult, 0, len(items))
+	for i, item := range items {
+		results = append(
+			results,
+			BatchResult{CorrelationID: item.CorrelationID, URLID: urlIDs[canonical[i]]},
+		)
+	}
+	// the previews of the URLs shortened before are refreshed
+	for url, urlID := range urlIDs {
+		sh.conf.Previews.Fetch(urlID, url)
+	}
+	if conflict {
+		return results, newError(ErrConflict, storage.ErrUniqueViolation)
+	}
+	return results, nil
+}
+
+// checkAliases checks that the aliases of a batch are not taken by other URLs,
+// including the other URLs of the batch. The aliases of deleted (or expired)
+// URLs are left for the storage to decide.
+func (sh *Shortener) checkAliases(
+	ctx context.Context,
+	urlIDs map[string]string,
+	aliases map[string]bool,
+) error {
+	taken := make(map[string]string, len(aliases))
+	for url := range aliases {
+		alias := urlIDs[url]
+		if other, ok := taken[alias]; ok {
+			return newError(
+				ErrConflict,
+				fmt.Errorf("%w: alias %v is given to %v and %v", storage.ErrURLIDTaken, alias, other, url),
+			)
+		}
+		taken[alias] = url
+		rec, err := sh.s.GetURLByID(ctx, alias)
+		switch {
+		case errors.Is(err, storage.ErrURLWasNotFound),
+			errors.Is(err, storage.ErrURLWasDeleted),
+			errors.Is(err, storage.ErrURLExpired):
+			continue
+		case err != nil:
+			return err
+		case rec.URL != url:
+			return newError(
+				ErrConflict,
+				fmt.Errorf("%w: url=%v, urlID=%v", storage.ErrURLIDTaken, url, alias),
+			)
+		}
+	}
+	return nil
+}
+
+// addOneByOne adds the URLs of a batch one by one and finds out their actual IDs.
+// It reports whether the user has already shortened some of the URLs.
+// The aliases are added first, so a taken one fails the whole batch
+// with ErrConflict before the other URLs are added.
+func (sh *Shortener) addOneByOne(
+	ctx context.Context,
+	urlIDs map[string]string,
+	aliases map[string]bool,
+	originals map[string]string,
+	userID uint32,
+) (bool, error) {
+	conflict := false
+	for _, withAlias := range []bool{true, false} {
+		for url, urlID := range urlIDs {
+			if aliases[url] != withAlias {
+				continue
+			}
+			opts := storage.LinkOptions{OriginalURL: originals[url]}
+			var err error
+			if withAlias {
+				urlID, err = shorten.SaveAlias(ctx, sh.s, url, urlID, sh.conf.AliasCfg, userID, opts)
+				var dupErr *storage.DuplicateURLError
+				switch {
+				case errors.As(err, &dupErr):
+					// the user has already shortened the URL or shares it with other users now
+					urlID = dupErr.URLID
+					if dupErr.NewOwner {
+						err = nil
+					}
+				case errors.Is(err, storage.ErrUniqueViolation):
+					return false, newError(ErrConflict, err)
+				}
+			} else {
+				urlID, err = shorten.SaveURL(ctx, sh.s, sh.gen, url, userID, opts)
+			}
+			if errors.Is(err, storage.ErrUniqueViolation) {
+				conflict = true
+			} else if err != nil {
+				return false, wrap(err)
+			}
+			urlIDs[url] = urlID
+		}
+	}
+	return conflict, nil
+}
+
+// Visit - the client following a short URL.
+type Visit struct {
+	Referrer  string
+	UserAgent string
+	IP        net.IP
+}
+
+// Resolve returns the record of the short URL and records the click.
+// The record's URL is where the visitor is sent to.
+// ErrGone is returned for deleted and expired URLs.
+func (sh *Shortener) Resolve(ctx context.Context, urlID string, visit Visit) (storage.Record, error) {
+	rec, err := sh.Lookup(ctx, urlID)
+	if err != nil {
+		return storage.Record{}, err
+	}
+	sh.recorder.Record(urlID, visit.Referrer, visit.UserAgent, visit.IP)
+	return rec, nil
+}
+
+// Lookup returns the record of the short URL like Resolve but doesn't record the click.
+func (sh *Shortener) Lookup(ctx context.Context, urlID string) (storage.Record, error) {
+	if !urlIDRe.MatchString(urlID) {
+		return storage.Record{}, newError(
+			ErrInvalidArgument,
+			fmt.Errorf("%w: %q", shorten.ErrInvalidID, urlID),
+		)
+	}
+	rec, err := sh.s.GetURLByID(ctx, urlID)
+	if err != nil {
+		if errors.Is(err, storage.ErrURLWasDeleted) || errors.Is(err, storage.ErrURLExpired) {
+			return storage.Record{}, newError(ErrGone, err)
+		}
+		return storage.Record{}, newError(ErrNotFound, err)
+	}
+	return rec, nil
+}
+
+// UserURLs returns the URLs the user has shortened which destinations
+// have the status (see linkcheck.Status), the empty status matches all the URLs.
+func (sh *Shortener) UserURLs(
+	ctx context.Context,
+	userID uint32,
+	status string,
+) ([]storage.Record, error) {
+	s, err := linkcheck.ParseStatus(status)
+	if err != nil {
+		return nil, newError(ErrInvalidArgument, err)
+	}
+	records, err := sh.s.GetURLsByUser(ctx, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrURLWasNotFound) {
+			return nil, newError(ErrNotFound, err)
+		}
+		return nil, err
+	}
+	filtered := make([]storage.Record, 0, len(records))
+	for _, rec := range records {
+		if s.Matches(rec, sh.conf.BrokenAfter) {
+			filtered = append(filtered, rec)
+		}
+	}
+	if len(filtered) == 0 {
+		return nil, newError(ErrNotFound, fmt.Errorf("no urls with status %q", status))
+	}
+	return filtered, nil
+}
+
+// Delete queues the URLs of the user for deletion.
+func (sh *Shortener) Delete(userID uint32, urlIDs ...string) {
+	sh.queue.Add(userID, urlIDs...)
+}
+
+// userURL returns the URL with the given ID if the user has shortened it.
+// For other users the URL is not found.
+func (sh *Shortener) userURL(ctx context.Context, userID uint32, urlID string) (storage.Record, error) {
+	records, err := sh.s.GetURLsByUser(ctx, userID)
+	if err != nil && !errors.Is(err, storage.ErrURLWasNotFound) {
+		return storage.Record{}, err
+	}
+	for _, rec := range records {
+		if rec.URLID == urlID {
+			return rec, nil
+		}
+	}
+	return storage.Record{}, newError(ErrNotFound, storage.ErrURLWasNotFound)
+}
+
+// URLInfo returns the user's short URL along with the preview of its destination.
+func (sh *Shortener) URLInfo(ctx context.Context, userID uint32, urlID string) (storage.Record, error) {
+	if !urlIDRe.MatchString(urlID) {
+		return storage.Record{}, newError(
+			ErrInvalidArgument,
+			fmt.Errorf("%w: %q", shorten.ErrInvalidID, urlID),
+		)
+	}
+	return sh.userURL(ctx, userID, urlID)
+}
+
+// URLStats returns click statistics of the user's short URL.
+// Only the owner can see the stats, for other users the URL is not found.
+func (sh *Shortener) URLStats(
+	ctx context.Context,
+	userID uint32,
+	urlID string,
+	granularity string,
+) (analytics.Stats, error) {
+	g, err := analytics.ParseGranularity(granularity)
+	if err != nil {
+		return analytics.Stats{}, newError(ErrInvalidArgument, err)
+	}
+	if _, err := sh.userURL(ctx, userID, urlID); err != nil {
+		return analytics.Stats{}, err
+	}
+	clicks, err := sh.s.GetClicks(ctx, urlID)
+	if err != nil {
+		return analytics.Stats{}, err
+	}
+	return analytics.Aggregate(urlID, clicks, g), nil
+}
+
+// UpdateURL changes the destination of the user's short URL and returns
+// the updated record. The previous destination is kept in the history.
+// Only the owner can change the URL, for other users the URL is not found.
+// A URL shared with other users can't be changed and neither can the destination
+// be one the user (or anyone if the links are shared) has already shortened:
+// ErrConflict is returned in both cases.
+func (sh *Shortener) UpdateURL(
+	ctx context.Context,
+	userID uint32,
+	urlID string,
+	url string,
+) (storage.Record, error) {
+	if !urlIDRe.MatchString(urlID) {
+		return storage.Record{}, newError(
+			ErrInvalidArgument,
+			fmt.Errorf("%w: %q", shorten.ErrInvalidID, urlID),
+		)
+	}
+	url, originalURL, err := sh.canonicalize(url)
+	if err != nil {
+		return storage.Record{}, err
+	}
+	if err := shorten.ValidateURL(url); err != nil {
+		return storage.Record{}, wrap(err)
+	}
+	if err := sh.checkURL(ctx, url); err != nil {
+		return storage.Record{}, err
+	}
+	err = sh.s.UpdateURL(ctx, urlID, userID, url, originalURL)
+	switch {
+	case errors.Is(err, storage.ErrURLWasNotFound), errors.Is(err, storage.ErrNotOwner):
+		return storage.Record{}, newError(ErrNotFound, err)
+	case errors.Is(err, storage.ErrURLWasDeleted), errors.Is(err, storage.ErrURLExpired):
+		return storage.Record{}, newError(ErrGone, err)
+	case errors.Is(err, storage.ErrURLShared):
+		return storage.Record{}, newError(ErrConflict, err)
+	case err != nil:
+		return storage.Record{}, wrap(err)
+	}
+	sh.conf.Previews.Fetch(urlID, url)
+	return sh.userURL(ctx, userID, urlID)
+}
+
+// URLHistory returns the previous destinations of the user's short URL
+// ordered by the time they were changed.
+// Only the owner can see the history, for other users the URL is not found.
+func (sh *Shortener) URLHistory(
+	ctx context.Context,
+	userID uint32,
+	urlID string,
+) ([]storage.Revision, error) {
+	if _, err := sh.userURL(ctx, userID, urlID); err != nil {
+		return nil, err
+	}
+	return sh.s.GetURLHistory(ctx, urlID)
+}
+
+// Stats - stats of the service.
+type Stats struct {
+	URLs  int                 `json:"urls"`
+	Users int                 `json:"users"`
+	Cache *storage.CacheStats `json:"cache,omitempty"`
+}
+
+// checkTrusted checks if the client is in the trusted subnet.
+func (sh *Shortener) checkTrusted(ip net.IP) error {
+	if sh.conf.TrustedSubnet == nil {
+		return newError(ErrPermissionDenied, fmt.Errorf("trusted network is not set"))
+	}
+	if ip == nil {
+		return newError(ErrInvalidArgument, fmt.Errorf("ip of the client is unknown"))
+	}
+	if !sh.conf.TrustedSubnet.Contains(ip) {
+		return newError(
+			ErrPermissionDenied,
+			fmt.Errorf("ip %v is not in trusted network %+v", ip, sh.conf.TrustedSubnet),
+		)
+	}
+	return nil
+}
+
+// Stats returns the stats of the service to a client from the trusted subnet.
+func (sh *Shortener) Stats(ctx context.Context, ip net.IP) (Stats, error) {
+	if err := sh.checkTrusted(ip); err != nil {
+		return Stats{}, err
+	}
+	urls, users, err := sh.s.GetStats(ctx)
+	if err != nil {
+		return Stats{}, err
+	}
+	stats := Stats{URLs: urls, Users: users}
+	if cached, ok := sh.s.(*storage.CachedStorage); ok {
+		cacheStats := cached.CacheStats()
+		stats.Cache = &cacheStats
+	}
+	return stats, nil
+}
+
+// PolicyLists returns the domain lists of the policy to a client from the trusted subnet.
+func (sh *Shortener) PolicyLists(ip net.IP) (map[policy.ListName][]string, error) {
+	if err := sh.checkTrusted(ip); err != nil {
+		return nil, err
+	}
+	if sh.conf.Policy == nil {
+		return nil, newError(ErrNotFound, fmt.Errorf("url policy is not set"))
+	}
+	return sh.conf.Policy.Lists(), nil
+}
+
+// UpdatePolicyList adds and removes the domains of the policy list
+// for a client from the trusted subnet and returns the updated lists.
+func (sh *Shortener) UpdatePolicyList(
+	ip net.IP,
+	name policy.ListName,
+	add []string,
+	remove []string,
+) (map[policy.ListName][]string, error) {
+	if err := sh.checkTrusted(ip); err != nil {
+		return nil, err
+	}
+	if sh.conf.Policy == nil {
+		return nil, newError(ErrNotFound, fmt.Errorf("url policy is not set"))
+	}
+	if err := sh.conf.Policy.UpdateList(name, add, remove); err != nil {
+		switch {
+		case errors.Is(err, policy.ErrUnknownList):
+			return nil, newError(ErrNotFound, err)
+		case errors.Is(err, policy.ErrInvalidDomain):
+			return nil, newError(ErrInvalidArgument, err)
+		}
+		return nil, err
+	}
+	return sh.conf.Policy.Lists(), nil
+}
+
+// Ping checks the connection to the storage.
+func (sh *Shortener) Ping(ctx context.Context) bool {
+	return sh.s.Ping(ctx)
+}
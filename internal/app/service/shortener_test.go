@@ -0,0 +1,587 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/blokhinnv/shorty/internal/app/analytics"
+	"github.com/blokhinnv/shorty/internal/app/database"
+	"github.com/blokhinnv/shorty/internal/app/deletion"
+	"github.com/blokhinnv/shorty/internal/app/linkcheck"
+	"github.com/blokhinnv/shorty/internal/app/policy"
+	"github.com/blokhinnv/shorty/internal/app/preview"
+	"github.com/blokhinnv/shorty/internal/app/server/config"
+	"github.com/blokhinnv/shorty/internal/app/shorten"
+	"github.com/blokhinnv/shorty/internal/app/storage"
+)
+
+// ShortenerSuite checks the service on top of a real storage,
+// so the same behaviour is expected from every backend.
+type ShortenerSuite struct {
+	suite.Suite
+	env      func(t *testing.T) map[string]string
+	s        storage.Storage
+	queue    *deletion.Queue
+	recorder *analytics.Recorder
+	svc      *Shortener
+}
+
+func (suite *ShortenerSuite) SetupTest() {
+	t := suite.T()
+	for k, v := range suite.env(t) {
+		t.Setenv(k, v)
+	}
+	t.Setenv("TRUSTED_SUBNET", "192.168.0.0/24")
+	cfg, err := config.NewServerConfig(&config.FlagConfig{})
+	suite.Require().NoError(err)
+	suite.s, err = database.NewDBStorage(cfg)
+	suite.Require().NoError(err)
+	suite.queue = deletion.NewQueue(suite.s, 10, 10*time.Millisecond)
+	suite.recorder, err = analytics.NewRecorder(
+		suite.s,
+		&analytics.RecorderConfig{BufferSize: 10, FlushInterval: 10 * time.Millisecond},
+	)
+	suite.Require().NoError(err)
+	conf, err := GetConfig(cfg)
+	suite.Require().NoError(err)
+	suite.svc = NewShortener(suite.s, shorten.HashGenerator{}, suite.queue, suite.recorder, conf)
+}
+
+func (suite *ShortenerSuite) TearDownTest() {
+	suite.queue.Close()
+	suite.recorder.Close()
+	suite.s.Close(context.Background())
+}
+
+func (suite *ShortenerSuite) TestShortenAndResolve() {
+	ctx := context.Background()
+	urlID, err := suite.svc.Shorten(ctx, 1, "https://mail.ru/", ShortenOptions{})
+	suite.Require().NoError(err)
+	rec, err := suite.svc.Resolve(ctx, urlID, Visit{})
+	suite.NoError(err)
+	suite.Equal("https://mail.ru/", rec.URL)
+}
+
+func (suite *ShortenerSuite) TestWarnBeforeRedirect() {
+	ctx := context.Background()
+	urlID, err := suite.svc.Shorten(
+		ctx,
+		1,
+		"https://mail.ru/",
+		ShortenOptions{WarnBeforeRedirect: true},
+	)
+	suite.Require().NoError(err)
+	rec, err := suite.svc.Resolve(ctx, urlID, Visit{})
+	suite.NoError(err)
+	suite.True(rec.WarnBeforeRedirect)
+	urlID, err = suite.svc.Shorten(ctx, 1, "https://ya.ru/", ShortenOptions{})
+	suite.Require().NoError(err)
+	rec, err = suite.svc.Resolve(ctx, urlID, Visit{})
+	suite.NoError(err)
+	suite.False(rec.WarnBeforeRedirect)
+}
+
+func (suite *ShortenerSuite) TestRedirectCode() {
+	ctx := context.Background()
+	urlID, err := suite.svc.Shorten(
+		ctx,
+		1,
+		"https://mail.ru/",
+		ShortenOptions{RedirectCode: http.StatusMovedPermanently},
+	)
+	suite.Require().NoError(err)
+	rec, err := suite.svc.Resolve(ctx, urlID, Visit{})
+	suite.NoError(err)
+	suite.Equal(http.StatusMovedPermanently, rec.RedirectCode)
+	_, err = suite.svc.Shorten(ctx, 1, "https://ya.ru/", ShortenOptions{RedirectCode: http.StatusOK})
+	suite.ErrorIs(err, ErrInvalidArgument)
+}
+
+func (suite *ShortenerSuite) TestShortenTwice() {
+	ctx := context.Background()
+	urlID, err := suite.svc.Shorten(ctx, 1, "https://mail.ru/", ShortenOptions{})
+	suite.Require().NoError(err)
+	again, err := suite.svc.Shorten(ctx, 1, "https://mail.ru/", ShortenOptions{})
+	suite.ErrorIs(err, ErrConflict)
+	suite.Equal(urlID, again)
+}
+
+func (suite *ShortenerSuite) TestShortenAlias() {
+	ctx := context.Background()
+	urlID, err := suite.svc.Shorten(ctx, 1, "https://mail.ru/", ShortenOptions{Alias: "mail"})
+	suite.Require().NoError(err)
+	suite.Equal("mail", urlID)
+	// the alias is taken by another URL
+	urlID, err = suite.svc.Shorten(ctx, 2, "https://ya.ru/", ShortenOptions{Alias: "mail"})
+	suite.ErrorIs(err, ErrConflict)
+	suite.Empty(urlID)
+}
+
+func (suite *ShortenerSuite) TestShortenInvalid() {
+	ctx := context.Background()
+	for name, tc := range map[string]struct {
+		url  string
+		opts ShortenOptions
+	}{
+		"not a URL":      {url: "mail", opts: ShortenOptions{}},
+		"bad alias":      {url: "https://mail.ru/", opts: ShortenOptions{Alias: "m"}},
+		"reserved alias": {url: "https://mail.ru/", opts: ShortenOptions{Alias: "api"}},
+		"negative TTL":   {url: "https://mail.ru/", opts: ShortenOptions{TTL: -time.Hour}},
+	} {
+		_, err := suite.svc.Shorten(ctx, 1, tc.url, tc.opts)
+		suite.ErrorIs(err, ErrInvalidArgument, name)
+	}
+}
+
+func (suite *ShortenerSuite) TestShortenBatch() {
+	ctx := context.Background()
+	items := []BatchItem{
+		{CorrelationID: "1", URL: "https://mail.ru/"},
+		{CorrelationID: "2", URL: "https://ya.ru/"},
+	}
+	results, err := suite.svc.ShortenBatch(ctx, 1, items)
+	suite.Require().NoError(err)
+	suite.Require().Len(results, 2)
+	rec, err := suite.svc.Resolve(ctx, results[1].URLID, Visit{})
+	suite.NoError(err)
+	suite.Equal("https://ya.ru/", rec.URL)
+	// the same IDs are returned for the URLs shortened before
+	again, err := suite.svc.ShortenBatch(ctx, 1, items)
+	suite.ErrorIs(err, ErrConflict)
+	suite.Equal(results, again)
+
+	_, err = suite.svc.ShortenBatch(ctx, 1, nil)
+	suite.ErrorIs(err, ErrInvalidArgument)
+}
+
+func (suite *ShortenerSuite) TestShortenBatchAlias() {
+	ctx := context.Background()
+	_, err := suite.svc.Shorten(ctx, 2, "https://ya.ru/", ShortenOptions{})
+	suite.Require().NoError(err)
+	// the URL shortened by another user doesn't make the alias taken
+	items := []BatchItem{
+		{CorrelationID: "1", URL: "https://mail.ru/", Alias: "mail"},
+		{CorrelationID: "2", URL: "https://ya.ru/"},
+	}
+	results, err := suite.svc.ShortenBatch(ctx, 1, items)
+	suite.Require().NoError(err)
+	suite.Require().Len(results, 2)
+	suite.Equal("mail", results[0].URLID)
+	again, err := suite.svc.ShortenBatch(ctx, 1, items)
+	suite.ErrorIs(err, ErrConflict)
+	suite.Equal(results, again)
+
+	// the alias of another URL fails the batch before anything is added
+	_, err = suite.svc.ShortenBatch(ctx, 1, []BatchItem{
+		{CorrelationID: "1", URL: "https://google.com/", Alias: "mail"},
+		{CorrelationID: "2", URL: "https://bing.com/"},
+	})
+	suite.ErrorIs(err, ErrConflict)
+	suite.ErrorIs(err, storage.ErrURLIDTaken)
+	_, err = suite.svc.ShortenBatch(ctx, 1, []BatchItem{
+		{CorrelationID: "1", URL: "https://google.com/", Alias: "search"},
+		{CorrelationID: "2", URL: "https://bing.com/", Alias: "search"},
+	})
+	suite.ErrorIs(err, ErrConflict)
+	records, err := suite.svc.UserURLs(ctx, 1, "")
+	suite.Require().NoError(err)
+	suite.Len(records, 2)
+}
+
+func (suite *ShortenerSuite) TestShortenCanonical() {
+	ctx := context.Background()
+	urlID, err := suite.svc.Shorten(ctx, 1, "https://Example.com/a", ShortenOptions{})
+	suite.Require().NoError(err)
+	for _, url := range []string{
+		"https://example.com/a/",
+		"https://example.com:443/a?utm_source=x",
+	} {
+		again, err := suite.svc.Shorten(ctx, 1, url, ShortenOptions{})
+		suite.ErrorIs(err, ErrConflict, url)
+		suite.Equal(urlID, again, url)
+	}
+	// the canonical URL is followed, the original one is listed
+	rec, err := suite.svc.Resolve(ctx, urlID, Visit{})
+	suite.NoError(err)
+	suite.Equal("https://example.com/a", rec.URL)
+	records, err := suite.svc.UserURLs(ctx, 1, "")
+	suite.Require().NoError(err)
+	suite.Require().Len(records, 1)
+	suite.Equal("https://Example.com/a", records[0].DisplayURL())
+
+	results, err := suite.svc.ShortenBatch(ctx, 2, []BatchItem{
+		{CorrelationID: "1", URL: "https://example.com/b?utm_medium=y"},
+		{CorrelationID: "2", URL: "https://example.com/c", Alias: "c-alias"},
+	})
+	suite.Require().NoError(err)
+	suite.Require().Len(results, 2)
+	suite.Equal("c-alias", results[1].URLID)
+	rec, err = suite.svc.Resolve(ctx, results[0].URLID, Visit{})
+	suite.NoError(err)
+	suite.Equal("https://example.com/b", rec.URL)
+	records, err = suite.svc.UserURLs(ctx, 2, "")
+	suite.Require().NoError(err)
+	listed := make(map[string]string)
+	for _, rec := range records {
+		listed[rec.URLID] = rec.DisplayURL()
+	}
+	suite.Equal(map[string]string{
+		results[0].URLID: "https://example.com/b?utm_medium=y",
+		"c-alias":        "https://example.com/c",
+	}, listed)
+}
+
+func (suite *ShortenerSuite) TestPreview() {
+	ctx := context.Background()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Destination</title></head></html>`))
+	}))
+	defer ts.Close()
+	previews := preview.NewFetcher(suite.s, &preview.Config{
+		Workers:      1,
+		QueueSize:    10,
+		Timeout:      time.Second,
+		MaxBodySize:  1024,
+		MaxRedirects: 1,
+	})
+	defer previews.Close()
+	suite.svc.conf.Previews = previews
+
+	urlID, err := suite.svc.Shorten(ctx, 1, ts.URL+"/page", ShortenOptions{})
+	suite.Require().NoError(err)
+	var info storage.Record
+	suite.Require().Eventually(func() bool {
+		info, err = suite.svc.URLInfo(ctx, 1, urlID)
+		return err == nil && info.Preview != nil
+	}, time.Second, 10*time.Millisecond)
+	suite.Equal("Destination", info.Preview.Title)
+	suite.Equal(ts.URL+"/page", info.Preview.FinalURL)
+	suite.Equal(http.StatusOK, info.Preview.StatusCode)
+	// the preview is listed and survives the lookups by ID
+	records, err := suite.svc.UserURLs(ctx, 1, "")
+	suite.Require().NoError(err)
+	suite.Require().Len(records, 1)
+	suite.Equal(info.Preview, records[0].Preview)
+	rec, err := suite.s.GetURLByID(ctx, urlID)
+	suite.Require().NoError(err)
+	suite.Equal("Destination", rec.Preview.Title)
+
+	_, err = suite.svc.URLInfo(ctx, 2, urlID)
+	suite.ErrorIs(err, ErrNotFound)
+	suite.ErrorIs(suite.s.SetPreview(ctx, "missing", storage.Preview{}), storage.ErrURLWasNotFound)
+}
+
+func (suite *ShortenerSuite) TestLinkHealth() {
+	ctx := context.Background()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/gone" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	okID, err := suite.svc.Shorten(ctx, 1, ts.URL+"/ok", ShortenOptions{})
+	suite.Require().NoError(err)
+	goneID, err := suite.svc.Shorten(ctx, 1, ts.URL+"/gone", ShortenOptions{})
+	suite.Require().NoError(err)
+	checker := linkcheck.NewChecker(suite.s, &linkcheck.Config{
+		BatchSize:   10,
+		Concurrency: 2,
+		Timeout:     time.Second,
+		BrokenAfter: 2,
+	})
+	defer checker.Close()
+	suite.svc.conf.BrokenAfter = 2
+
+	// the URLs which haven't been checked are neither ok nor broken
+	_, err = suite.svc.UserURLs(ctx, 1, "broken")
+	suite.ErrorIs(err, ErrNotFound)
+	for i := 0; i < 2; i++ {
+		n, err := checker.Check(ctx)
+		suite.Require().NoError(err)
+		suite.Equal(2, n)
+	}
+	broken, err := suite.svc.UserURLs(ctx, 1, "broken")
+	suite.Require().NoError(err)
+	suite.Require().Len(broken, 1)
+	suite.Equal(goneID, broken[0].URLID)
+	suite.Equal(http.StatusNotFound, broken[0].Health.StatusCode)
+	suite.Equal(2, broken[0].Health.Failures)
+	ok, err := suite.svc.UserURLs(ctx, 1, "ok")
+	suite.Require().NoError(err)
+	suite.Require().Len(ok, 1)
+	suite.Equal(okID, ok[0].URLID)
+	suite.Zero(ok[0].Health.Failures)
+	all, err := suite.svc.UserURLs(ctx, 1, "")
+	suite.Require().NoError(err)
+	suite.Len(all, 2)
+	_, err = suite.svc.UserURLs(ctx, 1, "dead")
+	suite.ErrorIs(err, ErrInvalidArgument)
+
+	// the fresh checks are not repeated
+	due, err := suite.s.GetURLsToCheck(ctx, time.Now().Add(-time.Hour), 10)
+	suite.NoError(err)
+	suite.Empty(due)
+	suite.ErrorIs(suite.s.SetHealth(ctx, "missing", storage.Health{}), storage.ErrURLWasNotFound)
+}
+
+func (suite *ShortenerSuite) TestResolveMissing() {
+	ctx := context.Background()
+	_, err := suite.svc.Resolve(ctx, "qwerty", Visit{})
+	suite.ErrorIs(err, ErrNotFound)
+	_, err = suite.svc.Resolve(ctx, "@!%", Visit{})
+	suite.ErrorIs(err, ErrInvalidArgument)
+}
+
+func (suite *ShortenerSuite) TestDelete() {
+	ctx := context.Background()
+	urlID, err := suite.svc.Shorten(ctx, 1, "https://mail.ru/", ShortenOptions{})
+	suite.Require().NoError(err)
+	// only the owner can delete the URL
+	suite.svc.Delete(2, urlID)
+	suite.svc.Delete(1, urlID)
+	suite.Eventually(func() bool {
+		_, err := suite.svc.Resolve(ctx, urlID, Visit{})
+		return errors.Is(err, ErrGone)
+	}, time.Second, 10*time.Millisecond)
+}
+
+func (suite *ShortenerSuite) TestUserURLs() {
+	ctx := context.Background()
+	urlID, err := suite.svc.Shorten(ctx, 1, "https://mail.ru/", ShortenOptions{})
+	suite.Require().NoError(err)
+	records, err := suite.svc.UserURLs(ctx, 1, "")
+	suite.Require().NoError(err)
+	suite.Require().Len(records, 1)
+	suite.Equal(urlID, records[0].URLID)
+	suite.Equal("https://mail.ru/", records[0].URL)
+}
+
+func (suite *ShortenerSuite) TestURLStats() {
+	ctx := context.Background()
+	urlID, err := suite.svc.Shorten(ctx, 1, "https://mail.ru/", ShortenOptions{})
+	suite.Require().NoError(err)
+	_, err = suite.svc.Resolve(ctx, urlID, Visit{Referrer: "https://ya.ru/"})
+	suite.Require().NoError(err)
+	suite.Eventually(func() bool {
+		stats, err := suite.svc.URLStats(ctx, 1, urlID, "")
+		return err == nil && stats.Clicks == 1 && stats.Referrers["https://ya.ru/"] == 1
+	}, time.Second, 10*time.Millisecond)
+
+	_, err = suite.svc.URLStats(ctx, 2, urlID, "")
+	suite.ErrorIs(err, ErrNotFound)
+	_, err = suite.svc.URLStats(ctx, 1, urlID, "week")
+	suite.ErrorIs(err, ErrInvalidArgument)
+}
+
+func (suite *ShortenerSuite) TestUpdateURL() {
+	ctx := context.Background()
+	urlID, err := suite.svc.Shorten(ctx, 1, "https://mail.ru/", ShortenOptions{})
+	suite.Require().NoError(err)
+	_, err = suite.svc.Shorten(ctx, 1, "https://ya.ru/", ShortenOptions{})
+	suite.Require().NoError(err)
+	// the cache keeps the old destination until the change
+	_, err = suite.svc.Resolve(ctx, urlID, Visit{})
+	suite.Require().NoError(err)
+
+	rec, err := suite.svc.UpdateURL(ctx, 1, urlID, "https://yandex.ru/")
+	suite.Require().NoError(err)
+	suite.Equal(urlID, rec.URLID)
+	suite.Equal("https://yandex.ru/", rec.URL)
+	rec, err = suite.svc.Resolve(ctx, urlID, Visit{})
+	suite.NoError(err)
+	suite.Equal("https://yandex.ru/", rec.URL)
+	_, err = suite.svc.UpdateURL(ctx, 1, urlID, "https://google.com/")
+	suite.Require().NoError(err)
+	// the same destination isn't a change
+	_, err = suite.svc.UpdateURL(ctx, 1, urlID, "https://google.com/")
+	suite.Require().NoError(err)
+
+	history, err := suite.svc.URLHistory(ctx, 1, urlID)
+	suite.Require().NoError(err)
+	suite.Require().Len(history, 2)
+	suite.Equal("https://mail.ru/", history[0].URL)
+	suite.Equal("https://yandex.ru/", history[1].URL)
+	suite.Equal(uint32(1), history[1].ChangedBy)
+	suite.False(history[1].ChangedAt.Before(history[0].ChangedAt))
+
+	// the previous destination can be shortened again under another ID
+	again, err := suite.svc.Shorten(ctx, 1, "https://mail.ru/", ShortenOptions{})
+	suite.NoError(err)
+	suite.NotEqual(urlID, again)
+}
+
+func (suite *ShortenerSuite) TestUpdateURLErrors() {
+	ctx := context.Background()
+	urlID, err := suite.svc.Shorten(ctx, 1, "https://mail.ru/", ShortenOptions{})
+	suite.Require().NoError(err)
+	_, err = suite.svc.Shorten(ctx, 1, "https://ya.ru/", ShortenOptions{})
+	suite.Require().NoError(err)
+
+	// only the owner can change the URL and see its history
+	_, err = suite.svc.UpdateURL(ctx, 2, urlID, "https://yandex.ru/")
+	suite.ErrorIs(err, ErrNotFound)
+	_, err = suite.svc.URLHistory(ctx, 2, urlID)
+	suite.ErrorIs(err, ErrNotFound)
+	_, err = suite.svc.UpdateURL(ctx, 1, "qwerty", "https://yandex.ru/")
+	suite.ErrorIs(err, ErrNotFound)
+	// the user has already shortened the destination
+	_, err = suite.svc.UpdateURL(ctx, 1, urlID, "https://ya.ru/")
+	suite.ErrorIs(err, ErrConflict)
+	_, err = suite.svc.UpdateURL(ctx, 1, urlID, "not a url")
+	suite.ErrorIs(err, ErrInvalidArgument)
+	_, err = suite.svc.UpdateURL(ctx, 1, "@!%", "https://yandex.ru/")
+	suite.ErrorIs(err, ErrInvalidArgument)
+	history, err := suite.svc.URLHistory(ctx, 1, urlID)
+	suite.NoError(err)
+	suite.Empty(history)
+
+	suite.svc.Delete(1, urlID)
+	suite.Eventually(func() bool {
+		_, err := suite.svc.UpdateURL(ctx, 1, urlID, "https://yandex.ru/")
+		return errors.Is(err, ErrGone)
+	}, time.Second, 10*time.Millisecond)
+}
+
+func (suite *ShortenerSuite) TestStats() {
+	ctx := context.Background()
+	_, err := suite.svc.Shorten(ctx, 1, "https://mail.ru/", ShortenOptions{})
+	suite.Require().NoError(err)
+	stats, err := suite.svc.Stats(ctx, net.ParseIP("192.168.0.1"))
+	suite.NoError(err)
+	suite.Equal(1, stats.URLs)
+	suite.Equal(1, stats.Users)
+
+	_, err = suite.svc.Stats(ctx, net.ParseIP("10.0.0.1"))
+	suite.ErrorIs(err, ErrPermissionDenied)
+	_, err = suite.svc.Stats(ctx, nil)
+	suite.ErrorIs(err, ErrInvalidArgument)
+}
+
+func (suite *ShortenerSuite) TestPing() {
+	suite.True(suite.svc.Ping(context.Background()))
+}
+
+func TestShortenerSuite(t *testing.T) {
+	for name, env := range map[string]func(t *testing.T) map[string]string{
+		"text": func(t *testing.T) map[string]string {
+			return map[string]string{"FILE_STORAGE_PATH": filepath.Join(t.TempDir(), "storage.jsonl")}
+		},
+		"kv": func(t *testing.T) map[string]string {
+			return map[string]string{
+				"FILE_STORAGE_PATH":   filepath.Join(t.TempDir(), "storage.db"),
+				"FILE_STORAGE_ENGINE": "kv",
+			}
+		},
+		"sqlite": func(t *testing.T) map[string]string {
+			return map[string]string{"SQLITE_DB_PATH": filepath.Join(t.TempDir(), "db.sqlite3")}
+		},
+		"redis": func(t *testing.T) map[string]string {
+			return map[string]string{"REDIS_URL": "redis://" + miniredis.RunT(t).Addr()}
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			suite.Run(t, &ShortenerSuite{env: env})
+		})
+	}
+}
+
+func TestShortenerStorageErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s := storage.NewMockStorage(ctrl)
+	svc := NewShortener(s, shorten.HashGenerator{}, nil, nil, &Config{})
+	ctx := context.Background()
+	errStorage := errors.New("storage is down")
+
+	s.EXPECT().AddURL(gomock.Any(), "https://mail.ru/", gomock.Any(), uint32(1), gomock.Any()).
+		Return(errStorage)
+	_, err := svc.Shorten(ctx, 1, "https://mail.ru/", ShortenOptions{})
+	assert.ErrorIs(t, err, errStorage)
+	assert.NotErrorIs(t, err, ErrInvalidArgument)
+
+	s.EXPECT().GetURLByID(gomock.Any(), "qwerty").Return(storage.Record{}, storage.ErrURLExpired)
+	_, err = svc.Resolve(ctx, "qwerty", Visit{})
+	assert.ErrorIs(t, err, ErrGone)
+	assert.ErrorIs(t, err, storage.ErrURLExpired)
+
+	s.EXPECT().GetURLsByUser(gomock.Any(), uint32(1)).Return(nil, errStorage)
+	_, err = svc.URLStats(ctx, 1, "qwerty", "")
+	assert.ErrorIs(t, err, errStorage)
+
+	// no trusted subnet, nobody is trusted
+	_, err = svc.Stats(ctx, net.ParseIP("192.168.0.1"))
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+}
+
+// noResolver resolves no hosts.
+type noResolver struct{}
+
+func (noResolver) LookupIP(ctx context.Context, network, host string) ([]net.IP, error) {
+	return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+func TestShortenerPolicy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s := storage.NewMockStorage(ctrl)
+	p, err := policy.New(
+		&policy.Config{Schemes: []string{"http", "https"}, RejectPrivateIPs: true},
+		noResolver{},
+	)
+	require.NoError(t, err)
+	defer p.Close()
+	_, subnet, err := net.ParseCIDR("192.168.0.0/24")
+	require.NoError(t, err)
+	svc := NewShortener(s, shorten.HashGenerator{}, nil, nil, &Config{Policy: p, TrustedSubnet: subnet})
+	ctx := context.Background()
+	trusted := net.ParseIP("192.168.0.1")
+
+	_, err = svc.UpdatePolicyList(net.ParseIP("10.0.0.1"), policy.BlockList, []string{"phishing.com"}, nil)
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+	_, err = svc.UpdatePolicyList(trusted, policy.BlockList, []string{"not a domain"}, nil)
+	assert.ErrorIs(t, err, ErrInvalidArgument)
+	_, err = svc.UpdatePolicyList(trusted, "grey", []string{"phishing.com"}, nil)
+	assert.ErrorIs(t, err, ErrNotFound)
+	lists, err := svc.UpdatePolicyList(trusted, policy.BlockList, []string{"phishing.com"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"phishing.com"}, lists[policy.BlockList])
+	_, err = svc.PolicyLists(nil)
+	assert.ErrorIs(t, err, ErrInvalidArgument)
+
+	// the rejected URLs never reach the storage
+	for url, reason := range map[string]policy.Reason{
+		"javascript:alert(1)":         policy.ReasonScheme,
+		"https://login.phishing.com/": policy.ReasonBlockedDomain,
+		"http://10.0.0.1/admin":       policy.ReasonPrivateAddress,
+	} {
+		_, err := svc.Shorten(ctx, 1, url, ShortenOptions{})
+		assert.ErrorIs(t, err, ErrInvalidArgument, url)
+		var rejected *policy.RejectedError
+		if assert.ErrorAs(t, err, &rejected, url) {
+			assert.Equal(t, reason, rejected.Reason)
+		}
+	}
+	_, err = svc.ShortenBatch(ctx, 1, []BatchItem{
+		{CorrelationID: "1", URL: "https://mail.ru/"},
+		{CorrelationID: "2", URL: "file:///etc/passwd"},
+	})
+	assert.ErrorIs(t, err, policy.ErrRejected)
+	assert.ErrorIs(t, err, ErrInvalidArgument)
+
+	s.EXPECT().AddURL(gomock.Any(), "https://mail.ru/", gomock.Any(), uint32(1), gomock.Any()).Return(nil)
+	_, err = svc.Shorten(ctx, 1, "https://mail.ru/", ShortenOptions{})
+	assert.NoError(t, err)
+}
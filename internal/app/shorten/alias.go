@@ -0,0 +1,75 @@
+package shorten
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/blokhinnv/shorty/internal/app/server/config"
+)
+
+// Alias errors.
+var (
+	ErrInvalidAlias  = errors.New("alias is not valid")
+	ErrReservedAlias = errors.New("alias is reserved")
+)
+
+// defaultAliasAlphabet - symbols allowed in aliases if nothing else is configured.
+// Everything here must be accepted by the GET /{idURL} handler.
+const defaultAliasAlphabet = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ_-"
+
+// reservedAliases - first path segments used by the router and the gRPC gateway.
+// An alias can't take one of them, otherwise it would shadow a route.
+var reservedAliases = []string{"api", "ping", "debug", "v1"}
+
+// AliasConfig - settings for validating custom aliases.
+type AliasConfig struct {
+	Alphabet  string
+	MinLength int
+	MaxLength int
+}
+
+// GetAliasConfig - alias config constructor based on server config.
+func GetAliasConfig(cfg *config.ServerConfig) *AliasConfig {
+	aliasCfg := &AliasConfig{
+		Alphabet:  cfg.AliasAlphabet,
+		MinLength: cfg.AliasMinLength,
+		MaxLength: cfg.AliasMaxLength,
+	}
+	if aliasCfg.Alphabet == "" {
+		aliasCfg.Alphabet = defaultAliasAlphabet
+	}
+	return aliasCfg
+}
+
+// ValidateAlias checks if the alias can be used as a short URL ID.
+func (c *AliasConfig) ValidateAlias(alias string) error {
+	if len(alias) < c.MinLength || len(alias) > c.MaxLength {
+		return fmt.Errorf(
+			"%w: length of %q should be in [%d, %d]",
+			ErrInvalidAlias,
+			alias,
+			c.MinLength,
+			c.MaxLength,
+		)
+	}
+	for _, r := range alias {
+		if !strings.ContainsRune(c.Alphabet, r) {
+			return fmt.Errorf("%w: %q contains forbidden symbol %q", ErrInvalidAlias, alias, r)
+		}
+	}
+	if isReserved(alias) {
+		return fmt.Errorf("%w: %q", ErrReservedAlias, alias)
+	}
+	return nil
+}
+
+// isReserved checks if the ID would shadow a route.
+func isReserved(urlID string) bool {
+	for _, word := range reservedAliases {
+		if strings.EqualFold(urlID, word) {
+			return true
+		}
+	}
+	return false
+}
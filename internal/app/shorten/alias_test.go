@@ -0,0 +1,113 @@
+package shorten
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/blokhinnv/shorty/internal/app/server/config"
+	"github.com/blokhinnv/shorty/internal/app/storage"
+)
+
+func TestSaveAlias(t *testing.T) {
+	aliasCfg := GetAliasConfig(&config.ServerConfig{AliasMinLength: 3, AliasMaxLength: 10})
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s := storage.NewMockStorage(ctrl)
+	s.EXPECT().
+		AddURL(gomock.Any(), "http://yandex.ru", "q3-report", uint32(1), gomock.Any()).
+		Return(nil)
+	type args struct {
+		url   string
+		alias string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr error
+	}{
+		{
+			name: "ok",
+			args: args{
+				url:   "http://yandex.ru",
+				alias: "q3-report",
+			},
+			want: "q3-report",
+		},
+		{
+			name: "not_url",
+			args: args{
+				url:   "@.@@",
+				alias: "q3-report",
+			},
+			wantErr: ErrInvalidURL,
+		},
+		{
+			name: "too_short",
+			args: args{
+				url:   "http://yandex.ru",
+				alias: "q3",
+			},
+			wantErr: ErrInvalidAlias,
+		},
+		{
+			name: "too_long",
+			args: args{
+				url:   "http://yandex.ru",
+				alias: "q3-report-2023",
+			},
+			wantErr: ErrInvalidAlias,
+		},
+		{
+			name: "bad_symbols",
+			args: args{
+				url:   "http://yandex.ru",
+				alias: "q3/report",
+			},
+			wantErr: ErrInvalidAlias,
+		},
+		{
+			name: "reserved",
+			args: args{
+				url:   "http://yandex.ru",
+				alias: "Debug",
+			},
+			wantErr: ErrReservedAlias,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SaveAlias(
+				context.Background(),
+				s,
+				tt.args.url,
+				tt.args.alias,
+				aliasCfg,
+				1,
+				storage.LinkOptions{},
+			)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("SaveAlias() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("SaveAlias() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateAliasReserved(t *testing.T) {
+	aliasCfg := GetAliasConfig(&config.ServerConfig{AliasMinLength: 1, AliasMaxLength: 10})
+	for _, alias := range []string{"api", "PING", "debug", "v1", "V1"} {
+		if err := aliasCfg.ValidateAlias(alias); !errors.Is(err, ErrReservedAlias) {
+			t.Errorf("ValidateAlias(%q) error = %v, wantErr %v", alias, err, ErrReservedAlias)
+		}
+	}
+	if err := aliasCfg.ValidateAlias("v2"); err != nil {
+		t.Errorf("ValidateAlias(%q) error = %v, want nil", "v2", err)
+	}
+}
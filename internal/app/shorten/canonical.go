@@ -0,0 +1,153 @@
+package shorten
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/idna"
+
+	"github.com/blokhinnv/shorty/internal/app/server/config"
+)
+
+// Fragment policies.
+const (
+	FragmentKeep  = "keep"
+	FragmentStrip = "strip"
+)
+
+// defaultPorts - the ports which are dropped from the URLs of the schemes.
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// CanonicalConfig - settings of URL canonicalization. The URLs which differ only
+// in the canonical form get the same ID.
+type CanonicalConfig struct {
+	// the query parameters to drop, "utm_*" matches all the parameters with the prefix
+	StripParams []string
+	SortQuery   bool
+	Fragment    string
+	TrimSlash   bool
+}
+
+// GetCanonicalConfig - canonicalization config constructor based on server config.
+// It returns nil if canonicalization is off.
+func GetCanonicalConfig(cfg *config.ServerConfig) *CanonicalConfig {
+	if !cfg.CanonicalizeURLs {
+		return nil
+	}
+	return &CanonicalConfig{
+		StripParams: cfg.CanonicalStripParams,
+		SortQuery:   cfg.CanonicalSortQuery,
+		Fragment:    cfg.CanonicalFragment,
+		TrimSlash:   cfg.CanonicalTrimSlash,
+	}
+}
+
+// Canonicalize returns the canonical form of the URL: the host is lowercased
+// and converted to punycode, the default port is dropped, the tracking parameters
+// are stripped and the rest are sorted, the fragment is kept or stripped.
+// The URLs without a host (e.g. mailto:) and a nil config leave the URL as it is.
+func (c *CanonicalConfig) Canonicalize(rawURL string) (string, error) {
+	if c == nil {
+		return rawURL, nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidURL, err)
+	}
+	if u.Host == "" || u.Opaque != "" {
+		return rawURL, nil
+	}
+	host, err := canonicalHost(u)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidURL, err)
+	}
+	u.Host = host
+	switch {
+	case u.Path == "":
+		u.Path, u.RawPath = "/", ""
+	case c.TrimSlash && len(u.Path) > 1 && strings.HasSuffix(u.Path, "/"):
+		u.Path = strings.TrimSuffix(u.Path, "/")
+		u.RawPath = strings.TrimSuffix(u.RawPath, "/")
+	}
+	u.RawQuery = c.canonicalQuery(u.RawQuery)
+	u.ForceQuery = false
+	if c.Fragment == FragmentStrip {
+		u.Fragment, u.RawFragment = "", ""
+	}
+	return u.String(), nil
+}
+
+// canonicalHost returns the lowercased ASCII host without the default port.
+func canonicalHost(u *url.URL) (string, error) {
+	hostname := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	port := u.Port()
+	if port == defaultPorts[u.Scheme] {
+		port = ""
+	}
+	if ip := net.ParseIP(hostname); ip == nil {
+		ascii, err := idna.Lookup.ToASCII(hostname)
+		if err != nil {
+			return "", err
+		}
+		hostname = ascii
+	} else if ip.To4() == nil && port == "" {
+		return "[" + hostname + "]", nil
+	}
+	if port == "" {
+		return hostname, nil
+	}
+	return net.JoinHostPort(hostname, port), nil
+}
+
+// queryKey returns the decoded name of the query parameter.
+func queryKey(param string) string {
+	key, _, _ := strings.Cut(param, "=")
+	if decoded, err := url.QueryUnescape(key); err == nil {
+		return decoded
+	}
+	return key
+}
+
+// isStripped checks if the query parameter must be dropped.
+func (c *CanonicalConfig) isStripped(key string) bool {
+	key = strings.ToLower(key)
+	for _, pattern := range c.StripParams {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if strings.HasSuffix(pattern, "*") {
+			if strings.HasPrefix(key, strings.TrimSuffix(pattern, "*")) {
+				return true
+			}
+		} else if key == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// canonicalQuery drops the tracking parameters and sorts the rest by name.
+// The parameters are kept as they are encoded, the values of the same
+// parameter keep their order.
+func (c *CanonicalConfig) canonicalQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+	params := make([]string, 0)
+	for _, param := range strings.Split(rawQuery, "&") {
+		if param == "" || c.isStripped(queryKey(param)) {
+			continue
+		}
+		params = append(params, param)
+	}
+	if c.SortQuery {
+		sort.SliceStable(params, func(i, j int) bool {
+			return queryKey(params[i]) < queryKey(params[j])
+		})
+	}
+	return strings.Join(params, "&")
+}
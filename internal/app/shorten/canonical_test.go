@@ -0,0 +1,123 @@
+package shorten
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCanonicalize(t *testing.T) {
+	conf := &CanonicalConfig{
+		StripParams: []string{"utm_*", "fbclid"},
+		SortQuery:   true,
+		Fragment:    FragmentKeep,
+		TrimSlash:   true,
+	}
+	tests := []struct {
+		name    string
+		conf    *CanonicalConfig
+		url     string
+		want    string
+		wantErr error
+	}{
+		{
+			name: "host_case",
+			conf: conf,
+			url:  "HTTP://Example.COM/Path",
+			want: "http://example.com/Path",
+		},
+		{
+			name: "empty_path",
+			conf: conf,
+			url:  "https://example.com",
+			want: "https://example.com/",
+		},
+		{
+			name: "default_port",
+			conf: conf,
+			url:  "https://example.com:443/a",
+			want: "https://example.com/a",
+		},
+		{
+			name: "other_port",
+			conf: conf,
+			url:  "http://example.com:8080/a",
+			want: "http://example.com:8080/a",
+		},
+		{
+			name: "idn",
+			conf: conf,
+			url:  "http://пример.рф/",
+			want: "http://xn--e1afmkfd.xn--p1ai/",
+		},
+		{
+			name: "ipv6",
+			conf: conf,
+			url:  "http://[::1]:80/",
+			want: "http://[::1]/",
+		},
+		{
+			name: "trailing_slash",
+			conf: conf,
+			url:  "http://example.com/a/b/",
+			want: "http://example.com/a/b",
+		},
+		{
+			name: "tracking_params",
+			conf: conf,
+			url:  "http://example.com/a?utm_source=x&id=1&UTM_Medium=y&fbclid=z",
+			want: "http://example.com/a?id=1",
+		},
+		{
+			name: "sorted_query",
+			conf: conf,
+			url:  "http://example.com/a?b=2&a=1&b=1&c=%20",
+			want: "http://example.com/a?a=1&b=2&b=1&c=%20",
+		},
+		{
+			name: "only_tracking_params",
+			conf: conf,
+			url:  "http://example.com/a?utm_source=x",
+			want: "http://example.com/a",
+		},
+		{
+			name: "fragment_kept",
+			conf: conf,
+			url:  "http://example.com/a#top",
+			want: "http://example.com/a#top",
+		},
+		{
+			name: "fragment_stripped",
+			conf: &CanonicalConfig{Fragment: FragmentStrip},
+			url:  "http://example.com/a/#top",
+			want: "http://example.com/a/",
+		},
+		{
+			name: "no_host",
+			conf: conf,
+			url:  "mailto:User@Example.com",
+			want: "mailto:User@Example.com",
+		},
+		{
+			name: "disabled",
+			url:  "HTTP://Example.COM",
+			want: "HTTP://Example.COM",
+		},
+		{
+			name:    "invalid",
+			conf:    conf,
+			url:     "http://exa mple.com/",
+			wantErr: ErrInvalidURL,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.conf.Canonicalize(tt.url)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Canonicalize() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Canonicalize() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
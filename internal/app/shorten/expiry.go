@@ -0,0 +1,27 @@
+package shorten
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidExpiry - the link expiration settings are not valid.
+var ErrInvalidExpiry = errors.New("expiration is not valid")
+
+// GetExpiresAt returns the moment the link expires.
+// Either a TTL or an absolute expiration time can be set, but not both.
+// Zero time means the link never expires.
+func GetExpiresAt(ttl time.Duration, expiresAt time.Time) (time.Time, error) {
+	switch {
+	case ttl != 0 && !expiresAt.IsZero():
+		return time.Time{}, fmt.Errorf("%w: ttl and expires_at are mutually exclusive", ErrInvalidExpiry)
+	case ttl < 0:
+		return time.Time{}, fmt.Errorf("%w: ttl %v should be positive", ErrInvalidExpiry, ttl)
+	case ttl > 0:
+		return time.Now().Add(ttl), nil
+	case !expiresAt.IsZero() && !expiresAt.After(time.Now()):
+		return time.Time{}, fmt.Errorf("%w: %v is in the past", ErrInvalidExpiry, expiresAt)
+	}
+	return expiresAt, nil
+}
@@ -0,0 +1,60 @@
+package shorten
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGetExpiresAt(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+	tests := []struct {
+		name      string
+		ttl       time.Duration
+		expiresAt time.Time
+		want      time.Time
+		wantErr   error
+	}{
+		{
+			name: "never",
+		},
+		{
+			name:      "absolute",
+			expiresAt: future,
+			want:      future,
+		},
+		{
+			name: "ttl",
+			ttl:  time.Hour,
+			want: future,
+		},
+		{
+			name:    "negative_ttl",
+			ttl:     -time.Hour,
+			wantErr: ErrInvalidExpiry,
+		},
+		{
+			name:      "past",
+			expiresAt: time.Now().Add(-time.Hour),
+			wantErr:   ErrInvalidExpiry,
+		},
+		{
+			name:      "both",
+			ttl:       time.Hour,
+			expiresAt: future,
+			wantErr:   ErrInvalidExpiry,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetExpiresAt(tt.ttl, tt.expiresAt)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetExpiresAt() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got.Sub(tt.want).Abs() > time.Second {
+				t.Errorf("GetExpiresAt() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package shorten
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync/atomic"
+
+	"github.com/cespare/xxhash/v2"
+
+	"github.com/blokhinnv/shorty/internal/app/server/config"
+	"github.com/blokhinnv/shorty/internal/app/storage"
+)
+
+// ID generation strategies.
+const (
+	HashStrategy    = "hash"
+	CounterStrategy = "counter"
+	RandomStrategy  = "random"
+	SqidsStrategy   = "sqids"
+)
+
+// minRandomIDLength - the shortest random ID which leaves enough room
+// to find a free one in a few attempts.
+const minRandomIDLength = 4
+
+// base62Letters - alphabet for base62 encoded IDs.
+const base62Letters = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// ErrInvalidID - the ID can't be decoded.
+var ErrInvalidID = errors.New("id is not valid")
+
+// IDGenerator produces IDs for short URLs.
+type IDGenerator interface {
+	// Generate returns a candidate ID for the URL. attempt grows each time
+	// the previous candidate turned out to be taken by another URL.
+	Generate(url string, attempt int) (string, error)
+}
+
+// HashGenerator derives the ID from the URL hash, so the same URL
+// always gets the same ID. IDs have variable length.
+type HashGenerator struct{}
+
+// Generate returns the hash of the URL in the 37th SS.
+// The retries hash the URL with the attempt number.
+func (HashGenerator) Generate(url string, attempt int) (string, error) {
+	if attempt == 0 {
+		return toShortenBase(xxhash.Sum64String(url)), nil
+	}
+	return toShortenBase(xxhash.Sum64String(fmt.Sprintf("%s#%d", url, attempt))), nil
+}
+
+// encodeBase converts the number to the SS with the given alphabet.
+func encodeBase(n uint64, alphabet string) string {
+	if n == 0 {
+		return alphabet[:1]
+	}
+	base := uint64(len(alphabet))
+	var buf []byte
+	for n > 0 {
+		buf = append(buf, alphabet[n%base])
+		n /= base
+	}
+	// most significant digit first
+	for i, j := 0, len(buf)-1; i < j; i, j = i+1, j-1 {
+		buf[i], buf[j] = buf[j], buf[i]
+	}
+	return string(buf)
+}
+
+// decodeBase converts the string in the SS with the given alphabet to a number.
+func decodeBase(s string, alphabet string) (uint64, error) {
+	base := uint64(len(alphabet))
+	var n uint64
+	for _, r := range s {
+		d := strings.IndexRune(alphabet, r)
+		if d < 0 {
+			return 0, fmt.Errorf("%w: %q contains forbidden symbol %q", ErrInvalidID, s, r)
+		}
+		n = n*base + uint64(d)
+	}
+	return n, nil
+}
+
+// CounterGenerator encodes a monotonic counter in base62.
+type CounterGenerator struct {
+	next func() (uint64, error)
+}
+
+// NewCounterGenerator - CounterGenerator constructor.
+// The counter is kept in memory and starts from start.
+func NewCounterGenerator(start uint64) *CounterGenerator {
+	var counter atomic.Uint64
+	counter.Store(start)
+	return &CounterGenerator{next: func() (uint64, error) {
+		return counter.Add(1) - 1, nil
+	}}
+}
+
+// NewStoredCounterGenerator - CounterGenerator constructor. The counter is kept
+// in the storage, so its values aren't issued again after restarts or purges
+// and the servers sharing the storage don't issue the same values.
+func NewStoredCounterGenerator(s storage.Storage) *CounterGenerator {
+	return &CounterGenerator{next: func() (uint64, error) {
+		return s.NextSequence(context.Background())
+	}}
+}
+
+// nextID returns the encoding of the next counter value
+// which doesn't shadow a route.
+func (g *CounterGenerator) nextID(encode func(n uint64) string) (string, error) {
+	for {
+		n, err := g.next()
+		if err != nil {
+			return "", err
+		}
+		if id := encode(n); !isReserved(id) {
+			return id, nil
+		}
+	}
+}
+
+// Generate returns the next counter value in base62.
+// Taken IDs are simply skipped.
+func (g *CounterGenerator) Generate(url string, attempt int) (string, error) {
+	return g.nextID(func(n uint64) string { return encodeBase(n, base62Letters) })
+}
+
+// RandomGenerator returns random base62 IDs of a fixed length.
+type RandomGenerator struct {
+	Length int
+}
+
+// Generate returns a new random ID on every attempt.
+func (g RandomGenerator) Generate(url string, attempt int) (string, error) {
+	var id strings.Builder
+	max := big.NewInt(int64(len(base62Letters)))
+	for i := 0; i < g.Length; i++ {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		id.WriteByte(base62Letters[n.Int64()])
+	}
+	return id.String(), nil
+}
+
+// SqidsGenerator encodes a counter like Sqids/Hashids do: IDs look random,
+// have a minimal length and can be decoded back to the number.
+type SqidsGenerator struct {
+	counter   *CounterGenerator
+	alphabet  string
+	minLength int
+}
+
+// NewSqidsGenerator - SqidsGenerator constructor. The alphabet is shuffled with the salt.
+func NewSqidsGenerator(start uint64, salt string, minLength int) *SqidsGenerator {
+	return &SqidsGenerator{
+		counter:   NewCounterGenerator(start),
+		alphabet:  shuffle(base62Letters, salt),
+		minLength: minLength,
+	}
+}
+
+// shuffle deterministically mixes the alphabet using the salt.
+func shuffle(alphabet, salt string) string {
+	letters := []byte(alphabet)
+	if salt == "" {
+		return alphabet
+	}
+	// consistent shuffle as in Hashids
+	for i, v, p := len(letters)-1, 0, 0; i > 0; i, v = i-1, v+1 {
+		v %= len(salt)
+		n := int(salt[v])
+		p += n
+		j := (n + v + p) % i
+		letters[i], letters[j] = letters[j], letters[i]
+	}
+	return string(letters)
+}
+
+// rotate returns the alphabet which starts from the i-th letter.
+func rotate(alphabet string, i int) string {
+	return alphabet[i:] + alphabet[:i]
+}
+
+// Encode converts the number to an ID. The first letter selects
+// the rotation of the alphabet used for the rest of the ID.
+func (g *SqidsGenerator) Encode(n uint64) string {
+	offset := int(n % uint64(len(g.alphabet)))
+	alphabet := rotate(g.alphabet, offset)
+	digits := alphabet[1:]
+	body := encodeBase(n, digits)
+	// leading zeros don't change the number
+	if pad := g.minLength - 1 - len(body); pad > 0 {
+		body = strings.Repeat(digits[:1], pad) + body
+	}
+	return alphabet[:1] + body
+}
+
+// Decode converts the ID back to the number.
+func (g *SqidsGenerator) Decode(id string) (uint64, error) {
+	if id == "" {
+		return 0, fmt.Errorf("%w: empty id", ErrInvalidID)
+	}
+	offset := strings.IndexByte(g.alphabet, id[0])
+	if offset < 0 {
+		return 0, fmt.Errorf("%w: %q contains forbidden symbol %q", ErrInvalidID, id, id[0])
+	}
+	n, err := decodeBase(id[1:], rotate(g.alphabet, offset)[1:])
+	if err != nil {
+		return 0, err
+	}
+	// every number has a single valid form
+	if g.Encode(n) != id {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidID, id)
+	}
+	return n, nil
+}
+
+// Generate returns the encoded next counter value.
+func (g *SqidsGenerator) Generate(url string, attempt int) (string, error) {
+	return g.counter.nextID(g.Encode)
+}
+
+// NewIDGenerator - ID generator constructor based on server config.
+// Counters are kept in the storage, the IDs which are taken
+// by aliases are skipped on collisions.
+func NewIDGenerator(cfg *config.ServerConfig, s storage.Storage) (IDGenerator, error) {
+	switch cfg.IDGenerator {
+	case "", HashStrategy:
+		return HashGenerator{}, nil
+	case RandomStrategy:
+		if cfg.IDLength < minRandomIDLength {
+			return nil, fmt.Errorf(
+				"id length %d is too short for random ids, at least %d is required",
+				cfg.IDLength,
+				minRandomIDLength,
+			)
+		}
+		return RandomGenerator{Length: cfg.IDLength}, nil
+	case CounterStrategy:
+		return NewStoredCounterGenerator(s), nil
+	case SqidsStrategy:
+		g := NewSqidsGenerator(0, cfg.IDSalt, cfg.IDLength)
+		g.counter = NewStoredCounterGenerator(s)
+		return g, nil
+	}
+	return nil, fmt.Errorf("unknown id generator %q", cfg.IDGenerator)
+}
@@ -0,0 +1,191 @@
+package shorten
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/blokhinnv/shorty/internal/app/server/config"
+	"github.com/blokhinnv/shorty/internal/app/storage"
+)
+
+func TestHashGenerator(t *testing.T) {
+	gen := HashGenerator{}
+	id, _ := gen.Generate("http://yandex.ru", 0)
+	if id != "3lmmrhti6j0e2" {
+		t.Errorf("Generate() got = %v, want %v", id, "3lmmrhti6j0e2")
+	}
+	retry, _ := gen.Generate("http://yandex.ru", 1)
+	if retry == id {
+		t.Errorf("Generate() retry should give another id, got %v", retry)
+	}
+}
+
+func TestCounterGenerator(t *testing.T) {
+	gen := NewCounterGenerator(61)
+	for _, want := range []string{"Z", "10", "11"} {
+		if got, _ := gen.Generate("http://yandex.ru", 0); got != want {
+			t.Errorf("Generate() got = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestCounterGeneratorReserved(t *testing.T) {
+	// the IDs which would shadow routes are skipped
+	for _, word := range []string{"api", "v1"} {
+		n, err := decodeBase(word, base62Letters)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gen := NewCounterGenerator(n)
+		if got, _ := gen.Generate("http://yandex.ru", 0); got != encodeBase(n+1, base62Letters) {
+			t.Errorf("Generate() got = %v, want %v", got, encodeBase(n+1, base62Letters))
+		}
+	}
+}
+
+func TestRandomGenerator(t *testing.T) {
+	gen := RandomGenerator{Length: 7}
+	a, err := gen.Generate("http://yandex.ru", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := gen.Generate("http://yandex.ru", 0)
+	if len(a) != 7 || len(b) != 7 {
+		t.Errorf("Generate() got ids %v, %v, want length 7", a, b)
+	}
+	if a == b {
+		t.Errorf("Generate() got the same id twice: %v", a)
+	}
+}
+
+func TestSqidsGenerator(t *testing.T) {
+	gen := NewSqidsGenerator(0, "shorty", 6)
+	seen := make(map[string]bool)
+	for _, n := range []uint64{0, 1, 2, 61, 62, 1000, 1 << 40} {
+		id := gen.Encode(n)
+		if len(id) < 6 {
+			t.Errorf("Encode(%v) = %v, shorter than 6", n, id)
+		}
+		if seen[id] {
+			t.Errorf("Encode(%v) = %v is not unique", n, id)
+		}
+		seen[id] = true
+		got, err := gen.Decode(id)
+		if err != nil || got != n {
+			t.Errorf("Decode(%v) = %v, %v, want %v", id, got, err, n)
+		}
+	}
+	if _, err := gen.Decode("!!!"); !errors.Is(err, ErrInvalidID) {
+		t.Errorf("Decode() error = %v, want %v", err, ErrInvalidID)
+	}
+	other := NewSqidsGenerator(0, "another salt", 6)
+	if other.Encode(1000) == gen.Encode(1000) {
+		t.Errorf("different salts should give different ids")
+	}
+}
+
+func TestNewIDGenerator(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s := storage.NewMockStorage(ctrl)
+	// the counter is kept in the storage
+	gomock.InOrder(
+		s.EXPECT().NextSequence(gomock.Any()).Return(uint64(61), nil),
+		s.EXPECT().NextSequence(gomock.Any()).Return(uint64(0), errors.New("storage is down")),
+	)
+
+	gen, err := NewIDGenerator(&config.ServerConfig{IDGenerator: CounterStrategy}, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, _ := gen.Generate("http://yandex.ru", 0); id != "Z" {
+		t.Errorf("Generate() got = %v, want %v", id, "Z")
+	}
+	if _, err := gen.Generate("http://yandex.ru", 0); err == nil {
+		t.Errorf("Generate() should fail if the storage fails")
+	}
+	s.EXPECT().NextSequence(gomock.Any()).Return(uint64(1000), nil)
+	sqids, err := NewIDGenerator(&config.ServerConfig{IDGenerator: SqidsStrategy, IDSalt: "shorty", IDLength: 6}, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, _ := sqids.Generate("http://yandex.ru", 0); id != NewSqidsGenerator(0, "shorty", 6).Encode(1000) {
+		t.Errorf("Generate() got = %v, want the encoding of %v", id, 1000)
+	}
+	if _, err := NewIDGenerator(&config.ServerConfig{IDGenerator: "uuid"}, s); err == nil {
+		t.Errorf("NewIDGenerator() should fail for unknown generator")
+	}
+	for _, length := range []int{-1, 0, minRandomIDLength - 1} {
+		if _, err := NewIDGenerator(&config.ServerConfig{IDGenerator: RandomStrategy, IDLength: length}, s); err == nil {
+			t.Errorf("NewIDGenerator() should fail for random ids of length %d", length)
+		}
+	}
+	random, err := NewIDGenerator(&config.ServerConfig{IDGenerator: RandomStrategy, IDLength: minRandomIDLength}, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, _ := random.Generate("http://yandex.ru", 0); len(id) != minRandomIDLength {
+		t.Errorf("Generate() got = %v, want an id of length %v", id, minRandomIDLength)
+	}
+}
+
+func TestSaveURL(t *testing.T) {
+	ctx := context.Background()
+	url := "http://yandex.ru"
+
+	t.Run("retry_taken", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		s := storage.NewMockStorage(ctrl)
+		gomock.InOrder(
+			s.EXPECT().AddURL(ctx, url, "0", uint32(1), gomock.Any()).Return(storage.ErrURLIDTaken),
+			s.EXPECT().AddURL(ctx, url, "1", uint32(1), gomock.Any()).Return(nil),
+		)
+		id, err := SaveURL(ctx, s, NewCounterGenerator(0), url, 1, storage.LinkOptions{})
+		if err != nil || id != "1" {
+			t.Errorf("SaveURL() = %v, %v, want %v", id, err, "1")
+		}
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		s := storage.NewMockStorage(ctrl)
+		s.EXPECT().
+			AddURL(ctx, url, "0", uint32(1), gomock.Any()).
+			Return(&storage.DuplicateURLError{URL: url, URLID: "abc"})
+		id, err := SaveURL(ctx, s, NewCounterGenerator(0), url, 1, storage.LinkOptions{})
+		if !errors.Is(err, storage.ErrUniqueViolation) || id != "abc" {
+			t.Errorf("SaveURL() = %v, %v, want %v", id, err, "abc")
+		}
+	})
+
+	t.Run("new_owner", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		s := storage.NewMockStorage(ctrl)
+		s.EXPECT().
+			AddURL(ctx, url, "0", uint32(2), gomock.Any()).
+			Return(&storage.DuplicateURLError{URL: url, URLID: "abc", NewOwner: true})
+		id, err := SaveURL(ctx, s, NewCounterGenerator(0), url, 2, storage.LinkOptions{})
+		if err != nil || id != "abc" {
+			t.Errorf("SaveURL() = %v, %v, want %v", id, err, "abc")
+		}
+	})
+
+	t.Run("no_free_id", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		s := storage.NewMockStorage(ctrl)
+		s.EXPECT().
+			AddURL(ctx, url, gomock.Any(), uint32(1), gomock.Any()).
+			Return(storage.ErrURLIDTaken).
+			Times(maxAttempts)
+		if _, err := SaveURL(ctx, s, HashGenerator{}, url, 1, storage.LinkOptions{}); !errors.Is(err, ErrNoFreeID) {
+			t.Errorf("SaveURL() error = %v, want %v", err, ErrNoFreeID)
+		}
+	})
+}
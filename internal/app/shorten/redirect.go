@@ -0,0 +1,42 @@
+package shorten
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/blokhinnv/shorty/internal/app/storage"
+)
+
+// DefaultRedirectCode - the status code of the links without a redirect type.
+const DefaultRedirectCode = http.StatusTemporaryRedirect
+
+// ErrInvalidRedirect - the redirect type is not supported.
+var ErrInvalidRedirect = errors.New("redirect type is not valid")
+
+// ValidateRedirectCode checks if the links can redirect with the status code:
+// 301 and 308 are permanent, 302 and 307 are temporary. Zero means the default one.
+func ValidateRedirectCode(code int) error {
+	switch code {
+	case 0,
+		http.StatusMovedPermanently,
+		http.StatusFound,
+		http.StatusTemporaryRedirect,
+		http.StatusPermanentRedirect:
+		return nil
+	}
+	return fmt.Errorf("%w: %d should be one of 301, 302, 307, 308", ErrInvalidRedirect, code)
+}
+
+// RedirectCode returns the status code the record redirects with.
+func RedirectCode(rec storage.Record) int {
+	if rec.RedirectCode == 0 {
+		return DefaultRedirectCode
+	}
+	return rec.RedirectCode
+}
+
+// IsPermanent checks if the redirect is permanent, so the clients may cache it.
+func IsPermanent(code int) bool {
+	return code == http.StatusMovedPermanently || code == http.StatusPermanentRedirect
+}
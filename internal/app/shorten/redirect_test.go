@@ -0,0 +1,47 @@
+package shorten
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/blokhinnv/shorty/internal/app/storage"
+)
+
+func TestValidateRedirectCode(t *testing.T) {
+	for _, code := range []int{0, 301, 302, 307, 308} {
+		if err := ValidateRedirectCode(code); err != nil {
+			t.Errorf("ValidateRedirectCode(%d) error = %v", code, err)
+		}
+	}
+	for _, code := range []int{200, 303, 304, 404} {
+		if err := ValidateRedirectCode(code); !errors.Is(err, ErrInvalidRedirect) {
+			t.Errorf("ValidateRedirectCode(%d) error = %v, want %v", code, err, ErrInvalidRedirect)
+		}
+	}
+}
+
+func TestRedirectCode(t *testing.T) {
+	tests := []struct {
+		name      string
+		code      int
+		want      int
+		permanent bool
+	}{
+		{name: "default", code: 0, want: http.StatusTemporaryRedirect},
+		{name: "found", code: http.StatusFound, want: http.StatusFound},
+		{name: "moved", code: http.StatusMovedPermanently, want: http.StatusMovedPermanently, permanent: true},
+		{name: "permanent", code: http.StatusPermanentRedirect, want: http.StatusPermanentRedirect, permanent: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RedirectCode(storage.Record{RedirectCode: tt.code})
+			if got != tt.want {
+				t.Errorf("RedirectCode() = %v, want %v", got, tt.want)
+			}
+			if IsPermanent(got) != tt.permanent {
+				t.Errorf("IsPermanent(%v) = %v, want %v", got, !tt.permanent, tt.permanent)
+			}
+		})
+	}
+}
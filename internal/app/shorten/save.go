@@ -0,0 +1,88 @@
+package shorten
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/blokhinnv/shorty/internal/app/log"
+	"github.com/blokhinnv/shorty/internal/app/storage"
+)
+
+// maxAttempts - how many IDs are tried before giving up.
+const maxAttempts = 10
+
+// ErrNoFreeID - all generated IDs were taken by other URLs.
+var ErrNoFreeID = errors.New("can't find a free id")
+
+// GenerateID returns the first candidate ID for the URL.
+func GenerateID(gen IDGenerator, url string) (string, error) {
+	if !isURL(url) {
+		return "", fmt.Errorf("%w: %s ", ErrInvalidURL, url)
+	}
+	return gen.Generate(url, 0)
+}
+
+// SaveURL adds the URL to the storage under an ID from the generator
+// and returns the ID. If the ID is taken by another URL, the next candidate
+// is tried. If the user has already shortened the URL, its ID is returned
+// along with the storage error.
+func SaveURL(
+	ctx context.Context,
+	s storage.Storage,
+	gen IDGenerator,
+	url string,
+	userID uint32,
+	opts storage.LinkOptions,
+) (string, error) {
+	if !isURL(url) {
+		return "", fmt.Errorf("%w: %s ", ErrInvalidURL, url)
+	}
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		urlID, err := gen.Generate(url, attempt)
+		if err != nil {
+			return "", err
+		}
+		err = s.AddURL(ctx, url, urlID, userID, opts)
+		var dupErr *storage.DuplicateURLError
+		switch {
+		case err == nil:
+			return urlID, nil
+		case errors.As(err, &dupErr) && dupErr.NewOwner:
+			// shortened by other users, but new for this one
+			return dupErr.URLID, nil
+		case errors.As(err, &dupErr):
+			return dupErr.URLID, err
+		case errors.Is(err, storage.ErrURLIDTaken):
+			log.Infof("ID %v is taken, trying another one: %v", urlID, err)
+		case errors.Is(err, storage.ErrUniqueViolation):
+			// the storage doesn't tell which ID the URL has
+			return urlID, err
+		default:
+			return "", err
+		}
+	}
+	return "", fmt.Errorf("%w: url=%v after %d attempts", ErrNoFreeID, url, maxAttempts)
+}
+
+// SaveAlias adds the URL to the storage using the alias as its ID.
+func SaveAlias(
+	ctx context.Context,
+	s storage.Storage,
+	url string,
+	alias string,
+	aliasCfg *AliasConfig,
+	userID uint32,
+	opts storage.LinkOptions,
+) (string, error) {
+	if !isURL(url) {
+		return "", fmt.Errorf("%w: %s ", ErrInvalidURL, url)
+	}
+	if err := aliasCfg.ValidateAlias(alias); err != nil {
+		return "", err
+	}
+	if err := s.AddURL(ctx, url, alias, userID, opts); err != nil {
+		return "", err
+	}
+	return alias, nil
+}
@@ -2,26 +2,36 @@
 package shorten
 
 import (
+	"errors"
 	"fmt"
 	"net/url"
 	"strings"
-
-	"github.com/cespare/xxhash/v2"
 )
 
 // Alphabet SS.
 const (
-	letters = "0123456789abcdefghijklmnopqrstuvwxyz_" // alphabet in 38th SS
+	letters = "0123456789abcdefghijklmnopqrstuvwxyz_" // alphabet in 37th SS
 	base    = 37
 )
 
+// ErrInvalidURL - the string to shorten is not a URL.
+var ErrInvalidURL = errors.New("not an URL")
+
 // isURL checks if the string is a URL.
 func isURL(s string) bool {
 	_, err := url.ParseRequestURI(s)
 	return err == nil
 }
 
-// toShortenBase translates the number to the 38th CC.
+// ValidateURL checks if the string can be the destination of a short URL.
+func ValidateURL(url string) error {
+	if !isURL(url) {
+		return fmt.Errorf("%w: %s ", ErrInvalidURL, url)
+	}
+	return nil
+}
+
+// toShortenBase translates the number to the 37th SS.
 func toShortenBase(urlUUID uint64) string {
 	var shortURL strings.Builder
 	for urlUUID > 0 {
@@ -39,11 +49,15 @@ func GetShortURL(
 ) (string, string, error) {
 	// If not URL, then will not shorten
 	if !isURL(url) {
-		return "", "", fmt.Errorf("not an URL: %s ", url)
+		return "", "", fmt.Errorf("%w: %s ", ErrInvalidURL, url)
 	}
 	// Reduce
-	shortURLID := toShortenBase(xxhash.Sum64String(url))
+	shortURLID, _ := HashGenerator{}.Generate(url, 0)
 	// Generate URL
-	shortURL := fmt.Sprintf("%v/%v", baseURL, shortURLID)
-	return shortURLID, shortURL, nil
+	return shortURLID, ShortURL(baseURL, shortURLID), nil
+}
+
+// ShortURL returns the shortened URL for the ID.
+func ShortURL(baseURL, urlID string) string {
+	return fmt.Sprintf("%v/%v", baseURL, urlID)
 }
@@ -0,0 +1,213 @@
+package storage
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/blokhinnv/shorty/internal/app/log"
+)
+
+// CacheConfig - read-through cache config.
+type CacheConfig struct {
+	// maximum number of cached URLs
+	Size int
+	// how long a URL is kept in the cache
+	TTL time.Duration
+	// how long a missing URL is remembered (0 disables negative caching)
+	NegativeTTL time.Duration
+}
+
+// CacheStats - counters of the cache.
+type CacheStats struct {
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+	Size   int    `json:"size"`
+}
+
+// cacheEntry - cached result of GetURLByID.
+type cacheEntry struct {
+	urlID     string
+	rec       Record
+	err       error
+	expiresAt time.Time
+}
+
+// CachedStorage wraps a storage with an LRU cache of GetURLByID results.
+// The cache is invalidated by the methods which change the records.
+type CachedStorage struct {
+	Storage
+	conf CacheConfig
+
+	mu    sync.Mutex
+	items map[string]*list.Element
+	// most recently used entries go first
+	order *list.List
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// NewCachedStorage - CachedStorage constructor.
+func NewCachedStorage(s Storage, conf CacheConfig) *CachedStorage {
+	return &CachedStorage{
+		Storage: s,
+		conf:    conf,
+		items:   make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// get returns the cached result for the ID.
+func (c *CachedStorage) get(urlID string) (*cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[urlID]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*cacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.order.Remove(el)
+		delete(c.items, urlID)
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return entry, true
+}
+
+// put caches the result for the ID and evicts the least recently used entries.
+func (c *CachedStorage) put(urlID string, rec Record, err error, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := &cacheEntry{urlID: urlID, rec: rec, err: err, expiresAt: time.Now().Add(ttl)}
+	if el, ok := c.items[urlID]; ok {
+		el.Value = entry
+		c.order.MoveToFront(el)
+		return
+	}
+	c.items[urlID] = c.order.PushFront(entry)
+	for c.order.Len() > c.conf.Size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheEntry).urlID)
+	}
+}
+
+// invalidate removes the IDs from the cache.
+func (c *CachedStorage) invalidate(urlIDs ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, urlID := range urlIDs {
+		if el, ok := c.items[urlID]; ok {
+			c.order.Remove(el)
+			delete(c.items, urlID)
+		}
+	}
+}
+
+// GetURLByID returns a URL by its ID, the storage is queried on cache misses.
+func (c *CachedStorage) GetURLByID(ctx context.Context, urlID string) (Record, error) {
+	if entry, ok := c.get(urlID); ok {
+		c.hits.Add(1)
+		if entry.err != nil {
+			return Record{}, entry.err
+		}
+		// the link may have expired while it was cached
+		if entry.rec.IsExpired() {
+			return Record{}, ErrURLExpired
+		}
+		return entry.rec, nil
+	}
+	c.misses.Add(1)
+	rec, err := c.Storage.GetURLByID(ctx, urlID)
+	switch {
+	case err == nil:
+		c.put(urlID, rec, nil, c.conf.TTL)
+	case errors.Is(err, ErrURLWasNotFound) && c.conf.NegativeTTL > 0:
+		c.put(urlID, Record{}, err, c.conf.NegativeTTL)
+	}
+	return rec, err
+}
+
+// AddURL adds a URL to the store. The ID may have been remembered as missing
+// or belong to a deleted URL which is restored.
+func (c *CachedStorage) AddURL(ctx context.Context, url, urlID string, userID uint32, opts LinkOptions) error {
+	err := c.Storage.AddURL(ctx, url, urlID, userID, opts)
+	c.invalidate(urlID)
+	var dupErr *DuplicateURLError
+	if errors.As(err, &dupErr) {
+		c.invalidate(dupErr.URLID)
+	}
+	return err
+}
+
+// AddURLBatch adds a batch of URLs to the store.
+func (c *CachedStorage) AddURLBatch(ctx context.Context, urlIDs map[string]string, userID uint32) error {
+	err := c.Storage.AddURLBatch(ctx, urlIDs, userID)
+	for _, urlID := range urlIDs {
+		c.invalidate(urlID)
+	}
+	return err
+}
+
+// DeleteMany removes the user from the owners of the URLs.
+func (c *CachedStorage) DeleteMany(ctx context.Context, userID uint32, urlIDs []string) error {
+	err := c.Storage.DeleteMany(ctx, userID, urlIDs)
+	c.invalidate(urlIDs...)
+	return err
+}
+
+// SetPreview saves the preview of the URL, the cached record gets outdated.
+func (c *CachedStorage) SetPreview(ctx context.Context, urlID string, preview Preview) error {
+	err := c.Storage.SetPreview(ctx, urlID, preview)
+	c.invalidate(urlID)
+	return err
+}
+
+// SetHealth saves the result of the check of the URL, the cached record gets outdated.
+func (c *CachedStorage) SetHealth(ctx context.Context, urlID string, health Health) error {
+	err := c.Storage.SetHealth(ctx, urlID, health)
+	c.invalidate(urlID)
+	return err
+}
+
+// UpdateURL changes the destination of the URL, the cached record gets outdated.
+func (c *CachedStorage) UpdateURL(
+	ctx context.Context,
+	urlID string,
+	userID uint32,
+	url, originalURL string,
+) error {
+	err := c.Storage.UpdateURL(ctx, urlID, userID, url, originalURL)
+	c.invalidate(urlID)
+	return err
+}
+
+// Clear clears the storage and the cache.
+func (c *CachedStorage) Clear(ctx context.Context) error {
+	err := c.Storage.Clear(ctx)
+	c.mu.Lock()
+	c.items = make(map[string]*list.Element)
+	c.order.Init()
+	c.mu.Unlock()
+	return err
+}
+
+// Close closes the store.
+func (c *CachedStorage) Close(ctx context.Context) {
+	stats := c.CacheStats()
+	log.Infof("Cache hits: %v, misses: %v\n", stats.Hits, stats.Misses)
+	c.Storage.Close(ctx)
+}
+
+// CacheStats returns the counters of the cache.
+func (c *CachedStorage) CacheStats() CacheStats {
+	c.mu.Lock()
+	size := c.order.Len()
+	c.mu.Unlock()
+	return CacheStats{Hits: c.hits.Load(), Misses: c.misses.Load(), Size: size}
+}
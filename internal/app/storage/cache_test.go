@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestCache(t *testing.T, size int) (*CachedStorage, *MockStorage) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+	m := NewMockStorage(ctrl)
+	return NewCachedStorage(m, CacheConfig{Size: size, TTL: time.Minute, NegativeTTL: time.Minute}), m
+}
+
+func TestCachedStorageHit(t *testing.T) {
+	ctx := context.Background()
+	c, m := newTestCache(t, 10)
+	rec := Record{URL: "http://yandex.ru", URLID: "qwerty"}
+	m.EXPECT().GetURLByID(ctx, "qwerty").Return(rec, nil).Times(1)
+
+	for i := 0; i < 3; i++ {
+		got, err := c.GetURLByID(ctx, "qwerty")
+		assert.NoError(t, err)
+		assert.Equal(t, rec, got)
+	}
+	assert.Equal(t, CacheStats{Hits: 2, Misses: 1, Size: 1}, c.CacheStats())
+}
+
+func TestCachedStorageNegative(t *testing.T) {
+	ctx := context.Background()
+	c, m := newTestCache(t, 10)
+	m.EXPECT().GetURLByID(ctx, "qwerty").Return(Record{}, ErrURLWasNotFound).Times(1)
+
+	_, err := c.GetURLByID(ctx, "qwerty")
+	assert.ErrorIs(t, err, ErrURLWasNotFound)
+	_, err = c.GetURLByID(ctx, "qwerty")
+	assert.ErrorIs(t, err, ErrURLWasNotFound)
+
+	// adding the URL forgets that it was missing
+	m.EXPECT().AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), LinkOptions{}).Return(nil)
+	m.EXPECT().GetURLByID(ctx, "qwerty").Return(Record{URL: "http://yandex.ru"}, nil)
+	assert.NoError(t, c.AddURL(ctx, "http://yandex.ru", "qwerty", uint32(1), LinkOptions{}))
+	rec, err := c.GetURLByID(ctx, "qwerty")
+	assert.NoError(t, err)
+	assert.Equal(t, "http://yandex.ru", rec.URL)
+}
+
+func TestCachedStorageDeletedNotCached(t *testing.T) {
+	ctx := context.Background()
+	c, m := newTestCache(t, 10)
+	m.EXPECT().GetURLByID(ctx, "qwerty").Return(Record{}, ErrURLWasDeleted).Times(2)
+
+	for i := 0; i < 2; i++ {
+		_, err := c.GetURLByID(ctx, "qwerty")
+		assert.ErrorIs(t, err, ErrURLWasDeleted)
+	}
+}
+
+func TestCachedStorageExpired(t *testing.T) {
+	ctx := context.Background()
+	c, m := newTestCache(t, 10)
+	rec := Record{URL: "http://yandex.ru", URLID: "qwerty", ExpiresAt: time.Now().Add(50 * time.Millisecond)}
+	m.EXPECT().GetURLByID(ctx, "qwerty").Return(rec, nil).Times(1)
+
+	_, err := c.GetURLByID(ctx, "qwerty")
+	assert.NoError(t, err)
+	time.Sleep(100 * time.Millisecond)
+	_, err = c.GetURLByID(ctx, "qwerty")
+	assert.ErrorIs(t, err, ErrURLExpired)
+}
+
+func TestCachedStorageInvalidation(t *testing.T) {
+	ctx := context.Background()
+	c, m := newTestCache(t, 10)
+	rec := Record{URL: "http://yandex.ru", URLID: "qwerty"}
+
+	m.EXPECT().GetURLByID(ctx, "qwerty").Return(rec, nil)
+	c.GetURLByID(ctx, "qwerty")
+	m.EXPECT().DeleteMany(ctx, uint32(1), []string{"qwerty"}).Return(nil)
+	assert.NoError(t, c.DeleteMany(ctx, uint32(1), []string{"qwerty"}))
+	m.EXPECT().GetURLByID(ctx, "qwerty").Return(Record{}, ErrURLWasDeleted)
+	_, err := c.GetURLByID(ctx, "qwerty")
+	assert.ErrorIs(t, err, ErrURLWasDeleted)
+
+	m.EXPECT().GetURLByID(ctx, "asdfgh").Return(rec, nil)
+	c.GetURLByID(ctx, "asdfgh")
+	m.EXPECT().Clear(ctx).Return(nil)
+	assert.NoError(t, c.Clear(ctx))
+	assert.Equal(t, 0, c.CacheStats().Size)
+}
+
+func TestCachedStorageEviction(t *testing.T) {
+	ctx := context.Background()
+	c, m := newTestCache(t, 2)
+	for _, id := range []string{"a", "b", "c"} {
+		m.EXPECT().GetURLByID(ctx, id).Return(Record{URLID: id}, nil)
+	}
+	c.GetURLByID(ctx, "a")
+	c.GetURLByID(ctx, "b")
+	// "a" is used more recently than "b"
+	c.GetURLByID(ctx, "a")
+	c.GetURLByID(ctx, "c")
+	assert.Equal(t, 2, c.CacheStats().Size)
+
+	m.EXPECT().GetURLByID(ctx, "b").Return(Record{URLID: "b"}, nil)
+	c.GetURLByID(ctx, "a")
+	c.GetURLByID(ctx, "b")
+	assert.Equal(t, CacheStats{Hits: 2, Misses: 4, Size: 2}, c.CacheStats())
+}
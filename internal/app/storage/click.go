@@ -0,0 +1,12 @@
+package storage
+
+import "time"
+
+// Click is a structure for storing a single visit of a short URL.
+type Click struct {
+	URLID     string    `json:"url_id"`
+	ClickedAt time.Time `json:"clicked_at"`
+	Referrer  string    `json:"referrer"`
+	UserAgent string    `json:"user_agent"`
+	Country   string    `json:"country"`
+}
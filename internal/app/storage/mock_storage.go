@@ -7,6 +7,7 @@ package storage
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -34,18 +35,46 @@ func (m *MockStorage) EXPECT() *MockStorageMockRecorder {
 	return m.recorder
 }
 
+// AddAPIKey mocks base method.
+func (m *MockStorage) AddAPIKey(arg0 context.Context, arg1 APIKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAPIKey", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddAPIKey indicates an expected call of AddAPIKey.
+func (mr *MockStorageMockRecorder) AddAPIKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAPIKey", reflect.TypeOf((*MockStorage)(nil).AddAPIKey), arg0, arg1)
+}
+
+// AddClicks mocks base method.
+func (m *MockStorage) AddClicks(arg0 context.Context, arg1 []Click) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddClicks", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddClicks indicates an expected call of AddClicks.
+func (mr *MockStorageMockRecorder) AddClicks(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddClicks", reflect.TypeOf((*MockStorage)(nil).AddClicks), arg0, arg1)
+}
+
 // AddURL mocks base method.
-func (m *MockStorage) AddURL(arg0 context.Context, arg1, arg2 string, arg3 uint32) error {
+func (m *MockStorage) AddURL(arg0 context.Context, arg1, arg2 string, arg3 uint32, arg4 LinkOptions) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddURL", arg0, arg1, arg2, arg3)
+	ret := m.ctrl.Call(m, "AddURL", arg0, arg1, arg2, arg3, arg4)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // AddURL indicates an expected call of AddURL.
-func (mr *MockStorageMockRecorder) AddURL(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+func (mr *MockStorageMockRecorder) AddURL(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddURL", reflect.TypeOf((*MockStorage)(nil).AddURL), arg0, arg1, arg2, arg3)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddURL", reflect.TypeOf((*MockStorage)(nil).AddURL), arg0, arg1, arg2, arg3, arg4)
 }
 
 // AddURLBatch mocks base method.
@@ -62,6 +91,20 @@ func (mr *MockStorageMockRecorder) AddURLBatch(arg0, arg1, arg2 interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddURLBatch", reflect.TypeOf((*MockStorage)(nil).AddURLBatch), arg0, arg1, arg2)
 }
 
+// AddUser mocks base method.
+func (m *MockStorage) AddUser(arg0 context.Context, arg1 User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddUser", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddUser indicates an expected call of AddUser.
+func (mr *MockStorageMockRecorder) AddUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddUser", reflect.TypeOf((*MockStorage)(nil).AddUser), arg0, arg1)
+}
+
 // Clear mocks base method.
 func (m *MockStorage) Clear(arg0 context.Context) error {
 	m.ctrl.T.Helper()
@@ -102,6 +145,36 @@ func (mr *MockStorageMockRecorder) DeleteMany(arg0, arg1, arg2 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMany", reflect.TypeOf((*MockStorage)(nil).DeleteMany), arg0, arg1, arg2)
 }
 
+// GetAPIKey mocks base method.
+func (m *MockStorage) GetAPIKey(arg0 context.Context, arg1 string) (APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKey", arg0, arg1)
+	ret0, _ := ret[0].(APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKey indicates an expected call of GetAPIKey.
+func (mr *MockStorageMockRecorder) GetAPIKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKey", reflect.TypeOf((*MockStorage)(nil).GetAPIKey), arg0, arg1)
+}
+
+// GetClicks mocks base method.
+func (m *MockStorage) GetClicks(arg0 context.Context, arg1 string) ([]Click, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetClicks", arg0, arg1)
+	ret0, _ := ret[0].([]Click)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetClicks indicates an expected call of GetClicks.
+func (mr *MockStorageMockRecorder) GetClicks(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetClicks", reflect.TypeOf((*MockStorage)(nil).GetClicks), arg0, arg1)
+}
+
 // GetStats mocks base method.
 func (m *MockStorage) GetStats(arg0 context.Context) (int, int, error) {
 	m.ctrl.T.Helper()
@@ -133,6 +206,21 @@ func (mr *MockStorageMockRecorder) GetURLByID(arg0, arg1 interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetURLByID", reflect.TypeOf((*MockStorage)(nil).GetURLByID), arg0, arg1)
 }
 
+// GetURLHistory mocks base method.
+func (m *MockStorage) GetURLHistory(arg0 context.Context, arg1 string) ([]Revision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetURLHistory", arg0, arg1)
+	ret0, _ := ret[0].([]Revision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetURLHistory indicates an expected call of GetURLHistory.
+func (mr *MockStorageMockRecorder) GetURLHistory(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetURLHistory", reflect.TypeOf((*MockStorage)(nil).GetURLHistory), arg0, arg1)
+}
+
 // GetURLsByUser mocks base method.
 func (m *MockStorage) GetURLsByUser(arg0 context.Context, arg1 uint32) ([]Record, error) {
 	m.ctrl.T.Helper()
@@ -148,6 +236,66 @@ func (mr *MockStorageMockRecorder) GetURLsByUser(arg0, arg1 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetURLsByUser", reflect.TypeOf((*MockStorage)(nil).GetURLsByUser), arg0, arg1)
 }
 
+// GetURLsToCheck mocks base method.
+func (m *MockStorage) GetURLsToCheck(arg0 context.Context, arg1 time.Time, arg2 int) ([]Record, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetURLsToCheck", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]Record)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetURLsToCheck indicates an expected call of GetURLsToCheck.
+func (mr *MockStorageMockRecorder) GetURLsToCheck(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetURLsToCheck", reflect.TypeOf((*MockStorage)(nil).GetURLsToCheck), arg0, arg1, arg2)
+}
+
+// GetUser mocks base method.
+func (m *MockStorage) GetUser(arg0 context.Context, arg1 string) (User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", arg0, arg1)
+	ret0, _ := ret[0].(User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockStorageMockRecorder) GetUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockStorage)(nil).GetUser), arg0, arg1)
+}
+
+// MoveURLs mocks base method.
+func (m *MockStorage) MoveURLs(arg0 context.Context, arg1, arg2 uint32) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MoveURLs", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MoveURLs indicates an expected call of MoveURLs.
+func (mr *MockStorageMockRecorder) MoveURLs(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MoveURLs", reflect.TypeOf((*MockStorage)(nil).MoveURLs), arg0, arg1, arg2)
+}
+
+// NextSequence mocks base method.
+func (m *MockStorage) NextSequence(arg0 context.Context) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NextSequence", arg0)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NextSequence indicates an expected call of NextSequence.
+func (mr *MockStorageMockRecorder) NextSequence(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NextSequence", reflect.TypeOf((*MockStorage)(nil).NextSequence), arg0)
+}
+
 // Ping mocks base method.
 func (m *MockStorage) Ping(arg0 context.Context) bool {
 	m.ctrl.T.Helper()
@@ -161,3 +309,45 @@ func (mr *MockStorageMockRecorder) Ping(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockStorage)(nil).Ping), arg0)
 }
+
+// SetHealth mocks base method.
+func (m *MockStorage) SetHealth(arg0 context.Context, arg1 string, arg2 Health) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetHealth", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetHealth indicates an expected call of SetHealth.
+func (mr *MockStorageMockRecorder) SetHealth(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetHealth", reflect.TypeOf((*MockStorage)(nil).SetHealth), arg0, arg1, arg2)
+}
+
+// SetPreview mocks base method.
+func (m *MockStorage) SetPreview(arg0 context.Context, arg1 string, arg2 Preview) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPreview", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPreview indicates an expected call of SetPreview.
+func (mr *MockStorageMockRecorder) SetPreview(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPreview", reflect.TypeOf((*MockStorage)(nil).SetPreview), arg0, arg1, arg2)
+}
+
+// UpdateURL mocks base method.
+func (m *MockStorage) UpdateURL(arg0 context.Context, arg1 string, arg2 uint32, arg3, arg4 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateURL", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateURL indicates an expected call of UpdateURL.
+func (mr *MockStorageMockRecorder) UpdateURL(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateURL", reflect.TypeOf((*MockStorage)(nil).UpdateURL), arg0, arg1, arg2, arg3, arg4)
+}
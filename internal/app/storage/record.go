@@ -1,13 +1,137 @@
 package storage
 
-import "time"
+import (
+	"sort"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
 
 // Record is a structure for storing a record from the repository.
+// UserID is the user who created the link, Owners are all the users
+// who have shortened the URL and see it in their lists. URL is the canonical
+// form of the URL, OriginalURL is set if the user sent it in another form.
 type Record struct {
-	URL         string    `json:"url"          valid:"url,required"`
-	URLID       string    `json:"url_id"       valid:"url,required"`
+	URL         string    `json:"url"              valid:"url,required"`
+	OriginalURL string    `json:"original_url,omitempty"`
+	URLID       string    `json:"url_id"           valid:"url,required"`
 	UserID      uint32    `json:"user_id"`
+	Owners      []uint32  `json:"owners,omitempty"`
 	Added       time.Time `json:"added"`
 	RequestedAt time.Time `json:"requested_at"`
 	IsDeleted   bool      `json:"is_deleted"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	Preview     *Preview  `json:"preview,omitempty"`
+	Health      *Health   `json:"health,omitempty"`
+	// the visitors see the destination before they are redirected
+	WarnBeforeRedirect bool `json:"warn_before_redirect,omitempty"`
+	// the status code of the redirect, zero means the default one
+	RedirectCode int `json:"redirect_code,omitempty"`
+}
+
+// Preview - the metadata of the destination of a URL. It's fetched
+// after the URL is added, a nil preview hasn't been fetched yet.
+type Preview struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	FaviconURL  string `json:"favicon_url,omitempty"`
+	// the URL after the redirects
+	FinalURL   string `json:"final_url,omitempty"`
+	StatusCode int    `json:"status_code,omitempty"`
+	// why the destination couldn't be fetched
+	Error     string    `json:"error,omitempty"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// Health - the result of the checks of the destination of a URL.
+// A nil health means the URL hasn't been checked yet.
+type Health struct {
+	CheckedAt  time.Time `json:"checked_at"`
+	StatusCode int       `json:"status_code,omitempty"`
+	// why the destination couldn't be reached
+	Error string `json:"error,omitempty"`
+	// how many checks in a row have failed
+	Failures int `json:"failures"`
+}
+
+// OwnerIDs returns the users who own the record. Records saved before
+// links could be shared belong to the user who created them.
+func (r Record) OwnerIDs() []uint32 {
+	if r.Owners == nil && !r.IsDeleted {
+		return []uint32{r.UserID}
+	}
+	return r.Owners
+}
+
+// HasOwner checks if the user owns the record.
+func (r Record) HasOwner(userID uint32) bool {
+	return slices.Contains(r.OwnerIDs(), userID)
+}
+
+// CheckUpdate checks if the user can change the destination of the record:
+// the record must be active and owned by the user alone.
+func (r Record) CheckUpdate(userID uint32) error {
+	switch {
+	case r.IsDeleted:
+		return ErrURLWasDeleted
+	case r.IsExpired():
+		return ErrURLExpired
+	case !r.HasOwner(userID):
+		return ErrNotOwner
+	case len(r.OwnerIDs()) > 1:
+		return ErrURLShared
+	}
+	return nil
+}
+
+// DisplayURL returns the URL as the user sent it.
+func (r Record) DisplayURL() string {
+	if r.OriginalURL != "" {
+		return r.OriginalURL
+	}
+	return r.URL
+}
+
+// IsExpired checks if the record has an expiry time which has already passed.
+func (r Record) IsExpired() bool {
+	return !r.ExpiresAt.IsZero() && time.Now().After(r.ExpiresAt)
+}
+
+// CheckedAt returns when the destination of the URL was checked last time.
+// It's zero if the URL hasn't been checked.
+func (r Record) CheckedAt() time.Time {
+	if r.Health == nil {
+		return time.Time{}
+	}
+	return r.Health.CheckedAt
+}
+
+// NeedsCheck checks if the record is active and hasn't been checked since the time.
+func (r Record) NeedsCheck(checkedBefore time.Time) bool {
+	return !r.IsDeleted && !r.IsExpired() && r.CheckedAt().Before(checkedBefore)
+}
+
+// OldestChecked sorts the records by the time of the last check, the records
+// which haven't been checked go first, and returns at most limit of them.
+func OldestChecked(records []Record, limit int) []Record {
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].CheckedAt().Before(records[j].CheckedAt())
+	})
+	if len(records) > limit {
+		return records[:limit]
+	}
+	return records
+}
+
+// LinkOptions - optional settings of a link which can be set on creation.
+type LinkOptions struct {
+	// ExpiresAt is the moment after which the link stops working.
+	// Zero value means that the link never expires.
+	ExpiresAt time.Time
+	// OriginalURL is the URL as the user sent it if it differs from the canonical one.
+	OriginalURL string
+	// WarnBeforeRedirect makes the visitors see the destination before they are redirected.
+	WarnBeforeRedirect bool
+	// RedirectCode is the status code of the redirect, zero means the default one.
+	RedirectCode int
 }
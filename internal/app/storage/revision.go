@@ -0,0 +1,13 @@
+package storage
+
+import "time"
+
+// Revision is a previous destination of a short URL.
+type Revision struct {
+	URLID string `json:"url_id"`
+	// the destination before the change
+	URL       string    `json:"url"`
+	ChangedAt time.Time `json:"changed_at"`
+	// the user who changed the destination
+	ChangedBy uint32 `json:"changed_by"`
+}
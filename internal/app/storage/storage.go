@@ -4,6 +4,8 @@ package storage
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
 )
 
 // Storage errors.
@@ -11,19 +13,51 @@ var (
 	ErrURLWasNotFound  = errors.New("requested URL was not found")
 	ErrUniqueViolation = errors.New("duplicate key value violates unique constraint")
 	ErrURLWasDeleted   = errors.New("requested url was deleted")
+	ErrURLExpired      = errors.New("requested url has expired")
+	ErrURLIDTaken      = fmt.Errorf("%w: url id is already taken", ErrUniqueViolation)
+	ErrUserNotFound    = errors.New("requested user was not found")
+	ErrUsernameTaken   = fmt.Errorf("%w: username is already taken", ErrUniqueViolation)
+	ErrUserIDTaken     = fmt.Errorf("%w: user id is already taken", ErrUniqueViolation)
+	ErrAPIKeyNotFound  = errors.New("requested api key was not found")
+	ErrNotOwner        = errors.New("url is owned by another user")
+	ErrURLShared       = errors.New("url is shared with other users")
 )
 
+// DuplicateURLError is returned when the URL has already been shortened.
+// It keeps the ID the URL is available by.
+type DuplicateURLError struct {
+	URL   string
+	URLID string
+	// NewOwner is set if the URL was shortened by other users
+	// and the user has just been added to its owners.
+	NewOwner bool
+}
+
+// Error returns the error message.
+func (e *DuplicateURLError) Error() string {
+	return fmt.Sprintf("%v: url=%v, urlID=%v", ErrUniqueViolation, e.URL, e.URLID)
+}
+
+// Unwrap makes the error match ErrUniqueViolation.
+func (e *DuplicateURLError) Unwrap() error {
+	return ErrUniqueViolation
+}
+
 // Storage - interface for storage.
 type Storage interface {
-	// AddURL adds a URL to the store.
-	AddURL(ctx context.Context, url, urlID string, userID uint32) error
-	// AddURLBatch adds a batch of URLs to the store.
+	// AddURL adds a URL to the store. It returns *DuplicateURLError
+	// if the URL is already stored and ErrURLIDTaken if the ID is used by another URL.
+	// If the links are shared, a URL shortened by other users gets one more owner.
+	AddURL(ctx context.Context, url, urlID string, userID uint32, opts LinkOptions) error
+	// AddURLBatch adds a batch of URLs to the store. URLs which are already
+	// stored are reported with ErrUniqueViolation and left as they are.
 	AddURLBatch(ctx context.Context, urlIDs map[string]string, userID uint32) error
 	// GetURLByID gets URL by ID.
 	GetURLByID(ctx context.Context, urlID string) (Record, error)
-	// GetURLsByUser gets URLs by user ID.
+	// GetURLsByUser gets URLs owned by the user.
 	GetURLsByUser(ctx context.Context, userID uint32) ([]Record, error)
-	// DeleteMany flags the URL to be deleted.
+	// DeleteMany removes the user from the owners of the URLs.
+	// A URL is flagged as deleted when no owners remain.
 	DeleteMany(ctx context.Context, userID uint32, urlIDs []string) error
 	// Ping checks the connection to the repository.
 	Ping(ctx context.Context) bool
@@ -33,4 +67,40 @@ type Storage interface {
 	Close(ctx context.Context)
 	// Returns DB stats.
 	GetStats(ctx context.Context) (int, int, error)
+	// AddClicks saves a batch of short URL visits.
+	AddClicks(ctx context.Context, clicks []Click) error
+	// GetClicks gets all visits of a short URL ordered by time.
+	GetClicks(ctx context.Context, urlID string) ([]Click, error)
+	// AddUser registers the user. It returns ErrUsernameTaken if the name is used
+	// and ErrUserIDTaken if the ID belongs to another account or owns URLs.
+	AddUser(ctx context.Context, user User) error
+	// GetUser gets the registered user by name.
+	GetUser(ctx context.Context, username string) (User, error)
+	// AddAPIKey saves the API key of the user.
+	AddAPIKey(ctx context.Context, key APIKey) error
+	// GetAPIKey gets the API key by its hash.
+	GetAPIKey(ctx context.Context, keyHash string) (APIKey, error)
+	// MoveURLs passes the URLs owned by one user to another and returns
+	// how many URLs were moved. URLs both users own are kept once.
+	MoveURLs(ctx context.Context, fromUserID, toUserID uint32) (int, error)
+	// SetPreview saves the preview of the URL's destination.
+	// It returns ErrURLWasNotFound if there is no URL with the ID.
+	SetPreview(ctx context.Context, urlID string, preview Preview) error
+	// GetURLsToCheck gets at most limit active URLs which haven't been checked
+	// since the time. The URLs checked long ago and never checked go first.
+	GetURLsToCheck(ctx context.Context, checkedBefore time.Time, limit int) ([]Record, error)
+	// SetHealth saves the result of the check of the URL's destination.
+	// It returns ErrURLWasNotFound if there is no URL with the ID.
+	SetHealth(ctx context.Context, urlID string, health Health) error
+	// UpdateURL changes the destination of the URL owned by the user and saves
+	// the previous one to the history of the URL. It returns ErrNotOwner if the user
+	// doesn't own the URL, ErrURLShared if other users own it too and
+	// *DuplicateURLError if the new destination is already stored.
+	UpdateURL(ctx context.Context, urlID string, userID uint32, url, originalURL string) error
+	// GetURLHistory gets the previous destinations of a short URL ordered by time.
+	GetURLHistory(ctx context.Context, urlID string) ([]Revision, error)
+	// NextSequence returns the next value of the counter used to generate IDs.
+	// The counter starts after the URLs stored before it was added and
+	// a value is never returned twice until the storage is cleared.
+	NextSequence(ctx context.Context) (uint64, error)
 }
@@ -0,0 +1,20 @@
+package storage
+
+import "time"
+
+// User is a structure for storing a registered account. Registered users
+// own URLs under their ID like anonymous users identified by the cookie.
+type User struct {
+	UserID       uint32    `json:"user_id"`
+	Username     string    `json:"username"`
+	PasswordHash string    `json:"password_hash"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// APIKey is a structure for storing a long-lived key of a registered user.
+// Only the hash of the key is stored.
+type APIKey struct {
+	KeyHash   string    `json:"key_hash"`
+	UserID    uint32    `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,228 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/blokhinnv/shorty/internal/app/storage"
+)
+
+// TracedStorage starts a span for every storage operation.
+type TracedStorage struct {
+	s       storage.Storage
+	backend string
+}
+
+// NewTracedStorage - TracedStorage constructor.
+func NewTracedStorage(s storage.Storage, backend string) *TracedStorage {
+	return &TracedStorage{s: s, backend: backend}
+}
+
+// start starts the span of the operation.
+func (t *TracedStorage) start(
+	ctx context.Context,
+	operation string,
+	attrs ...attribute.KeyValue,
+) (context.Context, trace.Span) {
+	attrs = append(attrs, attribute.String("db.system", t.backend), attribute.String("db.operation", operation))
+	return tracer().Start(
+		ctx,
+		"storage."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// end records the error and ends the span. Expected outcomes
+// like a missing URL are not marked as errors.
+func end(span trace.Span, err error) {
+	switch {
+	case err == nil:
+	case errors.Is(err, storage.ErrURLWasNotFound),
+		errors.Is(err, storage.ErrURLWasDeleted),
+		errors.Is(err, storage.ErrURLExpired),
+		errors.Is(err, storage.ErrUniqueViolation),
+		errors.Is(err, storage.ErrUserNotFound),
+		errors.Is(err, storage.ErrAPIKeyNotFound):
+		span.SetAttributes(attribute.String("shorty.result", err.Error()))
+	default:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// AddURL adds a URL to the store.
+func (t *TracedStorage) AddURL(
+	ctx context.Context,
+	url, urlID string,
+	userID uint32,
+	opts storage.LinkOptions,
+) (err error) {
+	ctx, span := t.start(ctx, "AddURL", attribute.String("shorty.url_id", urlID))
+	defer func() { end(span, err) }()
+	return t.s.AddURL(ctx, url, urlID, userID, opts)
+}
+
+// AddURLBatch adds a batch of URLs to the store.
+func (t *TracedStorage) AddURLBatch(
+	ctx context.Context,
+	urlIDs map[string]string,
+	userID uint32,
+) (err error) {
+	ctx, span := t.start(ctx, "AddURLBatch", attribute.Int("shorty.batch_size", len(urlIDs)))
+	defer func() { end(span, err) }()
+	return t.s.AddURLBatch(ctx, urlIDs, userID)
+}
+
+// GetURLByID gets URL by ID.
+func (t *TracedStorage) GetURLByID(ctx context.Context, urlID string) (rec storage.Record, err error) {
+	ctx, span := t.start(ctx, "GetURLByID", attribute.String("shorty.url_id", urlID))
+	defer func() { end(span, err) }()
+	return t.s.GetURLByID(ctx, urlID)
+}
+
+// GetURLsByUser gets URLs owned by the user.
+func (t *TracedStorage) GetURLsByUser(ctx context.Context, userID uint32) (recs []storage.Record, err error) {
+	ctx, span := t.start(ctx, "GetURLsByUser")
+	defer func() { end(span, err) }()
+	return t.s.GetURLsByUser(ctx, userID)
+}
+
+// DeleteMany removes the user from the owners of the URLs.
+func (t *TracedStorage) DeleteMany(ctx context.Context, userID uint32, urlIDs []string) (err error) {
+	ctx, span := t.start(ctx, "DeleteMany", attribute.Int("shorty.batch_size", len(urlIDs)))
+	defer func() { end(span, err) }()
+	return t.s.DeleteMany(ctx, userID, urlIDs)
+}
+
+// Ping checks the connection to the repository.
+func (t *TracedStorage) Ping(ctx context.Context) bool {
+	ctx, span := t.start(ctx, "Ping")
+	defer span.End()
+	return t.s.Ping(ctx)
+}
+
+// Clear clears the storage.
+func (t *TracedStorage) Clear(ctx context.Context) (err error) {
+	ctx, span := t.start(ctx, "Clear")
+	defer func() { end(span, err) }()
+	return t.s.Clear(ctx)
+}
+
+// Close closes the store.
+func (t *TracedStorage) Close(ctx context.Context) {
+	t.s.Close(ctx)
+}
+
+// GetStats returns DB stats.
+func (t *TracedStorage) GetStats(ctx context.Context) (urls int, users int, err error) {
+	ctx, span := t.start(ctx, "GetStats")
+	defer func() { end(span, err) }()
+	return t.s.GetStats(ctx)
+}
+
+// AddClicks saves a batch of short URL visits.
+func (t *TracedStorage) AddClicks(ctx context.Context, clicks []storage.Click) (err error) {
+	ctx, span := t.start(ctx, "AddClicks", attribute.Int("shorty.batch_size", len(clicks)))
+	defer func() { end(span, err) }()
+	return t.s.AddClicks(ctx, clicks)
+}
+
+// GetClicks gets all visits of a short URL ordered by time.
+func (t *TracedStorage) GetClicks(ctx context.Context, urlID string) (clicks []storage.Click, err error) {
+	ctx, span := t.start(ctx, "GetClicks", attribute.String("shorty.url_id", urlID))
+	defer func() { end(span, err) }()
+	return t.s.GetClicks(ctx, urlID)
+}
+
+// AddUser registers the user.
+func (t *TracedStorage) AddUser(ctx context.Context, user storage.User) (err error) {
+	ctx, span := t.start(ctx, "AddUser")
+	defer func() { end(span, err) }()
+	return t.s.AddUser(ctx, user)
+}
+
+// GetUser gets the registered user by name.
+func (t *TracedStorage) GetUser(ctx context.Context, username string) (user storage.User, err error) {
+	ctx, span := t.start(ctx, "GetUser")
+	defer func() { end(span, err) }()
+	return t.s.GetUser(ctx, username)
+}
+
+// AddAPIKey saves the API key of the user.
+func (t *TracedStorage) AddAPIKey(ctx context.Context, key storage.APIKey) (err error) {
+	ctx, span := t.start(ctx, "AddAPIKey")
+	defer func() { end(span, err) }()
+	return t.s.AddAPIKey(ctx, key)
+}
+
+// GetAPIKey gets the API key by its hash.
+func (t *TracedStorage) GetAPIKey(ctx context.Context, keyHash string) (key storage.APIKey, err error) {
+	ctx, span := t.start(ctx, "GetAPIKey")
+	defer func() { end(span, err) }()
+	return t.s.GetAPIKey(ctx, keyHash)
+}
+
+// MoveURLs passes the URLs owned by one user to another.
+func (t *TracedStorage) MoveURLs(ctx context.Context, fromUserID, toUserID uint32) (n int, err error) {
+	ctx, span := t.start(ctx, "MoveURLs")
+	defer func() { end(span, err) }()
+	return t.s.MoveURLs(ctx, fromUserID, toUserID)
+}
+
+// SetPreview saves the preview of the URL's destination.
+func (t *TracedStorage) SetPreview(ctx context.Context, urlID string, preview storage.Preview) (err error) {
+	ctx, span := t.start(ctx, "SetPreview", attribute.String("shorty.url_id", urlID))
+	defer func() { end(span, err) }()
+	return t.s.SetPreview(ctx, urlID, preview)
+}
+
+// GetURLsToCheck gets the URLs which destinations need to be checked.
+func (t *TracedStorage) GetURLsToCheck(
+	ctx context.Context,
+	checkedBefore time.Time,
+	limit int,
+) (recs []storage.Record, err error) {
+	ctx, span := t.start(ctx, "GetURLsToCheck", attribute.Int("shorty.batch_size", limit))
+	defer func() { end(span, err) }()
+	return t.s.GetURLsToCheck(ctx, checkedBefore, limit)
+}
+
+// SetHealth saves the result of the check of the URL's destination.
+func (t *TracedStorage) SetHealth(ctx context.Context, urlID string, health storage.Health) (err error) {
+	ctx, span := t.start(ctx, "SetHealth", attribute.String("shorty.url_id", urlID))
+	defer func() { end(span, err) }()
+	return t.s.SetHealth(ctx, urlID, health)
+}
+
+// UpdateURL changes the destination of the URL owned by the user.
+func (t *TracedStorage) UpdateURL(
+	ctx context.Context,
+	urlID string,
+	userID uint32,
+	url, originalURL string,
+) (err error) {
+	ctx, span := t.start(ctx, "UpdateURL", attribute.String("shorty.url_id", urlID))
+	defer func() { end(span, err) }()
+	return t.s.UpdateURL(ctx, urlID, userID, url, originalURL)
+}
+
+// GetURLHistory gets the previous destinations of a short URL.
+func (t *TracedStorage) GetURLHistory(ctx context.Context, urlID string) (revs []storage.Revision, err error) {
+	ctx, span := t.start(ctx, "GetURLHistory", attribute.String("shorty.url_id", urlID))
+	defer func() { end(span, err) }()
+	return t.s.GetURLHistory(ctx, urlID)
+}
+
+// NextSequence returns the next value of the counter used to generate IDs.
+func (t *TracedStorage) NextSequence(ctx context.Context) (n uint64, err error) {
+	ctx, span := t.start(ctx, "NextSequence")
+	defer func() { end(span, err) }()
+	return t.s.NextSequence(ctx)
+}
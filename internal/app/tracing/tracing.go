@@ -0,0 +1,95 @@
+// Package tracing sets up OpenTelemetry tracing of the servers and the storage.
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/blokhinnv/shorty/internal/app/server/config"
+)
+
+// Span exporters.
+const (
+	StdoutExporter = "stdout"
+	OTLPExporter   = "otlp"
+)
+
+// instrumentationName - name of the tracer.
+const instrumentationName = "github.com/blokhinnv/shorty"
+
+// TracingConfig - tracing config.
+type TracingConfig struct {
+	// stdout, otlp or empty to disable tracing
+	Exporter string
+	// host:port of the OTLP gRPC receiver
+	OTLPEndpoint string
+	// share of the traces which are sampled
+	SampleRatio float64
+}
+
+// GetTracingConfig - tracing config constructor based on server config.
+func GetTracingConfig(cfg *config.ServerConfig) *TracingConfig {
+	return &TracingConfig{
+		Exporter:     cfg.TracingExporter,
+		OTLPEndpoint: cfg.TracingOTLPEndpoint,
+		SampleRatio:  cfg.TracingSampleRatio,
+	}
+}
+
+// tracer returns the tracer of the application.
+// It's looked up every time, so the provider can be set after the start.
+func tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// newExporter creates the span exporter.
+func newExporter(ctx context.Context, conf *TracingConfig) (sdktrace.SpanExporter, error) {
+	switch conf.Exporter {
+	case StdoutExporter:
+		return stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case OTLPExporter:
+		return otlptracegrpc.New(
+			ctx,
+			otlptracegrpc.WithEndpoint(conf.OTLPEndpoint),
+			otlptracegrpc.WithInsecure(),
+		)
+	}
+	return nil, fmt.Errorf("unknown span exporter %q", conf.Exporter)
+}
+
+// Setup installs the global tracer provider and W3C trace-context propagation.
+// The returned function flushes the spans and stops the provider.
+func Setup(ctx context.Context, conf *TracingConfig) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+	if conf.Exporter == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+	exporter, err := newExporter(ctx, conf)
+	if err != nil {
+		return nil, err
+	}
+	provider := NewProvider(sdktrace.NewBatchSpanProcessor(exporter), conf.SampleRatio)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// NewProvider creates a tracer provider which passes the spans to the processor.
+func NewProvider(processor sdktrace.SpanProcessor, sampleRatio float64) *sdktrace.TracerProvider {
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(processor),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName("shorty"))),
+	)
+}
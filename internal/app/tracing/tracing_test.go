@@ -0,0 +1,147 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/blokhinnv/shorty/internal/app/storage"
+)
+
+const (
+	traceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+	parentID    = "00f067aa0ba902b7"
+	traceparent = "00-" + traceID + "-" + parentID + "-01"
+)
+
+// setupExporter installs a provider which keeps the spans in memory.
+func setupExporter(t *testing.T) *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := NewProvider(sdktrace.NewSimpleSpanProcessor(exporter), 1)
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	_, err := Setup(context.Background(), &TracingConfig{})
+	require.NoError(t, err)
+	return exporter
+}
+
+// spansByName returns the finished spans by their names.
+func spansByName(exporter *tracetest.InMemoryExporter) map[string]tracetest.SpanStub {
+	result := make(map[string]tracetest.SpanStub)
+	for _, span := range exporter.GetSpans() {
+		result[span.Name] = span
+	}
+	return result
+}
+
+func TestHTTPServer(t *testing.T) {
+	exporter := setupExporter(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	m := storage.NewMockStorage(ctrl)
+	m.EXPECT().GetURLByID(gomock.Any(), "qwerty").Return(storage.Record{}, storage.ErrURLWasNotFound)
+	s := NewTracedStorage(m, "test")
+
+	r := chi.NewRouter()
+	r.Use(HTTPServer)
+	r.Use(Middleware("auth", func(next http.Handler) http.Handler { return next }))
+	r.Get("/{idURL}", func(w http.ResponseWriter, r *http.Request) {
+		s.GetURLByID(r.Context(), chi.URLParam(r, "idURL"))
+		w.WriteHeader(http.StatusNotFound)
+	})
+	req := httptest.NewRequest(http.MethodGet, "/qwerty", nil)
+	req.Header.Set("traceparent", traceparent)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := spansByName(exporter)
+	require.Len(t, spans, 3)
+	server := spans["GET /{idURL}"]
+	auth := spans["middleware auth"]
+	get := spans["storage.GetURLByID"]
+	for _, span := range []tracetest.SpanStub{server, auth, get} {
+		assert.Equal(t, traceID, span.SpanContext.TraceID().String())
+	}
+	// the trace of the client is continued
+	assert.Equal(t, parentID, server.Parent.SpanID().String())
+	assert.Equal(t, server.SpanContext.SpanID(), auth.Parent.SpanID())
+	assert.Equal(t, auth.SpanContext.SpanID(), get.Parent.SpanID())
+	// a missing URL is not an error of the storage
+	assert.Equal(t, codes.Unset, get.Status.Code)
+}
+
+func TestTransport(t *testing.T) {
+	setupExporter(t)
+	var got string
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("traceparent")
+	}))
+	defer backend.Close()
+
+	ctx, span := tracer().Start(context.Background(), "test")
+	defer span.End()
+	client := &http.Client{Transport: &Transport{}}
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, backend.URL, nil)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Contains(t, got, span.SpanContext().TraceID().String())
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	exporter := setupExporter(t)
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("traceparent", traceparent))
+	info := &grpc.UnaryServerInfo{FullMethod: "/shorty.Shorty/Test"}
+	_, err := UnaryServerInterceptor(ctx, nil, info, func(ctx context.Context, req any) (any, error) {
+		return nil, errors.New("broken")
+	})
+	assert.Error(t, err)
+
+	spans := spansByName(exporter)
+	span, ok := spans[info.FullMethod]
+	require.True(t, ok)
+	assert.Equal(t, traceID, span.SpanContext.TraceID().String())
+	assert.Equal(t, parentID, span.Parent.SpanID().String())
+	assert.Equal(t, codes.Error, span.Status.Code)
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	setupExporter(t)
+	var md metadata.MD
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		md, _ = metadata.FromOutgoingContext(ctx)
+		return nil
+	}
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "usertoken", "abc")
+	err := UnaryClientInterceptor(ctx, "/shorty.Shorty/Test", nil, nil, nil, invoker)
+	require.NoError(t, err)
+	assert.Len(t, md.Get("traceparent"), 1)
+	assert.Equal(t, []string{"abc"}, md.Get("usertoken"))
+}
+
+func TestTracedStorageError(t *testing.T) {
+	exporter := setupExporter(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	m := storage.NewMockStorage(ctrl)
+	m.EXPECT().AddClicks(gomock.Any(), gomock.Any()).Return(errors.New("connection refused"))
+
+	err := NewTracedStorage(m, "test").AddClicks(context.Background(), []storage.Click{{URLID: "qwerty"}})
+	assert.Error(t, err)
+	span := spansByName(exporter)["storage.AddClicks"]
+	assert.Equal(t, codes.Error, span.Status.Code)
+	assert.Len(t, span.Events, 1)
+}
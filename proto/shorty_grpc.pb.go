@@ -25,6 +25,11 @@ const (
 	Shorty_GetShortURLJSON_FullMethodName  = "/proto.Shorty/GetShortURLJSON"
 	Shorty_GetShortURLBatch_FullMethodName = "/proto.Shorty/GetShortURLBatch"
 	Shorty_DeleteURL_FullMethodName        = "/proto.Shorty/DeleteURL"
+	Shorty_DeleteURLs_FullMethodName       = "/proto.Shorty/DeleteURLs"
+	Shorty_GetURLStats_FullMethodName      = "/proto.Shorty/GetURLStats"
+	Shorty_GetURLInfo_FullMethodName       = "/proto.Shorty/GetURLInfo"
+	Shorty_UpdateURL_FullMethodName        = "/proto.Shorty/UpdateURL"
+	Shorty_GetURLHistory_FullMethodName    = "/proto.Shorty/GetURLHistory"
 	Shorty_GetStats_FullMethodName         = "/proto.Shorty/GetStats"
 	Shorty_Ping_FullMethodName             = "/proto.Shorty/Ping"
 )
@@ -45,6 +50,16 @@ type ShortyClient interface {
 	GetShortURLBatch(ctx context.Context, in *GetShortURLBatchRequest, opts ...grpc.CallOption) (*GetShortURLBatchResponse, error)
 	// список url на удаление
 	DeleteURL(ctx context.Context, opts ...grpc.CallOption) (Shorty_DeleteURLClient, error)
+	// список url на удаление одним запросом, тело - массив id
+	DeleteURLs(ctx context.Context, in *DeleteURLsRequest, opts ...grpc.CallOption) (*DeleteURLResponse, error)
+	// статистика переходов по короткому URL
+	GetURLStats(ctx context.Context, in *GetURLStatsRequest, opts ...grpc.CallOption) (*GetURLStatsResponse, error)
+	// короткий URL пользователя и превью его страницы
+	GetURLInfo(ctx context.Context, in *GetURLInfoRequest, opts ...grpc.CallOption) (*GetURLInfoResponse, error)
+	// смена адреса назначения короткого URL пользователя
+	UpdateURL(ctx context.Context, in *UpdateURLRequest, opts ...grpc.CallOption) (*GetURLInfoResponse, error)
+	// прежние адреса назначения короткого URL
+	GetURLHistory(ctx context.Context, in *GetURLHistoryRequest, opts ...grpc.CallOption) (*GetURLHistoryResponse, error)
 	// технические
 	GetStats(ctx context.Context, in *GetStatsRequest, opts ...grpc.CallOption) (*GetStatsResponse, error)
 	Ping(ctx context.Context, in *PingRequest, opts ...grpc.CallOption) (*PingResponse, error)
@@ -160,6 +175,51 @@ func (x *shortyDeleteURLClient) CloseAndRecv() (*DeleteURLResponse, error) {
 	return m, nil
 }
 
+func (c *shortyClient) DeleteURLs(ctx context.Context, in *DeleteURLsRequest, opts ...grpc.CallOption) (*DeleteURLResponse, error) {
+	out := new(DeleteURLResponse)
+	err := c.cc.Invoke(ctx, Shorty_DeleteURLs_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shortyClient) GetURLStats(ctx context.Context, in *GetURLStatsRequest, opts ...grpc.CallOption) (*GetURLStatsResponse, error) {
+	out := new(GetURLStatsResponse)
+	err := c.cc.Invoke(ctx, Shorty_GetURLStats_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shortyClient) GetURLInfo(ctx context.Context, in *GetURLInfoRequest, opts ...grpc.CallOption) (*GetURLInfoResponse, error) {
+	out := new(GetURLInfoResponse)
+	err := c.cc.Invoke(ctx, Shorty_GetURLInfo_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shortyClient) UpdateURL(ctx context.Context, in *UpdateURLRequest, opts ...grpc.CallOption) (*GetURLInfoResponse, error) {
+	out := new(GetURLInfoResponse)
+	err := c.cc.Invoke(ctx, Shorty_UpdateURL_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shortyClient) GetURLHistory(ctx context.Context, in *GetURLHistoryRequest, opts ...grpc.CallOption) (*GetURLHistoryResponse, error) {
+	out := new(GetURLHistoryResponse)
+	err := c.cc.Invoke(ctx, Shorty_GetURLHistory_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *shortyClient) GetStats(ctx context.Context, in *GetStatsRequest, opts ...grpc.CallOption) (*GetStatsResponse, error) {
 	out := new(GetStatsResponse)
 	err := c.cc.Invoke(ctx, Shorty_GetStats_FullMethodName, in, out, opts...)
@@ -194,6 +254,16 @@ type ShortyServer interface {
 	GetShortURLBatch(context.Context, *GetShortURLBatchRequest) (*GetShortURLBatchResponse, error)
 	// список url на удаление
 	DeleteURL(Shorty_DeleteURLServer) error
+	// список url на удаление одним запросом, тело - массив id
+	DeleteURLs(context.Context, *DeleteURLsRequest) (*DeleteURLResponse, error)
+	// статистика переходов по короткому URL
+	GetURLStats(context.Context, *GetURLStatsRequest) (*GetURLStatsResponse, error)
+	// короткий URL пользователя и превью его страницы
+	GetURLInfo(context.Context, *GetURLInfoRequest) (*GetURLInfoResponse, error)
+	// смена адреса назначения короткого URL пользователя
+	UpdateURL(context.Context, *UpdateURLRequest) (*GetURLInfoResponse, error)
+	// прежние адреса назначения короткого URL
+	GetURLHistory(context.Context, *GetURLHistoryRequest) (*GetURLHistoryResponse, error)
 	// технические
 	GetStats(context.Context, *GetStatsRequest) (*GetStatsResponse, error)
 	Ping(context.Context, *PingRequest) (*PingResponse, error)
@@ -222,6 +292,21 @@ func (UnimplementedShortyServer) GetShortURLBatch(context.Context, *GetShortURLB
 func (UnimplementedShortyServer) DeleteURL(Shorty_DeleteURLServer) error {
 	return status.Errorf(codes.Unimplemented, "method DeleteURL not implemented")
 }
+func (UnimplementedShortyServer) DeleteURLs(context.Context, *DeleteURLsRequest) (*DeleteURLResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteURLs not implemented")
+}
+func (UnimplementedShortyServer) GetURLStats(context.Context, *GetURLStatsRequest) (*GetURLStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetURLStats not implemented")
+}
+func (UnimplementedShortyServer) GetURLInfo(context.Context, *GetURLInfoRequest) (*GetURLInfoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetURLInfo not implemented")
+}
+func (UnimplementedShortyServer) UpdateURL(context.Context, *UpdateURLRequest) (*GetURLInfoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateURL not implemented")
+}
+func (UnimplementedShortyServer) GetURLHistory(context.Context, *GetURLHistoryRequest) (*GetURLHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetURLHistory not implemented")
+}
 func (UnimplementedShortyServer) GetStats(context.Context, *GetStatsRequest) (*GetStatsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetStats not implemented")
 }
@@ -360,6 +445,96 @@ func (x *shortyDeleteURLServer) Recv() (*DeleteURLRequest, error) {
 	return m, nil
 }
 
+func _Shorty_DeleteURLs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteURLsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortyServer).DeleteURLs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Shorty_DeleteURLs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortyServer).DeleteURLs(ctx, req.(*DeleteURLsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Shorty_GetURLStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetURLStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortyServer).GetURLStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Shorty_GetURLStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortyServer).GetURLStats(ctx, req.(*GetURLStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Shorty_GetURLInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetURLInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortyServer).GetURLInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Shorty_GetURLInfo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortyServer).GetURLInfo(ctx, req.(*GetURLInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Shorty_UpdateURL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateURLRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortyServer).UpdateURL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Shorty_UpdateURL_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortyServer).UpdateURL(ctx, req.(*UpdateURLRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Shorty_GetURLHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetURLHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortyServer).GetURLHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Shorty_GetURLHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortyServer).GetURLHistory(ctx, req.(*GetURLHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Shorty_GetStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetStatsRequest)
 	if err := dec(in); err != nil {
@@ -419,6 +594,26 @@ var Shorty_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetShortURLBatch",
 			Handler:    _Shorty_GetShortURLBatch_Handler,
 		},
+		{
+			MethodName: "DeleteURLs",
+			Handler:    _Shorty_DeleteURLs_Handler,
+		},
+		{
+			MethodName: "GetURLStats",
+			Handler:    _Shorty_GetURLStats_Handler,
+		},
+		{
+			MethodName: "GetURLInfo",
+			Handler:    _Shorty_GetURLInfo_Handler,
+		},
+		{
+			MethodName: "UpdateURL",
+			Handler:    _Shorty_UpdateURL_Handler,
+		},
+		{
+			MethodName: "GetURLHistory",
+			Handler:    _Shorty_GetURLHistory_Handler,
+		},
 		{
 			MethodName: "GetStats",
 			Handler:    _Shorty_GetStats_Handler,